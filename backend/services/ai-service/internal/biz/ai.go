@@ -2,52 +2,404 @@ package biz
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	kerrors "github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
 	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/lint"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/skills"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
+// 错误定义
+var (
+	ErrJobNotFound         = errors.New("分析任务不存在")
+	ErrAnalysisNotFound    = errors.New("分析结果不存在")
+	ErrJobFinished         = errors.New("分析任务已结束")
+	ErrSessionNotFound     = errors.New("会话不存在")
+	ErrInvalidExportFormat = errors.New("不支持的导出格式")
+	ErrOrgQuotaExceeded    = errors.New("组织本月的AI分析次数已用完")
+	ErrTooManyJobs         = errors.New("分析任务过多，请稍后再试")
+)
+
+// ErrFileNotFound 分析的文件不存在或当前用户无权访问
+var ErrFileNotFound = kerrors.NotFound("FILE_NOT_FOUND", "文件不存在或无权访问")
+
+const (
+	// defaultSessionMaxIdle 会话默认最大空闲时长
+	defaultSessionMaxIdle = 30 * 24 * time.Hour
+	// defaultSessionCleanupInterval 会话清理任务默认执行间隔
+	defaultSessionCleanupInterval = time.Hour
+	// maxSessionTitleLength 自动生成会话标题的最大字符数
+	maxSessionTitleLength = 30
+	// exportPageSize 导出用户数据时每次读取的分析任务数
+	exportPageSize = 100
+	// defaultMaxConcurrentJobs 默认同时执行的分析任务数
+	defaultMaxConcurrentJobs = 10
+	// defaultMaxPendingJobs 默认本实例等待和执行中的分析任务上限
+	defaultMaxPendingJobs = 100
+	// defaultJobTimeout 分析任务从提交到结束的默认最长时间
+	defaultJobTimeout = 30 * time.Minute
+	// defaultJobCheckInterval 检查中断任务的默认间隔
+	defaultJobCheckInterval = time.Minute
+)
+
+// 分析任务状态
+const (
+	JobStatusPending    = "pending"
+	JobStatusProcessing = "processing"
+	JobStatusCompleted  = "completed"
+	JobStatusFailed     = "failed"
+	JobStatusCancelled  = "cancelled"
+)
+
+// 分析任务阶段，已完成的分析维度以 eino.Node* 名称记录在 CompletedStages 中
+const (
+	StageParsing   = "parsing"
+	StageLinting   = "linting"
+	StageAnalyzing = "analyzing"
+	StageSaving    = "saving"
+)
+
+// AnalysisJob 简历分析任务
+type AnalysisJob struct {
+	ID              string
+	ResumeID        string
+	UserID          string // 提交分析的用户，之前创建的任务为空
+	OrgID           int64  // 提交时用户所在的组织，组织成员都可以查看，0表示只属于提交者
+	FileID          string // 分析的简历文件
+	FileVersion     int32  // 分析的简历文件版本，上传新版本后仍指向该版本
+	TargetPosition  string
+	Status          string // pending, processing, completed, failed, cancelled
+	Progress        int    // 0-100
+	CurrentStage    string
+	CompletedStages []string
+	AnalysisID      string
+	ErrorMsg        string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	CompletedAt     *time.Time
+}
+
+// IsFinished 任务是否已结束
+func (j *AnalysisJob) IsFinished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
+
 // AIRepo AI数据仓库接口
 type AIRepo interface {
 	SaveAnalysisResult(ctx context.Context, result *eino.AnalysisResult) error
 	GetAnalysisResult(ctx context.Context, id string) (*eino.AnalysisResult, error)
+	CreateAnalysisJob(ctx context.Context, job *AnalysisJob) (*AnalysisJob, error)
+	GetAnalysisJob(ctx context.Context, jobID string) (*AnalysisJob, error)
+	// GetAnalysisJobByAnalysisID 获取产生该分析结果的任务，不存在时返回 ErrAnalysisNotFound
+	GetAnalysisJobByAnalysisID(ctx context.Context, analysisID string) (*AnalysisJob, error)
+	// UpdateAnalysisJob 仅更新未结束的任务，任务已结束时返回 ErrJobFinished
+	UpdateAnalysisJob(ctx context.Context, job *AnalysisJob) error
+	// FailStaleAnalysisJobs 将最后更新时间早于 updatedBefore 的未结束任务记为失败，返回更新的任务数
+	FailStaleAnalysisJobs(ctx context.Context, updatedBefore time.Time, errMsg string) (int64, error)
 	SaveChatSession(ctx context.Context, session *eino.ChatContext) error
 	GetChatSession(ctx context.Context, sessionID string) (*eino.ChatContext, error)
+	ListChatSessions(ctx context.Context, userID string, limit, offset int) ([]*ChatSessionSummary, int64, error)
+	RenameChatSession(ctx context.Context, sessionID, userID, title string) error
+	DeleteChatSession(ctx context.Context, sessionID, userID string) error
+	CleanupExpiredSessions(ctx context.Context, expiredBefore time.Time) (int64, error)
+	// ListUserAnalysisJobs 按任务ID顺序返回 ID 大于 afterID、由该用户提交的任务，以及分析 fileIDs 中文件且未记录用户的历史任务
+	ListUserAnalysisJobs(ctx context.Context, userID string, fileIDs []string, afterID string, limit int) ([]*AnalysisJob, error)
+	// CountOrgAnalysisJobs 统计组织自 since 起提交的分析任务数
+	CountOrgAnalysisJobs(ctx context.Context, orgID int64, since time.Time) (int64, error)
+}
+
+// OrganizationRepo 组织设置仓库接口，设置由 user-service 维护
+type OrganizationRepo interface {
+	// AIMonthlyQuota 组织每个自然月可发起的分析次数，0表示不限制
+	AIMonthlyQuota(ctx context.Context, orgID int64) (int32, error)
+}
+
+// FileRepo 文件仓库接口，文件由 file-service 维护
+type FileRepo interface {
+	// CheckAccess 以当前用户的身份确认文件及版本存在且可以访问，version 为0表示当前版本，否则返回 ErrFileNotFound
+	CheckAccess(ctx context.Context, fileID string, version int32) error
 }
 
 // AIUsecase AI用例
 type AIUsecase struct {
 	repo       AIRepo
+	orgs       OrganizationRepo
+	files      FileRepo
 	components *eino.EinoComponents
+	linter     *LintUsecase
+	chatConfig *conf.ChatConfig
 	logger     *log.Helper
+
+	jobSlots         chan struct{}
+	jobQueue         chan struct{}
+	jobTimeout       time.Duration
+	jobCheckInterval time.Duration
+	jobsMu           sync.Mutex
+	jobCancels       map[string]context.CancelFunc
 }
 
 // NewAIUsecase 创建AI用例
-func NewAIUsecase(repo AIRepo, aiConfig *conf.AI, logger log.Logger) *AIUsecase {
+func NewAIUsecase(repo AIRepo, orgs OrganizationRepo, files FileRepo, aiConfig *conf.AI, taxonomy *skills.Taxonomy, linter *LintUsecase, logger log.Logger) *AIUsecase {
 	helper := log.NewHelper(logger)
 
 	// 初始化Eino组件
-	components, err := eino.NewEinoComponents(aiConfig, logger)
+	components, err := eino.NewEinoComponents(aiConfig, taxonomy, logger)
 	if err != nil {
 		helper.Errorf("初始化Eino组件失败: %v", err)
 		// 使用空组件继续运行，避免启动失败
 		components = &eino.EinoComponents{}
 	}
 
+	maxConcurrent := int(aiConfig.GetEino().GetMaxConcurrent())
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultMaxConcurrentJobs
+	}
+	maxPending := int(aiConfig.GetEino().GetMaxPending())
+	if maxPending <= 0 {
+		maxPending = defaultMaxPendingJobs
+	}
+	if maxPending < maxConcurrent {
+		maxPending = maxConcurrent
+	}
+	jobTimeout := defaultJobTimeout
+	if d := aiConfig.GetEino().GetJobTimeout(); d != nil && d.AsDuration() > 0 {
+		jobTimeout = d.AsDuration()
+	}
+	jobCheckInterval := defaultJobCheckInterval
+	if d := aiConfig.GetEino().GetJobCheckInterval(); d != nil && d.AsDuration() > 0 {
+		jobCheckInterval = d.AsDuration()
+	}
+
 	return &AIUsecase{
-		repo:       repo,
-		components: components,
-		logger:     helper,
+		repo:             repo,
+		orgs:             orgs,
+		files:            files,
+		components:       components,
+		linter:           linter,
+		chatConfig:       aiConfig.GetChat(),
+		logger:           helper,
+		jobSlots:         make(chan struct{}, maxConcurrent),
+		jobQueue:         make(chan struct{}, maxPending),
+		jobTimeout:       jobTimeout,
+		jobCheckInterval: jobCheckInterval,
+		jobCancels:       make(map[string]context.CancelFunc),
 	}
 }
 
-// AnalyzeResume 分析简历
+// AnalyzeResume 为当前用户提交简历分析任务，分析在后台异步执行。
+// 用户属于组织时任务归属该组织，并计入组织的月度分析次数
 func (uc *AIUsecase) AnalyzeResume(ctx context.Context, req *AnalyzeResumeRequest) (*AnalyzeResumeResponse, error) {
-	uc.logger.WithContext(ctx).Infof("开始分析简历，ID: %s", req.ResumeID)
+	uc.logger.WithContext(ctx).Infof("提交简历分析任务，简历ID: %s", req.ResumeID)
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 检查规则始终按所在组织的设置启用，不允许指定其他组织
+	if req.OrgID, err = CallerOrgID(ctx, req.OrgID); err != nil {
+		return nil, err
+	}
+	// 任务记录的文件必须是当前用户可以访问的文件，否则文件所有者会在导出中看到他人的分析
+	if req.FileID != "" {
+		if err := uc.files.CheckAccess(ctx, req.FileID, req.FileVersion); err != nil {
+			uc.logger.WithContext(ctx).Warnf("分析的文件无法访问: file_id=%s, version=%d, %v", req.FileID, req.FileVersion, err)
+			return nil, err
+		}
+	}
+	orgID := auth.OrgID(ctx)
+	if err := uc.checkOrgQuota(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	// 限制本实例等待和执行中的任务数，积压已满时拒绝提交，由 processAnalysisJob 结束时释放
+	select {
+	case uc.jobQueue <- struct{}{}:
+	default:
+		return nil, ErrTooManyJobs
+	}
+
+	now := time.Now()
+	job := &AnalysisJob{
+		ID:             uuid.New().String(),
+		ResumeID:       req.ResumeID,
+		UserID:         userID,
+		OrgID:          orgID,
+		FileID:         req.FileID,
+		FileVersion:    req.FileVersion,
+		TargetPosition: req.TargetPosition,
+		Status:         JobStatusPending,
+		Progress:       0,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	job, err = uc.repo.CreateAnalysisJob(ctx, job)
+	if err != nil {
+		<-uc.jobQueue
+		return nil, fmt.Errorf("创建分析任务失败: %w", err)
+	}
+
+	// 包括等待执行的时间在内，任务必须在超时前结束，超时后记为失败
+	jobCtx, cancel := context.WithTimeout(context.Background(), uc.jobTimeout)
+	uc.jobsMu.Lock()
+	uc.jobCancels[job.ID] = cancel
+	uc.jobsMu.Unlock()
+
+	go uc.processAnalysisJob(jobCtx, job, req)
+
+	return &AnalyzeResumeResponse{
+		JobID:    job.ID,
+		Progress: job.Progress,
+		Status:   job.Status,
+		Message:  "分析任务已提交",
+	}, nil
+}
+
+// GetAnalysisJob 获取当前用户的分析任务状态，任务完成时附带分析结果
+func (uc *AIUsecase) GetAnalysisJob(ctx context.Context, jobID string) (*AnalysisJob, *eino.AnalysisResult, error) {
+	job, err := uc.getOwnedJob(ctx, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if job.Status != JobStatusCompleted || job.AnalysisID == "" {
+		return job, nil, nil
+	}
+
+	result, err := uc.repo.GetAnalysisResult(ctx, job.AnalysisID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取分析结果失败: %w", err)
+	}
+	return job, result, nil
+}
+
+// ExportAnalyses 遍历当前用户提交的所有分析任务及已完成任务的分析报告，用于导出用户数据。
+// 记录用户之前创建的任务按 fileIDs 中的文件归属，其他用户对这些文件的分析不导出
+func (uc *AIUsecase) ExportAnalyses(ctx context.Context, fileIDs []string, fn func(job *AnalysisJob, result *eino.AnalysisResult) error) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	afterID := ""
+	for {
+		jobs, err := uc.repo.ListUserAnalysisJobs(ctx, userID, fileIDs, afterID, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			afterID = job.ID
+			if job.UserID != "" && job.UserID != userID {
+				continue
+			}
+			var result *eino.AnalysisResult
+			if job.Status == JobStatusCompleted && job.AnalysisID != "" {
+				if result, err = uc.repo.GetAnalysisResult(ctx, job.AnalysisID); err != nil {
+					return fmt.Errorf("获取分析结果失败: %w", err)
+				}
+			}
+			if err := fn(job, result); err != nil {
+				return err
+			}
+		}
+		if len(jobs) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// CancelAnalysisJob 取消当前用户尚未结束的分析任务
+func (uc *AIUsecase) CancelAnalysisJob(ctx context.Context, jobID string) (*AnalysisJob, error) {
+	job, err := uc.getOwnedJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.IsFinished() {
+		return nil, fmt.Errorf("%w: %s", ErrJobFinished, job.Status)
+	}
+
+	now := time.Now()
+	job.Status = JobStatusCancelled
+	job.ErrorMsg = "任务已取消"
+	job.UpdatedAt = now
+	job.CompletedAt = &now
+	if err := uc.repo.UpdateAnalysisJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// 任务在本实例运行时立即中断；在其他实例运行时，由其下次上报进度时发现取消状态
+	uc.cancelLocalJob(jobID)
+
+	uc.logger.WithContext(ctx).Infof("分析任务已取消: %s", jobID)
+	return job, nil
+}
+
+// processAnalysisJob 执行分析任务
+func (uc *AIUsecase) processAnalysisJob(ctx context.Context, job *AnalysisJob, req *AnalyzeResumeRequest) {
+	defer func() {
+		uc.cancelLocalJob(job.ID)
+		uc.jobsMu.Lock()
+		delete(uc.jobCancels, job.ID)
+		uc.jobsMu.Unlock()
+		<-uc.jobQueue
+	}()
+
+	// 限制同时执行的分析任务数量
+	select {
+	case uc.jobSlots <- struct{}{}:
+		defer func() { <-uc.jobSlots }()
+	case <-ctx.Done():
+		uc.abortJob(ctx, job)
+		return
+	}
+
+	uc.updateJobProgress(ctx, job, StageParsing, 5)
+
+	analysisResult, err := uc.runAnalysis(ctx, job, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			uc.abortJob(ctx, job)
+			return
+		}
+		uc.finishJob(job, JobStatusFailed, err.Error())
+		uc.logger.Errorf("分析任务 %s 失败: %v", job.ID, err)
+		return
+	}
+
+	if ctx.Err() != nil {
+		uc.abortJob(ctx, job)
+		return
+	}
+
+	// 同一份简历可以多次提交分析，分析结果按任务区分
+	analysisResult.ID = fmt.Sprintf("analysis_%s", job.ID)
 
+	uc.updateJobProgress(ctx, job, StageSaving, 95)
+	if err := uc.repo.SaveAnalysisResult(ctx, analysisResult); err != nil {
+		uc.finishJob(job, JobStatusFailed, fmt.Sprintf("保存分析结果失败: %v", err))
+		return
+	}
+
+	job.AnalysisID = analysisResult.ID
+	job.Progress = 100
+	uc.finishJob(job, JobStatusCompleted, "")
+
+	uc.logger.Infof("分析任务 %s 完成，分析ID: %s", job.ID, analysisResult.ID)
+}
+
+// runAnalysis 解析简历并执行分析图
+func (uc *AIUsecase) runAnalysis(ctx context.Context, job *AnalysisJob, req *AnalyzeResumeRequest) (*eino.AnalysisResult, error) {
 	// 1. 解析简历内容为结构化数据
 	var resumeData *eino.ResumeData
 	var err error
@@ -78,46 +430,107 @@ func (uc *AIUsecase) AnalyzeResume(ctx context.Context, req *AnalyzeResumeReques
 	resumeData.CreatedAt = time.Now()
 	resumeData.UpdatedAt = time.Now()
 
-	// 2. 执行智能分析
-	var analysisResult *eino.AnalysisResult
+	// 2. 规则检查，在调用大模型之前完成；检查失败不影响后续分析
+	uc.updateJobProgress(ctx, job, StageLinting, 8)
+	lintSuggestions, err := uc.linter.lint(ctx, req.OrgID, &lint.Input{Resume: resumeData, Content: req.Content})
+	if err != nil {
+		uc.logger.WithContext(ctx).Warnf("简历规则检查失败: %v", err)
+	}
+
+	uc.updateJobProgress(ctx, job, StageAnalyzing, 10)
+
+	// 3. 执行智能分析，每个维度完成后上报进度
 	if uc.components.AnalysisGraph != nil {
-		analysisResult, err = uc.components.AnalysisGraph.Execute(ctx, resumeData, req.TargetPosition)
+		result, err := uc.components.AnalysisGraph.ExecuteWithProgress(ctx, resumeData, req.TargetPosition, req.JobDescription, func(node string, completed, total int) {
+			job.CompletedStages = append(job.CompletedStages, node)
+			uc.updateJobProgress(ctx, job, StageAnalyzing, 10+completed*80/total)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("智能分析失败: %w", err)
-		}
-	} else {
-		// 提供默认分析结果
-		analysisResult = &eino.AnalysisResult{
-			ID:             fmt.Sprintf("analysis_%s", req.ResumeID),
-			ResumeID:       req.ResumeID,
-			TargetPosition: req.TargetPosition,
-			Scores: eino.ScoreBreakdown{
-				OverallScore:        75.0,
-				CompletenessScore:   80.0,
-				ClarityScore:        70.0,
-				KeywordScore:        75.0,
-				FormatScore:         85.0,
-				QuantificationScore: 65.0,
-			},
-			Summary:    "简历整体质量良好，建议在量化描述方面进一步改进。",
-			AnalyzedAt: time.Now(),
+			return nil, err
 		}
+		result.LintSuggestions = lintSuggestions
+		return result, nil
 	}
 
-	// 3. 保存分析结果
-	if err := uc.repo.SaveAnalysisResult(ctx, analysisResult); err != nil {
-		uc.logger.WithContext(ctx).Errorf("保存分析结果失败: %v", err)
-		// 不阻断流程，继续返回结果
+	// 提供默认分析结果
+	return &eino.AnalysisResult{
+		ID:             fmt.Sprintf("analysis_%s", req.ResumeID),
+		ResumeID:       req.ResumeID,
+		TargetPosition: req.TargetPosition,
+		Scores: eino.ScoreBreakdown{
+			OverallScore:        75.0,
+			CompletenessScore:   80.0,
+			ClarityScore:        70.0,
+			KeywordScore:        75.0,
+			FormatScore:         85.0,
+			QuantificationScore: 65.0,
+		},
+		Summary:         "简历整体质量良好，建议在量化描述方面进一步改进。",
+		LintSuggestions: lintSuggestions,
+		AnalyzedAt:      time.Now(),
+	}, nil
+}
+
+// finishJob 将任务置为结束状态。使用独立的context，保证任务被中断后状态仍能写回
+func (uc *AIUsecase) finishJob(job *AnalysisJob, status, errMsg string) {
+	now := time.Now()
+	job.Status = status
+	job.CurrentStage = ""
+	job.ErrorMsg = errMsg
+	job.UpdatedAt = now
+	job.CompletedAt = &now
+	if err := uc.repo.UpdateAnalysisJob(context.Background(), job); err != nil {
+		uc.logger.Errorf("更新分析任务 %s 失败: %v", job.ID, err)
+	}
+}
+
+// abortJob 处理被中断的任务：超时的任务记为失败；已取消的任务状态由 CancelAnalysisJob 写入，这里不再覆盖
+func (uc *AIUsecase) abortJob(ctx context.Context, job *AnalysisJob) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		uc.finishJob(job, JobStatusFailed, "分析任务超时")
+		uc.logger.Warnf("分析任务 %s 超时", job.ID)
+		return
 	}
+	uc.logger.Infof("分析任务 %s 已中断", job.ID)
+}
 
-	uc.logger.WithContext(ctx).Infof("简历分析完成，分析ID: %s", analysisResult.ID)
+// FailStaleJobs 将超过任务超时时间仍未更新的任务记为失败。任务只在提交它的实例上执行，
+// 实例重启或崩溃后这些任务不会再有进展；分析请求没有持久化，因此无法重新执行，只能由用户重新提交
+func (uc *AIUsecase) FailStaleJobs(ctx context.Context) (int64, error) {
+	return uc.repo.FailStaleAnalysisJobs(ctx, time.Now().Add(-uc.jobTimeout), "分析任务已中断，请重新提交")
+}
 
-	return &AnalyzeResumeResponse{
-		AnalysisID: analysisResult.ID,
-		Result:     analysisResult,
-		Status:     "success",
-		Message:    "分析完成",
-	}, nil
+// JobCheckInterval 检查中断任务的间隔
+func (uc *AIUsecase) JobCheckInterval() time.Duration {
+	return uc.jobCheckInterval
+}
+
+// updateJobProgress 更新任务进度。若任务已被取消（可能在其他实例上），则中断本地执行
+func (uc *AIUsecase) updateJobProgress(ctx context.Context, job *AnalysisJob, stage string, progress int) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	job.Status = JobStatusProcessing
+	job.CurrentStage = stage
+	job.Progress = progress
+	job.UpdatedAt = time.Now()
+	if err := uc.repo.UpdateAnalysisJob(ctx, job); err != nil {
+		if errors.Is(err, ErrJobFinished) {
+			uc.cancelLocalJob(job.ID)
+			return
+		}
+		uc.logger.Warnf("更新分析任务 %s 进度失败: %v", job.ID, err)
+	}
+}
+
+// cancelLocalJob 中断本实例上正在运行的任务
+func (uc *AIUsecase) cancelLocalJob(jobID string) {
+	uc.jobsMu.Lock()
+	defer uc.jobsMu.Unlock()
+	if cancel, ok := uc.jobCancels[jobID]; ok {
+		cancel()
+	}
 }
 
 // GenerateSuggestions 生成优化建议
@@ -127,6 +540,10 @@ func (uc *AIUsecase) GenerateSuggestions(ctx context.Context, req *GenerateSugge
 	// 获取分析结果
 	analysisResult := req.AnalysisResult
 	if analysisResult == nil && req.AnalysisID != "" {
+		// 只能读取自己或所在组织的任务产生的分析结果
+		if _, err := uc.getOwnedAnalysisJob(ctx, req.AnalysisID); err != nil {
+			return nil, err
+		}
 		var err error
 		analysisResult, err = uc.repo.GetAnalysisResult(ctx, req.AnalysisID)
 		if err != nil {
@@ -135,7 +552,7 @@ func (uc *AIUsecase) GenerateSuggestions(ctx context.Context, req *GenerateSugge
 	}
 
 	if analysisResult == nil {
-		return nil, fmt.Errorf("分析结果不存在")
+		return nil, ErrAnalysisNotFound
 	}
 
 	// 生成建议（这里可以调用更复杂的AI生成逻辑）
@@ -184,20 +601,25 @@ func (uc *AIUsecase) GenerateSuggestions(ctx context.Context, req *GenerateSugge
 func (uc *AIUsecase) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	uc.logger.WithContext(ctx).Infof("开始处理智能问答，会话ID: %s", req.SessionID)
 
-	// 获取或创建会话上下文
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取或创建会话上下文，新会话的ID由服务端生成
 	var chatContext *eino.ChatContext
 	if req.SessionID != "" {
-		var err error
-		chatContext, err = uc.repo.GetChatSession(ctx, req.SessionID)
+		chatContext, err = uc.getOwnedSession(ctx, req.SessionID, userID)
 		if err != nil {
-			uc.logger.WithContext(ctx).Warnf("获取会话失败，创建新会话: %v", err)
+			return nil, err
 		}
-	}
-
-	if chatContext == nil {
+	} else {
 		chatContext = &eino.ChatContext{
-			SessionID: req.SessionID,
+			SessionID: uuid.New().String(),
+			UserID:    userID,
+			Title:     sessionTitleFromMessage(req.Message),
 			Messages:  []eino.Message{},
+			CreatedAt: time.Now(),
 		}
 	}
 
@@ -244,6 +666,7 @@ func (uc *AIUsecase) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 	})
 
 	// 保存会话
+	chatContext.LastActive = time.Now()
 	if err := uc.repo.SaveChatSession(ctx, chatContext); err != nil {
 		uc.logger.WithContext(ctx).Errorf("保存会话失败: %v", err)
 	}
@@ -257,6 +680,259 @@ func (uc *AIUsecase) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 	}, nil
 }
 
+// ListChatSessions 获取当前用户的会话列表
+func (uc *AIUsecase) ListChatSessions(ctx context.Context, req *ListChatSessionsRequest) (*ListChatSessionsResponse, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	sessions, total, err := uc.repo.ListChatSessions(ctx, userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取会话列表失败: %w", err)
+	}
+
+	return &ListChatSessionsResponse{
+		Sessions: sessions,
+		Total:    total,
+		Status:   "success",
+		Message:  "获取会话列表成功",
+	}, nil
+}
+
+// GetChatSession 获取当前用户的会话完整记录
+func (uc *AIUsecase) GetChatSession(ctx context.Context, sessionID string) (*eino.ChatContext, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return uc.getOwnedSession(ctx, sessionID, userID)
+}
+
+// RenameChatSession 重命名当前用户的会话
+func (uc *AIUsecase) RenameChatSession(ctx context.Context, sessionID, title string) (*eino.ChatContext, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, fmt.Errorf("会话标题不能为空")
+	}
+	if len([]rune(title)) > 200 {
+		return nil, fmt.Errorf("会话标题不能超过200个字符")
+	}
+
+	if err := uc.repo.RenameChatSession(ctx, sessionID, userID, title); err != nil {
+		return nil, err
+	}
+
+	return uc.getOwnedSession(ctx, sessionID, userID)
+}
+
+// ExportChatSession 导出当前用户的会话记录
+func (uc *AIUsecase) ExportChatSession(ctx context.Context, sessionID, format string) (*ExportChatSessionResponse, error) {
+	session, err := uc.GetChatSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(format) {
+	case "", "markdown", "md":
+		return &ExportChatSessionResponse{
+			Filename:    fmt.Sprintf("chat_%s.md", session.SessionID),
+			ContentType: "text/markdown; charset=utf-8",
+			Content:     renderSessionMarkdown(session),
+		}, nil
+	case "json":
+		content, err := json.MarshalIndent(map[string]interface{}{
+			"session_id":  session.SessionID,
+			"title":       session.Title,
+			"created_at":  session.CreatedAt,
+			"last_active": session.LastActive,
+			"messages":    session.Messages,
+		}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("序列化会话失败: %w", err)
+		}
+		return &ExportChatSessionResponse{
+			Filename:    fmt.Sprintf("chat_%s.json", session.SessionID),
+			ContentType: "application/json",
+			Content:     string(content),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidExportFormat, format)
+	}
+}
+
+// DeleteChatSession 删除当前用户的会话
+func (uc *AIUsecase) DeleteChatSession(ctx context.Context, sessionID string) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	return uc.repo.DeleteChatSession(ctx, sessionID, userID)
+}
+
+// CleanupIdleSessions 清理超过最大空闲时长的会话，返回清理数量
+func (uc *AIUsecase) CleanupIdleSessions(ctx context.Context) (int64, error) {
+	maxIdle := defaultSessionMaxIdle
+	if d := uc.chatConfig.GetSessionMaxIdle(); d != nil && d.AsDuration() > 0 {
+		maxIdle = d.AsDuration()
+	}
+	return uc.repo.CleanupExpiredSessions(ctx, time.Now().Add(-maxIdle))
+}
+
+// SessionCleanupInterval 会话清理任务的执行间隔
+func (uc *AIUsecase) SessionCleanupInterval() time.Duration {
+	if d := uc.chatConfig.GetCleanupInterval(); d != nil && d.AsDuration() > 0 {
+		return d.AsDuration()
+	}
+	return defaultSessionCleanupInterval
+}
+
+// currentUser 返回访问令牌中的用户ID，格式与任务和会话记录中的 user_id 相同
+func currentUser(ctx context.Context) (string, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(userID, 10), nil
+}
+
+// checkOrgQuota 检查组织本月的分析次数是否已用完，不属于组织的用户不受限制。
+// 并发提交时可能略微超出配额
+func (uc *AIUsecase) checkOrgQuota(ctx context.Context, orgID int64) error {
+	if orgID == 0 {
+		return nil
+	}
+	quota, err := uc.orgs.AIMonthlyQuota(ctx, orgID)
+	if err != nil {
+		uc.logger.WithContext(ctx).Errorf("获取组织设置失败: org_id=%d, %v", orgID, err)
+		return fmt.Errorf("获取组织设置失败: %w", err)
+	}
+	if quota <= 0 {
+		return nil
+	}
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	used, err := uc.repo.CountOrgAnalysisJobs(ctx, orgID, monthStart)
+	if err != nil {
+		return fmt.Errorf("统计组织分析次数失败: %w", err)
+	}
+	if used >= int64(quota) {
+		return ErrOrgQuotaExceeded
+	}
+	return nil
+}
+
+// getOwnedJob 获取当前用户可访问的分析任务：用户提交的任务和所在组织的任务，
+// 其他用户的任务和未记录用户的历史任务一律视为不存在
+func (uc *AIUsecase) getOwnedJob(ctx context.Context, jobID string) (*AnalysisJob, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job, err := uc.repo.GetAnalysisJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessJob(ctx, job, userID) {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	return job, nil
+}
+
+// getOwnedAnalysisJob 获取产生该分析结果、且属于当前用户或其所在组织的任务，其他用户的分析结果一律视为不存在
+func (uc *AIUsecase) getOwnedAnalysisJob(ctx context.Context, analysisID string) (*AnalysisJob, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job, err := uc.repo.GetAnalysisJobByAnalysisID(ctx, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessJob(ctx, job, userID) {
+		return nil, fmt.Errorf("%w: %s", ErrAnalysisNotFound, analysisID)
+	}
+	return job, nil
+}
+
+// canAccessJob 任务由该用户提交，或属于该用户当前所在的组织
+func canAccessJob(ctx context.Context, job *AnalysisJob, userID string) bool {
+	orgID := auth.OrgID(ctx)
+	return job.UserID == userID || orgID != 0 && job.OrgID == orgID
+}
+
+// getOwnedSession 获取属于指定用户的会话，其他用户的会话一律视为不存在
+func (uc *AIUsecase) getOwnedSession(ctx context.Context, sessionID, userID string) (*eino.ChatContext, error) {
+	session, err := uc.repo.GetChatSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// sessionTitleFromMessage 使用首条消息生成会话标题
+func sessionTitleFromMessage(message string) string {
+	title := strings.Join(strings.Fields(message), " ")
+	runes := []rune(title)
+	if len(runes) > maxSessionTitleLength {
+		return string(runes[:maxSessionTitleLength]) + "..."
+	}
+	if title == "" {
+		return "新对话"
+	}
+	return title
+}
+
+// renderSessionMarkdown 将会话渲染为Markdown
+func renderSessionMarkdown(session *eino.ChatContext) string {
+	var b strings.Builder
+
+	title := session.Title
+	if title == "" {
+		title = session.SessionID
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "- 会话ID: %s\n", session.SessionID)
+	if !session.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "- 创建时间: %s\n", session.CreatedAt.Format(time.RFC3339))
+	}
+	if !session.LastActive.IsZero() {
+		fmt.Fprintf(&b, "- 最后活跃: %s\n", session.LastActive.Format(time.RFC3339))
+	}
+	b.WriteString("\n")
+
+	for _, msg := range session.Messages {
+		role := msg.Role
+		switch msg.Role {
+		case "human", "user":
+			role = "用户"
+		case "assistant":
+			role = "助手"
+		case "system":
+			role = "系统"
+		}
+		fmt.Fprintf(&b, "## %s\n\n%s\n\n", role, msg.Content)
+	}
+
+	return b.String()
+}
+
 // RetrieveKnowledge 知识检索
 func (uc *AIUsecase) RetrieveKnowledge(ctx context.Context, req *RetrieveKnowledgeRequest) (*RetrieveKnowledgeResponse, error) {
 	uc.logger.WithContext(ctx).Infof("开始知识检索，查询: %s", req.Query)
@@ -297,10 +973,14 @@ func (uc *AIUsecase) RetrieveKnowledge(ctx context.Context, req *RetrieveKnowled
 
 type AnalyzeResumeRequest struct {
 	ResumeID       string
+	OrgID          string // 组织ID，决定启用哪些检查规则，必须为当前用户所在的组织
 	Content        string
 	FilePath       string
 	FileType       string
+	FileID         string
+	FileVersion    int32
 	TargetPosition string
+	JobDescription string
 	Options        *AnalysisOptions
 }
 
@@ -313,8 +993,10 @@ type AnalysisOptions struct {
 }
 
 type AnalyzeResumeResponse struct {
+	JobID      string
 	AnalysisID string
 	Result     *eino.AnalysisResult
+	Progress   int
 	Status     string
 	Message    string
 }
@@ -361,6 +1043,33 @@ type ChatResponse struct {
 	Message   string
 }
 
+type ChatSessionSummary struct {
+	SessionID    string
+	UserID       string
+	Title        string
+	MessageCount int
+	CreatedAt    time.Time
+	LastActive   time.Time
+}
+
+type ListChatSessionsRequest struct {
+	Page     int
+	PageSize int
+}
+
+type ListChatSessionsResponse struct {
+	Sessions []*ChatSessionSummary
+	Total    int64
+	Status   string
+	Message  string
+}
+
+type ExportChatSessionResponse struct {
+	Filename    string
+	ContentType string
+	Content     string
+}
+
 type RetrieveKnowledgeRequest struct {
 	Query               string
 	TopK                int32
@@ -0,0 +1,79 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+// denyFiles 任何文件都不可访问，记录查询的文件
+type denyFiles struct {
+	fileID  string
+	version int32
+}
+
+func (f *denyFiles) CheckAccess(_ context.Context, fileID string, version int32) error {
+	f.fileID, f.version = fileID, version
+	return ErrFileNotFound
+}
+
+func TestAnalyzeResumeRejectsInaccessibleFile(t *testing.T) {
+	files := &denyFiles{}
+	// repo 为空：拒绝必须发生在创建任务之前
+	uc := &AIUsecase{files: files, logger: log.NewHelper(log.NewStdLogger(io.Discard))}
+	ctx := auth.NewContext(context.Background(), 1)
+
+	_, err := uc.AnalyzeResume(ctx, &AnalyzeResumeRequest{ResumeID: "r1", FileID: "someone-elses-file", FileVersion: 2})
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("AnalyzeResume error = %v, want ErrFileNotFound", err)
+	}
+	if files.fileID != "someone-elses-file" || files.version != 2 {
+		t.Errorf("CheckAccess called with %q v%d", files.fileID, files.version)
+	}
+}
+
+// exportRepo 按旧查询的语义返回任务：用户提交的任务和分析这些文件的任何任务
+type exportRepo struct {
+	AIRepo
+	jobs []*AnalysisJob
+}
+
+func (r *exportRepo) ListUserAnalysisJobs(_ context.Context, _ string, _ []string, afterID string, limit int) ([]*AnalysisJob, error) {
+	var page []*AnalysisJob
+	for _, job := range r.jobs {
+		if job.ID > afterID && len(page) < limit {
+			page = append(page, job)
+		}
+	}
+	return page, nil
+}
+
+func TestExportAnalysesOnlyOwnJobs(t *testing.T) {
+	repo := &exportRepo{jobs: []*AnalysisJob{
+		{ID: "j1", UserID: "1", FileID: "f1", Status: JobStatusFailed},
+		{ID: "j2", UserID: "2", OrgID: 7, FileID: "f1", Status: JobStatusFailed}, // 组织成员对同一文件的分析
+		{ID: "j3", UserID: "", FileID: "f1", Status: JobStatusFailed},            // 未记录用户的历史任务
+		{ID: "j4", UserID: "3", FileID: "f2", Status: JobStatusFailed},
+	}}
+	uc := &AIUsecase{repo: repo, logger: log.NewHelper(log.NewStdLogger(io.Discard))}
+	ctx := auth.NewOrgContext(auth.NewContext(context.Background(), 1), 7)
+
+	var exported []string
+	err := uc.ExportAnalyses(ctx, []string{"f1", "f2"}, func(job *AnalysisJob, _ *eino.AnalysisResult) error {
+		exported = append(exported, job.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportAnalyses: %v", err)
+	}
+	if got := strings.Join(exported, ","); got != "j1,j3" {
+		t.Errorf("exported jobs = %s, want j1,j3", got)
+	}
+}
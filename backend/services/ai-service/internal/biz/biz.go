@@ -3,4 +3,4 @@ package biz
 import "github.com/google/wire"
 
 // ProviderSet is biz providers.
-var ProviderSet = wire.NewSet(NewAIUsecase)
+var ProviderSet = wire.NewSet(NewAIUsecase, NewSkillTaxonomy, NewSkillUsecase, NewLintEngine, NewLintUsecase)
@@ -0,0 +1,176 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/lint"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/models"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+var (
+	// ErrOrgIDRequired 组织ID不能为空
+	ErrOrgIDRequired = errors.New("组织ID不能为空")
+	// ErrOrgForbidden 请求指定的组织不是当前用户所在的组织
+	ErrOrgForbidden = kerrors.Forbidden("ORG_FORBIDDEN", "无权访问该组织")
+	// ErrOrgManagerRequired 只有组织所有者或管理员可以修改组织的检查规则
+	ErrOrgManagerRequired = kerrors.Forbidden("ORG_MANAGER_REQUIRED", "只有组织所有者或管理员可以修改检查规则")
+)
+
+// LintRuleRepo 组织级检查规则开关仓库接口
+type LintRuleRepo interface {
+	// ListRuleSettings 返回组织显式设置过的规则开关，key 为规则ID
+	ListRuleSettings(ctx context.Context, orgID string) (map[string]bool, error)
+	SaveRuleSetting(ctx context.Context, orgID, ruleID string, enabled bool) error
+}
+
+// NewLintEngine 加载检查规则，配置了规则文件时使用规则文件，否则使用内置规则
+func NewLintEngine(aiConfig *conf.AI, logger log.Logger) (*lint.Engine, error) {
+	helper := log.NewHelper(logger)
+
+	path := aiConfig.GetLint().GetRulesPath()
+	if path == "" {
+		engine, err := lint.Default()
+		if err != nil {
+			return nil, err
+		}
+		helper.Infof("加载内置检查规则 %d 条", len(engine.Rules()))
+		return engine, nil
+	}
+
+	engine, err := lint.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("加载检查规则 %s 失败: %w", path, err)
+	}
+	helper.Infof("从 %s 加载检查规则 %d 条", path, len(engine.Rules()))
+	return engine, nil
+}
+
+// LintRuleState 规则及其在组织中的开关状态
+type LintRuleState struct {
+	Rule       *lint.Rule
+	Enabled    bool
+	Overridden bool // 组织是否显式设置过该规则
+}
+
+// LintUsecase 规则检查用例。检查是确定性的，不调用大模型
+type LintUsecase struct {
+	repo   LintRuleRepo
+	engine *lint.Engine
+	logger *log.Helper
+}
+
+// NewLintUsecase 创建规则检查用例
+func NewLintUsecase(repo LintRuleRepo, engine *lint.Engine, logger log.Logger) *LintUsecase {
+	return &LintUsecase{
+		repo:   repo,
+		engine: engine,
+		logger: log.NewHelper(logger),
+	}
+}
+
+// Lint 按当前用户所在组织的规则开关检查简历，不属于组织时使用规则默认开关。
+// orgID 不为空时必须与所在组织一致
+func (uc *LintUsecase) Lint(ctx context.Context, orgID string, input *lint.Input) ([]models.Suggestion, error) {
+	orgID, err := CallerOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.lint(ctx, orgID, input)
+}
+
+// lint 按指定组织的规则开关检查简历，供已确定组织的分析任务使用
+func (uc *LintUsecase) lint(ctx context.Context, orgID string, input *lint.Input) ([]models.Suggestion, error) {
+	settings, err := uc.settings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.engine.Run(input, func(rule *lint.Rule) bool {
+		if enabled, ok := settings[rule.ID]; ok {
+			return enabled
+		}
+		return rule.EnabledByDefault()
+	}), nil
+}
+
+// ListRules 列出全部规则以及在当前用户所在组织中的开关状态，orgID 不为空时必须与所在组织一致
+func (uc *LintUsecase) ListRules(ctx context.Context, orgID string) ([]*LintRuleState, error) {
+	orgID, err := CallerOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := uc.settings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := uc.engine.Rules()
+	states := make([]*LintRuleState, 0, len(rules))
+	for _, rule := range rules {
+		states = append(states, ruleState(rule, settings))
+	}
+	return states, nil
+}
+
+// SetRuleEnabled 开启或关闭当前用户所在组织的某条规则，需要是组织的所有者或管理员。
+// orgID 不为空时必须与所在组织一致
+func (uc *LintUsecase) SetRuleEnabled(ctx context.Context, orgID, ruleID string, enabled bool) (*LintRuleState, error) {
+	orgID, err := CallerOrgID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if orgID == "" {
+		return nil, ErrOrgIDRequired
+	}
+	if !auth.IsOrgManager(ctx) {
+		return nil, ErrOrgManagerRequired
+	}
+	rule, ok := uc.engine.Rule(ruleID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", lint.ErrRuleNotFound, ruleID)
+	}
+
+	if err := uc.repo.SaveRuleSetting(ctx, orgID, ruleID, enabled); err != nil {
+		return nil, err
+	}
+
+	uc.logger.WithContext(ctx).Infof("组织 %s 的检查规则 %s 已设置为 enabled=%t", orgID, ruleID, enabled)
+	return ruleState(rule, map[string]bool{ruleID: enabled}), nil
+}
+
+// CallerOrgID 返回访问令牌中的组织ID，不属于组织时为空。
+// 请求中指定的组织必须与之一致，否则返回 ErrOrgForbidden
+func CallerOrgID(ctx context.Context, requested string) (string, error) {
+	var orgID string
+	if id := auth.OrgID(ctx); id > 0 {
+		orgID = strconv.FormatInt(id, 10)
+	}
+	if requested != "" && requested != orgID {
+		return "", ErrOrgForbidden
+	}
+	return orgID, nil
+}
+
+func (uc *LintUsecase) settings(ctx context.Context, orgID string) (map[string]bool, error) {
+	if orgID == "" {
+		return nil, nil
+	}
+	return uc.repo.ListRuleSettings(ctx, orgID)
+}
+
+func ruleState(rule *lint.Rule, settings map[string]bool) *LintRuleState {
+	state := &LintRuleState{Rule: rule, Enabled: rule.EnabledByDefault()}
+	if enabled, ok := settings[rule.ID]; ok {
+		state.Enabled = enabled
+		state.Overridden = true
+	}
+	return state
+}
@@ -0,0 +1,105 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/lint"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+func TestCallerOrgID(t *testing.T) {
+	member := auth.NewOrgContext(context.Background(), 7)
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		requested string
+		want      string
+		wantErr   error
+	}{
+		{name: "own org", ctx: member, requested: "7", want: "7"},
+		{name: "unspecified uses own org", ctx: member, want: "7"},
+		{name: "other org", ctx: member, requested: "8", wantErr: ErrOrgForbidden},
+		{name: "no org", ctx: context.Background(), want: ""},
+		{name: "no org requests org", ctx: context.Background(), requested: "7", wantErr: ErrOrgForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CallerOrgID(tt.ctx, tt.requested)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("CallerOrgID(%q) = %q, %v, want %q, %v", tt.requested, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// memLintRuleRepo 内存规则开关仓库
+type memLintRuleRepo struct {
+	settings map[string]map[string]bool
+}
+
+func (r *memLintRuleRepo) ListRuleSettings(_ context.Context, orgID string) (map[string]bool, error) {
+	return r.settings[orgID], nil
+}
+
+func (r *memLintRuleRepo) SaveRuleSetting(_ context.Context, orgID, ruleID string, enabled bool) error {
+	if r.settings[orgID] == nil {
+		r.settings[orgID] = make(map[string]bool)
+	}
+	r.settings[orgID][ruleID] = enabled
+	return nil
+}
+
+func TestSetRuleEnabledRequiresOrgManager(t *testing.T) {
+	engine, err := lint.Default()
+	if err != nil {
+		t.Fatalf("lint.Default: %v", err)
+	}
+	ruleID := engine.Rules()[0].ID
+	claimsContext := func(claims *auth.Claims) context.Context {
+		return auth.NewClaimsContext(context.Background(), claims)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{name: "owner", ctx: claimsContext(&auth.Claims{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleOwner})},
+		{name: "admin", ctx: claimsContext(&auth.Claims{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleAdmin})},
+		{name: "member", ctx: claimsContext(&auth.Claims{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleMember}), wantErr: ErrOrgManagerRequired},
+		{
+			name:    "coach who is a plain member",
+			ctx:     claimsContext(&auth.Claims{UserID: 1, Role: auth.RoleCoach, Permissions: auth.Permissions(auth.RoleCoach), OrgID: 7, OrgRole: auth.OrgRoleMember}),
+			wantErr: ErrOrgManagerRequired,
+		},
+		{name: "no org", ctx: claimsContext(&auth.Claims{UserID: 1, Role: auth.RoleAdmin}), wantErr: ErrOrgIDRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memLintRuleRepo{settings: make(map[string]map[string]bool)}
+			uc := NewLintUsecase(repo, engine, log.NewStdLogger(io.Discard))
+
+			state, err := uc.SetRuleEnabled(tt.ctx, "", ruleID, false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetRuleEnabled error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.settings) != 0 {
+					t.Errorf("rejected call saved settings %v", repo.settings)
+				}
+				return
+			}
+			if state.Enabled || !state.Overridden {
+				t.Errorf("state = %+v, want disabled and overridden", state)
+			}
+			if enabled, ok := repo.settings["7"][ruleID]; !ok || enabled {
+				t.Errorf("org 7 settings = %v, want %s disabled", repo.settings["7"], ruleID)
+			}
+		})
+	}
+}
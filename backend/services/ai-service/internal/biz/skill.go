@@ -0,0 +1,155 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/skills"
+)
+
+// SkillRepo 自定义技能仓库接口。内置技能数据随代码发布，管理员新增或修改的技能持久化在这里
+type SkillRepo interface {
+	ListCustomSkills(ctx context.Context) ([]*skills.Skill, error)
+	SaveCustomSkill(ctx context.Context, skill *skills.Skill) error
+}
+
+// NewSkillTaxonomy 加载内置技能数据集，并叠加管理员维护的自定义技能
+func NewSkillTaxonomy(repo SkillRepo, logger log.Logger) (*skills.Taxonomy, error) {
+	helper := log.NewHelper(logger)
+
+	taxonomy, err := skills.LoadBundled()
+	if err != nil {
+		return nil, err
+	}
+
+	custom, err := repo.ListCustomSkills(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("加载自定义技能失败: %w", err)
+	}
+
+	// 自定义技能之间可能互相引用为父节点，按依赖顺序多轮加载
+	pending := custom
+	for len(pending) > 0 {
+		var next []*skills.Skill
+		for _, skill := range pending {
+			if err := taxonomy.Upsert(skill); err != nil {
+				next = append(next, skill)
+			}
+		}
+		if len(next) == len(pending) {
+			for _, skill := range next {
+				helper.Warnf("忽略无效的自定义技能 %s: %v", skill.ID, taxonomy.Upsert(skill))
+			}
+			break
+		}
+		pending = next
+	}
+
+	helper.Infof("技能本体加载完成，共 %d 个技能（自定义 %d 个）", len(taxonomy.List("")), len(custom))
+	return taxonomy, nil
+}
+
+// SkillUsecase 技能本体用例
+type SkillUsecase struct {
+	repo     SkillRepo
+	taxonomy *skills.Taxonomy
+	logger   *log.Helper
+}
+
+// NewSkillUsecase 创建技能本体用例
+func NewSkillUsecase(repo SkillRepo, taxonomy *skills.Taxonomy, logger log.Logger) *SkillUsecase {
+	return &SkillUsecase{
+		repo:     repo,
+		taxonomy: taxonomy,
+		logger:   log.NewHelper(logger),
+	}
+}
+
+// SkillDetail 技能详情
+type SkillDetail struct {
+	Skill     *skills.Skill
+	Ancestors []string
+	Children  []string
+}
+
+// NormalizeSkillsResult 技能归一化结果
+type NormalizeSkillsResult struct {
+	Skills    []*skills.Skill // 归一化后的技能（去重）
+	Unknown   []string        // 无法识别的写法
+	Extracted []*skills.Skill // 从文本中提取的技能
+}
+
+// ListSkills 列出技能，category 为空时返回全部
+func (uc *SkillUsecase) ListSkills(ctx context.Context, category string) []*skills.Skill {
+	return uc.taxonomy.List(category)
+}
+
+// GetSkill 获取技能详情，id 也可以是任意别名
+func (uc *SkillUsecase) GetSkill(ctx context.Context, id string) (*SkillDetail, error) {
+	skill, ok := uc.taxonomy.Get(id)
+	if !ok {
+		if skill, ok = uc.taxonomy.Lookup(id); !ok {
+			return nil, fmt.Errorf("%w: %s", skills.ErrSkillNotFound, id)
+		}
+	}
+
+	return &SkillDetail{
+		Skill:     skill,
+		Ancestors: uc.taxonomy.Ancestors(skill.ID),
+		Children:  uc.taxonomy.Children(skill.ID),
+	}, nil
+}
+
+// UpsertSkill 新增或更新技能（管理员）。先在本体中校验，校验通过后持久化
+func (uc *SkillUsecase) UpsertSkill(ctx context.Context, skill *skills.Skill) (*skills.Skill, error) {
+	skill.ID = strings.ToLower(strings.TrimSpace(skill.ID))
+	skill.Name = strings.TrimSpace(skill.Name)
+
+	previous, existed := uc.taxonomy.Get(skill.ID)
+	if err := uc.taxonomy.Upsert(skill); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.SaveCustomSkill(ctx, skill); err != nil {
+		// 持久化失败时回滚内存中的修改
+		rollbackErr := uc.taxonomy.Remove(skill.ID)
+		if existed {
+			rollbackErr = uc.taxonomy.Upsert(previous)
+		}
+		if rollbackErr != nil {
+			uc.logger.WithContext(ctx).Errorf("回滚技能 %s 失败: %v", skill.ID, rollbackErr)
+		}
+		return nil, fmt.Errorf("保存技能失败: %w", err)
+	}
+
+	uc.logger.WithContext(ctx).Infof("技能已更新: %s (%s)", skill.ID, skill.Name)
+	saved, _ := uc.taxonomy.Get(skill.ID)
+	return saved, nil
+}
+
+// NormalizeSkills 归一化技能写法，并可从职位描述等自由文本中提取技能
+func (uc *SkillUsecase) NormalizeSkills(ctx context.Context, terms []string, text string) *NormalizeSkillsResult {
+	ids, unknown := uc.taxonomy.Normalize(terms)
+
+	result := &NormalizeSkillsResult{
+		Skills:  uc.skillsByID(ids),
+		Unknown: unknown,
+	}
+	if text != "" {
+		result.Extracted = uc.skillsByID(uc.taxonomy.Extract(text))
+	}
+	return result
+}
+
+func (uc *SkillUsecase) skillsByID(ids []string) []*skills.Skill {
+	list := make([]*skills.Skill, 0, len(ids))
+	for _, id := range ids {
+		if skill, ok := uc.taxonomy.Get(id); ok {
+			list = append(list, skill)
+		}
+	}
+	return list
+}
@@ -7,11 +7,16 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
 	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
 )
 
+// sessionCleanupBatchSize 清理过期会话时每批删除的会话数
+const sessionCleanupBatchSize = 500
+
 // aiRepo AI数据仓库实现
 type aiRepo struct {
 	data *Data
@@ -32,13 +37,36 @@ type AnalysisResultModel struct {
 
 // ChatSessionModel 聊天会话数据模型
 type ChatSessionModel struct {
-	ID         string    `gorm:"primaryKey;size:64" json:"id"`
-	SessionID  string    `gorm:"uniqueIndex;size:64;not null" json:"session_id"`
-	Messages   string    `gorm:"type:longtext" json:"messages"` // JSON格式存储消息列表
-	Context    string    `gorm:"type:text" json:"context"`      // JSON格式存储上下文
-	LastActive time.Time `gorm:"index" json:"last_active"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID           string    `gorm:"primaryKey;size:64" json:"id"`
+	SessionID    string    `gorm:"uniqueIndex;size:64;not null" json:"session_id"`
+	UserID       string    `gorm:"index;size:64" json:"user_id"`
+	Title        string    `gorm:"size:200" json:"title"`
+	MessageCount int       `gorm:"default:0" json:"message_count"`
+	Messages     string    `gorm:"type:longtext" json:"messages"` // JSON格式存储消息列表
+	Context      string    `gorm:"type:text" json:"context"`      // JSON格式存储上下文
+	LastActive   time.Time `gorm:"index" json:"last_active"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AnalysisJobModel 分析任务数据模型
+type AnalysisJobModel struct {
+	ID              string     `gorm:"primaryKey;size:64" json:"id"`
+	ResumeID        string     `gorm:"index;size:64;not null" json:"resume_id"`
+	UserID          string     `gorm:"index;size:64" json:"user_id"`
+	OrgID           int64      `gorm:"index;default:0" json:"org_id"`
+	FileID          string     `gorm:"index;size:100" json:"file_id"`
+	FileVersion     int32      `gorm:"default:0" json:"file_version"`
+	TargetPosition  string     `gorm:"size:100" json:"target_position"`
+	Status          string     `gorm:"index;size:20;default:pending" json:"status"`
+	Progress        int        `gorm:"default:0" json:"progress"`
+	CurrentStage    string     `gorm:"size:50" json:"current_stage"`
+	CompletedStages string     `gorm:"type:text" json:"completed_stages"` // JSON格式存储已完成阶段
+	AnalysisID      string     `gorm:"index;size:64" json:"analysis_id"`
+	ErrorMsg        string     `gorm:"type:text" json:"error_msg"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // TableName 设置表名
@@ -50,6 +78,10 @@ func (ChatSessionModel) TableName() string {
 	return "ai_chat_sessions"
 }
 
+func (AnalysisJobModel) TableName() string {
+	return "ai_analysis_jobs"
+}
+
 // SaveAnalysisResult 保存分析结果
 func (r *aiRepo) SaveAnalysisResult(ctx context.Context, result *eino.AnalysisResult) error {
 	r.log.WithContext(ctx).Infof("保存分析结果: %s", result.ID)
@@ -123,6 +155,160 @@ func (r *aiRepo) GetAnalysisResult(ctx context.Context, id string) (*eino.Analys
 	return &result, nil
 }
 
+// CreateAnalysisJob 创建分析任务
+func (r *aiRepo) CreateAnalysisJob(ctx context.Context, job *biz.AnalysisJob) (*biz.AnalysisJob, error) {
+	model := analysisJobBizToModel(job)
+	if err := r.data.db.WithContext(ctx).Create(model).Error; err != nil {
+		return nil, fmt.Errorf("创建分析任务失败: %w", err)
+	}
+	return analysisJobModelToBiz(model), nil
+}
+
+// GetAnalysisJob 获取分析任务。任务进度需要跨实例可见，因此直接读取数据库，不做缓存
+func (r *aiRepo) GetAnalysisJob(ctx context.Context, jobID string) (*biz.AnalysisJob, error) {
+	var model AnalysisJobModel
+	if err := r.data.db.WithContext(ctx).Where("id = ?", jobID).First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: %s", biz.ErrJobNotFound, jobID)
+		}
+		return nil, fmt.Errorf("查询分析任务失败: %w", err)
+	}
+	return analysisJobModelToBiz(&model), nil
+}
+
+// GetAnalysisJobByAnalysisID 按分析结果ID获取产生它的任务
+func (r *aiRepo) GetAnalysisJobByAnalysisID(ctx context.Context, analysisID string) (*biz.AnalysisJob, error) {
+	var model AnalysisJobModel
+	if err := r.data.db.WithContext(ctx).Where("analysis_id = ?", analysisID).First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: %s", biz.ErrAnalysisNotFound, analysisID)
+		}
+		return nil, fmt.Errorf("查询分析任务失败: %w", err)
+	}
+	return analysisJobModelToBiz(&model), nil
+}
+
+// UpdateAnalysisJob 更新分析任务。只更新仍处于等待或执行中的任务，
+// 保证已取消的任务不会被执行中的进度上报覆盖
+func (r *aiRepo) UpdateAnalysisJob(ctx context.Context, job *biz.AnalysisJob) error {
+	model := analysisJobBizToModel(job)
+	result := r.data.db.WithContext(ctx).Model(&AnalysisJobModel{}).
+		Where("id = ? AND status IN ?", job.ID, []string{biz.JobStatusPending, biz.JobStatusProcessing}).
+		Updates(map[string]interface{}{
+			"status":           model.Status,
+			"progress":         model.Progress,
+			"current_stage":    model.CurrentStage,
+			"completed_stages": model.CompletedStages,
+			"analysis_id":      model.AnalysisID,
+			"error_msg":        model.ErrorMsg,
+			"completed_at":     model.CompletedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新分析任务失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", biz.ErrJobFinished, job.ID)
+	}
+	return nil
+}
+
+// FailStaleAnalysisJobs 将最后更新时间早于 updatedBefore 的等待或执行中的任务记为失败。
+// 条件更新保证不会覆盖其间已结束或更新了进度的任务
+func (r *aiRepo) FailStaleAnalysisJobs(ctx context.Context, updatedBefore time.Time, errMsg string) (int64, error) {
+	result := r.data.db.WithContext(ctx).Model(&AnalysisJobModel{}).
+		Where("status IN ? AND updated_at < ?", []string{biz.JobStatusPending, biz.JobStatusProcessing}, updatedBefore).
+		Updates(map[string]interface{}{
+			"status":        biz.JobStatusFailed,
+			"current_stage": "",
+			"error_msg":     errMsg,
+			"completed_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("更新中断的分析任务失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ListUserAnalysisJobs 按任务ID分页查询用户提交的分析任务，记录用户之前创建的任务通过文件ID关联。
+// 其他用户（包括组织成员）对同一文件的分析不属于该用户
+func (r *aiRepo) ListUserAnalysisJobs(ctx context.Context, userID string, fileIDs []string, afterID string, limit int) ([]*biz.AnalysisJob, error) {
+	query := r.data.db.WithContext(ctx).Where("id > ?", afterID)
+	if len(fileIDs) > 0 {
+		query = query.Where("(user_id = ? OR ((user_id IS NULL OR user_id = '') AND file_id IN ?))", userID, fileIDs)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var models []AnalysisJobModel
+	if err := query.Order("id ASC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询用户分析任务失败: %w", err)
+	}
+
+	jobs := make([]*biz.AnalysisJob, len(models))
+	for i := range models {
+		jobs[i] = analysisJobModelToBiz(&models[i])
+	}
+	return jobs, nil
+}
+
+// CountOrgAnalysisJobs 统计组织自 since 起提交的分析任务数，已取消和失败的任务同样计入
+func (r *aiRepo) CountOrgAnalysisJobs(ctx context.Context, orgID int64, since time.Time) (int64, error) {
+	var count int64
+	if err := r.data.db.WithContext(ctx).Model(&AnalysisJobModel{}).
+		Where("org_id = ? AND created_at >= ?", orgID, since).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("统计组织分析任务失败: %w", err)
+	}
+	return count, nil
+}
+
+func analysisJobBizToModel(job *biz.AnalysisJob) *AnalysisJobModel {
+	stages, _ := json.Marshal(job.CompletedStages)
+	return &AnalysisJobModel{
+		ID:              job.ID,
+		ResumeID:        job.ResumeID,
+		UserID:          job.UserID,
+		OrgID:           job.OrgID,
+		FileID:          job.FileID,
+		FileVersion:     job.FileVersion,
+		TargetPosition:  job.TargetPosition,
+		Status:          job.Status,
+		Progress:        job.Progress,
+		CurrentStage:    job.CurrentStage,
+		CompletedStages: string(stages),
+		AnalysisID:      job.AnalysisID,
+		ErrorMsg:        job.ErrorMsg,
+		CompletedAt:     job.CompletedAt,
+		CreatedAt:       job.CreatedAt,
+		UpdatedAt:       job.UpdatedAt,
+	}
+}
+
+func analysisJobModelToBiz(model *AnalysisJobModel) *biz.AnalysisJob {
+	var stages []string
+	if model.CompletedStages != "" {
+		_ = json.Unmarshal([]byte(model.CompletedStages), &stages)
+	}
+	return &biz.AnalysisJob{
+		ID:              model.ID,
+		ResumeID:        model.ResumeID,
+		UserID:          model.UserID,
+		OrgID:           model.OrgID,
+		FileID:          model.FileID,
+		FileVersion:     model.FileVersion,
+		TargetPosition:  model.TargetPosition,
+		Status:          model.Status,
+		Progress:        model.Progress,
+		CurrentStage:    model.CurrentStage,
+		CompletedStages: stages,
+		AnalysisID:      model.AnalysisID,
+		ErrorMsg:        model.ErrorMsg,
+		CompletedAt:     model.CompletedAt,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+	}
+}
+
 // SaveChatSession 保存聊天会话
 func (r *aiRepo) SaveChatSession(ctx context.Context, session *eino.ChatContext) error {
 	r.log.WithContext(ctx).Infof("保存聊天会话: %s", session.SessionID)
@@ -142,24 +328,35 @@ func (r *aiRepo) SaveChatSession(ctx context.Context, session *eino.ChatContext)
 		return fmt.Errorf("序列化上下文失败: %w", err)
 	}
 
+	if session.LastActive.IsZero() {
+		session.LastActive = time.Now()
+	}
+
 	model := &ChatSessionModel{
-		SessionID:  session.SessionID,
-		Messages:   string(messagesData),
-		Context:    string(contextData),
-		LastActive: time.Now(),
+		ID:           uuid.New().String(),
+		SessionID:    session.SessionID,
+		UserID:       session.UserID,
+		Title:        session.Title,
+		MessageCount: len(session.Messages),
+		Messages:     string(messagesData),
+		Context:      string(contextData),
+		LastActive:   session.LastActive,
 	}
 
 	// 使用UPSERT操作
 	if err := r.data.db.WithContext(ctx).
 		Where("session_id = ?", session.SessionID).
 		Assign(map[string]interface{}{
-			"messages":    model.Messages,
-			"context":     model.Context,
-			"last_active": model.LastActive,
+			"title":         model.Title,
+			"message_count": model.MessageCount,
+			"messages":      model.Messages,
+			"context":       model.Context,
+			"last_active":   model.LastActive,
 		}).
 		FirstOrCreate(model).Error; err != nil {
 		return fmt.Errorf("保存聊天会话失败: %w", err)
 	}
+	session.CreatedAt = model.CreatedAt
 
 	// 缓存会话（30分钟过期）
 	cacheKey := fmt.Sprintf("chat_session:%s", session.SessionID)
@@ -191,7 +388,7 @@ func (r *aiRepo) GetChatSession(ctx context.Context, sessionID string) (*eino.Ch
 	var model ChatSessionModel
 	if err := r.data.db.WithContext(ctx).Where("session_id = ?", sessionID).First(&model).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("会话不存在: %s", sessionID)
+			return nil, fmt.Errorf("%w: %s", biz.ErrSessionNotFound, sessionID)
 		}
 		return nil, fmt.Errorf("查询会话失败: %w", err)
 	}
@@ -208,8 +405,12 @@ func (r *aiRepo) GetChatSession(ctx context.Context, sessionID string) (*eino.Ch
 	}
 
 	session := &eino.ChatContext{
-		SessionID: sessionID,
-		Messages:  messages,
+		SessionID:  sessionID,
+		UserID:     model.UserID,
+		Title:      model.Title,
+		Messages:   messages,
+		CreatedAt:  model.CreatedAt,
+		LastActive: model.LastActive,
 	}
 
 	// 恢复上下文数据
@@ -244,20 +445,123 @@ func (r *aiRepo) GetChatSession(ctx context.Context, sessionID string) (*eino.Ch
 	return session, nil
 }
 
-// CleanupExpiredSessions 清理过期会话（可以定时调用）
-func (r *aiRepo) CleanupExpiredSessions(ctx context.Context, expiredBefore time.Time) error {
+// ListChatSessions 获取用户的会话列表
+func (r *aiRepo) ListChatSessions(ctx context.Context, userID string, limit, offset int) ([]*biz.ChatSessionSummary, int64, error) {
+	var models []ChatSessionModel
+	var total int64
+
+	query := r.data.db.WithContext(ctx).Model(&ChatSessionModel{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计会话数量失败: %w", err)
+	}
+
+	// 列表只需要摘要字段，避免加载完整消息记录
+	if err := query.
+		Select("session_id", "user_id", "title", "message_count", "created_at", "last_active").
+		Order("last_active DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&models).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询会话列表失败: %w", err)
+	}
+
+	sessions := make([]*biz.ChatSessionSummary, len(models))
+	for i, model := range models {
+		sessions[i] = &biz.ChatSessionSummary{
+			SessionID:    model.SessionID,
+			UserID:       model.UserID,
+			Title:        model.Title,
+			MessageCount: model.MessageCount,
+			CreatedAt:    model.CreatedAt,
+			LastActive:   model.LastActive,
+		}
+	}
+
+	return sessions, total, nil
+}
+
+// RenameChatSession 重命名会话
+func (r *aiRepo) RenameChatSession(ctx context.Context, sessionID, userID, title string) error {
 	result := r.data.db.WithContext(ctx).
-		Where("last_active < ?", expiredBefore).
-		Delete(&ChatSessionModel{})
+		Model(&ChatSessionModel{}).
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		Update("title", title)
+	if result.Error != nil {
+		return fmt.Errorf("重命名会话失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrSessionNotFound
+	}
 
+	r.evictChatSession(ctx, sessionID)
+	return nil
+}
+
+// DeleteChatSession 删除会话
+func (r *aiRepo) DeleteChatSession(ctx context.Context, sessionID, userID string) error {
+	result := r.data.db.WithContext(ctx).
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		Delete(&ChatSessionModel{})
 	if result.Error != nil {
-		return fmt.Errorf("清理过期会话失败: %w", result.Error)
+		return fmt.Errorf("删除会话失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrSessionNotFound
 	}
 
-	r.log.WithContext(ctx).Infof("清理了 %d 个过期会话", result.RowsAffected)
+	r.evictChatSession(ctx, sessionID)
 	return nil
 }
 
+// CleanupExpiredSessions 清理最后活跃时间早于expiredBefore的会话，每批最多删除 sessionCleanupBatchSize 个
+func (r *aiRepo) CleanupExpiredSessions(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	var total int64
+	for {
+		var sessionIDs []string
+		if err := r.data.db.WithContext(ctx).
+			Model(&ChatSessionModel{}).
+			Where("last_active < ?", expiredBefore).
+			Order("last_active ASC").
+			Limit(sessionCleanupBatchSize).
+			Pluck("session_id", &sessionIDs).Error; err != nil {
+			return total, fmt.Errorf("查询过期会话失败: %w", err)
+		}
+		if len(sessionIDs) == 0 {
+			break
+		}
+
+		// 再次带上时间条件，避免误删在查询之后重新活跃的会话
+		result := r.data.db.WithContext(ctx).
+			Where("session_id IN ? AND last_active < ?", sessionIDs, expiredBefore).
+			Delete(&ChatSessionModel{})
+		if result.Error != nil {
+			return total, fmt.Errorf("清理过期会话失败: %w", result.Error)
+		}
+		total += result.RowsAffected
+
+		for _, sessionID := range sessionIDs {
+			r.evictChatSession(ctx, sessionID)
+		}
+		if len(sessionIDs) < sessionCleanupBatchSize {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+
+	r.log.WithContext(ctx).Infof("清理了 %d 个过期会话", total)
+	return total, nil
+}
+
+// evictChatSession 删除会话缓存
+func (r *aiRepo) evictChatSession(ctx context.Context, sessionID string) {
+	cacheKey := fmt.Sprintf("chat_session:%s", sessionID)
+	if err := r.data.rdb.Del(ctx, cacheKey).Err(); err != nil {
+		r.log.WithContext(ctx).Warnf("删除会话缓存失败: %v", err)
+	}
+}
+
 // GetRecentAnalysisResults 获取最近的分析结果
 func (r *aiRepo) GetRecentAnalysisResults(ctx context.Context, limit int) ([]*eino.AnalysisResult, error) {
 	var models []AnalysisResultModel
@@ -11,11 +11,12 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(NewData, NewAIRepo)
+var ProviderSet = wire.NewSet(NewData, NewAIRepo, NewSkillRepo, NewLintRuleRepo, NewRevocations, NewDiscovery, NewOrganizationRepo, NewFileRepo)
 
 // Data represents the data layer.
 type Data struct {
@@ -35,7 +36,7 @@ func NewData(c *conf.Data, logger log.Logger) (*Data, func(), error) {
 	}
 
 	// 自动迁移数据库表
-	if err := db.AutoMigrate(&AnalysisResultModel{}, &ChatSessionModel{}); err != nil {
+	if err := db.AutoMigrate(&AnalysisResultModel{}, &ChatSessionModel{}, &AnalysisJobModel{}, &SkillModel{}, &LintRuleSettingModel{}); err != nil {
 		return nil, nil, err
 	}
 
@@ -77,3 +78,24 @@ func NewAIRepo(data *Data, logger log.Logger) biz.AIRepo {
 		log:  log.NewHelper(logger),
 	}
 }
+
+// NewSkillRepo creates a new skill repository.
+func NewSkillRepo(data *Data, logger log.Logger) biz.SkillRepo {
+	return &skillRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+// NewLintRuleRepo creates a new lint rule setting repository.
+func NewLintRuleRepo(data *Data, logger log.Logger) biz.LintRuleRepo {
+	return &lintRuleRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+// NewRevocations 访问令牌吊销列表，由 user-service 在登出时写入同一个 Redis
+func NewRevocations(d *Data) *auth.Revocations {
+	return auth.NewRevocations(d.rdb)
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	ggrpc "google.golang.org/grpc"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	filev1 "github.com/lyb88999/resume_helper/backend/shared/proto/file"
+)
+
+const defaultFileServiceEndpoint = "discovery:///file-service"
+
+// fileRepo 通过 file-service 的 FileContentService 查询文件，以当前请求的用户身份调用
+type fileRepo struct {
+	client filev1.FileContentServiceClient
+	log    *log.Helper
+}
+
+// NewFileRepo 连接 file-service
+func NewFileRepo(c *conf.Bootstrap, aiConfig *conf.AI, discovery registry.Discovery, logger log.Logger) (biz.FileRepo, func(), error) {
+	helper := log.NewHelper(logger)
+	endpoint := aiConfig.GetFileServiceEndpoint()
+	if endpoint == "" {
+		endpoint = defaultFileServiceEndpoint
+	}
+	conn, err := grpc.DialInsecure(
+		context.Background(),
+		grpc.WithEndpoint(endpoint),
+		grpc.WithDiscovery(discovery),
+		grpc.WithOptions(ggrpc.WithPerRPCCredentials(auth.NewCredentials(c.GetAuth().GetJwtSecret()))),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接 %s 失败: %w", endpoint, err)
+	}
+	cleanup := func() {
+		helper.Info("关闭 file-service 连接")
+		conn.Close()
+	}
+	return &fileRepo{
+		client: filev1.NewFileContentServiceClient(conn),
+		log:    helper,
+	}, cleanup, nil
+}
+
+func (r *fileRepo) CheckAccess(ctx context.Context, fileID string, version int32) error {
+	_, err := r.client.StatFile(ctx, &filev1.ReadFileRequest{FileId: fileID, Version: version})
+	if err == nil {
+		return nil
+	}
+	switch kerrors.FromError(err).Reason {
+	case "FILE_NOT_FOUND", "VERSION_NOT_FOUND":
+		return fmt.Errorf("%w: %s", biz.ErrFileNotFound, fileID)
+	case "FILE_NOT_CLEAN":
+		// 文件可以访问，只是尚未通过扫描
+		return nil
+	}
+	return fmt.Errorf("查询文件失败: %w", err)
+}
@@ -0,0 +1,61 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm/clause"
+)
+
+// lintRuleRepo 组织级检查规则开关仓库实现
+type lintRuleRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// LintRuleSettingModel 组织对检查规则的开关设置，未设置的规则使用默认开关
+type LintRuleSettingModel struct {
+	OrgID     string    `gorm:"primaryKey;size:64" json:"org_id"`
+	RuleID    string    `gorm:"primaryKey;size:64" json:"rule_id"`
+	Enabled   bool      `gorm:"not null" json:"enabled"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (LintRuleSettingModel) TableName() string {
+	return "ai_lint_rule_settings"
+}
+
+// ListRuleSettings 获取组织的规则开关设置
+func (r *lintRuleRepo) ListRuleSettings(ctx context.Context, orgID string) (map[string]bool, error) {
+	var models []LintRuleSettingModel
+	if err := r.data.db.WithContext(ctx).Where("org_id = ?", orgID).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询检查规则设置失败: %w", err)
+	}
+
+	settings := make(map[string]bool, len(models))
+	for _, m := range models {
+		settings[m.RuleID] = m.Enabled
+	}
+	return settings, nil
+}
+
+// SaveRuleSetting 保存组织的规则开关，已存在时覆盖
+func (r *lintRuleRepo) SaveRuleSetting(ctx context.Context, orgID, ruleID string, enabled bool) error {
+	model := &LintRuleSettingModel{
+		OrgID:   orgID,
+		RuleID:  ruleID,
+		Enabled: enabled,
+	}
+
+	err := r.data.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}, {Name: "rule_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(model).Error
+	if err != nil {
+		return fmt.Errorf("保存检查规则设置失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/kratos/contrib/registry/consul/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/hashicorp/consul/api"
+	ggrpc "google.golang.org/grpc"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	orgv1 "github.com/lyb88999/resume_helper/backend/shared/proto/organization"
+)
+
+const defaultUserServiceEndpoint = "discovery:///user-service"
+
+// NewDiscovery 创建服务发现，用于查找 user-service
+func NewDiscovery(c *conf.Bootstrap) (registry.Discovery, error) {
+	consulConfig := api.DefaultConfig()
+	if c.Registry != nil && c.Registry.GetConsul() != nil {
+		consulConfig.Address = c.Registry.GetConsul().Address
+		consulConfig.Scheme = c.Registry.GetConsul().Scheme
+	}
+
+	consulClient, err := api.NewClient(consulConfig)
+	if err != nil {
+		return nil, err
+	}
+	return consul.New(consulClient), nil
+}
+
+// organizationRepo 通过 user-service 的 OrganizationSettingsService 读取组织设置
+type organizationRepo struct {
+	client orgv1.OrganizationSettingsServiceClient
+	log    *log.Helper
+}
+
+// NewOrganizationRepo 连接 user-service，以提交分析的用户身份读取其所在组织的设置
+func NewOrganizationRepo(c *conf.Bootstrap, aiConfig *conf.AI, discovery registry.Discovery, logger log.Logger) (biz.OrganizationRepo, func(), error) {
+	helper := log.NewHelper(logger)
+	endpoint := aiConfig.GetUserServiceEndpoint()
+	if endpoint == "" {
+		endpoint = defaultUserServiceEndpoint
+	}
+	conn, err := grpc.DialInsecure(
+		context.Background(),
+		grpc.WithEndpoint(endpoint),
+		grpc.WithDiscovery(discovery),
+		grpc.WithOptions(ggrpc.WithPerRPCCredentials(auth.NewCredentials(c.GetAuth().GetJwtSecret()))),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接 %s 失败: %w", endpoint, err)
+	}
+	cleanup := func() {
+		helper.Info("关闭 user-service 连接")
+		conn.Close()
+	}
+	return &organizationRepo{
+		client: orgv1.NewOrganizationSettingsServiceClient(conn),
+		log:    helper,
+	}, cleanup, nil
+}
+
+func (r *organizationRepo) AIMonthlyQuota(ctx context.Context, orgID int64) (int32, error) {
+	settings, err := r.client.GetOrganizationSettings(ctx, &orgv1.GetOrganizationSettingsRequest{OrgId: orgID})
+	if err != nil {
+		return 0, err
+	}
+	return settings.AiMonthlyQuota, nil
+}
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm/clause"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/skills"
+)
+
+// skillRepo 自定义技能仓库实现
+type skillRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// SkillModel 自定义技能数据模型（管理员新增或覆盖的技能）
+type SkillModel struct {
+	ID            string    `gorm:"primaryKey;size:64" json:"id"`
+	Name          string    `gorm:"size:100;not null" json:"name"`
+	NameZh        string    `gorm:"size:100" json:"name_zh"`
+	Category      string    `gorm:"index;size:32;not null" json:"category"`
+	Parent        string    `gorm:"size:64" json:"parent"`
+	Aliases       string    `gorm:"type:text" json:"aliases"` // JSON格式存储别名列表
+	CaseSensitive bool      `gorm:"default:false" json:"case_sensitive"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (SkillModel) TableName() string {
+	return "ai_skills"
+}
+
+// ListCustomSkills 获取所有自定义技能
+func (r *skillRepo) ListCustomSkills(ctx context.Context) ([]*skills.Skill, error) {
+	var models []SkillModel
+	if err := r.data.db.WithContext(ctx).Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询自定义技能失败: %w", err)
+	}
+
+	list := make([]*skills.Skill, 0, len(models))
+	for _, m := range models {
+		skill := &skills.Skill{
+			ID:            m.ID,
+			Name:          m.Name,
+			NameZh:        m.NameZh,
+			Category:      m.Category,
+			Parent:        m.Parent,
+			CaseSensitive: m.CaseSensitive,
+		}
+		if m.Aliases != "" {
+			if err := json.Unmarshal([]byte(m.Aliases), &skill.Aliases); err != nil {
+				r.log.WithContext(ctx).Warnf("反序列化技能别名失败 %s: %v", m.ID, err)
+			}
+		}
+		list = append(list, skill)
+	}
+	return list, nil
+}
+
+// SaveCustomSkill 保存自定义技能，已存在时覆盖
+func (r *skillRepo) SaveCustomSkill(ctx context.Context, skill *skills.Skill) error {
+	aliases, err := json.Marshal(skill.Aliases)
+	if err != nil {
+		return fmt.Errorf("序列化技能别名失败: %w", err)
+	}
+
+	model := &SkillModel{
+		ID:            skill.ID,
+		Name:          skill.Name,
+		NameZh:        skill.NameZh,
+		Category:      skill.Category,
+		Parent:        skill.Parent,
+		Aliases:       string(aliases),
+		CaseSensitive: skill.CaseSensitive,
+	}
+
+	err = r.data.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "name_zh", "category", "parent", "aliases", "case_sensitive", "updated_at"}),
+	}).Create(model).Error
+	if err != nil {
+		return fmt.Errorf("保存自定义技能失败: %w", err)
+	}
+	return nil
+}
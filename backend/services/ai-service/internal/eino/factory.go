@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/models"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/skills"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/timeline"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
@@ -16,6 +19,7 @@ type EinoComponents struct {
 	Embedding     EmbeddingModel
 	ParsingChain  *ResumeParsingChain
 	AnalysisGraph *AnalysisGraph
+	taxonomy      *skills.Taxonomy
 	logger        *log.Helper
 }
 
@@ -78,13 +82,18 @@ func WithTemperature(temperature float64) GenerateOption {
 
 // AnalysisResult 分析结果
 type AnalysisResult struct {
-	ID             string         `json:"id"`
-	ResumeID       string         `json:"resume_id"`
-	TargetPosition string         `json:"target_position"`
-	Scores         ScoreBreakdown `json:"scores"`
-	Suggestions    []Suggestion   `json:"suggestions"`
-	Summary        string         `json:"summary"`
-	AnalyzedAt     time.Time      `json:"analyzed_at"`
+	ID              string              `json:"id"`
+	ResumeID        string              `json:"resume_id"`
+	TargetPosition  string              `json:"target_position"`
+	Scores          ScoreBreakdown      `json:"scores"`
+	Suggestions     []Suggestion        `json:"suggestions"`
+	Summary         string              `json:"summary"`
+	MatchedSkills   []string            `json:"matched_skills,omitempty"`
+	MissingSkills   []string            `json:"missing_skills,omitempty"`
+	Issues          []Issue             `json:"issues,omitempty"`
+	Timeline        *timeline.Report    `json:"timeline,omitempty"`
+	LintSuggestions []models.Suggestion `json:"lint_suggestions,omitempty"` // 规则检查结果，不依赖大模型
+	AnalyzedAt      time.Time           `json:"analyzed_at"`
 }
 
 // ScoreBreakdown 评分详情
@@ -111,11 +120,12 @@ type Suggestion struct {
 }
 
 // NewEinoComponents 创建Eino组件集合
-func NewEinoComponents(aiConfig *conf.AI, logger log.Logger) (*EinoComponents, error) {
+func NewEinoComponents(aiConfig *conf.AI, taxonomy *skills.Taxonomy, logger log.Logger) (*EinoComponents, error) {
 	helper := log.NewHelper(logger)
 
 	components := &EinoComponents{
-		logger: helper,
+		taxonomy: taxonomy,
+		logger:   helper,
 	}
 
 	// 初始化ChatModel
@@ -201,6 +211,11 @@ func (c *EinoComponents) initAdvancedComponents(config *conf.AI) error {
 	// 初始化分析Graph
 	c.AnalysisGraph = NewAnalysisGraph(
 		c.ChatModel,
+		c.taxonomy,
+		timeline.Options{
+			GapThresholdMonths:     int(config.GetAnalysis().GetGapThresholdMonths()),
+			OverlapToleranceMonths: int(config.GetAnalysis().GetOverlapToleranceMonths()),
+		},
 		c.logger,
 	)
 
@@ -302,18 +317,24 @@ func (c *ResumeParsingChain) parseModelResponse(content string) (*ResumeData, er
 
 // AnalysisGraph 分析图
 type AnalysisGraph struct {
-	chatModel ChatModel
-	logger    *log.Helper
+	chatModel       ChatModel
+	taxonomy        *skills.Taxonomy
+	timelineOptions timeline.Options
+	logger          *log.Helper
 }
 
 // NewAnalysisGraph 创建分析图
 func NewAnalysisGraph(
 	chatModel ChatModel,
+	taxonomy *skills.Taxonomy,
+	timelineOptions timeline.Options,
 	logger *log.Helper,
 ) *AnalysisGraph {
 	return &AnalysisGraph{
-		chatModel: chatModel,
-		logger:    logger,
+		chatModel:       chatModel,
+		taxonomy:        taxonomy,
+		timelineOptions: timelineOptions,
+		logger:          logger,
 	}
 }
 
@@ -323,31 +344,77 @@ func (g *AnalysisGraph) buildGraph() {
 	g.logger.Info("分析图构建完成")
 }
 
-// Execute 执行分析图
-func (g *AnalysisGraph) Execute(ctx context.Context, resumeData *ResumeData, targetPosition string) (*AnalysisResult, error) {
-	g.logger.WithContext(ctx).Infof("开始执行智能分析，目标职位: %s", targetPosition)
+// 分析节点名称
+const (
+	NodeCompleteness   = "completeness"
+	NodeClarity        = "clarity"
+	NodeKeyword        = "keyword"
+	NodeFormat         = "format"
+	NodeQuantification = "quantification"
+	NodeTimeline       = "timeline"
+)
 
-	// 简化的分析流程
-	// 1. 完整性分析
-	completenessScore := g.analyzeCompleteness(ctx, resumeData)
+// ProgressFunc 分析进度回调，每个维度节点完成后调用一次
+type ProgressFunc func(node string, completed, total int)
 
-	// 2. 清晰度分析
-	clarityScore := g.analyzeClarity(ctx, resumeData)
+// Execute 执行分析图
+func (g *AnalysisGraph) Execute(ctx context.Context, resumeData *ResumeData, targetPosition string) (*AnalysisResult, error) {
+	return g.ExecuteWithProgress(ctx, resumeData, targetPosition, "", nil)
+}
 
-	// 3. 关键词分析
-	keywordScore := g.analyzeKeywords(ctx, resumeData, targetPosition)
+// ExecuteWithProgress 执行分析图，并在每个维度完成后回调进度。
+// 每个节点开始前检查ctx，取消后立即返回ctx.Err()
+func (g *AnalysisGraph) ExecuteWithProgress(ctx context.Context, resumeData *ResumeData, targetPosition, jobDescription string, onProgress ProgressFunc) (*AnalysisResult, error) {
+	g.logger.WithContext(ctx).Infof("开始执行智能分析，目标职位: %s", targetPosition)
 
-	// 4. 格式分析
-	formatScore := g.analyzeFormat(ctx, resumeData)
+	NormalizeResumeSkills(g.taxonomy, &resumeData.Skills)
+
+	var skillMatch *SkillMatch
+	var timelineReport *timeline.Report
+	var issues []Issue
+
+	nodes := []struct {
+		name string
+		run  func() float64
+	}{
+		{NodeCompleteness, func() float64 { return g.analyzeCompleteness(ctx, resumeData) }},
+		{NodeClarity, func() float64 { return g.analyzeClarity(ctx, resumeData) }},
+		{NodeKeyword, func() float64 {
+			var score float64
+			score, skillMatch = g.analyzeKeywords(ctx, resumeData, targetPosition, jobDescription)
+			return score
+		}},
+		{NodeFormat, func() float64 { return g.analyzeFormat(ctx, resumeData) }},
+		{NodeQuantification, func() float64 { return g.analyzeQuantification(ctx, resumeData) }},
+		// 时间线节点输出结构化结果与问题，不参与评分
+		{NodeTimeline, func() float64 {
+			timelineReport, issues = g.analyzeTimeline(resumeData)
+			return 0
+		}},
+	}
+
+	scores := make(map[string]float64, len(nodes))
+	for i, node := range nodes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		scores[node.name] = node.run()
+		if onProgress != nil {
+			onProgress(node.name, i+1, len(nodes))
+		}
+	}
 
-	// 5. 量化分析
-	quantificationScore := g.analyzeQuantification(ctx, resumeData)
+	completenessScore := scores[NodeCompleteness]
+	clarityScore := scores[NodeClarity]
+	keywordScore := scores[NodeKeyword]
+	formatScore := scores[NodeFormat]
+	quantificationScore := scores[NodeQuantification]
 
 	// 计算总分
 	overallScore := (completenessScore + clarityScore + keywordScore + formatScore + quantificationScore) / 5
 
 	// 生成建议
-	suggestions := g.generateSuggestions(ctx, overallScore, targetPosition)
+	suggestions := g.generateSuggestions(ctx, overallScore, targetPosition, skillMatch)
 
 	// 构建分析结果
 	analysisResult := &AnalysisResult{
@@ -367,6 +434,13 @@ func (g *AnalysisGraph) Execute(ctx context.Context, resumeData *ResumeData, tar
 		AnalyzedAt:  time.Now(),
 	}
 
+	if skillMatch != nil {
+		analysisResult.MatchedSkills = skillMatch.Matched
+		analysisResult.MissingSkills = skillMatch.Missing
+	}
+	analysisResult.Issues = issues
+	analysisResult.Timeline = timelineReport
+
 	g.logger.WithContext(ctx).Info("智能分析执行完成")
 	return analysisResult, nil
 }
@@ -409,27 +483,29 @@ func (g *AnalysisGraph) analyzeClarity(ctx context.Context, resumeData *ResumeDa
 	return score
 }
 
-func (g *AnalysisGraph) analyzeKeywords(ctx context.Context, resumeData *ResumeData, targetPosition string) float64 {
-	// 关键词分析：与目标职位的匹配度
-	if targetPosition == "" {
-		return 70.0
+func (g *AnalysisGraph) analyzeKeywords(ctx context.Context, resumeData *ResumeData, targetPosition, jobDescription string) (float64, *SkillMatch) {
+	// 关键词分析：基于技能本体比对简历技能与职位要求，别名（如 Golang/Go语言）与
+	// 子技能（如 Kubernetes 满足"容器编排"）都视为命中
+	if targetPosition == "" && jobDescription == "" {
+		return 70.0, nil
 	}
 
-	positionLower := strings.ToLower(targetPosition)
-	matchCount := 0
 	totalSkills := len(resumeData.Skills.Technical) + len(resumeData.Skills.Frameworks)
+	if totalSkills == 0 {
+		return 50.0, nil
+	}
 
-	for _, skill := range resumeData.Skills.Technical {
-		if strings.Contains(positionLower, strings.ToLower(skill)) {
-			matchCount++
-		}
+	if g.taxonomy == nil {
+		return 70.0, nil
 	}
 
-	if totalSkills == 0 {
-		return 50.0
+	match := matchSkills(g.taxonomy, resumeData, targetPosition+"\n"+jobDescription)
+	if len(match.Required) == 0 {
+		// 未能从职位信息中识别出技能要求
+		return 70.0, match
 	}
 
-	return float64(matchCount) / float64(totalSkills) * 100
+	return float64(len(match.Matched)) / float64(len(match.Required)) * 100, match
 }
 
 func (g *AnalysisGraph) analyzeFormat(ctx context.Context, resumeData *ResumeData) float64 {
@@ -465,7 +541,7 @@ func (g *AnalysisGraph) analyzeQuantification(ctx context.Context, resumeData *R
 	return float64(quantifiedCount) / float64(totalDescriptions) * 100
 }
 
-func (g *AnalysisGraph) generateSuggestions(ctx context.Context, overallScore float64, targetPosition string) []Suggestion {
+func (g *AnalysisGraph) generateSuggestions(ctx context.Context, overallScore float64, targetPosition string, skillMatch *SkillMatch) []Suggestion {
 	suggestions := []Suggestion{
 		{
 			ID:          "suggestion_1",
@@ -490,7 +566,18 @@ func (g *AnalysisGraph) generateSuggestions(ctx context.Context, overallScore fl
 		})
 	}
 
-	if targetPosition != "" {
+	if skillMatch != nil && len(skillMatch.Missing) > 0 {
+		suggestions = append(suggestions, Suggestion{
+			ID:          "suggestion_3",
+			Type:        "keywords",
+			Title:       "补充职位要求的技能",
+			Description: fmt.Sprintf("职位要求中的以下技能未在简历中体现：%s", strings.Join(skillMatch.Missing, "、")),
+			Priority:    "medium",
+			Section:     "skills",
+			Action:      "如具备相关经验，请在技能栏或项目经历中明确写出",
+			Examples:    skillMatch.Missing,
+		})
+	} else if targetPosition != "" {
 		suggestions = append(suggestions, Suggestion{
 			ID:          "suggestion_3",
 			Type:        "keywords",
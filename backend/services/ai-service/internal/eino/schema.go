@@ -110,10 +110,14 @@ type Document struct {
 // ChatContext 聊天上下文
 type ChatContext struct {
 	SessionID      string      `json:"session_id"`
+	UserID         string      `json:"user_id"`
+	Title          string      `json:"title"`
 	Messages       []Message   `json:"messages"`
 	ResumeData     *ResumeData `json:"resume_data,omitempty"`
 	TargetPosition string      `json:"target_position,omitempty"`
 	Knowledge      []Document  `json:"knowledge,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	LastActive     time.Time   `json:"last_active"`
 }
 
 // AgentInput Agent输入
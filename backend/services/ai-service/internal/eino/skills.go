@@ -0,0 +1,77 @@
+package eino
+
+import (
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/skills"
+)
+
+// SkillMatch 简历技能与职位要求的匹配结果（技能规范名称）
+type SkillMatch struct {
+	Required []string
+	Matched  []string
+	Missing  []string
+}
+
+// NormalizeResumeSkills 将简历中的技能写法归一为规范名称并去重，无法识别的技能保持原样
+func NormalizeResumeSkills(taxonomy *skills.Taxonomy, s *Skills) {
+	if taxonomy == nil || s == nil {
+		return
+	}
+	s.Technical = normalizeSkillNames(taxonomy, s.Technical)
+	s.Frameworks = normalizeSkillNames(taxonomy, s.Frameworks)
+	s.Tools = normalizeSkillNames(taxonomy, s.Tools)
+}
+
+func normalizeSkillNames(taxonomy *skills.Taxonomy, names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if skill, ok := taxonomy.Lookup(name); ok {
+			name = skill.Name
+		}
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// resumeSkillIDs 收集简历中出现的技能ID，包括技能栏以及工作、项目经历中的技术栈
+func resumeSkillIDs(taxonomy *skills.Taxonomy, resumeData *ResumeData) []string {
+	var terms []string
+	terms = append(terms, resumeData.Skills.Technical...)
+	terms = append(terms, resumeData.Skills.Frameworks...)
+	terms = append(terms, resumeData.Skills.Tools...)
+	terms = append(terms, resumeData.Skills.Languages...)
+	for _, exp := range resumeData.Experience {
+		terms = append(terms, exp.Technologies...)
+	}
+	for _, proj := range resumeData.Projects {
+		terms = append(terms, proj.Technologies...)
+	}
+
+	ids, _ := taxonomy.Normalize(terms)
+	return ids
+}
+
+// matchSkills 从职位名称与职位描述中提取技能要求，并与简历技能比对
+func matchSkills(taxonomy *skills.Taxonomy, resumeData *ResumeData, requirementText string) *SkillMatch {
+	required := taxonomy.Extract(requirementText)
+	matched, missing := taxonomy.Match(resumeSkillIDs(taxonomy, resumeData), required)
+
+	return &SkillMatch{
+		Required: skillNames(taxonomy, required),
+		Matched:  skillNames(taxonomy, matched),
+		Missing:  skillNames(taxonomy, missing),
+	}
+}
+
+func skillNames(taxonomy *skills.Taxonomy, ids []string) []string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if skill, ok := taxonomy.Get(id); ok {
+			names = append(names, skill.Name)
+		}
+	}
+	return names
+}
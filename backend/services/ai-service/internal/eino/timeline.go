@@ -0,0 +1,107 @@
+package eino
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/timeline"
+)
+
+// 时间线问题类型
+const (
+	IssueEmploymentGap         = "employment_gap"
+	IssueOverlappingEmployment = "overlapping_employment"
+	IssueEducationWorkOverlap  = "education_work_overlap"
+	IssueMissingDates          = "missing_dates"
+)
+
+// analyzeTimeline 分析工作与教育经历的时间线，返回结构化结果以及发现的问题
+func (g *AnalysisGraph) analyzeTimeline(resumeData *ResumeData) (*timeline.Report, []Issue) {
+	var entries []timeline.Entry
+	var issues []Issue
+
+	for _, exp := range resumeData.Experience {
+		label := joinLabel(exp.Company, exp.Position)
+		period, ok := timeline.FromTimes(exp.StartDate, exp.EndDate)
+		if !ok {
+			issues = append(issues, Issue{
+				Type:        IssueMissingDates,
+				Description: fmt.Sprintf("工作经历「%s」缺少有效的起止时间", label),
+				Severity:    "low",
+				Suggestion:  "为每段经历注明起止年月，如 2019.03 - 2021.06",
+			})
+			continue
+		}
+		entries = append(entries, timeline.Entry{
+			Kind:     timeline.KindWork,
+			Label:    label,
+			Period:   period,
+			FullTime: !timeline.IsPartTime(exp.Position, exp.Company),
+		})
+	}
+
+	for _, edu := range resumeData.Education {
+		period, ok := timeline.FromTimes(edu.StartDate, edu.EndDate)
+		if !ok {
+			continue
+		}
+		entries = append(entries, timeline.Entry{
+			Kind:   timeline.KindEducation,
+			Label:  joinLabel(edu.School, edu.Degree),
+			Period: period,
+		})
+	}
+
+	report := timeline.Analyze(entries, g.timelineOptions)
+
+	for _, gap := range report.Gaps {
+		severity := "medium"
+		if gap.Months >= 12 {
+			severity = "high"
+		}
+		description := fmt.Sprintf("%s 至 %s 存在 %d 个月的空窗期（%s 之后）", gap.Start, gap.End, gap.Months, gap.After)
+		if gap.End == timeline.Present {
+			description = fmt.Sprintf("自 %s 起已有 %d 个月没有在职或在读经历（%s 之后）", gap.Start, gap.Months, gap.After)
+		}
+		issues = append(issues, Issue{
+			Type:        IssueEmploymentGap,
+			Description: description,
+			Severity:    severity,
+			Suggestion:  "简要说明空窗期的原因（如进修、创业、家庭安排），避免招聘方产生疑虑",
+		})
+	}
+
+	for _, overlap := range report.Overlaps {
+		switch overlap.Type {
+		case timeline.OverlapWork:
+			issues = append(issues, Issue{
+				Type:        IssueOverlappingEmployment,
+				Description: fmt.Sprintf("全职工作「%s」与「%s」在 %s 至 %s 重叠 %d 个月", overlap.First, overlap.Second, overlap.Start, overlap.End, overlap.Months),
+				Severity:    "medium",
+				Suggestion:  "核对两段工作的起止时间；如其中一段为兼职或顾问工作，请注明工作性质",
+			})
+		case timeline.OverlapEducationWork:
+			issues = append(issues, Issue{
+				Type:        IssueEducationWorkOverlap,
+				Description: fmt.Sprintf("在读「%s」期间从事全职工作「%s」（%s 至 %s，%d 个月）", overlap.First, overlap.Second, overlap.Start, overlap.End, overlap.Months),
+				Severity:    "low",
+				Suggestion:  "如为在读期间的实习或兼职，请在职位中注明；否则请核对时间",
+			})
+		}
+	}
+
+	return report, issues
+}
+
+func joinLabel(parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return "未命名经历"
+	}
+	return strings.Join(nonEmpty, " ")
+}
@@ -0,0 +1,294 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/models"
+)
+
+// item 待检查的一段文本及其在简历中的位置
+type item struct {
+	loc  models.SuggestionLoc
+	text string
+}
+
+// extractors 检查字段到文本提取函数的映射
+var extractors = map[string]func(in *Input) []item{
+	"personal_info.name":     personalField("name", func(in *Input) string { return in.Resume.PersonalInfo.Name }),
+	"personal_info.email":    personalField("email", func(in *Input) string { return in.Resume.PersonalInfo.Email }),
+	"personal_info.phone":    personalField("phone", func(in *Input) string { return in.Resume.PersonalInfo.Phone }),
+	"personal_info.location": personalField("location", func(in *Input) string { return in.Resume.PersonalInfo.Location }),
+	"experience.description": func(in *Input) []item {
+		var items []item
+		for i, exp := range in.Resume.Experience {
+			items = append(items, listItems("experience", i, "description", exp.Description)...)
+		}
+		return items
+	},
+	"experience.achievements": func(in *Input) []item {
+		var items []item
+		for i, exp := range in.Resume.Experience {
+			items = append(items, listItems("experience", i, "achievements", exp.Achievements)...)
+		}
+		return items
+	},
+	"projects.description": func(in *Input) []item {
+		var items []item
+		for i, proj := range in.Resume.Projects {
+			if strings.TrimSpace(proj.Description) != "" {
+				items = append(items, item{loc: models.SuggestionLoc{Section: "projects", Index: i, Field: "description"}, text: proj.Description})
+			}
+		}
+		return items
+	},
+	"projects.achievements": func(in *Input) []item {
+		var items []item
+		for i, proj := range in.Resume.Projects {
+			items = append(items, listItems("projects", i, "achievements", proj.Achievements)...)
+		}
+		return items
+	},
+	"content": func(in *Input) []item {
+		if strings.TrimSpace(in.Content) == "" {
+			return nil
+		}
+		return []item{{loc: models.SuggestionLoc{Section: "content"}, text: in.Content}}
+	},
+}
+
+// fieldNames 必填字段的展示名称
+var fieldNames = map[string]string{
+	"personal_info.name":     "姓名",
+	"personal_info.email":    "邮箱",
+	"personal_info.phone":    "手机号",
+	"personal_info.location": "所在城市",
+}
+
+func personalField(field string, get func(in *Input) string) func(in *Input) []item {
+	return func(in *Input) []item {
+		return []item{{loc: models.SuggestionLoc{Section: "personal_info", Field: field}, text: get(in)}}
+	}
+}
+
+func listItems(section string, index int, field string, list []string) []item {
+	items := make([]item, 0, len(list))
+	for j, text := range list {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		items = append(items, item{
+			loc:  models.SuggestionLoc{Section: section, Index: index, Field: fmt.Sprintf("%s[%d]", field, j)},
+			text: text,
+		})
+	}
+	return items
+}
+
+func (r *Rule) items(in *Input) []item {
+	var items []item
+	for _, target := range r.Targets {
+		items = append(items, extractors[target](in)...)
+	}
+	return items
+}
+
+func (r *Rule) run(in *Input) []models.Suggestion {
+	switch r.Check {
+	case CheckPhrase:
+		return r.checkPhrase(in)
+	case CheckPattern:
+		return r.checkPattern(in)
+	case CheckMaxLength:
+		return r.checkMaxLength(in)
+	case CheckRequired:
+		return r.checkRequired(in)
+	case CheckDensity:
+		return r.checkDensity(in)
+	case CheckDateFormat:
+		return r.checkDateFormat(in)
+	}
+	return nil
+}
+
+func (r *Rule) checkPhrase(in *Input) []models.Suggestion {
+	var suggestions []models.Suggestion
+	for _, it := range r.items(in) {
+		text := strings.TrimSpace(it.text)
+		if r.Params.PrefixOnly {
+			text = trimBullet(text)
+		}
+		if match, ok := findPhrase(text, r.Params.Phrases, r.Params.PrefixOnly); ok {
+			suggestions = append(suggestions, r.suggestion(it.loc, []string{it.text}, "{match}", match))
+		}
+	}
+	return suggestions
+}
+
+func (r *Rule) checkPattern(in *Input) []models.Suggestion {
+	var suggestions []models.Suggestion
+	for _, it := range r.items(in) {
+		if match := r.pattern.FindString(it.text); match != "" {
+			suggestions = append(suggestions, r.suggestion(it.loc, []string{it.text}, "{match}", match))
+		}
+	}
+	return suggestions
+}
+
+func (r *Rule) checkMaxLength(in *Input) []models.Suggestion {
+	var suggestions []models.Suggestion
+	for _, it := range r.items(in) {
+		if length := utf8.RuneCountInString(strings.TrimSpace(it.text)); length > r.Params.MaxLength {
+			suggestions = append(suggestions, r.suggestion(it.loc, []string{it.text},
+				"{length}", strconv.Itoa(length), "{limit}", strconv.Itoa(r.Params.MaxLength)))
+		}
+	}
+	return suggestions
+}
+
+func (r *Rule) checkRequired(in *Input) []models.Suggestion {
+	var suggestions []models.Suggestion
+	for _, target := range r.Targets {
+		items := extractors[target](in)
+		filled := false
+		for _, it := range items {
+			if strings.TrimSpace(it.text) != "" {
+				filled = true
+				break
+			}
+		}
+		if filled {
+			continue
+		}
+
+		name := fieldNames[target]
+		if name == "" {
+			name = target
+		}
+		section, field, _ := strings.Cut(target, ".")
+		suggestions = append(suggestions, r.suggestion(models.SuggestionLoc{Section: section, Field: field}, nil, "{field}", name))
+	}
+	return suggestions
+}
+
+func (r *Rule) checkDensity(in *Input) []models.Suggestion {
+	items := r.items(in)
+	if len(items) == 0 {
+		return nil
+	}
+
+	var hits []item
+	var matches []string
+	seen := make(map[string]bool)
+	for _, it := range items {
+		if match, ok := findPhrase(it.text, r.Params.Phrases, false); ok {
+			hits = append(hits, it)
+			if !seen[match] {
+				seen[match] = true
+				matches = append(matches, "「"+match+"」")
+			}
+		}
+	}
+
+	ratio := float64(len(hits)) / float64(len(items))
+	if len(hits) == 0 || ratio <= r.Params.MaxRatio {
+		return nil
+	}
+
+	examples := make([]string, 0, 3)
+	for _, it := range hits {
+		if len(examples) == cap(examples) {
+			break
+		}
+		examples = append(examples, it.text)
+	}
+	// 整体性问题，定位到第一条命中的描述
+	return []models.Suggestion{r.suggestion(hits[0].loc, examples,
+		"{ratio}", fmt.Sprintf("%d%%", int(ratio*100+0.5)), "{match}", strings.Join(matches, "、"))}
+}
+
+// dateStyles 常见的日期写法
+var dateStyles = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"2019.03", regexp.MustCompile(`\b(?:19|20)\d{2}\.\d{1,2}\b`)},
+	{"2019-03", regexp.MustCompile(`\b(?:19|20)\d{2}-\d{1,2}\b`)},
+	{"2019/03", regexp.MustCompile(`\b(?:19|20)\d{2}/\d{1,2}\b`)},
+	{"03/2019", regexp.MustCompile(`\b\d{1,2}/(?:19|20)\d{2}\b`)},
+	{"2019年3月", regexp.MustCompile(`(?:19|20)\d{2}\s*年\s*\d{1,2}\s*月`)},
+	{"Mar 2019", regexp.MustCompile(`(?i)\b(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?,?\s+(?:19|20)\d{2}\b`)},
+}
+
+func (r *Rule) checkDateFormat(in *Input) []models.Suggestion {
+	var suggestions []models.Suggestion
+	for _, it := range r.items(in) {
+		var styles, examples []string
+		for _, style := range dateStyles {
+			if match := style.pattern.FindString(it.text); match != "" {
+				styles = append(styles, style.name)
+				examples = append(examples, match)
+			}
+		}
+		if len(styles) > 1 {
+			suggestions = append(suggestions, r.suggestion(it.loc, examples, "{match}", strings.Join(styles, "、")))
+		}
+	}
+	return suggestions
+}
+
+// findPhrase 查找第一个命中的短语。英文短语忽略大小写并要求单词边界
+func findPhrase(text string, phrases []string, prefixOnly bool) (string, bool) {
+	lower := strings.ToLower(text)
+	for _, phrase := range phrases {
+		p := strings.ToLower(phrase)
+		if p == "" {
+			continue
+		}
+		for offset := 0; offset < len(lower); {
+			idx := strings.Index(lower[offset:], p)
+			if idx < 0 {
+				break
+			}
+			start, end := offset+idx, offset+idx+len(p)
+			if prefixOnly && start != 0 {
+				break
+			}
+			if boundary(lower, start, end) {
+				return phrase, true
+			}
+			offset = end
+		}
+	}
+	return "", false
+}
+
+// boundary 判断 [start, end) 前后是否为单词边界，只对英文字母和数字生效
+func boundary(s string, start, end int) bool {
+	if start > 0 && isWordByte(s[start-1]) && isWordByte(s[start]) {
+		return false
+	}
+	if end < len(s) && isWordByte(s[end]) && isWordByte(s[end-1]) {
+		return false
+	}
+	return true
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '_'
+}
+
+// trimBullet 去掉列表符号，如 "• "、"- "、"1. "
+func trimBullet(text string) string {
+	text = strings.TrimLeft(text, "•·●▪◦-*–—> \t")
+	if i := strings.IndexAny(text, ".、)）"); i > 0 && i <= 2 {
+		if _, err := strconv.Atoi(text[:i]); err == nil {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			text = text[i+size:]
+		}
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,238 @@
+// Package lint 提供基于规则的简历检查：弱动词、第一人称、描述过长、日期格式不统一、
+// 缺少联系方式、空泛词过多等问题无需调用大模型即可确定性地发现。
+// 规则以 YAML 定义，内置一份默认规则，也可以通过配置指定规则文件覆盖。
+package lint
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/models"
+)
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+// 检查器类型
+const (
+	CheckPhrase     = "phrase"      // 命中短语，prefix_only 为 true 时只检查开头
+	CheckPattern    = "pattern"     // 命中正则表达式
+	CheckMaxLength  = "max_length"  // 单条文本超过长度上限
+	CheckRequired   = "required"    // 字段不能为空
+	CheckDensity    = "density"     // 包含短语的条目占比超过上限
+	CheckDateFormat = "date_format" // 混用多种日期格式
+)
+
+var (
+	// ErrInvalidRule 规则定义不合法
+	ErrInvalidRule = errors.New("无效的检查规则")
+	// ErrRuleNotFound 规则不存在
+	ErrRuleNotFound = errors.New("检查规则不存在")
+)
+
+// Rule 检查规则
+type Rule struct {
+	ID          string                 `yaml:"id"`
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	Severity    models.SuggestionLevel `yaml:"severity"`
+	Type        models.SuggestionType  `yaml:"type"`
+	Check       string                 `yaml:"check"`
+	Targets     []string               `yaml:"targets"`
+	Enabled     *bool                  `yaml:"enabled"` // 默认启用
+	Priority    int                    `yaml:"priority"`
+	Params      Params                 `yaml:"params"`
+	Message     string                 `yaml:"message"`
+	Suggestion  string                 `yaml:"suggestion"`
+
+	pattern *regexp.Regexp
+}
+
+// Params 检查器参数
+type Params struct {
+	Phrases    []string `yaml:"phrases"`
+	PrefixOnly bool     `yaml:"prefix_only"`
+	Pattern    string   `yaml:"pattern"`
+	MaxLength  int      `yaml:"max_length"`
+	MaxRatio   float64  `yaml:"max_ratio"`
+}
+
+// EnabledByDefault 规则默认是否启用
+func (r *Rule) EnabledByDefault() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// Input 检查输入，Resume 为结构化简历，Content 为简历原文（用于日期格式等基于原文的检查）
+type Input struct {
+	Resume  *eino.ResumeData
+	Content string
+}
+
+// Engine 规则检查引擎，加载后只读，可并发使用
+type Engine struct {
+	rules []*Rule
+	byID  map[string]*Rule
+}
+
+// Default 加载内置默认规则
+func Default() (*Engine, error) {
+	return Load(defaultRules)
+}
+
+// LoadFile 从 YAML 文件加载规则
+func LoadFile(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取规则文件失败: %w", err)
+	}
+	return Load(data)
+}
+
+// Load 解析 YAML 规则并校验
+func Load(data []byte) (*Engine, error) {
+	var doc struct {
+		Rules []*Rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: 解析失败: %v", ErrInvalidRule, err)
+	}
+
+	e := &Engine{byID: make(map[string]*Rule, len(doc.Rules))}
+	for _, rule := range doc.Rules {
+		if err := validate(rule); err != nil {
+			return nil, err
+		}
+		if _, exists := e.byID[rule.ID]; exists {
+			return nil, fmt.Errorf("%w: 规则ID重复: %s", ErrInvalidRule, rule.ID)
+		}
+		e.byID[rule.ID] = rule
+		e.rules = append(e.rules, rule)
+	}
+	return e, nil
+}
+
+func validate(rule *Rule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("%w: 缺少规则ID", ErrInvalidRule)
+	}
+	switch rule.Severity {
+	case models.SuggestionLevelCritical, models.SuggestionLevelWarning, models.SuggestionLevelInfo:
+	default:
+		return fmt.Errorf("%w: %s: 未知的严重程度 %q", ErrInvalidRule, rule.ID, rule.Severity)
+	}
+	if rule.Type == "" {
+		rule.Type = models.SuggestionTypeContent
+	}
+	if len(rule.Targets) == 0 {
+		return fmt.Errorf("%w: %s: 缺少检查字段", ErrInvalidRule, rule.ID)
+	}
+	for _, target := range rule.Targets {
+		if _, ok := extractors[target]; !ok {
+			return fmt.Errorf("%w: %s: 未知的检查字段 %q", ErrInvalidRule, rule.ID, target)
+		}
+	}
+
+	switch rule.Check {
+	case CheckPhrase, CheckDensity:
+		if len(rule.Params.Phrases) == 0 {
+			return fmt.Errorf("%w: %s: 缺少 phrases 参数", ErrInvalidRule, rule.ID)
+		}
+		if rule.Check == CheckDensity && (rule.Params.MaxRatio <= 0 || rule.Params.MaxRatio > 1) {
+			return fmt.Errorf("%w: %s: max_ratio 必须在 (0, 1] 之间", ErrInvalidRule, rule.ID)
+		}
+	case CheckPattern:
+		re, err := regexp.Compile(rule.Params.Pattern)
+		if err != nil || rule.Params.Pattern == "" {
+			return fmt.Errorf("%w: %s: 无效的正则表达式 %q", ErrInvalidRule, rule.ID, rule.Params.Pattern)
+		}
+		rule.pattern = re
+	case CheckMaxLength:
+		if rule.Params.MaxLength <= 0 {
+			return fmt.Errorf("%w: %s: max_length 必须大于0", ErrInvalidRule, rule.ID)
+		}
+	case CheckRequired, CheckDateFormat:
+	default:
+		return fmt.Errorf("%w: %s: 未知的检查器 %q", ErrInvalidRule, rule.ID, rule.Check)
+	}
+	return nil
+}
+
+// Rules 返回全部规则（按定义顺序）
+func (e *Engine) Rules() []*Rule {
+	return e.rules
+}
+
+// Rule 按ID获取规则
+func (e *Engine) Rule(id string) (*Rule, bool) {
+	rule, ok := e.byID[id]
+	return rule, ok
+}
+
+// Run 执行检查。enabled 决定规则是否启用，为 nil 时使用规则的默认开关。
+// 结果按严重程度、优先级排序，同一优先级内保持简历中的先后顺序
+func (e *Engine) Run(input *Input, enabled func(rule *Rule) bool) []models.Suggestion {
+	if input == nil {
+		return nil
+	}
+	if input.Resume == nil {
+		input = &Input{Resume: &eino.ResumeData{}, Content: input.Content}
+	}
+
+	var suggestions []models.Suggestion
+	for _, rule := range e.rules {
+		if enabled != nil {
+			if !enabled(rule) {
+				continue
+			}
+		} else if !rule.EnabledByDefault() {
+			continue
+		}
+		suggestions = append(suggestions, rule.run(input)...)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if li, lj := levelRank(suggestions[i].Level), levelRank(suggestions[j].Level); li != lj {
+			return li < lj
+		}
+		return suggestions[i].Priority > suggestions[j].Priority
+	})
+	return suggestions
+}
+
+func levelRank(level models.SuggestionLevel) int {
+	switch level {
+	case models.SuggestionLevelCritical:
+		return 0
+	case models.SuggestionLevelWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// suggestion 根据规则生成一条建议，vars 用于替换 message 中的占位符
+func (r *Rule) suggestion(loc models.SuggestionLoc, examples []string, vars ...string) models.Suggestion {
+	description := strings.NewReplacer(vars...).Replace(r.Message)
+	if r.Suggestion != "" {
+		description += "。" + r.Suggestion
+	}
+	return models.Suggestion{
+		Section:     loc.Section,
+		Level:       r.Severity,
+		Type:        r.Type,
+		Title:       r.Name,
+		Description: description,
+		Examples:    examples,
+		Location:    loc,
+		Priority:    r.Priority,
+		RuleID:      r.ID,
+	}
+}
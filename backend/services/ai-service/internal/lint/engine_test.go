@@ -0,0 +1,263 @@
+package lint
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/models"
+)
+
+func defaultEngine(t *testing.T) *Engine {
+	t.Helper()
+	e, err := Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	return e
+}
+
+// completeResume 不触发任何默认规则的简历
+func completeResume() *eino.ResumeData {
+	return &eino.ResumeData{
+		PersonalInfo: eino.PersonalInfo{Name: "张三", Email: "zhangsan@example.com", Phone: "13800000000"},
+		Experience: []eino.Experience{{
+			Company:      "某某科技",
+			Description:  []string{"主导订单服务重构，接口耗时降低40%"},
+			Achievements: []string{"设计并实现库存预占方案，超卖率降为0"},
+		}},
+	}
+}
+
+// ruleIDs 按顺序返回命中的规则ID
+func ruleIDs(suggestions []models.Suggestion) []string {
+	ids := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		ids[i] = s.RuleID
+	}
+	return ids
+}
+
+func TestDefaultRulesClean(t *testing.T) {
+	e := defaultEngine(t)
+	if got := e.Run(&Input{Resume: completeResume(), Content: "2019.03 - 2021.06 某某科技"}, nil); len(got) != 0 {
+		t.Errorf("Run on a clean resume = %v", ruleIDs(got))
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	e := defaultEngine(t)
+	tests := []struct {
+		name    string
+		modify  func(r *eino.ResumeData)
+		content string
+		want    string
+		wantLoc models.SuggestionLoc
+	}{
+		{
+			name:    "weak verb after bullet",
+			modify:  func(r *eino.ResumeData) { r.Experience[0].Description = []string{"• 负责订单系统开发"} },
+			want:    "weak-verb",
+			wantLoc: models.SuggestionLoc{Section: "experience", Index: 0, Field: "description[0]"},
+		},
+		{
+			name: "english weak verb after numbering",
+			modify: func(r *eino.ResumeData) {
+				r.Experience[0].Achievements = []string{"1. Helped with the billing migration"}
+			},
+			want:    "weak-verb",
+			wantLoc: models.SuggestionLoc{Section: "experience", Index: 0, Field: "achievements[0]"},
+		},
+		{
+			name:    "first person",
+			modify:  func(r *eino.ResumeData) { r.Projects = []eino.Project{{Description: "我设计了缓存方案"}} },
+			want:    "first-person",
+			wantLoc: models.SuggestionLoc{Section: "projects", Index: 0, Field: "description"},
+		},
+		{
+			name: "long bullet",
+			modify: func(r *eino.ResumeData) {
+				r.Experience[0].Description = []string{"主导" + strings.Repeat("长", 130)}
+			},
+			want:    "long-bullet",
+			wantLoc: models.SuggestionLoc{Section: "experience", Index: 0, Field: "description[0]"},
+		},
+		{
+			name:    "mixed date formats",
+			modify:  func(r *eino.ResumeData) {},
+			content: "2019.03 - 2021.06 某某科技\n2021年7月 - 至今 另一家公司",
+			want:    "inconsistent-date-format",
+			wantLoc: models.SuggestionLoc{Section: "content"},
+		},
+		{
+			name:    "missing contact",
+			modify:  func(r *eino.ResumeData) { r.PersonalInfo.Phone = " " },
+			want:    "missing-contact",
+			wantLoc: models.SuggestionLoc{Section: "personal_info", Field: "phone"},
+		},
+		{
+			name:    "missing name",
+			modify:  func(r *eino.ResumeData) { r.PersonalInfo.Name = "" },
+			want:    "missing-name",
+			wantLoc: models.SuggestionLoc{Section: "personal_info", Field: "name"},
+		},
+		{
+			name: "buzzword density",
+			modify: func(r *eino.ResumeData) {
+				r.Experience[0].Description = []string{"抗压能力强，积极主动", "主导支付网关设计"}
+			},
+			want:    "buzzword-density",
+			wantLoc: models.SuggestionLoc{Section: "experience", Index: 0, Field: "description[0]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resume := completeResume()
+			tt.modify(resume)
+			got := e.Run(&Input{Resume: resume, Content: tt.content}, nil)
+			if len(got) != 1 || got[0].RuleID != tt.want {
+				t.Fatalf("Run = %v, want [%s]", ruleIDs(got), tt.want)
+			}
+			if got[0].Location != tt.wantLoc {
+				t.Errorf("Location = %+v, want %+v", got[0].Location, tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestPhraseBoundaries(t *testing.T) {
+	tests := []struct {
+		text       string
+		prefixOnly bool
+		want       bool
+	}{
+		{"helped with the migration", true, true},
+		{"Helpedwith nothing", true, false},
+		{"the team helped", true, false},
+		{"the team helped", false, true},
+		{"unhelped", false, false},
+		{"负责订单系统", true, true},
+	}
+	for _, tt := range tests {
+		_, got := findPhrase(tt.text, []string{"helped", "负责"}, tt.prefixOnly)
+		if got != tt.want {
+			t.Errorf("findPhrase(%q, prefixOnly=%v) = %v, want %v", tt.text, tt.prefixOnly, got, tt.want)
+		}
+	}
+}
+
+func TestTrimBullet(t *testing.T) {
+	tests := map[string]string{
+		"• 负责开发":     "负责开发",
+		"- 负责开发":     "负责开发",
+		"1. 负责开发":    "负责开发",
+		"2、负责开发":     "负责开发",
+		"3）负责开发":     "负责开发",
+		"2019.03 入职": "2019.03 入职",
+	}
+	for in, want := range tests {
+		if got := trimBullet(in); got != want {
+			t.Errorf("trimBullet(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRunOrderingAndEnabled(t *testing.T) {
+	e := defaultEngine(t)
+	resume := completeResume()
+	resume.PersonalInfo.Email = ""
+	resume.Experience[0].Description = []string{"负责订单系统，由我牵头重构"}
+
+	got := e.Run(&Input{Resume: resume}, nil)
+	want := []string{"missing-contact", "weak-verb", "first-person"}
+	if strings.Join(ruleIDs(got), ",") != strings.Join(want, ",") {
+		t.Fatalf("Run = %v, want %v (by severity, then priority)", ruleIDs(got), want)
+	}
+
+	onlyWeakVerb := func(rule *Rule) bool { return rule.ID == "weak-verb" }
+	if got := e.Run(&Input{Resume: resume}, onlyWeakVerb); strings.Join(ruleIDs(got), ",") != "weak-verb" {
+		t.Errorf("Run with enabled filter = %v", ruleIDs(got))
+	}
+
+	if got := e.Run(nil, nil); got != nil {
+		t.Errorf("Run(nil) = %v", got)
+	}
+	if got := e.Run(&Input{}, nil); len(got) != 3 {
+		t.Errorf("Run without resume = %v, want name, email and phone missing", ruleIDs(got))
+	}
+}
+
+func TestRuleMessages(t *testing.T) {
+	e := defaultEngine(t)
+	resume := completeResume()
+	resume.Experience[0].Description = []string{"主导" + strings.Repeat("长", 130)}
+
+	got := e.Run(&Input{Resume: resume}, nil)
+	if len(got) != 1 {
+		t.Fatalf("Run = %v", ruleIDs(got))
+	}
+	if !strings.Contains(got[0].Description, "132 字，超过 120 字") {
+		t.Errorf("Description = %q, want placeholders replaced", got[0].Description)
+	}
+	if got[0].Level != models.SuggestionLevelWarning || got[0].Title != "描述过长" {
+		t.Errorf("suggestion = %+v", got[0])
+	}
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	e, err := Load([]byte(`
+rules:
+  - id: no-todo
+    severity: info
+    check: phrase
+    targets: [experience.description]
+    enabled: false
+    params:
+      phrases: ["TODO"]
+    message: "包含 {match}"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	rule, ok := e.Rule("no-todo")
+	if !ok || rule.EnabledByDefault() {
+		t.Fatalf("Rule(no-todo) = %+v, %v", rule, ok)
+	}
+	if rule.Type != models.SuggestionTypeContent {
+		t.Errorf("default Type = %q, want content", rule.Type)
+	}
+
+	input := &Input{Resume: &eino.ResumeData{Experience: []eino.Experience{{Description: []string{"todo: 补充"}}}}}
+	if got := e.Run(input, nil); len(got) != 0 {
+		t.Errorf("disabled rule ran by default: %v", ruleIDs(got))
+	}
+	got := e.Run(input, func(*Rule) bool { return true })
+	if len(got) != 1 || got[0].Description != "包含 TODO" {
+		t.Errorf("Run with rule enabled = %+v", got)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not yaml":          "rules: [",
+		"missing id":        "rules:\n  - severity: info\n    check: required\n    targets: [personal_info.name]",
+		"unknown severity":  "rules:\n  - id: a\n    severity: fatal\n    check: required\n    targets: [personal_info.name]",
+		"missing targets":   "rules:\n  - id: a\n    severity: info\n    check: required",
+		"unknown target":    "rules:\n  - id: a\n    severity: info\n    check: required\n    targets: [skills.technical]",
+		"unknown check":     "rules:\n  - id: a\n    severity: info\n    check: spelling\n    targets: [content]",
+		"missing phrases":   "rules:\n  - id: a\n    severity: info\n    check: phrase\n    targets: [content]",
+		"invalid ratio":     "rules:\n  - id: a\n    severity: info\n    check: density\n    targets: [content]\n    params:\n      phrases: [x]\n      max_ratio: 1.5",
+		"invalid pattern":   "rules:\n  - id: a\n    severity: info\n    check: pattern\n    targets: [content]\n    params:\n      pattern: '('",
+		"invalid maxlength": "rules:\n  - id: a\n    severity: info\n    check: max_length\n    targets: [content]",
+		"duplicate id": "rules:\n  - id: a\n    severity: info\n    check: required\n    targets: [personal_info.name]\n" +
+			"  - id: a\n    severity: info\n    check: required\n    targets: [personal_info.email]",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load([]byte(data)); !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Load error = %v, want ErrInvalidRule", err)
+			}
+		})
+	}
+}
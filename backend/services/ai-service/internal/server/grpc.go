@@ -3,7 +3,9 @@ package server
 import (
 	v1 "github.com/lyb88999/resume_helper/backend/services/ai-service/api/ai/v1"
 	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/service"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	exportv1 "github.com/lyb88999/resume_helper/backend/shared/proto/export"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
@@ -11,11 +13,15 @@ import (
 )
 
 // NewGRPCServer new a gRPC server.
-func NewGRPCServer(c *conf.Server, aiService *service.AIService, logger log.Logger) *grpc.Server {
+func NewGRPCServer(c *conf.Server, bc *conf.Bootstrap, revocations *auth.Revocations, aiService *service.AIService, exportService *service.ExportService, logger log.Logger) *grpc.Server {
+	secret := bc.GetAuth().GetJwtSecret()
 	var opts = []grpc.ServerOption{
 		grpc.Middleware(
 			recovery.Recovery(),
+			auth.Server(secret, publicOperations(), knowledgeOperations(), auth.WithRevocations(revocations)),
 		),
+		// kratos 的中间件不作用于流式调用，导出数据由拦截器校验
+		grpc.StreamInterceptor(auth.StreamServerInterceptor(secret, auth.WithRevocations(revocations))),
 	}
 	if c.Grpc.Network != "" {
 		opts = append(opts, grpc.Network(c.Grpc.Network))
@@ -28,5 +34,6 @@ func NewGRPCServer(c *conf.Server, aiService *service.AIService, logger log.Logg
 	}
 	srv := grpc.NewServer(opts...)
 	v1.RegisterAIServiceServer(srv, aiService)
+	exportv1.RegisterUserDataExportServiceServer(srv, exportService)
 	return srv
 }
@@ -7,6 +7,7 @@ import (
 
 	v1 "github.com/lyb88999/resume_helper/backend/services/ai-service/api/ai/v1"
 	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/service"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 
 	"github.com/go-kratos/kratos/v2/log"
@@ -15,10 +16,11 @@ import (
 )
 
 // NewHTTPServer new an HTTP server.
-func NewHTTPServer(c *conf.Server, aiService *service.AIService, logger log.Logger) *khttp.Server {
+func NewHTTPServer(c *conf.Server, bc *conf.Bootstrap, revocations *auth.Revocations, aiService *service.AIService, logger log.Logger) *khttp.Server {
 	var opts = []khttp.ServerOption{
 		khttp.Middleware(
 			recovery.Recovery(),
+			auth.Server(bc.GetAuth().GetJwtSecret(), publicOperations(), knowledgeOperations(), auth.WithRevocations(revocations)),
 		),
 	}
 	if c.Http.Network != "" {
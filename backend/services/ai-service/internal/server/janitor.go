@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
+)
+
+// SessionJanitor 定期清理长时间未活跃的聊天会话
+type SessionJanitor struct {
+	uc       *biz.AIUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewSessionJanitor 创建会话清理任务
+func NewSessionJanitor(uc *biz.AIUsecase, logger log.Logger) *SessionJanitor {
+	return &SessionJanitor{
+		uc:       uc,
+		interval: uc.SessionCleanupInterval(),
+		log:      log.NewHelper(logger),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动清理循环，实现 transport.Server 接口
+func (j *SessionJanitor) Start(ctx context.Context) error {
+	j.log.Infof("[Janitor] 会话清理任务已启动，间隔: %s", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce(ctx)
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止清理循环
+func (j *SessionJanitor) Stop(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.log.Info("[Janitor] 会话清理任务已停止")
+	return nil
+}
+
+func (j *SessionJanitor) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	count, err := j.uc.CleanupIdleSessions(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] 清理过期会话失败: %v", err)
+		return
+	}
+	if count > 0 {
+		j.log.Infof("[Janitor] 已清理 %d 个过期会话", count)
+	}
+}
+
+// JobJanitor 定期将中断的分析任务记为失败。分析任务在提交它的实例内存中执行，
+// 实例重启或崩溃后，这些任务会一直停留在等待或执行中
+type JobJanitor struct {
+	uc       *biz.AIUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewJobJanitor 创建中断任务检查任务
+func NewJobJanitor(uc *biz.AIUsecase, logger log.Logger) *JobJanitor {
+	return &JobJanitor{
+		uc:       uc,
+		interval: uc.JobCheckInterval(),
+		log:      log.NewHelper(logger),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动检查循环，实现 transport.Server 接口。启动时先检查一次，处理上次退出时遗留的任务
+func (j *JobJanitor) Start(ctx context.Context) error {
+	j.log.Infof("[Janitor] 中断任务检查已启动，间隔: %s", j.interval)
+	j.runOnce(ctx)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce(ctx)
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止检查循环
+func (j *JobJanitor) Stop(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.log.Info("[Janitor] 中断任务检查已停止")
+	return nil
+}
+
+func (j *JobJanitor) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	count, err := j.uc.FailStaleJobs(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] 处理中断的分析任务失败: %v", err)
+		return
+	}
+	if count > 0 {
+		j.log.Warnf("[Janitor] %d 个中断的分析任务已记为失败", count)
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"github.com/google/wire"
 	"github.com/hashicorp/consul/api"
 
+	v1 "github.com/lyb88999/resume_helper/backend/services/ai-service/api/ai/v1"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
 // ProviderSet is server providers.
-var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewRegistrar)
+var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewRegistrar, NewSessionJanitor, NewJobJanitor)
 
 // NewRegistrar 创建服务注册器
 func NewRegistrar(c *conf.Bootstrap) registry.Registrar {
@@ -32,3 +34,16 @@ func NewRegistrar(c *conf.Bootstrap) registry.Registrar {
 	r := consul.New(consulClient)
 	return r
 }
+
+// publicOperations 健康检查无需访问令牌
+func publicOperations() auth.Option {
+	return auth.WithPublic(v1.OperationAIServiceHealth)
+}
+
+// knowledgeOperations 修改技能库需要维护知识库的权限（辅导和管理员）。
+// 检查规则开关属于组织设置，由 LintUsecase 按组织角色检查
+func knowledgeOperations() auth.Option {
+	return auth.WithPermission(auth.PermManageKnowledge,
+		v1.OperationAIServiceUpsertSkill,
+	)
+}
@@ -2,28 +2,35 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "github.com/lyb88999/resume_helper/backend/services/ai-service/api/ai/v1"
 	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
 	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/timeline"
 )
 
 // AIService AI服务实现
 type AIService struct {
 	pb.UnimplementedAIServiceServer
 
-	aiUsecase *biz.AIUsecase
-	log       *log.Helper
+	aiUsecase    *biz.AIUsecase
+	skillUsecase *biz.SkillUsecase
+	lintUsecase  *biz.LintUsecase
+	log          *log.Helper
 }
 
 // NewAIService 创建AI服务
-func NewAIService(aiUsecase *biz.AIUsecase, logger log.Logger) *AIService {
+func NewAIService(aiUsecase *biz.AIUsecase, skillUsecase *biz.SkillUsecase, lintUsecase *biz.LintUsecase, logger log.Logger) *AIService {
 	return &AIService{
-		aiUsecase: aiUsecase,
-		log:       log.NewHelper(logger),
+		aiUsecase:    aiUsecase,
+		skillUsecase: skillUsecase,
+		lintUsecase:  lintUsecase,
+		log:          log.NewHelper(logger),
 	}
 }
 
@@ -34,9 +41,13 @@ func (s *AIService) AnalyzeResume(ctx context.Context, req *pb.AnalyzeResumeRequ
 	// 转换请求参数
 	bizReq := &biz.AnalyzeResumeRequest{
 		ResumeID:       req.ResumeId,
+		OrgID:          req.OrgId,
 		Content:        req.Content,
 		FileType:       req.FileType,
+		FileID:         req.FileId,
+		FileVersion:    req.FileVersion,
 		TargetPosition: req.TargetPosition,
+		JobDescription: req.JobDescription,
 	}
 
 	if req.Options != nil {
@@ -52,6 +63,9 @@ func (s *AIService) AnalyzeResume(ctx context.Context, req *pb.AnalyzeResumeRequ
 	// 调用业务逻辑
 	bizResp, err := s.aiUsecase.AnalyzeResume(ctx, bizReq)
 	if err != nil {
+		if errors.Is(err, biz.ErrOrgForbidden) || errors.Is(err, biz.ErrFileNotFound) {
+			return nil, err
+		}
 		s.log.WithContext(ctx).Errorf("简历分析失败: %v", err)
 		return &pb.AnalyzeResumeResponse{
 			Status:  "error",
@@ -61,7 +75,9 @@ func (s *AIService) AnalyzeResume(ctx context.Context, req *pb.AnalyzeResumeRequ
 
 	// 转换响应
 	resp := &pb.AnalyzeResumeResponse{
+		JobId:      bizResp.JobID,
 		AnalysisId: bizResp.AnalysisID,
+		Progress:   int32(bizResp.Progress),
 		Status:     bizResp.Status,
 		Message:    bizResp.Message,
 	}
@@ -70,10 +86,52 @@ func (s *AIService) AnalyzeResume(ctx context.Context, req *pb.AnalyzeResumeRequ
 		resp.Result = s.convertAnalysisResult(bizResp.Result)
 	}
 
-	s.log.WithContext(ctx).Infof("简历分析完成，分析ID: %s", bizResp.AnalysisID)
+	s.log.WithContext(ctx).Infof("简历分析任务已提交，任务ID: %s", bizResp.JobID)
 	return resp, nil
 }
 
+// GetAnalysisJob 获取分析任务状态
+func (s *AIService) GetAnalysisJob(ctx context.Context, req *pb.GetAnalysisJobRequest) (*pb.GetAnalysisJobResponse, error) {
+	job, result, err := s.aiUsecase.GetAnalysisJob(ctx, req.JobId)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取分析任务失败: %v", err)
+		return &pb.GetAnalysisJobResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	resp := &pb.GetAnalysisJobResponse{
+		Job:     s.convertAnalysisJob(job),
+		Status:  "success",
+		Message: "获取成功",
+	}
+	if result != nil {
+		resp.Result = s.convertAnalysisResult(result)
+	}
+	return resp, nil
+}
+
+// CancelAnalysisJob 取消分析任务
+func (s *AIService) CancelAnalysisJob(ctx context.Context, req *pb.CancelAnalysisJobRequest) (*pb.CancelAnalysisJobResponse, error) {
+	s.log.WithContext(ctx).Infof("收到取消分析任务请求，任务ID: %s", req.JobId)
+
+	job, err := s.aiUsecase.CancelAnalysisJob(ctx, req.JobId)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("取消分析任务失败: %v", err)
+		return &pb.CancelAnalysisJobResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.CancelAnalysisJobResponse{
+		Job:     s.convertAnalysisJob(job),
+		Status:  "success",
+		Message: "任务已取消",
+	}, nil
+}
+
 // GenerateSuggestions 生成优化建议
 func (s *AIService) GenerateSuggestions(ctx context.Context, req *pb.GenerateSuggestionsRequest) (*pb.GenerateSuggestionsResponse, error) {
 	s.log.WithContext(ctx).Infof("收到生成建议请求，分析ID: %s", req.AnalysisId)
@@ -167,6 +225,132 @@ func (s *AIService) Chat(ctx context.Context, req *pb.ChatRequest) (*pb.ChatResp
 	}, nil
 }
 
+// ListChatSessions 获取会话列表
+func (s *AIService) ListChatSessions(ctx context.Context, req *pb.ListChatSessionsRequest) (*pb.ListChatSessionsResponse, error) {
+	s.log.WithContext(ctx).Infof("收到会话列表请求，页码: %d", req.Page)
+
+	bizResp, err := s.aiUsecase.ListChatSessions(ctx, &biz.ListChatSessionsRequest{
+		Page:     int(req.Page),
+		PageSize: int(req.PageSize),
+	})
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取会话列表失败: %v", err)
+		return &pb.ListChatSessionsResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	sessions := make([]*pb.ChatSessionInfo, len(bizResp.Sessions))
+	for i, session := range bizResp.Sessions {
+		sessions[i] = &pb.ChatSessionInfo{
+			SessionId:    session.SessionID,
+			Title:        session.Title,
+			MessageCount: int32(session.MessageCount),
+			CreatedAt:    timestamppb.New(session.CreatedAt),
+			LastActive:   timestamppb.New(session.LastActive),
+		}
+	}
+
+	return &pb.ListChatSessionsResponse{
+		Sessions: sessions,
+		Total:    int32(bizResp.Total),
+		Status:   bizResp.Status,
+		Message:  bizResp.Message,
+	}, nil
+}
+
+// GetChatSession 获取会话完整记录
+func (s *AIService) GetChatSession(ctx context.Context, req *pb.GetChatSessionRequest) (*pb.GetChatSessionResponse, error) {
+	s.log.WithContext(ctx).Infof("收到获取会话请求，会话ID: %s", req.SessionId)
+
+	session, err := s.aiUsecase.GetChatSession(ctx, req.SessionId)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取会话失败: %v", err)
+		return &pb.GetChatSessionResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	messages := make([]*pb.ChatMessage, len(session.Messages))
+	for i, msg := range session.Messages {
+		messages[i] = &pb.ChatMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		}
+	}
+
+	return &pb.GetChatSessionResponse{
+		Session:  s.convertChatSessionInfo(session),
+		Messages: messages,
+		Status:   "success",
+		Message:  "获取会话成功",
+	}, nil
+}
+
+// RenameChatSession 重命名会话
+func (s *AIService) RenameChatSession(ctx context.Context, req *pb.RenameChatSessionRequest) (*pb.RenameChatSessionResponse, error) {
+	s.log.WithContext(ctx).Infof("收到重命名会话请求，会话ID: %s", req.SessionId)
+
+	session, err := s.aiUsecase.RenameChatSession(ctx, req.SessionId, req.Title)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("重命名会话失败: %v", err)
+		return &pb.RenameChatSessionResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.RenameChatSessionResponse{
+		Session: s.convertChatSessionInfo(session),
+		Status:  "success",
+		Message: "重命名成功",
+	}, nil
+}
+
+// ExportChatSession 导出会话
+func (s *AIService) ExportChatSession(ctx context.Context, req *pb.ExportChatSessionRequest) (*pb.ExportChatSessionResponse, error) {
+	s.log.WithContext(ctx).Infof("收到导出会话请求，会话ID: %s，格式: %s", req.SessionId, req.Format)
+
+	bizResp, err := s.aiUsecase.ExportChatSession(ctx, req.SessionId, req.Format)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("导出会话失败: %v", err)
+		return &pb.ExportChatSessionResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.ExportChatSessionResponse{
+		Filename:    bizResp.Filename,
+		ContentType: bizResp.ContentType,
+		Content:     bizResp.Content,
+		Status:      "success",
+		Message:     "导出完成",
+	}, nil
+}
+
+// DeleteChatSession 删除会话
+func (s *AIService) DeleteChatSession(ctx context.Context, req *pb.DeleteChatSessionRequest) (*pb.DeleteChatSessionResponse, error) {
+	s.log.WithContext(ctx).Infof("收到删除会话请求，会话ID: %s", req.SessionId)
+
+	if err := s.aiUsecase.DeleteChatSession(ctx, req.SessionId); err != nil {
+		s.log.WithContext(ctx).Errorf("删除会话失败: %v", err)
+		return &pb.DeleteChatSessionResponse{
+			Success: false,
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.DeleteChatSessionResponse{
+		Success: true,
+		Status:  "success",
+		Message: "删除成功",
+	}, nil
+}
+
 // RetrieveKnowledge 知识检索
 func (s *AIService) RetrieveKnowledge(ctx context.Context, req *pb.RetrieveKnowledgeRequest) (*pb.RetrieveKnowledgeResponse, error) {
 	s.log.WithContext(ctx).Infof("收到知识检索请求，查询: %s", req.Query)
@@ -295,16 +479,107 @@ func (s *AIService) convertAnalysisResult(result *eino.AnalysisResult) *pb.Analy
 		}
 	}
 
+	// 转换问题
+	issues := make([]*pb.Issue, len(result.Issues))
+	for i, issue := range result.Issues {
+		issues[i] = &pb.Issue{
+			Type:        issue.Type,
+			Description: issue.Description,
+			Severity:    issue.Severity,
+			Suggestion:  issue.Suggestion,
+		}
+	}
+
 	return &pb.AnalysisResult{
-		Sections:     make(map[string]*pb.Section), // 使用空map替代sections
-		Suggestions:  suggestions,
-		Scores:       scores,
-		Improvements: improvements,
-		Summary:      result.Summary,
+		Sections:        make(map[string]*pb.Section), // 使用空map替代sections
+		Suggestions:     suggestions,
+		Scores:          scores,
+		Improvements:    improvements,
+		Summary:         result.Summary,
+		MatchedSkills:   result.MatchedSkills,
+		MissingSkills:   result.MissingSkills,
+		Issues:          issues,
+		Timeline:        convertTimeline(result.Timeline),
+		LintSuggestions: convertLintSuggestions(result.LintSuggestions),
 		// AnalyzedAt:   timestamppb.New(result.AnalyzedAt), // 如果proto中没有这个字段就注释掉
 	}
 }
 
+func (s *AIService) convertAnalysisJob(job *biz.AnalysisJob) *pb.AnalysisJob {
+	pbJob := &pb.AnalysisJob{
+		JobId:           job.ID,
+		ResumeId:        job.ResumeID,
+		UserId:          job.UserID,
+		OrgId:           job.OrgID,
+		FileId:          job.FileID,
+		FileVersion:     job.FileVersion,
+		TargetPosition:  job.TargetPosition,
+		Status:          job.Status,
+		Progress:        int32(job.Progress),
+		CurrentStage:    job.CurrentStage,
+		CompletedStages: job.CompletedStages,
+		AnalysisId:      job.AnalysisID,
+		ErrorMessage:    job.ErrorMsg,
+		CreatedAt:       timestamppb.New(job.CreatedAt),
+		UpdatedAt:       timestamppb.New(job.UpdatedAt),
+	}
+	if job.CompletedAt != nil {
+		pbJob.CompletedAt = timestamppb.New(*job.CompletedAt)
+	}
+	return pbJob
+}
+
+func convertTimeline(report *timeline.Report) *pb.TimelineAnalysis {
+	if report == nil {
+		return nil
+	}
+
+	result := &pb.TimelineAnalysis{
+		TotalExperienceMonths: int32(report.TotalExperienceMonths),
+		TotalExperienceYears:  float32(report.TotalExperienceYears),
+		AverageTenureMonths:   float32(report.AverageTenureMonths),
+	}
+	for _, t := range report.Tenures {
+		result.Tenures = append(result.Tenures, &pb.RoleTenure{
+			Label:    t.Label,
+			Start:    t.Start,
+			End:      t.End,
+			Months:   int32(t.Months),
+			FullTime: t.FullTime,
+		})
+	}
+	for _, g := range report.Gaps {
+		result.Gaps = append(result.Gaps, &pb.EmploymentGap{
+			After:  g.After,
+			Before: g.Before,
+			Start:  g.Start,
+			End:    g.End,
+			Months: int32(g.Months),
+		})
+	}
+	for _, o := range report.Overlaps {
+		result.Overlaps = append(result.Overlaps, &pb.TimelineOverlap{
+			Type:   o.Type,
+			First:  o.First,
+			Second: o.Second,
+			Start:  o.Start,
+			End:    o.End,
+			Months: int32(o.Months),
+		})
+	}
+	return result
+}
+
+func (s *AIService) convertChatSessionInfo(session *eino.ChatContext) *pb.ChatSessionInfo {
+	return &pb.ChatSessionInfo{
+		SessionId:    session.SessionID,
+		Title:        session.Title,
+		MessageCount: int32(len(session.Messages)),
+		CreatedAt:    timestamppb.New(session.CreatedAt),
+		LastActive:   timestamppb.New(session.LastActive),
+	}
+}
+
 func (s *AIService) convertToBizAnalysisResult(pbResult *pb.AnalysisResult) *eino.AnalysisResult {
 	if pbResult == nil {
 		return nil
@@ -313,7 +588,9 @@ func (s *AIService) convertToBizAnalysisResult(pbResult *pb.AnalysisResult) *ein
 	// 这里实现protobuf到业务对象的转换
 	// 简化实现，实际应该完整转换所有字段
 	result := &eino.AnalysisResult{
-		Summary: pbResult.Summary,
+		Summary:       pbResult.Summary,
+		MatchedSkills: pbResult.MatchedSkills,
+		MissingSkills: pbResult.MissingSkills,
 	}
 
 	if pbResult.Scores != nil {
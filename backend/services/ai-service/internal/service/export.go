@@ -0,0 +1,85 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	exportv1 "github.com/lyb88999/resume_helper/backend/shared/proto/export"
+)
+
+// ExportService 用户数据导出服务，供 file-service 生成导出压缩包，每个分析任务导出为一个 JSON 文件
+type ExportService struct {
+	exportv1.UnimplementedUserDataExportServiceServer
+
+	aiUsecase *biz.AIUsecase
+	log       *log.Helper
+}
+
+// NewExportService 创建用户数据导出服务
+func NewExportService(aiUsecase *biz.AIUsecase, logger log.Logger) *ExportService {
+	return &ExportService{
+		aiUsecase: aiUsecase,
+		log:       log.NewHelper(logger),
+	}
+}
+
+// exportedAnalysis 导出的分析任务，已完成的任务附带分析报告
+type exportedAnalysis struct {
+	JobID          string               `json:"job_id"`
+	ResumeID       string               `json:"resume_id"`
+	FileID         string               `json:"file_id,omitempty"`
+	FileVersion    int32                `json:"file_version,omitempty"`
+	TargetPosition string               `json:"target_position,omitempty"`
+	Status         string               `json:"status"`
+	Error          string               `json:"error,omitempty"`
+	Report         *eino.AnalysisResult `json:"report,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	CompletedAt    *time.Time           `json:"completed_at,omitempty"`
+}
+
+// ExportUserData 导出访问令牌中用户的所有分析任务和分析报告
+func (s *ExportService) ExportUserData(req *exportv1.ExportUserDataRequest, stream exportv1.UserDataExportService_ExportUserDataServer) error {
+	ctx := stream.Context()
+	userID, _ := auth.FromContext(ctx)
+	s.log.WithContext(ctx).Infof("导出用户分析数据: user_id=%d, files=%d", userID, len(req.FileIds))
+
+	count := 0
+	err := s.aiUsecase.ExportAnalyses(ctx, req.FileIds, func(job *biz.AnalysisJob, result *eino.AnalysisResult) error {
+		content, err := json.MarshalIndent(&exportedAnalysis{
+			JobID:          job.ID,
+			ResumeID:       job.ResumeID,
+			FileID:         job.FileID,
+			FileVersion:    job.FileVersion,
+			TargetPosition: job.TargetPosition,
+			Status:         job.Status,
+			Error:          job.ErrorMsg,
+			Report:         result,
+			CreatedAt:      job.CreatedAt,
+			UpdatedAt:      job.UpdatedAt,
+			CompletedAt:    job.CompletedAt,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		count++
+		return stream.Send(&exportv1.ExportRecord{
+			Name:       job.ID + ".json",
+			Content:    content,
+			ModifiedAt: timestamppb.New(job.UpdatedAt),
+		})
+	})
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("导出用户分析数据失败: %v", err)
+		return err
+	}
+
+	s.log.WithContext(ctx).Infof("导出用户分析数据完成: user_id=%d, jobs=%d", userID, count)
+	return nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	pb "github.com/lyb88999/resume_helper/backend/services/ai-service/api/ai/v1"
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/eino"
+	"github.com/lyb88999/resume_helper/backend/services/ai-service/internal/lint"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/models"
+)
+
+// LintResume 简历规则检查
+func (s *AIService) LintResume(ctx context.Context, req *pb.LintResumeRequest) (*pb.LintResumeResponse, error) {
+	input := &lint.Input{Content: req.Content}
+	if req.Resume != nil {
+		// Struct 与 eino.ResumeData 的 JSON 结构一致，经 JSON 中转
+		data, err := req.Resume.MarshalJSON()
+		if err == nil {
+			input.Resume = &eino.ResumeData{}
+			err = json.Unmarshal(data, input.Resume)
+		}
+		if err != nil {
+			return &pb.LintResumeResponse{
+				Status:  "error",
+				Message: fmt.Sprintf("简历格式错误: %v", err),
+			}, nil
+		}
+	}
+
+	suggestions, err := s.lintUsecase.Lint(ctx, req.OrgId, input)
+	if err != nil {
+		if errors.Is(err, biz.ErrOrgForbidden) {
+			return nil, err
+		}
+		s.log.WithContext(ctx).Errorf("简历规则检查失败: %v", err)
+		return &pb.LintResumeResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.LintResumeResponse{
+		Suggestions: convertLintSuggestions(suggestions),
+		Status:      "success",
+		Message:     fmt.Sprintf("发现 %d 个问题", len(suggestions)),
+	}, nil
+}
+
+// ListLintRules 获取检查规则
+func (s *AIService) ListLintRules(ctx context.Context, req *pb.ListLintRulesRequest) (*pb.ListLintRulesResponse, error) {
+	states, err := s.lintUsecase.ListRules(ctx, req.OrgId)
+	if err != nil {
+		if errors.Is(err, biz.ErrOrgForbidden) {
+			return nil, err
+		}
+		return &pb.ListLintRulesResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	rules := make([]*pb.LintRule, len(states))
+	for i, state := range states {
+		rules[i] = convertLintRule(state)
+	}
+	return &pb.ListLintRulesResponse{
+		Rules:   rules,
+		Status:  "success",
+		Message: "获取成功",
+	}, nil
+}
+
+// UpdateLintRule 开启或关闭组织的检查规则
+func (s *AIService) UpdateLintRule(ctx context.Context, req *pb.UpdateLintRuleRequest) (*pb.UpdateLintRuleResponse, error) {
+	s.log.WithContext(ctx).Infof("收到检查规则更新请求，组织ID: %s，规则ID: %s", req.OrgId, req.RuleId)
+
+	state, err := s.lintUsecase.SetRuleEnabled(ctx, req.OrgId, req.RuleId, req.Enabled)
+	if err != nil {
+		if errors.Is(err, biz.ErrOrgForbidden) || errors.Is(err, biz.ErrOrgManagerRequired) {
+			return nil, err
+		}
+		s.log.WithContext(ctx).Errorf("更新检查规则失败: %v", err)
+		return &pb.UpdateLintRuleResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.UpdateLintRuleResponse{
+		Rule:    convertLintRule(state),
+		Status:  "success",
+		Message: "更新成功",
+	}, nil
+}
+
+func convertLintRule(state *biz.LintRuleState) *pb.LintRule {
+	rule := state.Rule
+	return &pb.LintRule{
+		Id:             rule.ID,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		Severity:       string(rule.Severity),
+		Type:           string(rule.Type),
+		Check:          rule.Check,
+		Targets:        rule.Targets,
+		Enabled:        state.Enabled,
+		DefaultEnabled: rule.EnabledByDefault(),
+		Overridden:     state.Overridden,
+	}
+}
+
+func convertLintSuggestions(suggestions []models.Suggestion) []*pb.LintSuggestion {
+	result := make([]*pb.LintSuggestion, len(suggestions))
+	for i, sg := range suggestions {
+		result[i] = &pb.LintSuggestion{
+			RuleId:      sg.RuleID,
+			Level:       string(sg.Level),
+			Type:        string(sg.Type),
+			Title:       sg.Title,
+			Description: sg.Description,
+			Examples:    sg.Examples,
+			Location: &pb.LintLocation{
+				Section: sg.Location.Section,
+				Index:   int32(sg.Location.Index),
+				Field:   sg.Location.Field,
+			},
+			Priority: int32(sg.Priority),
+		}
+	}
+	return result
+}
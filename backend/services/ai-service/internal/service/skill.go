@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/lyb88999/resume_helper/backend/services/ai-service/api/ai/v1"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/skills"
+)
+
+// ListSkills 获取技能列表
+func (s *AIService) ListSkills(ctx context.Context, req *pb.ListSkillsRequest) (*pb.ListSkillsResponse, error) {
+	list := s.skillUsecase.ListSkills(ctx, req.Category)
+
+	return &pb.ListSkillsResponse{
+		Skills:  convertSkills(list),
+		Status:  "success",
+		Message: "获取成功",
+	}, nil
+}
+
+// GetSkill 获取技能详情
+func (s *AIService) GetSkill(ctx context.Context, req *pb.GetSkillRequest) (*pb.GetSkillResponse, error) {
+	detail, err := s.skillUsecase.GetSkill(ctx, req.SkillId)
+	if err != nil {
+		return &pb.GetSkillResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.GetSkillResponse{
+		Skill:     convertSkill(detail.Skill),
+		Ancestors: detail.Ancestors,
+		Children:  detail.Children,
+		Status:    "success",
+		Message:   "获取成功",
+	}, nil
+}
+
+// UpsertSkill 新增或更新技能
+func (s *AIService) UpsertSkill(ctx context.Context, req *pb.UpsertSkillRequest) (*pb.UpsertSkillResponse, error) {
+	if req.Skill == nil {
+		return &pb.UpsertSkillResponse{
+			Status:  "error",
+			Message: "技能定义不能为空",
+		}, nil
+	}
+	s.log.WithContext(ctx).Infof("收到技能更新请求，技能ID: %s", req.Skill.Id)
+
+	skill, err := s.skillUsecase.UpsertSkill(ctx, &skills.Skill{
+		ID:            req.Skill.Id,
+		Name:          req.Skill.Name,
+		NameZh:        req.Skill.NameZh,
+		Category:      req.Skill.Category,
+		Parent:        req.Skill.Parent,
+		Aliases:       req.Skill.Aliases,
+		CaseSensitive: req.Skill.CaseSensitive,
+	})
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("更新技能失败: %v", err)
+		return &pb.UpsertSkillResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.UpsertSkillResponse{
+		Skill:   convertSkill(skill),
+		Status:  "success",
+		Message: "保存成功",
+	}, nil
+}
+
+// NormalizeSkills 技能归一化
+func (s *AIService) NormalizeSkills(ctx context.Context, req *pb.NormalizeSkillsRequest) (*pb.NormalizeSkillsResponse, error) {
+	result := s.skillUsecase.NormalizeSkills(ctx, req.Skills, req.Text)
+
+	return &pb.NormalizeSkillsResponse{
+		Skills:    convertSkills(result.Skills),
+		Unknown:   result.Unknown,
+		Extracted: convertSkills(result.Extracted),
+		Status:    "success",
+		Message:   "归一化完成",
+	}, nil
+}
+
+func convertSkill(skill *skills.Skill) *pb.Skill {
+	return &pb.Skill{
+		Id:            skill.ID,
+		Name:          skill.Name,
+		NameZh:        skill.NameZh,
+		Category:      skill.Category,
+		Parent:        skill.Parent,
+		Aliases:       skill.Aliases,
+		CaseSensitive: skill.CaseSensitive,
+	}
+}
+
+func convertSkills(list []*skills.Skill) []*pb.Skill {
+	result := make([]*pb.Skill, 0, len(list))
+	for _, skill := range list {
+		result = append(result, convertSkill(skill))
+	}
+	return result
+}
@@ -0,0 +1,478 @@
+package biz
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// 压缩包条目的处理结果
+const (
+	EntryAccepted = "accepted" // 已保存为文件
+	EntrySkipped  = "skipped"  // 系统文件或不支持的类型，未处理
+	EntryRejected = "rejected" // 路径不安全、超过大小限制或未通过校验
+)
+
+// 已保存文件的解析状态：等待扫描通过后提交到解析队列，解析服务回传 processing、completed 或 failed
+const (
+	ParseWaiting    = "waiting"
+	ParseQueued     = "queued"
+	ParseProcessing = "processing"
+	ParseCompleted  = "completed"
+	ParseFailed     = "failed"
+	ParseSkipped    = "skipped" // 文件未通过扫描或已删除，不再解析
+)
+
+const (
+	defaultArchiveMaxSize             = 100 << 20
+	defaultArchiveMaxEntries          = 500
+	defaultArchiveMaxUncompressedSize = 500 << 20
+	defaultParseQueue                 = "parser_tasks"
+	defaultResultQueue                = "parser_results"
+	defaultDispatchInterval           = 10 * time.Second
+	defaultParseTimeout               = 30 * time.Minute
+	// maxParseAttempts 解析请求超时后最多提交的次数
+	maxParseAttempts = 3
+	// dispatchBatchSize 每轮提交解析的最大文件数
+	dispatchBatchSize = 100
+	// resultWait 等待解析结果的最长时间
+	resultWait = 5 * time.Second
+)
+
+var (
+	ErrInvalidArchive = errors.New("invalid zip archive")
+	ErrBatchNotFound  = errors.New("batch not found")
+)
+
+// Batch 一次压缩包上传产生的批次
+type Batch struct {
+	BatchID     string
+	UserID      int64
+	ArchiveName string
+	CreatedAt   time.Time
+	Entries     []*BatchEntry
+}
+
+// Counts 按处理结果和解析状态统计条目
+func (b *Batch) Counts() (byStatus, byParseStatus map[string]int32) {
+	byStatus = make(map[string]int32)
+	byParseStatus = make(map[string]int32)
+	for _, entry := range b.Entries {
+		byStatus[entry.Status]++
+		if entry.Status == EntryAccepted {
+			byParseStatus[entry.ParseStatus]++
+		}
+	}
+	return byStatus, byParseStatus
+}
+
+// Finished 所有已保存文件的解析是否都已结束
+func (b *Batch) Finished() bool {
+	for _, entry := range b.Entries {
+		switch entry.ParseStatus {
+		case ParseWaiting, ParseQueued, ParseProcessing:
+			return false
+		}
+	}
+	return true
+}
+
+// BatchEntry 压缩包中的一个文件
+type BatchEntry struct {
+	ID          int64
+	BatchID     string
+	UserID      int64
+	Name        string // 压缩包内的路径
+	Status      string
+	Reason      string // 跳过或拒绝的原因
+	FileID      string
+	ParseStatus string
+	ParseTaskID string
+	ParseError  string
+	// ParseAttempts 解析请求超时后重新提交的次数
+	ParseAttempts int32
+	UpdatedAt     time.Time
+}
+
+// ParseRequest 提交给解析服务的请求，以 JSON 写入解析请求队列。
+// 解析服务按文件ID、版本和用户ID通过 FileContentService 读取文件内容
+type ParseRequest struct {
+	BatchID  string `json:"batch_id"`
+	FileID   string `json:"file_id"`
+	Version  int32  `json:"version"` // 提交时的当前版本，之后上传新版本不影响该次解析
+	UserID   int64  `json:"user_id"`
+	OrgID    int64  `json:"org_id,omitempty"` // 文件所属的组织，解析任务同样归属该组织
+	Filename string `json:"filename"`
+	FileType string `json:"file_type"`
+}
+
+// ParseResult 解析服务通过结果队列回传的任务状态
+type ParseResult struct {
+	BatchID string `json:"batch_id"`
+	FileID  string `json:"file_id"`
+	TaskID  string `json:"task_id"`
+	Status  string `json:"status"` // processing, completed, failed
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchRepo 压缩包批次仓库
+type BatchRepo interface {
+	CreateBatch(ctx context.Context, batch *Batch) error
+	AddEntry(ctx context.Context, entry *BatchEntry) error
+	// FindBatch 返回用户的批次及其所有条目
+	FindBatch(ctx context.Context, batchID string, userID int64) (*Batch, error)
+	// ListDispatchable 返回等待解析、且文件已扫描结束或已删除的条目
+	ListDispatchable(ctx context.Context, limit int) ([]*BatchEntry, error)
+	// UpdateParseStatus 仅当条目的解析状态属于 from 时更新，返回是否更新。taskID 为空时保留原值
+	UpdateParseStatus(ctx context.Context, fileID string, from []string, status, taskID, errMsg string) (bool, error)
+	// ResetStaleParses 处理 updatedBefore 之前进入 queued 或 processing 后再无更新的条目：重新提交次数少于 maxAttempts 的
+	// queued 条目放回 waiting 并增加次数，其余记为 failed
+	ResetStaleParses(ctx context.Context, updatedBefore time.Time, maxAttempts int32, errMsg string) (requeued, failed int64, err error)
+}
+
+// ParseQueue 与解析服务之间的任务队列
+type ParseQueue interface {
+	Publish(ctx context.Context, req *ParseRequest) error
+	// NextResult 等待下一条解析结果，wait 内没有结果时返回 nil
+	NextResult(ctx context.Context, wait time.Duration) (*ParseResult, error)
+}
+
+// ArchiveUsecase 解压 ZIP 压缩包，每个支持的文件按普通上传保存，并在扫描通过后提交解析
+type ArchiveUsecase struct {
+	files            *FileUsecase
+	repo             BatchRepo
+	queue            ParseQueue
+	maxSize          int64
+	maxEntries       int
+	maxUncompressed  int64
+	dispatchInterval time.Duration
+	parseTimeout     time.Duration
+	log              *log.Helper
+}
+
+// NewArchiveUsecase 创建压缩包上传用例
+func NewArchiveUsecase(files *FileUsecase, repo BatchRepo, queue ParseQueue, config *conf.Storage, logger log.Logger) *ArchiveUsecase {
+	archive := config.GetArchive()
+	uc := &ArchiveUsecase{
+		files:            files,
+		repo:             repo,
+		queue:            queue,
+		maxSize:          archive.GetMaxSize(),
+		maxEntries:       int(archive.GetMaxEntries()),
+		maxUncompressed:  archive.GetMaxUncompressedSize(),
+		dispatchInterval: archive.GetDispatchInterval().AsDuration(),
+		parseTimeout:     archive.GetParseTimeout().AsDuration(),
+		log:              log.NewHelper(logger),
+	}
+	if uc.maxSize <= 0 {
+		uc.maxSize = defaultArchiveMaxSize
+	}
+	if uc.maxEntries <= 0 {
+		uc.maxEntries = defaultArchiveMaxEntries
+	}
+	if uc.maxUncompressed <= 0 {
+		uc.maxUncompressed = defaultArchiveMaxUncompressedSize
+	}
+	if uc.dispatchInterval <= 0 {
+		uc.dispatchInterval = defaultDispatchInterval
+	}
+	if uc.parseTimeout <= 0 {
+		uc.parseTimeout = defaultParseTimeout
+	}
+	return uc
+}
+
+// MaxSize 压缩包大小上限
+func (uc *ArchiveUsecase) MaxSize() int64 {
+	return uc.maxSize
+}
+
+// DispatchInterval 提交解析的检查间隔
+func (uc *ArchiveUsecase) DispatchInterval() time.Duration {
+	return uc.dispatchInterval
+}
+
+// UploadArchive 接收 ZIP 压缩包并逐个保存其中的文件。条目数和解压后的总大小超限时拒绝整个压缩包；
+// 单个条目路径不安全、类型不支持或未通过校验时只跳过该条目，原因记录在批次中
+func (uc *ArchiveUsecase) UploadArchive(ctx context.Context, in *UploadInput) (*Batch, error) {
+	if in.Size > uc.maxSize {
+		return nil, ErrFileSizeExceeded
+	}
+	if !strings.EqualFold(filepath.Ext(in.Filename), ".zip") {
+		return nil, ErrInvalidFileType
+	}
+
+	spooled, err := uc.files.spool(in.Content, uc.maxSize)
+	if err != nil {
+		if !errors.Is(err, ErrFileSizeExceeded) && !errors.Is(err, ErrEmptyFile) {
+			uc.log.WithContext(ctx).Errorf("failed to receive archive: %v", err)
+		}
+		return nil, err
+	}
+	defer spooled.Close()
+
+	zr, err := zip.NewReader(spooled.file, spooled.size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	files, err := uc.checkArchive(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &Batch{
+		BatchID:     uuid.New().String(),
+		UserID:      in.UserID,
+		ArchiveName: in.Filename,
+	}
+	if err := uc.repo.CreateBatch(ctx, batch); err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to create batch: %v", err)
+		return nil, err
+	}
+
+	accepted := 0
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		entry := uc.extract(ctx, batch, f)
+		if err := uc.repo.AddEntry(ctx, entry); err != nil {
+			uc.log.WithContext(ctx).Errorf("failed to save batch entry %s: %v", entry.Name, err)
+			return nil, err
+		}
+		if entry.Status == EntryAccepted {
+			accepted++
+		}
+		batch.Entries = append(batch.Entries, entry)
+	}
+
+	uc.log.WithContext(ctx).Infof("archive extracted: batch_id=%s, entries=%d, accepted=%d", batch.BatchID, len(batch.Entries), accepted)
+	return batch, nil
+}
+
+// checkArchive 返回压缩包中的文件条目，并按声明的大小检查总量。
+// 解压时 archive/zip 会校验实际大小不超过声明的大小，伪造的声明无法绕过限制
+func (uc *ArchiveUsecase) checkArchive(zr *zip.Reader) ([]*zip.File, error) {
+	var files []*zip.File
+	var total uint64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files = append(files, f)
+		total += f.UncompressedSize64
+	}
+
+	switch {
+	case len(files) == 0:
+		return nil, fmt.Errorf("%w: archive contains no files", ErrInvalidArchive)
+	case len(files) > uc.maxEntries:
+		return nil, fmt.Errorf("%w: archive contains %d files, at most %d allowed", ErrInvalidArchive, len(files), uc.maxEntries)
+	case total > uint64(uc.maxUncompressed):
+		return nil, fmt.Errorf("%w: uncompressed size %d exceeds %d bytes", ErrInvalidArchive, total, uc.maxUncompressed)
+	}
+	return files, nil
+}
+
+// extract 保存一个条目，返回处理结果
+func (uc *ArchiveUsecase) extract(ctx context.Context, batch *Batch, f *zip.File) *BatchEntry {
+	entry := &BatchEntry{
+		BatchID: batch.BatchID,
+		UserID:  batch.UserID,
+		Name:    strings.ToValidUTF8(f.Name, "_"),
+	}
+	reject := func(status, reason string) *BatchEntry {
+		entry.Status = status
+		entry.Reason = reason
+		return entry
+	}
+
+	name, ok := archiveEntryName(f.Name)
+	switch {
+	case !ok:
+		return reject(EntryRejected, "unsafe path")
+	case isSystemEntry(name):
+		return reject(EntrySkipped, "hidden or system file")
+	case !uc.files.isAllowedType(name):
+		return reject(EntrySkipped, "file type is not allowed")
+	case f.Flags&0x1 != 0:
+		return reject(EntryRejected, "encrypted entry")
+	case uc.files.config.MaxFileSize > 0 && f.UncompressedSize64 > uint64(uc.files.config.MaxFileSize):
+		return reject(EntryRejected, ErrFileSizeExceeded.Error())
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return reject(EntryRejected, err.Error())
+	}
+	defer rc.Close()
+
+	reply, err := uc.files.UploadStream(ctx, &UploadInput{
+		Filename: path.Base(name),
+		UserID:   batch.UserID,
+		Size:     int64(f.UncompressedSize64),
+		Content:  rc,
+		BatchID:  batch.BatchID,
+	})
+	if err != nil {
+		return reject(EntryRejected, err.Error())
+	}
+
+	entry.Status = EntryAccepted
+	entry.FileID = reply.File.FileId
+	entry.ParseStatus = ParseWaiting
+	return entry
+}
+
+// archiveEntryName 规范化条目路径，绝对路径、盘符和包含 .. 的路径视为不安全
+func archiveEntryName(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || !utf8.ValidString(name) || strings.ContainsRune(name, 0) ||
+		strings.HasPrefix(name, "/") || len(name) >= 2 && name[1] == ':' {
+		return "", false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	return path.Clean(name), true
+}
+
+// isSystemEntry 是否为 macOS 资源文件、隐藏文件等非简历内容
+func isSystemEntry(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" || strings.EqualFold(part, "Thumbs.db") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBatch 获取用户的批次及每个文件的处理和解析状态
+func (uc *ArchiveUsecase) GetBatch(ctx context.Context, batchID string, userID int64) (*Batch, error) {
+	return uc.repo.FindBatch(ctx, batchID, userID)
+}
+
+// Dispatch 将已通过扫描的文件提交到解析队列，未通过扫描或已删除的文件不再解析。
+// 提交前先通过条件更新占有条目，多个实例同时执行时每个文件只提交一次
+func (uc *ArchiveUsecase) Dispatch(ctx context.Context) (int, error) {
+	entries, err := uc.repo.ListDispatchable(ctx, dispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, entry := range entries {
+		file, err := uc.files.repo.FindByID(ctx, entry.FileID, Scope{UserID: entry.UserID})
+		if errors.Is(err, ErrFileNotFound) {
+			uc.skipParse(ctx, entry, "file has been deleted")
+			continue
+		}
+		if err != nil {
+			return dispatched, err
+		}
+
+		switch file.Status {
+		case FileStatusClean:
+		case FileStatusInfected:
+			uc.skipParse(ctx, entry, "file failed malware scanning")
+			continue
+		default:
+			continue
+		}
+
+		submitted, err := uc.submit(ctx, file)
+		if err != nil {
+			uc.log.WithContext(ctx).Warnf("failed to submit parse request: file_id=%s, err=%v", file.FileID, err)
+			continue
+		}
+		if submitted {
+			dispatched++
+		}
+	}
+	return dispatched, nil
+}
+
+// submit 占有条目并提交解析请求，提交失败时放回等待状态，下一轮重试
+func (uc *ArchiveUsecase) submit(ctx context.Context, file *File) (bool, error) {
+	claimed, err := uc.repo.UpdateParseStatus(ctx, file.FileID, []string{ParseWaiting}, ParseQueued, "", "")
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	req := &ParseRequest{
+		BatchID:  file.BatchID,
+		FileID:   file.FileID,
+		Version:  file.Version,
+		UserID:   file.UserID,
+		OrgID:    file.OrgID,
+		Filename: file.OriginalName,
+		FileType: strings.TrimPrefix(strings.ToLower(filepath.Ext(file.OriginalName)), "."),
+	}
+	if err := uc.queue.Publish(ctx, req); err != nil {
+		if _, rerr := uc.repo.UpdateParseStatus(ctx, file.FileID, []string{ParseQueued}, ParseWaiting, "", ""); rerr != nil {
+			uc.log.WithContext(ctx).Errorf("failed to reset parse status: file_id=%s, err=%v", file.FileID, rerr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// RecoverStale 处理超过 parse_timeout 没有进展的条目。queued 的请求可能在提交后丢失，放回等待状态重新提交；
+// processing 说明解析服务已收到请求，多半是处理中崩溃或结果丢失，重新提交可能反复失败，直接记为失败
+func (uc *ArchiveUsecase) RecoverStale(ctx context.Context) error {
+	requeued, failed, err := uc.repo.ResetStaleParses(ctx, time.Now().Add(-uc.parseTimeout), maxParseAttempts-1, "parse timed out")
+	if err != nil {
+		return err
+	}
+	if requeued > 0 || failed > 0 {
+		uc.log.WithContext(ctx).Warnf("recovered stale batch entries: requeued=%d, failed=%d", requeued, failed)
+	}
+	return nil
+}
+
+func (uc *ArchiveUsecase) skipParse(ctx context.Context, entry *BatchEntry, reason string) {
+	if _, err := uc.repo.UpdateParseStatus(ctx, entry.FileID, []string{ParseWaiting}, ParseSkipped, "", reason); err != nil {
+		uc.log.WithContext(ctx).Warnf("failed to skip parsing: file_id=%s, err=%v", entry.FileID, err)
+	}
+}
+
+// HandleNextResult 等待并处理一条解析结果，没有结果时返回 false。已结束的条目不会被回退
+func (uc *ArchiveUsecase) HandleNextResult(ctx context.Context) (bool, error) {
+	result, err := uc.queue.NextResult(ctx, resultWait)
+	if err != nil || result == nil {
+		return false, err
+	}
+
+	var from []string
+	switch result.Status {
+	case ParseProcessing:
+		from = []string{ParseQueued}
+	case ParseCompleted, ParseFailed:
+		from = []string{ParseQueued, ParseProcessing}
+	default:
+		uc.log.WithContext(ctx).Warnf("ignored parse result with unknown status %q: file_id=%s", result.Status, result.FileID)
+		return true, nil
+	}
+
+	updated, err := uc.repo.UpdateParseStatus(ctx, result.FileID, from, result.Status, result.TaskID, result.Error)
+	if err != nil {
+		return true, fmt.Errorf("failed to update parse status of %s: %w", result.FileID, err)
+	}
+	if !updated {
+		uc.log.WithContext(ctx).Debugf("ignored stale parse result: file_id=%s, status=%s", result.FileID, result.Status)
+	}
+	return true, nil
+}
@@ -0,0 +1,57 @@
+package biz
+
+import "testing"
+
+func TestArchiveEntryName(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{in: "resume.pdf", want: "resume.pdf", wantOK: true},
+		{in: "2024/张三.docx", want: "2024/张三.docx", wantOK: true},
+		{in: `candidates\lisi.pdf`, want: "candidates/lisi.pdf", wantOK: true},
+		{in: "a/./b//c.pdf", want: "a/b/c.pdf", wantOK: true},
+		{in: "..resume.pdf", want: "..resume.pdf", wantOK: true},
+		{in: "a/..b/c.pdf", want: "a/..b/c.pdf", wantOK: true},
+		{in: "", wantOK: false},
+		{in: "../evil.pdf", wantOK: false},
+		{in: "a/../../evil.pdf", wantOK: false},
+		{in: "a/../b.pdf", wantOK: false},
+		{in: `..\evil.pdf`, wantOK: false},
+		{in: `a\..\..\evil.pdf`, wantOK: false},
+		{in: "/etc/passwd", wantOK: false},
+		{in: `\windows\system32\evil.dll`, wantOK: false},
+		{in: "C:/evil.pdf", wantOK: false},
+		{in: `c:\evil.pdf`, wantOK: false},
+		{in: "a\x00.pdf", wantOK: false},
+		{in: "\xff\xfe.pdf", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := archiveEntryName(tt.in)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("archiveEntryName(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsSystemEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"resume.pdf", false},
+		{"2024/resume.pdf", false},
+		{"__MACOSX/._resume.pdf", true},
+		{"2024/.DS_Store", true},
+		{".hidden/resume.pdf", true},
+		{"photos/thumbs.db", true},
+	}
+	for _, tt := range tests {
+		if got := isSystemEntry(tt.name); got != tt.want {
+			t.Errorf("isSystemEntry(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package biz
+
+import (
+	"context"
+	"time"
+)
+
+// blobKeyPrefix 内容寻址存储对象的前缀，对象名为 sha256_<十六进制哈希>
+const blobKeyPrefix = "sha256_"
+
+// Blob 按内容寻址存储的文件内容。内容相同的文件共享同一个 Blob，RefCount 为引用它的文件数
+type Blob struct {
+	Hash       string // 内容的 SHA-256，十六进制
+	StorageKey string
+	Size       int64
+	RefCount   int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// BlobRepo 内容存储引用计数仓库接口。同一哈希的 Acquire 和 Release 串行执行，
+// 保证存储对象不会在被引用时删除
+type BlobRepo interface {
+	// Acquire 增加引用计数。Blob 不存在时先调用 store 写入存储再创建记录，返回调用前 Blob 是否已存在
+	Acquire(ctx context.Context, blob *Blob, store func(ctx context.Context) error) (bool, error)
+	// Release 减少引用计数，归零时删除记录并调用 remove 删除存储对象
+	Release(ctx context.Context, hash string, remove func(ctx context.Context) error) error
+	// ListUpdatedBefore 按哈希顺序返回哈希大于 after 且在 before 之前更新的 Blob
+	ListUpdatedBefore(ctx context.Context, after string, before time.Time, limit int) ([]*Blob, error)
+	// Reconcile 按实际引用它的文件版本数（包括回收站中文件的版本）核对在 before 之前更新的 Blob：
+	// 没有版本引用时删除记录并调用 remove，引用计数偏低时调高
+	Reconcile(ctx context.Context, hash string, before time.Time, remove func(ctx context.Context) error) (BlobReconcileResult, error)
+	// RemoveOrphan 删除没有记录的存储对象。删除期间占用该哈希，阻止并发的 Acquire 写入相同内容，
+	// 记录已存在时不删除并返回 false
+	RemoveOrphan(ctx context.Context, hash, storageKey string, remove func(ctx context.Context) error) (bool, error)
+	// ListMissing 返回被在 createdBefore 之前创建的文件版本引用、但没有记录的内容哈希
+	ListMissing(ctx context.Context, createdBefore time.Time, limit int) ([]string, error)
+	// Recreate 按引用内容的文件版本重建记录，记录已存在时不做修改
+	Recreate(ctx context.Context, hash, storageKey string) error
+}
+
+// BlobReconcileResult Blob 核对结果
+type BlobReconcileResult int
+
+const (
+	BlobConsistent  BlobReconcileResult = iota
+	BlobRepaired                        // 引用计数低于实际引用数，已修正
+	BlobRemoved                         // 没有文件引用，已删除
+	BlobOverCounted                     // 引用计数高于实际引用数。可能是正在删除的文件，不调低，引用数归零时会被删除
+)
+
+// blobKey 返回内容哈希对应的存储对象名
+func blobKey(hash string) string {
+	return blobKeyPrefix + hash
+}
@@ -0,0 +1,258 @@
+package biz
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// ContentBasePath 签名下载链接的路径前缀，完整路径为 ContentBasePath + file_id
+const ContentBasePath = "/api/v1/files/content/"
+
+// defaultDownloadURLExpiry 下载链接默认有效期
+const defaultDownloadURLExpiry = 15 * time.Minute
+
+var (
+	ErrInvalidSignature = errors.New("invalid download signature")
+	ErrLinkExpired      = errors.New("download link expired")
+)
+
+// SignedURL 带签名的下载链接
+type SignedURL struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// DownloadParams 下载链接中的参数
+type DownloadParams struct {
+	FileID     string
+	UserID     int64
+	Expires    int64 // 过期时间，Unix 秒
+	Attachment bool  // true 时浏览器下载，false 时内嵌预览
+	Signature  string
+}
+
+// Download 通过签名链接打开的文件，Content 支持 Seek，用于 Range 请求
+type Download struct {
+	File       *File
+	Content    io.ReadSeeker
+	ETag       string
+	Attachment bool
+	ExpiresAt  time.Time
+	closer     io.Closer
+}
+
+// Disposition 返回带原始文件名的 Content-Disposition
+func (d *Download) Disposition() string {
+	disposition := "inline"
+	if d.Attachment {
+		disposition = "attachment"
+	}
+	return mime.FormatMediaType(disposition, map[string]string{"filename": d.File.OriginalName})
+}
+
+// Close 关闭存储连接
+func (d *Download) Close() error {
+	return d.closer.Close()
+}
+
+// DownloadUsecase 签发和校验下载链接。链接与用户、文件、过期时间和打开方式绑定，
+// 由 HMAC-SHA256 签名，不需要额外认证即可在浏览器中直接使用
+type DownloadUsecase struct {
+	repo    FileRepo
+	storage StorageRepo
+	key     []byte
+	expiry  time.Duration
+	baseURL string
+	log     *log.Helper
+}
+
+// NewDownloadUsecase 创建下载用例
+func NewDownloadUsecase(repo FileRepo, storage StorageRepo, config *conf.Storage, logger log.Logger) (*DownloadUsecase, error) {
+	helper := log.NewHelper(logger)
+
+	key := []byte(config.GetDownload().GetSigningKey())
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate download signing key: %w", err)
+		}
+		helper.Warn("download signing key is not configured, using a random key; signed URLs will not survive restarts")
+	}
+	expiry := config.GetDownload().GetUrlExpiry().AsDuration()
+	if expiry <= 0 {
+		expiry = defaultDownloadURLExpiry
+	}
+
+	return &DownloadUsecase{
+		repo:    repo,
+		storage: storage,
+		key:     key,
+		expiry:  expiry,
+		baseURL: strings.TrimSuffix(config.GetDownload().GetBaseUrl(), "/"),
+		log:     helper,
+	}, nil
+}
+
+// GetDownloadURL 为访问范围内的文件签发下载链接，文件需已通过扫描
+func (uc *DownloadUsecase) GetDownloadURL(ctx context.Context, fileID string, scope Scope, attachment bool) (*SignedURL, error) {
+	file, err := uc.repo.FindByID(ctx, fileID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if file.Status != FileStatusClean {
+		return nil, ErrFileNotClean
+	}
+	return uc.Sign(file, attachment), nil
+}
+
+// Sign 签发下载链接
+func (uc *DownloadUsecase) Sign(file *File, attachment bool) *SignedURL {
+	expiresAt := time.Now().Add(uc.expiry).Truncate(time.Second)
+	params := &DownloadParams{
+		FileID:     file.FileID,
+		UserID:     file.UserID,
+		Expires:    expiresAt.Unix(),
+		Attachment: attachment,
+	}
+
+	query := url.Values{}
+	query.Set("uid", strconv.FormatInt(params.UserID, 10))
+	query.Set("exp", strconv.FormatInt(params.Expires, 10))
+	if attachment {
+		query.Set("dl", "1")
+	}
+	query.Set("sig", uc.signature(params))
+
+	return &SignedURL{
+		URL:       uc.baseURL + ContentBasePath + url.PathEscape(file.FileID) + "?" + query.Encode(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Open 校验签名和有效期后打开文件，调用方负责关闭
+func (uc *DownloadUsecase) Open(ctx context.Context, params *DownloadParams) (*Download, error) {
+	expected := uc.signature(params)
+	if !hmac.Equal([]byte(expected), []byte(params.Signature)) {
+		return nil, ErrInvalidSignature
+	}
+	expiresAt := time.Unix(params.Expires, 0)
+	if time.Now().After(expiresAt) {
+		return nil, ErrLinkExpired
+	}
+
+	// 链接按文件上传者签发，组织成员取得的链接同样有效
+	file, err := uc.repo.FindByID(ctx, params.FileID, Scope{UserID: params.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if file.Status != FileStatusClean {
+		return nil, ErrFileNotClean
+	}
+
+	content := &rangeReader{ctx: ctx, storage: uc.storage, filename: file.Filename, size: file.Size}
+	return &Download{
+		File:       file,
+		Content:    content,
+		ETag:       fileETag(file),
+		Attachment: params.Attachment,
+		ExpiresAt:  expiresAt,
+		closer:     content,
+	}, nil
+}
+
+// signature 对文件ID、用户ID、过期时间和打开方式签名
+func (uc *DownloadUsecase) signature(params *DownloadParams) string {
+	mac := hmac.New(sha256.New, uc.key)
+	fmt.Fprintf(mac, "%s\n%d\n%d\n%t", params.FileID, params.UserID, params.Expires, params.Attachment)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// exportSignature 对导出任务ID、用户ID和过期时间签名，与文件链接的签名内容格式不同，不能互相冒用
+func (uc *DownloadUsecase) exportSignature(params *ExportDownloadParams) string {
+	mac := hmac.New(sha256.New, uc.key)
+	fmt.Fprintf(mac, "export\n%s\n%d\n%d", params.ExportID, params.UserID, params.Expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// fileETag 内容寻址的文件使用内容哈希，内容相同则 ETag 相同
+func fileETag(file *File) string {
+	if file.ContentHash != "" {
+		return `"` + file.ContentHash + `"`
+	}
+	return fmt.Sprintf(`"%s-%d"`, file.FileID, file.Size)
+}
+
+// rangeReader 按需从存储的指定偏移量开始读取，Seek 不产生 I/O，
+// 使 http.ServeContent 可以处理 Range 请求而无需读取整个文件
+type rangeReader struct {
+	ctx      context.Context
+	storage  StorageRepo
+	filename string
+	size     int64
+	offset   int64
+	current  io.ReadCloser
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.current == nil {
+		rc, err := r.storage.DownloadRange(r.ctx, r.filename, r.offset, r.size-r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.current = rc
+	}
+
+	n, err := r.current.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if abs != r.offset {
+		r.Close()
+		r.offset = abs
+	}
+	return abs, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
@@ -0,0 +1,588 @@
+package biz
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// 导出任务状态
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired" // 压缩包已过保留期被删除
+)
+
+// ExportContentBasePath 导出压缩包签名下载链接的路径前缀，完整路径为 ExportContentBasePath + export_id
+const ExportContentBasePath = "/api/v1/exports/content/"
+
+const (
+	defaultExportMaxSyncSize  = 100 << 20
+	defaultExportRetention    = 7 * 24 * time.Hour
+	defaultExportPollInterval = 10 * time.Second
+	defaultExportTimeout      = 2 * time.Hour
+	// exportKeyPrefix 导出压缩包的存储对象名前缀，与内容寻址的对象区分，不参与孤立对象检查
+	exportKeyPrefix = "export_"
+	// exportBatchSize 每轮处理的最大导出任务数
+	exportBatchSize = 5
+	// manifestName 压缩包中描述导出内容的清单文件
+	manifestName = "manifest.json"
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportTooLarge = errors.New("files exceed the maximum size for direct export")
+)
+
+// Export 用户数据导出任务
+type Export struct {
+	ExportID    string
+	UserID      int64
+	Status      string
+	StorageKey  string
+	Size        int64
+	FileCount   int32
+	RecordCount int32
+	Error       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt time.Time // 零值表示未结束
+	ExpiresAt   time.Time // 压缩包的删除时间，仅 completed 有值
+}
+
+// ExportRepo 导出任务仓库
+type ExportRepo interface {
+	Create(ctx context.Context, export *Export) error
+	// Find 返回用户的导出任务，不存在时返回 ErrExportNotFound
+	Find(ctx context.Context, exportID string, userID int64) (*Export, error)
+	// FindActive 返回用户等待中或执行中的导出任务，不存在时返回 ErrExportNotFound
+	FindActive(ctx context.Context, userID int64) (*Export, error)
+	// ListByStatus 按创建时间升序返回指定状态且在 updatedBefore 之前更新的导出任务
+	ListByStatus(ctx context.Context, status string, updatedBefore time.Time, limit int) ([]*Export, error)
+	// ListExpired 返回压缩包在 before 之前到期的已完成任务
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*Export, error)
+	// Update 仅当任务状态为 from 时更新状态和结果，返回是否更新
+	Update(ctx context.Context, export *Export, from string) (bool, error)
+}
+
+// ExportRecord 其他服务中与用户相关的一条记录
+type ExportRecord struct {
+	Name       string // 在该服务目录中的文件名
+	Content    []byte
+	ModifiedAt time.Time
+}
+
+// ExportSource 保存用户派生数据的服务，如解析结果和分析报告
+type ExportSource interface {
+	// Name 记录在压缩包中的目录名
+	Name() string
+	// Export 依次返回用户的所有记录，fileIDs 为用户的所有文件，fn 返回错误时停止
+	Export(ctx context.Context, userID int64, fileIDs []string, fn func(record *ExportRecord) error) error
+}
+
+// ExportSources 导出时依次读取的派生数据来源
+type ExportSources []ExportSource
+
+// ExportPlan 一次导出包含的文件版本
+type ExportPlan struct {
+	UserID   int64
+	Size     int64 // 已通过扫描的文件版本的总大小
+	versions []*FileVersion
+}
+
+// ExportStats 写入压缩包的内容统计
+type ExportStats struct {
+	Files   int32 // 文件版本数
+	Records int32 // 派生数据记录数
+	Size    int64 // 压缩包大小
+}
+
+// ExportDownloadParams 导出压缩包下载链接中的参数
+type ExportDownloadParams struct {
+	ExportID  string
+	UserID    int64
+	Expires   int64 // 过期时间，Unix 秒
+	Signature string
+}
+
+// exportManifest 压缩包中的清单，列出每个文件版本及未导出的原因
+type exportManifest struct {
+	UserID      int64            `json:"user_id"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Files       []*exportedFile  `json:"files"`
+	Records     map[string]int32 `json:"records"` // 各目录中的记录数
+}
+
+type exportedFile struct {
+	FileID       string    `json:"file_id"`
+	Version      int32     `json:"version"`
+	Name         string    `json:"name"`
+	Path         string    `json:"path,omitempty"` // 在压缩包中的路径，未导出时为空
+	Size         int64     `json:"size"`
+	MimeType     string    `json:"mime_type"`
+	ContentHash  string    `json:"content_hash,omitempty"`
+	RestoredFrom int32     `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Skipped      string    `json:"skipped,omitempty"` // 未导出的原因
+}
+
+// ExportUsecase 将用户的所有文件版本、解析结果和分析报告打包为 ZIP 压缩包。
+// 文件较少时直接流式返回；否则由后台任务生成压缩包写入存储，通过签名链接下载，保留期后删除
+type ExportUsecase struct {
+	files        *FileUsecase
+	repo         ExportRepo
+	sources      ExportSources
+	downloads    *DownloadUsecase
+	maxSyncSize  int64
+	retention    time.Duration
+	pollInterval time.Duration
+	timeout      time.Duration
+	log          *log.Helper
+}
+
+// NewExportUsecase 创建用户数据导出用例
+func NewExportUsecase(files *FileUsecase, repo ExportRepo, sources ExportSources, downloads *DownloadUsecase, config *conf.Storage, logger log.Logger) *ExportUsecase {
+	export := config.GetExport()
+	uc := &ExportUsecase{
+		files:        files,
+		repo:         repo,
+		sources:      sources,
+		downloads:    downloads,
+		maxSyncSize:  export.GetMaxSyncSize(),
+		retention:    export.GetRetention().AsDuration(),
+		pollInterval: export.GetPollInterval().AsDuration(),
+		timeout:      export.GetTimeout().AsDuration(),
+		log:          log.NewHelper(logger),
+	}
+	if uc.maxSyncSize <= 0 {
+		uc.maxSyncSize = defaultExportMaxSyncSize
+	}
+	if uc.retention <= 0 {
+		uc.retention = defaultExportRetention
+	}
+	if uc.pollInterval <= 0 {
+		uc.pollInterval = defaultExportPollInterval
+	}
+	if uc.timeout <= 0 {
+		uc.timeout = defaultExportTimeout
+	}
+	return uc
+}
+
+// PollInterval 检查待处理导出任务的间隔
+func (uc *ExportUsecase) PollInterval() time.Duration {
+	return uc.pollInterval
+}
+
+// PlanExport 列出用户的所有文件版本，包括回收站中的文件。文件总大小超过直接导出的上限时返回 ErrExportTooLarge
+func (uc *ExportUsecase) PlanExport(ctx context.Context, userID int64) (*ExportPlan, error) {
+	plan, err := uc.plan(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Size > uc.maxSyncSize {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", ErrExportTooLarge, plan.Size, uc.maxSyncSize)
+	}
+	return plan, nil
+}
+
+func (uc *ExportUsecase) plan(ctx context.Context, userID int64) (*ExportPlan, error) {
+	versions, err := uc.files.repo.ListUserVersions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ExportPlan{UserID: userID, versions: versions}
+	for _, version := range versions {
+		if version.Status == FileStatusClean {
+			plan.Size += version.Size
+		}
+	}
+	return plan, nil
+}
+
+// WriteExport 将导出内容以 ZIP 格式写入 w。先写入派生数据，来源服务不可用时尽早失败；
+// 未通过扫描的文件版本不导出，原因记录在清单中
+func (uc *ExportUsecase) WriteExport(ctx context.Context, plan *ExportPlan, w io.Writer) (*ExportStats, error) {
+	counter := &countingWriter{w: w}
+	zw := zip.NewWriter(counter)
+	stats := &ExportStats{}
+	manifest := &exportManifest{
+		UserID:      plan.UserID,
+		GeneratedAt: time.Now().UTC(),
+		Files:       make([]*exportedFile, 0, len(plan.versions)),
+		Records:     make(map[string]int32),
+	}
+
+	var fileIDs []string
+	seen := make(map[string]struct{})
+	for _, version := range plan.versions {
+		if _, ok := seen[version.FileID]; !ok {
+			seen[version.FileID] = struct{}{}
+			fileIDs = append(fileIDs, version.FileID)
+		}
+	}
+
+	for _, source := range uc.sources {
+		err := source.Export(ctx, plan.UserID, fileIDs, func(record *ExportRecord) error {
+			name, ok := archiveEntryName(record.Name)
+			if !ok || strings.Contains(name, "/") {
+				return fmt.Errorf("invalid record name %q", record.Name)
+			}
+			fw, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     source.Name() + "/" + name,
+				Method:   zip.Deflate,
+				Modified: record.ModifiedAt,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := fw.Write(record.Content); err != nil {
+				return err
+			}
+			manifest.Records[source.Name()]++
+			stats.Records++
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", source.Name(), err)
+		}
+	}
+
+	for _, version := range plan.versions {
+		entry := &exportedFile{
+			FileID:       version.FileID,
+			Version:      version.Version,
+			Name:         version.OriginalName,
+			Size:         version.Size,
+			MimeType:     version.MimeType,
+			ContentHash:  version.ContentHash,
+			RestoredFrom: version.RestoredFrom,
+			CreatedAt:    version.CreatedAt,
+		}
+		manifest.Files = append(manifest.Files, entry)
+		switch version.Status {
+		case FileStatusClean:
+		case FileStatusInfected:
+			entry.Skipped = "file failed malware scanning"
+			continue
+		default:
+			entry.Skipped = "malware scanning has not finished"
+			continue
+		}
+
+		entry.Path = fmt.Sprintf("files/%s/v%d/%s", version.FileID, version.Version, exportFileName(version.OriginalName))
+		if err := uc.writeVersion(ctx, zw, entry.Path, version); err != nil {
+			return nil, fmt.Errorf("failed to export file %s version %d: %w", version.FileID, version.Version, err)
+		}
+		stats.Files++
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: manifestName, Method: zip.Deflate, Modified: manifest.GeneratedAt})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	stats.Size = counter.n
+	return stats, nil
+}
+
+// writeVersion 从存储流式读取文件版本的内容写入压缩包
+func (uc *ExportUsecase) writeVersion(ctx context.Context, zw *zip.Writer, name string, version *FileVersion) error {
+	rc, err := uc.files.storage.Download(ctx, version.Filename)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: version.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+// exportFileName 返回用户上传时文件名的最后一段，用作压缩包中的文件名
+func exportFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(strings.ToValidUTF8(name, "_"), "\\", "/"))
+	switch name {
+	case "", ".", "..", "/":
+		return "file"
+	}
+	return name
+}
+
+// countingWriter 统计写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// CreateExport 创建后台导出任务，用户已有等待中或执行中的任务时直接返回该任务
+func (uc *ExportUsecase) CreateExport(ctx context.Context, userID int64) (*Export, error) {
+	active, err := uc.repo.FindActive(ctx, userID)
+	if err == nil {
+		return active, nil
+	}
+	if !errors.Is(err, ErrExportNotFound) {
+		return nil, err
+	}
+
+	export := &Export{
+		ExportID: uuid.New().String(),
+		UserID:   userID,
+		Status:   ExportPending,
+	}
+	if err := uc.repo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	uc.log.WithContext(ctx).Infof("export created: export_id=%s, user_id=%d", export.ExportID, userID)
+	return export, nil
+}
+
+// GetExport 获取用户的导出任务，已完成时附带签名下载链接
+func (uc *ExportUsecase) GetExport(ctx context.Context, exportID string, userID int64) (*Export, *SignedURL, error) {
+	export, err := uc.repo.Find(ctx, exportID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != ExportCompleted {
+		return export, nil, nil
+	}
+	return export, uc.sign(export), nil
+}
+
+// ProcessPending 依次执行等待中的导出任务。执行前通过条件更新占有任务，多个实例同时执行时每个任务只执行一次；
+// 执行超时的任务（如实例在执行中退出）标记为失败，用户可以重新创建
+func (uc *ExportUsecase) ProcessPending(ctx context.Context) (int, error) {
+	stale, err := uc.repo.ListByStatus(ctx, ExportRunning, time.Now().Add(-uc.timeout), exportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, export := range stale {
+		export.Status = ExportFailed
+		export.Error = "export timed out"
+		export.CompletedAt = time.Now()
+		if _, err := uc.repo.Update(ctx, export, ExportRunning); err != nil {
+			uc.log.WithContext(ctx).Warnf("failed to mark stale export %s as failed: %v", export.ExportID, err)
+		}
+	}
+
+	pending, err := uc.repo.ListByStatus(ctx, ExportPending, time.Now(), exportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	processed := 0
+	for _, export := range pending {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		export.Status = ExportRunning
+		claimed, err := uc.repo.Update(ctx, export, ExportPending)
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			continue
+		}
+		uc.run(ctx, export)
+		processed++
+	}
+	return processed, nil
+}
+
+// run 生成压缩包并写入存储，内容经管道直接上传，不在本地暂存
+func (uc *ExportUsecase) run(ctx context.Context, export *Export) {
+	ctx, cancel := context.WithTimeout(ctx, uc.timeout)
+	defer cancel()
+
+	stats, err := uc.build(ctx, export)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("export failed: export_id=%s, err=%v", export.ExportID, err)
+		export.Status = ExportFailed
+		export.Error = err.Error()
+	} else {
+		export.Status = ExportCompleted
+		export.Size = stats.Size
+		export.FileCount = stats.Files
+		export.RecordCount = stats.Records
+		export.ExpiresAt = time.Now().Add(uc.retention)
+		uc.log.WithContext(ctx).Infof("export completed: export_id=%s, files=%d, records=%d, size=%d",
+			export.ExportID, stats.Files, stats.Records, stats.Size)
+	}
+	export.CompletedAt = time.Now()
+
+	// 任务可能已因超时被标记为失败，此时删除刚生成的压缩包
+	updated, err := uc.repo.Update(context.WithoutCancel(ctx), export, ExportRunning)
+	if err != nil || !updated {
+		if err != nil {
+			uc.log.WithContext(ctx).Errorf("failed to save export %s: %v", export.ExportID, err)
+		}
+		if export.Status == ExportCompleted {
+			uc.removeArchive(context.WithoutCancel(ctx), export)
+		}
+	}
+}
+
+// build 生成压缩包，失败时删除已写入的部分
+func (uc *ExportUsecase) build(ctx context.Context, export *Export) (*ExportStats, error) {
+	plan, err := uc.plan(ctx, export.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	export.StorageKey = exportKeyPrefix + export.ExportID + ".zip"
+	pr, pw := io.Pipe()
+	type result struct {
+		stats *ExportStats
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		stats, err := uc.WriteExport(ctx, plan, pw)
+		pw.CloseWithError(err)
+		done <- result{stats, err}
+	}()
+
+	_, uploadErr := uc.files.storage.Upload(ctx, export.StorageKey, pr)
+	// 上传提前失败时结束写入
+	pr.CloseWithError(uploadErr)
+	res := <-done
+
+	err = res.err
+	if err == nil {
+		err = uploadErr
+	}
+	if err != nil {
+		uc.removeArchive(context.WithoutCancel(ctx), export)
+		return nil, err
+	}
+	return res.stats, nil
+}
+
+// RemoveExpired 删除过了保留期的压缩包，任务状态改为 expired
+func (uc *ExportUsecase) RemoveExpired(ctx context.Context) (int, error) {
+	exports, err := uc.repo.ListExpired(ctx, time.Now(), exportBatchSize*20)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, export := range exports {
+		export.Status = ExportExpired
+		updated, err := uc.repo.Update(ctx, export, ExportCompleted)
+		if err != nil {
+			return removed, err
+		}
+		if !updated {
+			continue
+		}
+		uc.removeArchive(ctx, export)
+		removed++
+	}
+	return removed, nil
+}
+
+func (uc *ExportUsecase) removeArchive(ctx context.Context, export *Export) {
+	if export.StorageKey == "" {
+		return
+	}
+	if err := uc.files.storage.Delete(ctx, export.StorageKey); err != nil {
+		uc.log.WithContext(ctx).Warnf("failed to remove export archive %s: %v", export.StorageKey, err)
+	}
+}
+
+// sign 签发压缩包下载链接，有效期不超过压缩包的保留期
+func (uc *ExportUsecase) sign(export *Export) *SignedURL {
+	expiresAt := time.Now().Add(uc.downloads.expiry).Truncate(time.Second)
+	if export.ExpiresAt.Before(expiresAt) {
+		expiresAt = export.ExpiresAt.Truncate(time.Second)
+	}
+	params := &ExportDownloadParams{
+		ExportID: export.ExportID,
+		UserID:   export.UserID,
+		Expires:  expiresAt.Unix(),
+	}
+
+	query := url.Values{}
+	query.Set("uid", strconv.FormatInt(params.UserID, 10))
+	query.Set("exp", strconv.FormatInt(params.Expires, 10))
+	query.Set("sig", uc.downloads.exportSignature(params))
+
+	return &SignedURL{
+		URL:       uc.downloads.baseURL + ExportContentBasePath + url.PathEscape(export.ExportID) + "?" + query.Encode(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Open 校验签名和有效期后打开压缩包，调用方负责关闭
+func (uc *ExportUsecase) Open(ctx context.Context, params *ExportDownloadParams) (*Download, error) {
+	expected := uc.downloads.exportSignature(params)
+	if !hmac.Equal([]byte(expected), []byte(params.Signature)) {
+		return nil, ErrInvalidSignature
+	}
+	expiresAt := time.Unix(params.Expires, 0)
+	if time.Now().After(expiresAt) {
+		return nil, ErrLinkExpired
+	}
+
+	export, err := uc.repo.Find(ctx, params.ExportID, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != ExportCompleted {
+		return nil, ErrExportNotFound
+	}
+
+	file := &File{
+		FileID:       export.ExportID,
+		Filename:     export.StorageKey,
+		OriginalName: "export-" + export.CompletedAt.Format("20060102") + ".zip",
+		Size:         export.Size,
+		MimeType:     "application/zip",
+		UserID:       export.UserID,
+		CreatedAt:    export.CreatedAt,
+		UpdatedAt:    export.CompletedAt,
+	}
+	content := &rangeReader{ctx: ctx, storage: uc.files.storage, filename: file.Filename, size: file.Size}
+	return &Download{
+		File:       file,
+		Content:    content,
+		ETag:       `"` + export.ExportID + `"`,
+		Attachment: true,
+		ExpiresAt:  expiresAt,
+		closer:     content,
+	}, nil
+}
@@ -1,12 +1,16 @@
 package biz
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"mime"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,6 +20,8 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/filecheck"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
@@ -24,6 +30,8 @@ var (
 	ErrInvalidFileType  = errors.New("invalid file type")
 	ErrFileSizeExceeded = errors.New("file size exceeded")
 	ErrPermissionDenied = errors.New("permission denied")
+	ErrEmptyFile        = errors.New("empty file")
+	ErrInvalidContent   = errors.New("invalid file content")
 )
 
 // File 文件业务模型
@@ -37,8 +45,14 @@ type File struct {
 	URL          string
 	Status       string
 	UserID       int64
+	OrgID        int64  // 上传时用户所在的组织，组织成员共享该文件；0表示只属于上传者
+	ContentHash  string // 内容的 SHA-256，为空表示内容寻址存储之前上传的文件
+	ScanResult   string // 扫描命中的病毒特征名
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	DeletedAt    time.Time // 移入回收站的时间，零值表示不在回收站中
+	BatchID      string    // 所属的压缩包批次
+	Version      int32     // 当前版本号，内容字段与该版本相同
 }
 
 // ListFilesRequest 文件列表请求
@@ -47,110 +61,378 @@ type ListFilesRequest struct {
 	PerPage       int
 	Type          string
 	Status        string
-	UserID        int64
+	Scope         Scope
 	CreatedAfter  string
 	CreatedBefore string
 }
 
+// Scope 用户可以访问的文件：自己上传的文件，以及所在组织的成员上传到组织的文件。OrgID 为0表示不属于任何组织
+type Scope struct {
+	UserID  int64
+	OrgID   int64
+	OrgRole string // 在组织中的角色，决定能否修改其他成员的文件
+}
+
+// ScopeFromContext 当前请求用户的访问范围，组织和组织角色取自访问令牌
+func ScopeFromContext(ctx context.Context) (Scope, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return Scope{}, err
+	}
+	return Scope{UserID: userID, OrgID: auth.OrgID(ctx), OrgRole: auth.OrgRole(ctx)}, nil
+}
+
+// managesOrg 是否为所在组织的所有者或管理员
+func (s Scope) managesOrg() bool {
+	return s.OrgID > 0 && (s.OrgRole == auth.OrgRoleOwner || s.OrgRole == auth.OrgRoleAdmin)
+}
+
+// CanModify 能否删除、恢复、永久删除文件或为其添加版本：文件上传者，以及文件所属组织的所有者和管理员。
+// 组织的其他成员只能查看
+func (s Scope) CanModify(file *File) bool {
+	return file.UserID == s.UserID || s.managesOrg() && file.OrgID == s.OrgID
+}
+
+// Writable 修改文件时使用的访问范围，不是组织所有者或管理员时只包含自己上传的文件
+func (s Scope) Writable() Scope {
+	if s.managesOrg() {
+		return s
+	}
+	return Scope{UserID: s.UserID}
+}
+
+// UploadInput 流式上传参数，Content 直接写入存储，不在内存中整体缓存
+type UploadInput struct {
+	FileID      string // 上传新版本的目标文件，仅 UploadVersion 使用
+	Filename    string
+	Title       string
+	Description string
+	UserID      int64
+	Size        int64 // 客户端声明的文件大小，未知时为0
+	Content     io.Reader
+	BatchID     string // 所属的压缩包批次，单独上传的文件为空
+}
+
 // FileRepo 文件仓库接口
 type FileRepo interface {
 	Save(ctx context.Context, file *File) (*File, error)
 	Update(ctx context.Context, file *File) (*File, error)
-	FindByID(ctx context.Context, fileID string, userID int64) (*File, error)
+	// FindByID 返回访问范围内的文件，不存在时返回 ErrFileNotFound
+	FindByID(ctx context.Context, fileID string, scope Scope) (*File, error)
 	List(ctx context.Context, req *ListFilesRequest) ([]*File, int64, error)
-	Delete(ctx context.Context, fileID string, userID int64) error
+	// Delete 将访问范围内的文件移入回收站，内容和配额在永久删除时才释放
+	Delete(ctx context.Context, fileID string, scope Scope) error
+	// FindByHash 返回用户最近上传的指定内容的文件，不存在时返回 ErrFileNotFound
+	FindByHash(ctx context.Context, userID int64, hash string) (*File, error)
+	// UpdateStatus 更新文件中内容为 hash 且状态为 from 的当前版本和历史版本的状态，都不存在时返回 ErrFileNotFound
+	UpdateStatus(ctx context.Context, fileID, hash, from, to, scanResult string) error
+	// UpdateStatusByHash 更新所有指定内容的文件和版本的状态
+	UpdateStatusByHash(ctx context.Context, hash, status, scanResult string) error
+	// ListByStatus 按更新时间升序返回指定状态且在 updatedBefore 之前更新的文件
+	ListByStatus(ctx context.Context, status string, updatedBefore time.Time, limit int) ([]*File, error)
+	// FindTrashed 返回访问范围内回收站中的文件，不存在时返回 ErrFileNotFound
+	FindTrashed(ctx context.Context, fileID string, scope Scope) (*File, error)
+	// ListTrashed 按移入回收站的时间倒序分页返回访问范围内回收站中的文件
+	ListTrashed(ctx context.Context, scope Scope, page, perPage int) ([]*File, int64, error)
+	// ListTrashedBefore 返回在 before 之前移入回收站的文件
+	ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*File, error)
+	// Restore 将文件移出回收站，文件不在回收站中时返回 ErrFileNotFound
+	Restore(ctx context.Context, fileID string, scope Scope) error
+	// Purge 永久删除在 trashedBefore 之前移入回收站的文件及其所有版本，否则返回 ErrFileNotFound。
+	// 并发调用时只有一个成功，成功的调用方负责释放内容和配额
+	Purge(ctx context.Context, fileID string, trashedBefore time.Time) error
+	// AddVersion 为访问范围内未删除的文件添加一个版本并设为当前版本，版本号由仓库分配，文件不存在时返回 ErrFileNotFound
+	AddVersion(ctx context.Context, fileID string, scope Scope, version *FileVersion) (*File, error)
+	// ListVersions 按版本号倒序返回文件的所有版本
+	ListVersions(ctx context.Context, fileID string) ([]*FileVersion, error)
+	// FindVersion 返回文件的指定版本，不存在时返回 ErrVersionNotFound
+	FindVersion(ctx context.Context, fileID string, version int32) (*FileVersion, error)
+	// ListUserVersions 按文件ID和版本号顺序返回用户所有文件的所有版本，包括回收站中的文件
+	ListUserVersions(ctx context.Context, userID int64) ([]*FileVersion, error)
 }
 
 // StorageRepo 存储仓库接口
 type StorageRepo interface {
 	Upload(ctx context.Context, filename string, content io.Reader) (string, error)
 	Download(ctx context.Context, filename string) (io.ReadCloser, error)
+	// DownloadRange 读取从 offset 开始的 length 字节，length 小于0时读到文件末尾
+	DownloadRange(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error)
 	Delete(ctx context.Context, filename string) error
 	GetURL(filename string) string
+	// Exists 对象是否存在
+	Exists(ctx context.Context, filename string) (bool, error)
+	// List 按名称顺序遍历指定前缀的对象，fn 返回错误时停止遍历
+	List(ctx context.Context, prefix string, fn func(obj *StorageObject) error) error
+}
+
+// OrganizationRepo 读取组织设置，由 user-service 提供
+type OrganizationRepo interface {
+	// AllowedFileTypes 组织允许上传的文件扩展名（小写，不含点），为空表示不限制
+	AllowedFileTypes(ctx context.Context, orgID int64) ([]string, error)
+}
+
+// StorageObject 存储中的对象
+type StorageObject struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
 }
 
 // FileUsecase 文件用例
 type FileUsecase struct {
-	repo    FileRepo
-	storage StorageRepo
-	config  *conf.Storage
-	log     *log.Helper
+	repo      FileRepo
+	blobs     BlobRepo
+	storage   StorageRepo
+	scans     *ScanUsecase
+	downloads *DownloadUsecase
+	quotas    *QuotaUsecase
+	orgs      OrganizationRepo
+	config    *conf.Storage
+	log       *log.Helper
 }
 
 // NewFileUsecase 创建文件用例
-func NewFileUsecase(repo FileRepo, storage StorageRepo, config *conf.Storage, logger log.Logger) *FileUsecase {
+func NewFileUsecase(repo FileRepo, blobs BlobRepo, storage StorageRepo, scans *ScanUsecase, downloads *DownloadUsecase, quotas *QuotaUsecase, orgs OrganizationRepo, config *conf.Storage, logger log.Logger) *FileUsecase {
 	return &FileUsecase{
-		repo:    repo,
-		storage: storage,
-		config:  config,
-		log:     log.NewHelper(logger),
+		repo:      repo,
+		blobs:     blobs,
+		storage:   storage,
+		scans:     scans,
+		downloads: downloads,
+		quotas:    quotas,
+		orgs:      orgs,
+		config:    config,
+		log:       log.NewHelper(logger),
 	}
 }
 
+// MaxFileSize 单个文件大小上限，小于等于0表示不限制
+func (uc *FileUsecase) MaxFileSize() int64 {
+	return uc.config.MaxFileSize
+}
+
 // Upload 上传文件
 func (uc *FileUsecase) Upload(ctx context.Context, req *v1.UploadRequest) (*v1.UploadReply, error) {
-	// 验证文件大小
-	if int64(len(req.File)) > uc.config.MaxFileSize {
-		return nil, ErrFileSizeExceeded
-	}
-
-	// 检测文件类型
-	mimeType := mime.TypeByExtension(filepath.Ext(req.Filename))
-	if mimeType == "" {
-		mimeType = "application/octet-stream"
-	}
-
-	// 验证文件类型
-	if !uc.isAllowedType(req.Filename) {
-		return nil, ErrInvalidFileType
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return uc.UploadStream(ctx, &UploadInput{
+		Filename:    req.Filename,
+		Title:       req.Title,
+		Description: req.Description,
+		UserID:      userID,
+		Size:        int64(len(req.File)),
+		Content:     bytes.NewReader(req.File),
+	})
+}
 
-	// 生成文件ID和存储文件名
-	fileID := uuid.New().String()
-	ext := filepath.Ext(req.Filename)
-	storageFilename := fmt.Sprintf("%s%s", fileID, ext)
-
-	// 上传到存储
-	reader := strings.NewReader(string(req.File))
-	storageURL, err := uc.storage.Upload(ctx, storageFilename, reader)
+// UploadStream 流式上传文件。内容边读边写入本地暂存文件并计算 SHA-256，超过 max_file_size 时立即中止；
+// 暂存文件按内容识别类型并校验结构，通过后才写入内容寻址存储，相同内容只保存一份。
+// 文件归属上传者当前所在的组织，组织成员都可以访问
+func (uc *FileUsecase) UploadStream(ctx context.Context, in *UploadInput) (*v1.UploadReply, error) {
+	stored, err := uc.storeContent(ctx, in, true)
 	if err != nil {
-		uc.log.WithContext(ctx).Errorf("failed to upload file: %v", err)
-		return nil, fmt.Errorf("failed to upload file: %w", err)
+		return nil, err
+	}
+	hash := stored.hash
+	storageFilename := stored.key
+
+	// 检查用户是否已上传过相同内容的文件
+	var duplicateOf string
+	if existing, err := uc.repo.FindByHash(ctx, in.UserID, hash); err == nil {
+		duplicateOf = existing.FileID
+	} else if !errors.Is(err, ErrFileNotFound) {
+		uc.log.WithContext(ctx).Warnf("failed to check duplicate file: %v", err)
 	}
 
 	// 保存文件信息
+	fileID := uuid.New().String()
 	file := &File{
 		FileID:       fileID,
 		Filename:     storageFilename,
-		OriginalName: req.Filename,
-		Size:         int64(len(req.File)),
-		MimeType:     mimeType,
-		URL:          storageURL,
-		Status:       "uploaded",
-		UserID:       req.UserId,
+		OriginalName: in.Filename,
+		Size:         stored.size,
+		MimeType:     stored.mimeType,
+		URL:          uc.storage.GetURL(storageFilename),
+		Status:       FileStatusScanning,
+		UserID:       in.UserID,
+		OrgID:        auth.OrgID(ctx),
+		ContentHash:  hash,
+		BatchID:      in.BatchID,
 	}
 
 	savedFile, err := uc.repo.Save(ctx, file)
 	if err != nil {
 		uc.log.WithContext(ctx).Errorf("failed to save file info: %v", err)
-		// 清理已上传的文件
-		uc.storage.Delete(ctx, storageFilename)
+		// 释放对内容的引用和预占的配额
+		uc.releaseContent(ctx, file.ContentHash, file.Filename)
+		uc.quotas.Release(ctx, file.UserID, file.Size)
 		return nil, fmt.Errorf("failed to save file info: %w", err)
 	}
 
+	// 后台扫描，扫描通过前文件不能下载或解析
+	uc.scans.Submit(savedFile)
+
+	if duplicateOf != "" {
+		uc.log.WithContext(ctx).Infof("duplicate upload: file_id=%s, duplicate_of=%s, hash=%s", fileID, duplicateOf, hash)
+	}
 	return &v1.UploadReply{
-		File: uc.toProtoFileInfo(savedFile),
+		File:        uc.toProtoFileInfo(savedFile),
+		Duplicate:   duplicateOf != "",
+		DuplicateOf: duplicateOf,
 	}, nil
 }
 
-// ListFiles 获取文件列表
+// storedContent 已写入内容寻址存储的上传内容
+type storedContent struct {
+	key      string
+	hash     string
+	mimeType string
+	size     int64
+}
+
+// storeContent 校验上传内容、预占配额并写入内容寻址存储。newFile 为 false 时按已有文件的新版本预占配额，
+// 只计入大小。成功后调用方持有内容的一个引用和预占的配额，后续失败时需要释放
+func (uc *FileUsecase) storeContent(ctx context.Context, in *UploadInput, newFile bool) (*storedContent, error) {
+	// 客户端声明的大小已超限时直接拒绝
+	if uc.config.MaxFileSize > 0 && in.Size > uc.config.MaxFileSize {
+		return nil, ErrFileSizeExceeded
+	}
+
+	// 验证文件类型
+	if err := uc.checkFileType(ctx, in.Filename); err != nil {
+		return nil, err
+	}
+
+	spooled, err := uc.spool(in.Content, uc.config.MaxFileSize)
+	if err != nil {
+		if !errors.Is(err, ErrFileSizeExceeded) && !errors.Is(err, ErrEmptyFile) {
+			uc.log.WithContext(ctx).Errorf("failed to receive file: %v", err)
+		}
+		return nil, err
+	}
+	defer spooled.Close()
+
+	// 按内容识别文件类型并校验结构，扩展名与内容不符时拒绝
+	result, err := filecheck.Check(spooled.file, spooled.size, filepath.Ext(in.Filename), uc.checkLimits())
+	if err != nil {
+		uc.log.WithContext(ctx).Warnf("file content rejected: filename=%s, err=%v", in.Filename, err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+
+	// 预占用户存储配额，后续步骤失败时释放
+	reserve, release := uc.quotas.Reserve, uc.quotas.Release
+	if !newFile {
+		reserve, release = uc.quotas.ReserveVersion, uc.quotas.ReleaseVersion
+	}
+	if err := reserve(ctx, in.UserID, spooled.size); err != nil {
+		if !errors.Is(err, ErrQuotaExceeded) {
+			uc.log.WithContext(ctx).Errorf("failed to reserve storage quota: %v", err)
+		}
+		return nil, err
+	}
+
+	// 归并到内容寻址存储
+	stored := &storedContent{
+		key:      blobKey(spooled.hash),
+		hash:     spooled.hash,
+		mimeType: contentMimeType(in.Filename, result),
+		size:     spooled.size,
+	}
+	existed, err := uc.blobs.Acquire(ctx, &Blob{Hash: stored.hash, StorageKey: stored.key, Size: stored.size}, func(ctx context.Context) error {
+		if _, err := spooled.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := uc.storage.Upload(ctx, stored.key, spooled.file)
+		return err
+	})
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to store blob %s: %v", stored.hash, err)
+		release(ctx, in.UserID, stored.size)
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	if existed {
+		uc.log.WithContext(ctx).Infof("blob %s already stored, skipped writing to storage", stored.hash)
+	}
+	return stored, nil
+}
+
+// spooledFile 暂存在本地的上传内容
+type spooledFile struct {
+	file *os.File
+	size int64
+	hash string // 内容的 SHA-256，十六进制
+}
+
+func (f *spooledFile) Close() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+// spool 将上传内容写入本地暂存文件，同时统计大小、计算哈希并检查上限，limit 小于等于0表示不限制
+func (uc *FileUsecase) spool(content io.Reader, limit int64) (*spooledFile, error) {
+	file, err := os.CreateTemp(uc.config.TempDir, "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	spooled := &spooledFile{file: file}
+
+	hasher := sha256.New()
+	reader := &sizeLimitReader{r: io.TeeReader(content, hasher), limit: limit}
+	if _, err := io.Copy(file, reader); err != nil {
+		spooled.Close()
+		if errors.Is(err, ErrFileSizeExceeded) || reader.exceeded() {
+			return nil, ErrFileSizeExceeded
+		}
+		return nil, fmt.Errorf("failed to receive file: %w", err)
+	}
+	if reader.n == 0 {
+		spooled.Close()
+		return nil, ErrEmptyFile
+	}
+
+	spooled.size = reader.n
+	spooled.hash = hex.EncodeToString(hasher.Sum(nil))
+	return spooled, nil
+}
+
+// checkLimits zip 类文件的解压限制
+func (uc *FileUsecase) checkLimits() filecheck.Limits {
+	validation := uc.config.GetValidation()
+	return filecheck.Limits{
+		MaxUncompressedSize: validation.GetMaxUncompressedSize(),
+		MaxCompressionRatio: int64(validation.GetMaxCompressionRatio()),
+		MaxZipEntries:       int(validation.GetMaxZipEntries()),
+	}
+}
+
+// contentMimeType 优先使用按内容识别的类型，文本文件保留扩展名对应的具体类型（如 text/markdown）
+func contentMimeType(filename string, result *filecheck.Result) string {
+	byExt := mime.TypeByExtension(filepath.Ext(filename))
+	switch {
+	case result.Kind == filecheck.KindText && strings.HasPrefix(byExt, "text/"):
+		return byExt
+	case result.MimeType != "":
+		return result.MimeType
+	case byExt != "":
+		return byExt
+	}
+	return "application/octet-stream"
+}
+
+// ListFiles 获取文件列表，包括用户所在组织的文件
 func (uc *FileUsecase) ListFiles(ctx context.Context, req *v1.ListFilesRequest) (*v1.ListFilesReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	bizReq := &ListFilesRequest{
 		Page:          int(req.Page),
 		PerPage:       int(req.PerPage),
 		Type:          req.Type,
 		Status:        req.Status,
-		UserID:        req.UserId,
+		Scope:         scope,
 		CreatedAfter:  req.CreatedAfter,
 		CreatedBefore: req.CreatedBefore,
 	}
@@ -179,7 +461,11 @@ func (uc *FileUsecase) ListFiles(ctx context.Context, req *v1.ListFilesRequest)
 
 // GetFile 获取文件详情
 func (uc *FileUsecase) GetFile(ctx context.Context, req *v1.GetFileRequest) (*v1.GetFileReply, error) {
-	file, err := uc.repo.FindByID(ctx, req.FileId, req.UserId)
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	file, err := uc.repo.FindByID(ctx, req.FileId, scope)
 	if err != nil {
 		if err == ErrFileNotFound {
 			return nil, ErrFileNotFound
@@ -195,8 +481,12 @@ func (uc *FileUsecase) GetFile(ctx context.Context, req *v1.GetFileRequest) (*v1
 
 // DownloadFile 下载文件
 func (uc *FileUsecase) DownloadFile(ctx context.Context, req *v1.DownloadFileRequest) (*v1.DownloadFileReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	// 获取文件信息
-	file, err := uc.repo.FindByID(ctx, req.FileId, req.UserId)
+	file, err := uc.repo.FindByID(ctx, req.FileId, scope)
 	if err != nil {
 		if err == ErrFileNotFound {
 			return nil, ErrFileNotFound
@@ -204,6 +494,9 @@ func (uc *FileUsecase) DownloadFile(ctx context.Context, req *v1.DownloadFileReq
 		uc.log.WithContext(ctx).Errorf("failed to get file: %v", err)
 		return nil, err
 	}
+	if file.Status != FileStatusClean {
+		return nil, ErrFileNotClean
+	}
 
 	// 从存储下载文件
 	reader, err := uc.storage.Download(ctx, file.Filename)
@@ -227,10 +520,49 @@ func (uc *FileUsecase) DownloadFile(ctx context.Context, req *v1.DownloadFileReq
 	}, nil
 }
 
+// StatContent 返回其他服务可以读取的用户文件，version 为0时返回当前版本，否则返回内容字段为指定版本的文件。
+// 只有通过扫描的版本可以读取
+func (uc *FileUsecase) StatContent(ctx context.Context, fileID string, scope Scope, version int32) (*File, error) {
+	file, err := uc.repo.FindByID(ctx, fileID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if version > 0 && version != file.Version {
+		v, err := uc.repo.FindVersion(ctx, fileID, version)
+		if err != nil {
+			return nil, err
+		}
+		file = fileAtVersion(file, v)
+	}
+	if file.Status != FileStatusClean {
+		return nil, ErrFileNotClean
+	}
+	return file, nil
+}
+
+// OpenContent 打开用户文件指定版本的内容，供其他服务按文件ID读取，调用方负责关闭
+func (uc *FileUsecase) OpenContent(ctx context.Context, fileID string, scope Scope, version int32) (*File, io.ReadCloser, error) {
+	file, err := uc.StatContent(ctx, fileID, scope, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := uc.storage.Download(ctx, file.Filename)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to open file content: %v", err)
+		return nil, nil, fmt.Errorf("failed to open file content: %w", err)
+	}
+	return file, reader, nil
+}
+
 // DeleteFile 删除文件
 func (uc *FileUsecase) DeleteFile(ctx context.Context, req *v1.DeleteFileRequest) (*v1.DeleteFileReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	// 获取文件信息
-	file, err := uc.repo.FindByID(ctx, req.FileId, req.UserId)
+	file, err := uc.repo.FindByID(ctx, req.FileId, scope)
 	if err != nil {
 		if err == ErrFileNotFound {
 			return nil, ErrFileNotFound
@@ -239,23 +571,75 @@ func (uc *FileUsecase) DeleteFile(ctx context.Context, req *v1.DeleteFileRequest
 		return nil, err
 	}
 
-	// 从存储删除文件
-	if err := uc.storage.Delete(ctx, file.Filename); err != nil {
-		uc.log.WithContext(ctx).Errorf("failed to delete file from storage: %v", err)
+	if !scope.CanModify(file) {
+		return nil, ErrPermissionDenied
 	}
 
-	// 从数据库删除记录
-	if err := uc.repo.Delete(ctx, req.FileId, req.UserId); err != nil {
-		uc.log.WithContext(ctx).Errorf("failed to delete file record: %v", err)
+	// 移入回收站，保留期内可以恢复
+	if err := uc.repo.Delete(ctx, file.FileID, scope.Writable()); err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to move file to trash: %v", err)
 		return nil, err
 	}
 
 	return &v1.DeleteFileReply{
 		Success: true,
-		Message: "file deleted successfully",
+		Message: "file moved to trash",
 	}, nil
 }
 
+// removeFile 不经过回收站直接永久删除文件
+func (uc *FileUsecase) removeFile(ctx context.Context, file *File) error {
+	if err := uc.repo.Delete(ctx, file.FileID, Scope{UserID: file.UserID}); err != nil {
+		return err
+	}
+	return uc.purgeFile(ctx, file, time.Now())
+}
+
+// purgeFile 永久删除回收站中的文件，成功后释放其所有版本的内容和占用的配额
+func (uc *FileUsecase) purgeFile(ctx context.Context, file *File, trashedBefore time.Time) error {
+	// 版本记录随文件一起删除，需要先取出
+	versions, err := uc.repo.ListVersions(ctx, file.FileID)
+	if err != nil {
+		return fmt.Errorf("failed to list file versions: %w", err)
+	}
+	if err := uc.repo.Purge(ctx, file.FileID, trashedBefore); err != nil {
+		return err
+	}
+
+	var size int64
+	legacy := make(map[string]bool)
+	for _, version := range versions {
+		size += version.Size
+		// 恢复内容寻址之前的版本时复用同一个存储对象，只删除一次
+		if version.ContentHash == "" {
+			if legacy[version.Filename] {
+				continue
+			}
+			legacy[version.Filename] = true
+		}
+		uc.releaseContent(ctx, version.ContentHash, version.Filename)
+	}
+	uc.quotas.Release(ctx, file.UserID, size)
+	return nil
+}
+
+// releaseContent 释放对存储内容的一个引用。内容寻址之前上传的文件独占存储对象，直接删除
+func (uc *FileUsecase) releaseContent(ctx context.Context, hash, key string) {
+	if hash == "" {
+		if err := uc.storage.Delete(ctx, key); err != nil {
+			uc.log.WithContext(ctx).Errorf("failed to delete file from storage: %v", err)
+		}
+		return
+	}
+
+	err := uc.blobs.Release(ctx, hash, func(ctx context.Context) error {
+		return uc.storage.Delete(ctx, key)
+	})
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to release blob %s: %v", hash, err)
+	}
+}
+
 // isAllowedType 检查文件类型是否允许
 func (uc *FileUsecase) isAllowedType(filename string) bool {
 	if len(uc.config.AllowedTypes) == 0 {
@@ -271,18 +655,78 @@ func (uc *FileUsecase) isAllowedType(filename string) bool {
 	return false
 }
 
-// toProtoFileInfo 转换为proto文件信息
+// checkFileType 文件类型需同时满足全局配置和当前组织允许的类型
+func (uc *FileUsecase) checkFileType(ctx context.Context, filename string) error {
+	if !uc.isAllowedType(filename) {
+		return ErrInvalidFileType
+	}
+	orgID := auth.OrgID(ctx)
+	if orgID == 0 {
+		return nil
+	}
+	allowed, err := uc.orgs.AllowedFileTypes(ctx, orgID)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to get organization settings: org_id=%d, %v", orgID, err)
+		return fmt.Errorf("failed to get organization settings: %w", err)
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	for _, t := range allowed {
+		if t == ext {
+			return nil
+		}
+	}
+	return ErrInvalidFileType
+}
+
+// toProtoFileInfo 转换为proto文件信息，扫描通过的文件附带签名下载链接
 func (uc *FileUsecase) toProtoFileInfo(file *File) *v1.FileInfo {
-	return &v1.FileInfo{
+	var url string
+	if file.Status == FileStatusClean && file.DeletedAt.IsZero() {
+		url = uc.downloads.Sign(file, false).URL
+	}
+	info := &v1.FileInfo{
 		FileId:       file.FileID,
 		Filename:     file.Filename,
 		OriginalName: file.OriginalName,
 		Size:         file.Size,
 		MimeType:     file.MimeType,
-		Url:          file.URL,
+		Url:          url,
 		Status:       file.Status,
 		UserId:       file.UserID,
+		ContentHash:  file.ContentHash,
+		ScanResult:   file.ScanResult,
 		CreatedAt:    timestamppb.New(file.CreatedAt),
 		UpdatedAt:    timestamppb.New(file.UpdatedAt),
+		BatchId:      file.BatchID,
+		Version:      file.Version,
+		OrgId:        file.OrgID,
+	}
+	if !file.DeletedAt.IsZero() {
+		info.DeletedAt = timestamppb.New(file.DeletedAt)
+		info.PurgeAt = timestamppb.New(file.DeletedAt.Add(uc.trashRetention()))
 	}
+	return info
+}
+
+// sizeLimitReader 统计已读取的字节数，超过上限时返回 ErrFileSizeExceeded。limit 小于等于0表示不限制
+type sizeLimitReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.exceeded() {
+		return n, ErrFileSizeExceeded
+	}
+	return n, err
+}
+
+func (r *sizeLimitReader) exceeded() bool {
+	return r.limit > 0 && r.n > r.limit
 }
@@ -0,0 +1,156 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+// memFileRepo 内存文件仓库，访问范围与 data 层的 inScope 相同
+type memFileRepo struct {
+	FileRepo
+	files map[string]*File
+}
+
+func (r *memFileRepo) visible(file *File, scope Scope) bool {
+	return file.UserID == scope.UserID || scope.OrgID > 0 && file.OrgID == scope.OrgID
+}
+
+func (r *memFileRepo) find(fileID string, scope Scope, trashed bool) (*File, error) {
+	file, ok := r.files[fileID]
+	if !ok || !r.visible(file, scope) || file.DeletedAt.IsZero() == trashed {
+		return nil, ErrFileNotFound
+	}
+	copied := *file
+	return &copied, nil
+}
+
+func (r *memFileRepo) FindByID(_ context.Context, fileID string, scope Scope) (*File, error) {
+	return r.find(fileID, scope, false)
+}
+
+func (r *memFileRepo) FindTrashed(_ context.Context, fileID string, scope Scope) (*File, error) {
+	return r.find(fileID, scope, true)
+}
+
+func (r *memFileRepo) Delete(_ context.Context, fileID string, scope Scope) error {
+	if _, err := r.find(fileID, scope, false); err != nil {
+		return err
+	}
+	r.files[fileID].DeletedAt = time.Now()
+	return nil
+}
+
+func (r *memFileRepo) Restore(_ context.Context, fileID string, scope Scope) error {
+	if _, err := r.find(fileID, scope, true); err != nil {
+		return err
+	}
+	r.files[fileID].DeletedAt = time.Time{}
+	return nil
+}
+
+// orgContext 组织7中用户的请求，角色取自访问令牌
+func orgContext(userID int64, orgRole string) context.Context {
+	return auth.NewClaimsContext(context.Background(), &auth.Claims{UserID: userID, OrgID: 7, OrgRole: orgRole})
+}
+
+func TestScopeCanModify(t *testing.T) {
+	own := &File{UserID: 1, OrgID: 7}
+	colleague := &File{UserID: 2, OrgID: 7}
+	otherOrg := &File{UserID: 3, OrgID: 8}
+	tests := []struct {
+		name  string
+		scope Scope
+		file  *File
+		want  bool
+	}{
+		{name: "own file", scope: Scope{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleMember}, file: own, want: true},
+		{name: "member on colleague's file", scope: Scope{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleMember}, file: colleague},
+		{name: "admin on colleague's file", scope: Scope{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleAdmin}, file: colleague, want: true},
+		{name: "owner on colleague's file", scope: Scope{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleOwner}, file: colleague, want: true},
+		{name: "admin on another org's file", scope: Scope{UserID: 1, OrgID: 7, OrgRole: auth.OrgRoleAdmin}, file: otherOrg},
+		{name: "role without org", scope: Scope{UserID: 1, OrgRole: auth.OrgRoleOwner}, file: &File{UserID: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.CanModify(tt.file); got != tt.want {
+				t.Errorf("CanModify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrgMemberCannotModifyColleagueFiles(t *testing.T) {
+	newUsecase := func() (*FileUsecase, *memFileRepo) {
+		repo := &memFileRepo{files: map[string]*File{
+			"f1": {FileID: "f1", UserID: 2, OrgID: 7, Status: FileStatusScanning},
+			"f2": {FileID: "f2", UserID: 2, OrgID: 7, Status: FileStatusScanning, DeletedAt: time.Now().Add(-time.Hour)},
+		}}
+		return &FileUsecase{repo: repo, log: log.NewHelper(log.NewStdLogger(io.Discard))}, repo
+	}
+
+	t.Run("member", func(t *testing.T) {
+		uc, repo := newUsecase()
+		ctx := orgContext(1, auth.OrgRoleMember)
+
+		// 组织成员仍然可以查看同事的文件
+		if _, err := uc.GetFile(ctx, &v1.GetFileRequest{FileId: "f1"}); err != nil {
+			t.Fatalf("GetFile: %v", err)
+		}
+		if _, err := uc.DeleteFile(ctx, &v1.DeleteFileRequest{FileId: "f1"}); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("DeleteFile error = %v, want ErrPermissionDenied", err)
+		}
+		if _, err := uc.RestoreFile(ctx, &v1.RestoreFileRequest{FileId: "f2"}); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("RestoreFile error = %v, want ErrPermissionDenied", err)
+		}
+		if _, err := uc.PurgeFile(ctx, &v1.PurgeFileRequest{FileId: "f2"}); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("PurgeFile error = %v, want ErrPermissionDenied", err)
+		}
+		if _, err := uc.UploadVersion(ctx, &UploadInput{FileID: "f1", UserID: 1, Filename: "resume.pdf"}); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("UploadVersion error = %v, want ErrPermissionDenied", err)
+		}
+		if _, err := uc.RestoreVersion(ctx, &v1.RestoreFileVersionRequest{FileId: "f1", Version: 1}); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("RestoreVersion error = %v, want ErrPermissionDenied", err)
+		}
+		if !repo.files["f1"].DeletedAt.IsZero() || repo.files["f2"].DeletedAt.IsZero() {
+			t.Errorf("rejected calls changed the files")
+		}
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		uc, repo := newUsecase()
+		ctx := orgContext(1, auth.OrgRoleAdmin)
+
+		if _, err := uc.DeleteFile(ctx, &v1.DeleteFileRequest{FileId: "f1"}); err != nil {
+			t.Errorf("DeleteFile: %v", err)
+		}
+		if repo.files["f1"].DeletedAt.IsZero() {
+			t.Errorf("f1 was not moved to trash")
+		}
+		if _, err := uc.RestoreFile(ctx, &v1.RestoreFileRequest{FileId: "f2"}); err != nil {
+			t.Errorf("RestoreFile: %v", err)
+		}
+		if !repo.files["f2"].DeletedAt.IsZero() {
+			t.Errorf("f2 was not restored")
+		}
+	})
+
+	t.Run("uploader", func(t *testing.T) {
+		uc, repo := newUsecase()
+		ctx := orgContext(2, auth.OrgRoleMember)
+
+		if _, err := uc.DeleteFile(ctx, &v1.DeleteFileRequest{FileId: "f1"}); err != nil {
+			t.Errorf("DeleteFile: %v", err)
+		}
+		if repo.files["f1"].DeletedAt.IsZero() {
+			t.Errorf("f1 was not moved to trash")
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// defaultQuotaPlan 未配置 default_plan 时的默认套餐
+const defaultQuotaPlan = "free"
+
+var (
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	ErrUnknownPlan   = errors.New("unknown storage plan")
+)
+
+// QuotaLimits 套餐的配额，0表示不限制
+type QuotaLimits struct {
+	MaxBytes int64
+	MaxFiles int64
+}
+
+// TypeUsage 按文件类型统计的用量
+type TypeUsage struct {
+	MimeType  string
+	Bytes     int64
+	FileCount int64
+}
+
+// StorageUsage 用户的存储用量
+type StorageUsage struct {
+	UserID    int64
+	Plan      string // 用户分配的套餐，为空表示使用默认套餐
+	UsedBytes int64
+	FileCount int64
+	Limits    QuotaLimits
+	ByType    []*TypeUsage
+	Trash     *TypeUsage // 回收站中的文件，同样占用配额
+}
+
+// QuotaRepo 用户存储用量仓库
+type QuotaRepo interface {
+	// Get 返回用户的套餐和已用量，用户没有用量记录时按已有文件初始化
+	Get(ctx context.Context, userID int64) (*StorageUsage, error)
+	// Reserve 在不超过 limits 的前提下原子地增加用量和文件数，超过时返回 ErrQuotaExceeded
+	Reserve(ctx context.Context, userID, bytes, files int64, limits QuotaLimits) error
+	// Release 减少用量和文件数
+	Release(ctx context.Context, userID, bytes, files int64) error
+	// SetPlan 设置用户的套餐
+	SetPlan(ctx context.Context, userID int64, plan string) error
+	// UsageByType 按 MIME 类型统计用户文件的所有版本，按大小降序，不包括回收站中的文件
+	UsageByType(ctx context.Context, userID int64) ([]*TypeUsage, error)
+	// TrashUsage 统计用户回收站中的文件及其所有版本
+	TrashUsage(ctx context.Context, userID int64) (*TypeUsage, error)
+}
+
+// QuotaUsecase 按套餐限制每个用户的文件总大小和文件数。用量在上传时预占，永久删除时释放，
+// 相同内容的文件虽然只存储一份，仍按每个文件的大小计入用量；文件的每个版本都计入大小，但只算一个文件
+type QuotaUsecase struct {
+	repo        QuotaRepo
+	plans       map[string]QuotaLimits
+	defaultPlan string
+	log         *log.Helper
+}
+
+// NewQuotaUsecase 创建配额用例
+func NewQuotaUsecase(repo QuotaRepo, config *conf.Storage, logger log.Logger) *QuotaUsecase {
+	helper := log.NewHelper(logger)
+
+	plans := make(map[string]QuotaLimits, len(config.GetQuota().GetPlans()))
+	for name, plan := range config.GetQuota().GetPlans() {
+		plans[name] = QuotaLimits{MaxBytes: plan.GetMaxBytes(), MaxFiles: plan.GetMaxFiles()}
+	}
+	defaultPlan := config.GetQuota().GetDefaultPlan()
+	if defaultPlan == "" {
+		defaultPlan = defaultQuotaPlan
+	}
+	if len(plans) == 0 {
+		helper.Warn("no storage plans configured, storage quotas are not enforced")
+	} else if _, ok := plans[defaultPlan]; !ok {
+		helper.Warnf("default storage plan %q is not configured, users without a plan are not limited", defaultPlan)
+	}
+
+	return &QuotaUsecase{
+		repo:        repo,
+		plans:       plans,
+		defaultPlan: defaultPlan,
+		log:         helper,
+	}
+}
+
+// Reserve 为一个 size 字节的新文件预占配额，超过配额时返回 ErrQuotaExceeded
+func (uc *QuotaUsecase) Reserve(ctx context.Context, userID, size int64) error {
+	return uc.reserve(ctx, userID, size, 1)
+}
+
+// ReserveVersion 为已有文件的一个 size 字节的新版本预占配额，只计入大小，不计入文件数
+func (uc *QuotaUsecase) ReserveVersion(ctx context.Context, userID, size int64) error {
+	return uc.reserve(ctx, userID, size, 0)
+}
+
+func (uc *QuotaUsecase) reserve(ctx context.Context, userID, size, files int64) error {
+	usage, err := uc.repo.Get(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	plan, limits := uc.limits(usage.Plan)
+
+	if err := uc.repo.Reserve(ctx, userID, size, files, limits); err != nil {
+		if errors.Is(err, ErrQuotaExceeded) {
+			return quotaError(plan, limits)
+		}
+		return fmt.Errorf("failed to reserve storage quota: %w", err)
+	}
+	return nil
+}
+
+// Check 检查剩余配额是否能容纳一个 size 字节的新文件，不预占配额
+func (uc *QuotaUsecase) Check(ctx context.Context, userID, size int64) error {
+	usage, err := uc.repo.Get(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	plan, limits := uc.limits(usage.Plan)
+
+	if limits.MaxBytes > 0 && usage.UsedBytes+size > limits.MaxBytes ||
+		limits.MaxFiles > 0 && usage.FileCount+1 > limits.MaxFiles {
+		return quotaError(plan, limits)
+	}
+	return nil
+}
+
+// Release 释放文件占用的配额，size 为文件所有版本的总大小
+func (uc *QuotaUsecase) Release(ctx context.Context, userID, size int64) {
+	uc.release(ctx, userID, size, 1)
+}
+
+// ReleaseVersion 释放一个版本占用的配额
+func (uc *QuotaUsecase) ReleaseVersion(ctx context.Context, userID, size int64) {
+	uc.release(ctx, userID, size, 0)
+}
+
+func (uc *QuotaUsecase) release(ctx context.Context, userID, size, files int64) {
+	if err := uc.repo.Release(ctx, userID, size, files); err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to release storage quota: user_id=%d, size=%d, err=%v", userID, size, err)
+	}
+}
+
+// GetUsage 返回用户的用量、配额和按文件类型的统计
+func (uc *QuotaUsecase) GetUsage(ctx context.Context, userID int64) (*StorageUsage, error) {
+	usage, err := uc.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage.Plan, usage.Limits = uc.limits(usage.Plan)
+
+	usage.ByType, err = uc.repo.UsageByType(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage.Trash, err = uc.repo.TrashUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// SetPlan 为用户分配套餐，套餐需在配置中存在
+func (uc *QuotaUsecase) SetPlan(ctx context.Context, userID int64, plan string) error {
+	if _, ok := uc.plans[plan]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPlan, plan)
+	}
+	if err := uc.repo.SetPlan(ctx, userID, plan); err != nil {
+		return err
+	}
+	uc.log.WithContext(ctx).Infof("storage plan changed: user_id=%d, plan=%s", userID, plan)
+	return nil
+}
+
+// limits 返回套餐的配额，未分配或已从配置中移除的套餐使用默认套餐
+func (uc *QuotaUsecase) limits(plan string) (string, QuotaLimits) {
+	if limits, ok := uc.plans[plan]; ok && plan != "" {
+		return plan, limits
+	}
+	return uc.defaultPlan, uc.plans[uc.defaultPlan]
+}
+
+func quotaError(plan string, limits QuotaLimits) error {
+	var allowed []string
+	if limits.MaxBytes > 0 {
+		allowed = append(allowed, fmt.Sprintf("%d bytes", limits.MaxBytes))
+	}
+	if limits.MaxFiles > 0 {
+		allowed = append(allowed, fmt.Sprintf("%d files", limits.MaxFiles))
+	}
+	return fmt.Errorf("%w: plan %s allows %s", ErrQuotaExceeded, plan, strings.Join(allowed, " and "))
+}
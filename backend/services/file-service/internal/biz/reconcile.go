@@ -0,0 +1,208 @@
+package biz
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	// defaultReconcileInterval 默认的清理和一致性检查间隔
+	defaultReconcileInterval = time.Hour
+	// defaultOrphanGrace 默认的孤立判定宽限期
+	defaultOrphanGrace = 24 * time.Hour
+	// reconcileBatchSize 每批核对的记录数
+	reconcileBatchSize = 500
+)
+
+// ReconcileReport 一轮清理和一致性检查的结果
+type ReconcileReport struct {
+	PurgedFiles    int // 超过保留期被永久删除的回收站文件
+	RemovedBlobs   int // 没有文件引用、已删除的内容记录
+	RepairedBlobs  int // 引用计数已修正的内容记录
+	OrphanObjects  int // 没有内容记录、已删除的存储对象
+	RecreatedBlobs int // 被文件引用但缺失、已按存储对象重建的内容记录
+	MissingContent int // 被文件引用但存储中已不存在的内容
+}
+
+// Empty 本轮是否没有任何修改或发现
+func (r *ReconcileReport) Empty() bool {
+	return *r == ReconcileReport{}
+}
+
+func (r *ReconcileReport) String() string {
+	return fmt.Sprintf("purged_files=%d, removed_blobs=%d, repaired_blobs=%d, orphan_objects=%d, recreated_blobs=%d, missing_content=%d",
+		r.PurgedFiles, r.RemovedBlobs, r.RepairedBlobs, r.OrphanObjects, r.RecreatedBlobs, r.MissingContent)
+}
+
+// ReconcileUsecase 清理过期的回收站文件，并核对文件记录、内容记录和存储对象三者的一致性。
+// 所有修改都在行锁或条件更新下进行，多个实例可以同时执行。宽限期内更新过的记录和对象不处理，
+// 避免把进行中的上传误判为孤立
+type ReconcileUsecase struct {
+	files    *FileUsecase
+	blobs    BlobRepo
+	storage  StorageRepo
+	interval time.Duration
+	grace    time.Duration
+	log      *log.Helper
+}
+
+// NewReconcileUsecase 创建一致性检查用例
+func NewReconcileUsecase(files *FileUsecase, blobs BlobRepo, storage StorageRepo, config *conf.Storage, logger log.Logger) *ReconcileUsecase {
+	interval := config.GetTrash().GetReconcileInterval().AsDuration()
+	if interval <= 0 {
+		interval = defaultReconcileInterval
+	}
+	grace := config.GetTrash().GetOrphanGrace().AsDuration()
+	if grace <= 0 {
+		grace = defaultOrphanGrace
+	}
+
+	return &ReconcileUsecase{
+		files:    files,
+		blobs:    blobs,
+		storage:  storage,
+		interval: interval,
+		grace:    grace,
+		log:      log.NewHelper(logger),
+	}
+}
+
+// Interval 检查间隔
+func (uc *ReconcileUsecase) Interval() time.Duration {
+	return uc.interval
+}
+
+// Reconcile 执行一轮清理和一致性检查
+func (uc *ReconcileUsecase) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+
+	purged, err := uc.files.PurgeExpired(ctx)
+	report.PurgedFiles = purged
+	if err != nil {
+		return report, fmt.Errorf("failed to purge expired trash: %w", err)
+	}
+
+	cutoff := time.Now().Add(-uc.grace)
+	if err := uc.reconcileBlobs(ctx, cutoff, report); err != nil {
+		return report, fmt.Errorf("failed to reconcile blobs: %w", err)
+	}
+	if err := uc.reconcileObjects(ctx, cutoff, report); err != nil {
+		return report, fmt.Errorf("failed to reconcile storage objects: %w", err)
+	}
+	if err := uc.reconcileMissing(ctx, cutoff, report); err != nil {
+		return report, fmt.Errorf("failed to reconcile missing blobs: %w", err)
+	}
+	return report, nil
+}
+
+// reconcileBlobs 删除没有文件引用的内容，修正偏低的引用计数
+func (uc *ReconcileUsecase) reconcileBlobs(ctx context.Context, cutoff time.Time, report *ReconcileReport) error {
+	after := ""
+	for {
+		blobs, err := uc.blobs.ListUpdatedBefore(ctx, after, cutoff, reconcileBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, blob := range blobs {
+			key := blob.StorageKey
+			result, err := uc.blobs.Reconcile(ctx, blob.Hash, cutoff, func(ctx context.Context) error {
+				return uc.storage.Delete(ctx, key)
+			})
+			if err != nil {
+				uc.log.WithContext(ctx).Warnf("failed to reconcile blob %s: %v", blob.Hash, err)
+				continue
+			}
+			switch result {
+			case BlobRemoved:
+				report.RemovedBlobs++
+				uc.log.WithContext(ctx).Infof("removed unreferenced blob %s", blob.Hash)
+			case BlobRepaired:
+				report.RepairedBlobs++
+				uc.log.WithContext(ctx).Warnf("repaired reference count of blob %s", blob.Hash)
+			case BlobOverCounted:
+				uc.log.WithContext(ctx).Debugf("blob %s has more references than files", blob.Hash)
+			}
+		}
+
+		if len(blobs) < reconcileBatchSize {
+			return nil
+		}
+		after = blobs[len(blobs)-1].Hash
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// reconcileObjects 删除没有内容记录的存储对象，例如 Release 时删除失败残留的对象
+func (uc *ReconcileUsecase) reconcileObjects(ctx context.Context, cutoff time.Time, report *ReconcileReport) error {
+	return uc.storage.List(ctx, blobKeyPrefix, func(obj *StorageObject) error {
+		if obj.ModTime.After(cutoff) {
+			return nil
+		}
+		hash := strings.TrimPrefix(obj.Key, blobKeyPrefix)
+		if !isContentHash(hash) {
+			return nil
+		}
+
+		removed, err := uc.blobs.RemoveOrphan(ctx, hash, obj.Key, func(ctx context.Context) error {
+			return uc.storage.Delete(ctx, obj.Key)
+		})
+		if err != nil {
+			uc.log.WithContext(ctx).Warnf("failed to remove orphan object %s: %v", obj.Key, err)
+			return ctx.Err()
+		}
+		if removed {
+			report.OrphanObjects++
+			uc.log.WithContext(ctx).Infof("removed orphan object %s, size=%d", obj.Key, obj.Size)
+		}
+		return nil
+	})
+}
+
+// reconcileMissing 处理被文件引用但缺少内容记录的哈希：存储对象还在时重建记录，否则报告内容丢失
+func (uc *ReconcileUsecase) reconcileMissing(ctx context.Context, cutoff time.Time, report *ReconcileReport) error {
+	hashes, err := uc.blobs.ListMissing(ctx, cutoff, reconcileBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		key := blobKey(hash)
+		exists, err := uc.storage.Exists(ctx, key)
+		if err != nil {
+			uc.log.WithContext(ctx).Warnf("failed to check object %s: %v", key, err)
+			continue
+		}
+		if !exists {
+			report.MissingContent++
+			uc.log.WithContext(ctx).Errorf("content of blob %s is missing from storage, files referencing it cannot be downloaded", hash)
+			continue
+		}
+
+		if err := uc.blobs.Recreate(ctx, hash, key); err != nil {
+			uc.log.WithContext(ctx).Warnf("failed to recreate blob %s: %v", hash, err)
+			continue
+		}
+		report.RecreatedBlobs++
+		uc.log.WithContext(ctx).Warnf("recreated missing blob record %s", hash)
+	}
+	return nil
+}
+
+// isContentHash 是否为十六进制的 SHA-256
+func isContentHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
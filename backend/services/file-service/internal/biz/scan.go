@@ -0,0 +1,163 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// 文件状态：上传后为 scanning，扫描通过为 clean，发现恶意内容为 infected。
+// uploaded 为引入扫描之前上传的文件，同样需要扫描
+const (
+	FileStatusUploaded = "uploaded"
+	FileStatusScanning = "scanning"
+	FileStatusClean    = "clean"
+	FileStatusInfected = "infected"
+)
+
+var ErrFileNotClean = errors.New("file has not passed malware scanning")
+
+const (
+	// defaultScanTimeout 单个文件默认扫描超时
+	defaultScanTimeout = 60 * time.Second
+	// defaultScanRetryInterval 待扫描文件默认重试间隔
+	defaultScanRetryInterval = 5 * time.Minute
+	// defaultScanConcurrency 默认同时扫描的文件数
+	defaultScanConcurrency = 4
+	// scanBatchSize 每轮重试扫描的最大文件数
+	scanBatchSize = 100
+)
+
+// ScanResult 扫描结果
+type ScanResult struct {
+	Infected  bool
+	Signature string // 命中的病毒特征名
+}
+
+// Scanner 恶意软件扫描器接口
+type Scanner interface {
+	// Scan 扫描内容，扫描器不可用时返回错误
+	Scan(ctx context.Context, content io.Reader) (*ScanResult, error)
+}
+
+// ScanUsecase 上传文件的恶意软件扫描。扫描在后台进行，失败的扫描由 ScanJanitor 定期重试
+type ScanUsecase struct {
+	repo          FileRepo
+	storage       StorageRepo
+	scanner       Scanner
+	timeout       time.Duration
+	retryInterval time.Duration
+	sem           chan struct{}
+	log           *log.Helper
+}
+
+// NewScanUsecase 创建扫描用例
+func NewScanUsecase(repo FileRepo, storage StorageRepo, scanner Scanner, config *conf.Storage, logger log.Logger) *ScanUsecase {
+	timeout := config.GetScan().GetTimeout().AsDuration()
+	if timeout <= 0 {
+		timeout = defaultScanTimeout
+	}
+	retryInterval := config.GetScan().GetRetryInterval().AsDuration()
+	if retryInterval <= 0 {
+		retryInterval = defaultScanRetryInterval
+	}
+	concurrency := int(config.GetScan().GetMaxConcurrent())
+	if concurrency <= 0 {
+		concurrency = defaultScanConcurrency
+	}
+
+	return &ScanUsecase{
+		repo:          repo,
+		storage:       storage,
+		scanner:       scanner,
+		timeout:       timeout,
+		retryInterval: retryInterval,
+		sem:           make(chan struct{}, concurrency),
+		log:           log.NewHelper(logger),
+	}
+}
+
+// RetryInterval 待扫描文件的重试间隔
+func (uc *ScanUsecase) RetryInterval() time.Duration {
+	return uc.retryInterval
+}
+
+// Submit 在后台扫描文件，不阻塞上传请求
+func (uc *ScanUsecase) Submit(file *File) {
+	// 复制一份，避免与调用方并发读写
+	scanned := *file
+	go func() {
+		if err := uc.Scan(context.Background(), &scanned); err != nil {
+			uc.log.Warnf("scan failed, will retry later: file_id=%s, err=%v", scanned.FileID, err)
+		}
+	}()
+}
+
+// Scan 扫描文件并更新状态。发现恶意内容时，所有相同内容的文件都标记为 infected
+func (uc *ScanUsecase) Scan(ctx context.Context, file *File) error {
+	select {
+	case uc.sem <- struct{}{}:
+		defer func() { <-uc.sem }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, uc.timeout)
+	defer cancel()
+
+	reader, err := uc.storage.Download(ctx, file.Filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file for scanning: %w", err)
+	}
+	defer reader.Close()
+
+	result, err := uc.scanner.Scan(ctx, reader)
+	if err != nil {
+		return fmt.Errorf("failed to scan file: %w", err)
+	}
+
+	if result.Infected {
+		uc.log.WithContext(ctx).Warnf("malware detected: file_id=%s, hash=%s, signature=%s", file.FileID, file.ContentHash, result.Signature)
+		if file.ContentHash != "" {
+			return uc.repo.UpdateStatusByHash(ctx, file.ContentHash, FileStatusInfected, result.Signature)
+		}
+		return uc.repo.UpdateStatus(ctx, file.FileID, file.ContentHash, file.Status, FileStatusInfected, result.Signature)
+	}
+
+	if err := uc.repo.UpdateStatus(ctx, file.FileID, file.ContentHash, file.Status, FileStatusClean, ""); err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			// 扫描期间文件被删除或状态已被修改
+			return nil
+		}
+		return err
+	}
+	file.Status = FileStatusClean
+	uc.log.WithContext(ctx).Infof("file scanned clean: file_id=%s", file.FileID)
+	return nil
+}
+
+// RescanPending 重新扫描超过重试间隔仍未完成扫描的文件，包括引入扫描之前上传的文件
+func (uc *ScanUsecase) RescanPending(ctx context.Context) (int, error) {
+	before := time.Now().Add(-uc.retryInterval)
+	total := 0
+	for _, status := range []string{FileStatusScanning, FileStatusUploaded} {
+		files, err := uc.repo.ListByStatus(ctx, status, before, scanBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, file := range files {
+			if err := uc.Scan(ctx, file); err != nil {
+				uc.log.WithContext(ctx).Warnf("rescan failed: file_id=%s, err=%v", file.FileID, err)
+				continue
+			}
+			total++
+		}
+	}
+	return total, nil
+}
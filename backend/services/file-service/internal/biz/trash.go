@@ -0,0 +1,193 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+)
+
+const (
+	// defaultTrashRetention 回收站中的文件默认保留时长
+	defaultTrashRetention = 30 * 24 * time.Hour
+	// trashBatchSize 每批永久删除的文件数
+	trashBatchSize = 100
+	// defaultTrashPerPage 回收站列表每页默认条数
+	defaultTrashPerPage = 20
+	// maxTrashPerPage 回收站列表每页最大条数
+	maxTrashPerPage = 100
+)
+
+// trashRetention 回收站中的文件保留时长
+func (uc *FileUsecase) trashRetention() time.Duration {
+	if retention := uc.config.GetTrash().GetRetention().AsDuration(); retention > 0 {
+		return retention
+	}
+	return defaultTrashRetention
+}
+
+// ListTrash 获取回收站中的文件
+func (uc *FileUsecase) ListTrash(ctx context.Context, req *v1.ListTrashRequest) (*v1.ListTrashReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page, perPage := req.Page, req.PerPage
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultTrashPerPage
+	}
+	if perPage > maxTrashPerPage {
+		perPage = maxTrashPerPage
+	}
+	files, total, err := uc.repo.ListTrashed(ctx, scope, int(page), int(perPage))
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to list trash: %v", err)
+		return nil, err
+	}
+
+	protoFiles := make([]*v1.FileInfo, len(files))
+	for i, file := range files {
+		protoFiles[i] = uc.toProtoFileInfo(file)
+	}
+
+	return &v1.ListTrashReply{
+		Files:      protoFiles,
+		Total:      int32(total),
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: int32(math.Ceil(float64(total) / float64(perPage))),
+	}, nil
+}
+
+// RestoreFile 将文件移出回收站
+func (uc *FileUsecase) RestoreFile(ctx context.Context, req *v1.RestoreFileRequest) (*v1.RestoreFileReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := uc.repo.FindTrashed(ctx, req.FileId, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.CanModify(trashed) {
+		return nil, ErrPermissionDenied
+	}
+	if err := uc.repo.Restore(ctx, req.FileId, scope.Writable()); err != nil {
+		if !errors.Is(err, ErrFileNotFound) {
+			uc.log.WithContext(ctx).Errorf("failed to restore file: %v", err)
+		}
+		return nil, err
+	}
+
+	file, err := uc.repo.FindByID(ctx, req.FileId, scope)
+	if err != nil {
+		return nil, err
+	}
+	uc.log.WithContext(ctx).Infof("file restored from trash: file_id=%s", file.FileID)
+	return &v1.RestoreFileReply{
+		File: uc.toProtoFileInfo(file),
+	}, nil
+}
+
+// PurgeFile 永久删除回收站中的文件
+func (uc *FileUsecase) PurgeFile(ctx context.Context, req *v1.PurgeFileRequest) (*v1.PurgeFileReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	file, err := uc.repo.FindTrashed(ctx, req.FileId, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.CanModify(file) {
+		return nil, ErrPermissionDenied
+	}
+
+	if err := uc.purgeFile(ctx, file, time.Now()); err != nil {
+		if !errors.Is(err, ErrFileNotFound) {
+			uc.log.WithContext(ctx).Errorf("failed to purge file: %v", err)
+		}
+		return nil, err
+	}
+
+	uc.log.WithContext(ctx).Infof("file purged: file_id=%s", file.FileID)
+	return &v1.PurgeFileReply{Success: true}, nil
+}
+
+// EmptyTrash 永久删除回收站中用户可以修改的所有文件：自己上传的文件，组织所有者和管理员还包括组织的文件
+func (uc *FileUsecase) EmptyTrash(ctx context.Context, req *v1.EmptyTrashRequest) (*v1.EmptyTrashReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope = scope.Writable()
+	now := time.Now()
+	var purged int32
+	for {
+		files, _, err := uc.repo.ListTrashed(ctx, scope, 1, trashBatchSize)
+		if err != nil {
+			uc.log.WithContext(ctx).Errorf("failed to list trash: %v", err)
+			return nil, err
+		}
+
+		progressed := false
+		for _, file := range files {
+			// 清空期间新移入回收站的文件不处理
+			if file.DeletedAt.After(now) {
+				continue
+			}
+			if err := uc.purgeFile(ctx, file, now); err != nil {
+				if errors.Is(err, ErrFileNotFound) {
+					// 已被恢复或已被其他请求删除
+					continue
+				}
+				uc.log.WithContext(ctx).Errorf("failed to purge file %s: %v", file.FileID, err)
+				return nil, err
+			}
+			purged++
+			progressed = true
+		}
+		if len(files) < trashBatchSize || !progressed {
+			break
+		}
+	}
+
+	uc.log.WithContext(ctx).Infof("trash emptied: user_id=%d, purged=%d", scope.UserID, purged)
+	return &v1.EmptyTrashReply{Purged: purged}, nil
+}
+
+// PurgeExpired 永久删除超过保留期的回收站文件。多个实例同时执行时每个文件只会被删除一次
+func (uc *FileUsecase) PurgeExpired(ctx context.Context) (int, error) {
+	before := time.Now().Add(-uc.trashRetention())
+	total := 0
+	for {
+		files, err := uc.repo.ListTrashedBefore(ctx, before, trashBatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		purged := 0
+		for _, file := range files {
+			if err := uc.purgeFile(ctx, file, before); err != nil {
+				if !errors.Is(err, ErrFileNotFound) {
+					uc.log.WithContext(ctx).Warnf("failed to purge expired file %s: %v", file.FileID, err)
+				}
+				continue
+			}
+			purged++
+		}
+		total += purged
+		// 整批都失败时等下一轮重试，避免反复处理同一批文件
+		if len(files) < trashBatchSize || purged == 0 {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
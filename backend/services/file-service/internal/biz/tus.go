@@ -0,0 +1,383 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrUploadExpired  = errors.New("upload expired")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrInvalidUpload  = errors.New("invalid upload")
+)
+
+const (
+	// defaultTusExpiration 未完成的上传默认保留时长
+	defaultTusExpiration = 24 * time.Hour
+	// defaultTusCleanupInterval 过期上传清理任务默认执行间隔
+	defaultTusCleanupInterval = time.Hour
+	// tusCleanupBatchSize 每轮清理的最大上传数
+	tusCleanupBatchSize = 100
+)
+
+// TusUpload 断点续传上传（tus 协议）。每次 PATCH 写入的数据作为一个分片保存在存储中，
+// 全部写入后按顺序合并为普通文件
+type TusUpload struct {
+	ID        string
+	UserID    int64
+	Filename  string
+	Length    int64             // 文件总大小
+	Offset    int64             // 已接收的字节数
+	Metadata  map[string]string // 创建时的 Upload-Metadata
+	Chunks    []string          // 已写入的分片存储名，按偏移量排序
+	FileID    string            // 合并完成后的文件ID，为空表示未完成
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Completed 上传是否已合并为文件
+func (u *TusUpload) Completed() bool {
+	return u.FileID != ""
+}
+
+// TusUploadRepo 断点续传上传仓库接口
+type TusUploadRepo interface {
+	Create(ctx context.Context, upload *TusUpload) error
+	Get(ctx context.Context, id string) (*TusUpload, error)
+	// UpdateOffset 仅当当前偏移量等于 expectedOffset 且上传未完成时更新偏移量、分片和过期时间，否则返回 ErrOffsetMismatch
+	UpdateOffset(ctx context.Context, upload *TusUpload, expectedOffset int64) error
+	// Complete 记录合并后的文件ID，上传已完成时返回 ErrOffsetMismatch
+	Complete(ctx context.Context, id, fileID string) error
+	Delete(ctx context.Context, id string) error
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*TusUpload, error)
+}
+
+// TusUsecase 断点续传用例
+type TusUsecase struct {
+	repo            TusUploadRepo
+	files           *FileUsecase
+	storage         StorageRepo
+	expiration      time.Duration
+	cleanupInterval time.Duration
+	log             *log.Helper
+}
+
+// NewTusUsecase 创建断点续传用例
+func NewTusUsecase(repo TusUploadRepo, files *FileUsecase, storage StorageRepo, config *conf.Storage, logger log.Logger) *TusUsecase {
+	expiration := config.GetTus().GetExpiration().AsDuration()
+	if expiration <= 0 {
+		expiration = defaultTusExpiration
+	}
+	cleanupInterval := config.GetTus().GetCleanupInterval().AsDuration()
+	if cleanupInterval <= 0 {
+		cleanupInterval = defaultTusCleanupInterval
+	}
+
+	return &TusUsecase{
+		repo:            repo,
+		files:           files,
+		storage:         storage,
+		expiration:      expiration,
+		cleanupInterval: cleanupInterval,
+		log:             log.NewHelper(logger),
+	}
+}
+
+// MaxSize 单个上传的大小上限，小于等于0表示不限制
+func (uc *TusUsecase) MaxSize() int64 {
+	return uc.files.MaxFileSize()
+}
+
+// CleanupInterval 过期上传清理任务的执行间隔
+func (uc *TusUsecase) CleanupInterval() time.Duration {
+	return uc.cleanupInterval
+}
+
+// CreateUpload 为当前用户创建上传。metadata 中需包含 filename
+func (uc *TusUsecase) CreateUpload(ctx context.Context, length int64, metadata map[string]string) (*TusUpload, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("%w: invalid Upload-Length", ErrInvalidUpload)
+	}
+	if limit := uc.MaxSize(); limit > 0 && length > limit {
+		return nil, ErrFileSizeExceeded
+	}
+
+	filename := strings.TrimSpace(metadata["filename"])
+	if filename == "" {
+		filename = strings.TrimSpace(metadata["name"])
+	}
+	if filename == "" {
+		return nil, fmt.Errorf("%w: filename is required in Upload-Metadata", ErrInvalidUpload)
+	}
+	if err := uc.files.checkFileType(ctx, filename); err != nil {
+		return nil, err
+	}
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 剩余配额不足时在上传开始前拒绝，合并时再正式预占
+	if err := uc.files.quotas.Check(ctx, userID, length); err != nil {
+		return nil, err
+	}
+
+	upload := &TusUpload{
+		ID:        strings.ReplaceAll(uuid.New().String(), "-", ""),
+		UserID:    userID,
+		Filename:  filename,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: time.Now().Add(uc.expiration),
+	}
+	if err := uc.repo.Create(ctx, upload); err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to create upload: %v", err)
+		return nil, err
+	}
+
+	uc.log.WithContext(ctx).Infof("tus upload created: id=%s, filename=%s, length=%d", upload.ID, filename, length)
+	return upload, nil
+}
+
+// GetUpload 获取当前用户的上传状态
+func (uc *TusUsecase) GetUpload(ctx context.Context, id string) (*TusUpload, error) {
+	upload, err := uc.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !upload.Completed() && time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadExpired
+	}
+	return upload, nil
+}
+
+// WriteChunk 从 offset 处追加数据，数据全部写入后合并为文件。
+// 读取请求体中断时保存中断前已接收的数据并推进偏移量，同时返回上传状态和读取错误，
+// 客户端通过 HEAD 获取偏移量后从断点继续
+func (uc *TusUsecase) WriteChunk(ctx context.Context, id string, offset int64, content io.Reader) (*TusUpload, error) {
+	upload, err := uc.GetUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Completed() || offset != upload.Offset {
+		return nil, ErrOffsetMismatch
+	}
+
+	body := &interruptibleReader{r: content}
+	if upload.Offset < upload.Length {
+		chunk := fmt.Sprintf("tus_%s_%020d_%s.part", upload.ID, offset, uuid.New().String()[:8])
+		reader := &sizeLimitReader{r: body, limit: upload.Length - upload.Offset}
+		if _, err := uc.storage.Upload(ctx, chunk, reader); err != nil {
+			if errors.Is(err, ErrFileSizeExceeded) || reader.exceeded() {
+				return nil, ErrFileSizeExceeded
+			}
+			uc.log.WithContext(ctx).Errorf("failed to store upload chunk: %v", err)
+			return nil, fmt.Errorf("failed to store upload chunk: %w", err)
+		}
+
+		if reader.n == 0 {
+			uc.storage.Delete(ctx, chunk)
+		} else {
+			upload.Offset += reader.n
+			upload.Chunks = append(upload.Chunks, chunk)
+			upload.ExpiresAt = time.Now().Add(uc.expiration)
+			if err := uc.repo.UpdateOffset(ctx, upload, offset); err != nil {
+				// 并发写入同一偏移量时只有一个请求成功
+				uc.storage.Delete(ctx, chunk)
+				return nil, err
+			}
+		}
+	}
+
+	if body.err != nil && upload.Offset < upload.Length {
+		uc.log.WithContext(ctx).Warnf("tus upload interrupted: id=%s, offset=%d, err=%v", upload.ID, upload.Offset, body.err)
+		if upload.Offset == offset {
+			return nil, fmt.Errorf("failed to receive upload chunk: %w", body.err)
+		}
+		return upload, fmt.Errorf("upload interrupted at offset %d: %w", upload.Offset, body.err)
+	}
+
+	if upload.Offset == upload.Length {
+		if err := uc.finalize(ctx, upload); err != nil {
+			return nil, err
+		}
+	}
+	return upload, nil
+}
+
+// interruptibleReader 把读取错误转换为 EOF，使存储保存出错前已读取的数据，原错误记录在 err 中
+type interruptibleReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *interruptibleReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, io.EOF
+	}
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// find 获取上传记录，其他用户的上传视为不存在
+func (uc *TusUsecase) find(ctx context.Context, id string) (*TusUpload, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := uc.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.UserID != userID {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// Terminate 终止当前用户的上传并删除已接收的数据
+func (uc *TusUsecase) Terminate(ctx context.Context, id string) error {
+	upload, err := uc.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.deleteChunks(ctx, upload)
+
+	uc.log.WithContext(ctx).Infof("tus upload terminated: id=%s", id)
+	return nil
+}
+
+// CleanupExpired 清理过期的上传：未完成的删除已接收的分片，已完成的仅删除上传记录
+func (uc *TusUsecase) CleanupExpired(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		uploads, err := uc.repo.ListExpired(ctx, time.Now(), tusCleanupBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, upload := range uploads {
+			if err := uc.repo.Delete(ctx, upload.ID); err != nil {
+				return total, err
+			}
+			if !upload.Completed() {
+				uc.deleteChunks(ctx, upload)
+			}
+			total++
+		}
+		if len(uploads) < tusCleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// finalize 按顺序合并分片为普通文件。内容校验失败时删除上传，其他失败保留分片，客户端可以发送空的 PATCH 重试
+func (uc *TusUsecase) finalize(ctx context.Context, upload *TusUpload) error {
+	reader := &chunksReader{ctx: ctx, storage: uc.storage, chunks: upload.Chunks}
+	defer reader.Close()
+
+	reply, err := uc.files.UploadStream(ctx, &UploadInput{
+		Filename:    upload.Filename,
+		Title:       upload.Metadata["title"],
+		Description: upload.Metadata["description"],
+		UserID:      upload.UserID,
+		Size:        upload.Length,
+		Content:     reader,
+	})
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to finalize upload %s: %v", upload.ID, err)
+		if errors.Is(err, ErrInvalidContent) {
+			// 内容校验失败时重试无意义，直接删除上传
+			if err := uc.repo.Delete(ctx, upload.ID); err == nil {
+				uc.deleteChunks(ctx, upload)
+			}
+		}
+		return err
+	}
+
+	if err := uc.repo.Complete(ctx, upload.ID, reply.File.FileId); err != nil {
+		// 并发合并时只保留先完成的文件
+		uc.files.removeFile(ctx, &File{
+			FileID:      reply.File.FileId,
+			Filename:    reply.File.Filename,
+			Size:        reply.File.Size,
+			UserID:      upload.UserID,
+			ContentHash: reply.File.ContentHash,
+		})
+		return err
+	}
+	upload.FileID = reply.File.FileId
+	uc.deleteChunks(ctx, upload)
+
+	uc.log.WithContext(ctx).Infof("tus upload completed: id=%s, file_id=%s", upload.ID, upload.FileID)
+	return nil
+}
+
+func (uc *TusUsecase) deleteChunks(ctx context.Context, upload *TusUpload) {
+	for _, chunk := range upload.Chunks {
+		if err := uc.storage.Delete(ctx, chunk); err != nil {
+			uc.log.WithContext(ctx).Warnf("failed to delete upload chunk %s: %v", chunk, err)
+		}
+	}
+}
+
+// chunksReader 依次读取存储中的分片，同一时间只打开一个分片
+type chunksReader struct {
+	ctx     context.Context
+	storage StorageRepo
+	chunks  []string
+	current io.ReadCloser
+}
+
+func (r *chunksReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := r.storage.Download(r.ctx, r.chunks[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed to open upload chunk %s: %w", r.chunks[0], err)
+			}
+			r.current = rc
+			r.chunks = r.chunks[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunksReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
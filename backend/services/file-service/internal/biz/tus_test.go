@@ -0,0 +1,174 @@
+package biz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// memStorage 内存存储，Upload 与真实存储一样读到 EOF 为止，读取出错时不保存
+type memStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{objects: make(map[string][]byte)}
+}
+
+func (s *memStorage) Upload(_ context.Context, filename string, content io.Reader) (string, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[filename] = data
+	return filename, nil
+}
+
+func (s *memStorage) Download(_ context.Context, filename string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[filename]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStorage) DownloadRange(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *memStorage) Delete(_ context.Context, filename string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, filename)
+	return nil
+}
+
+func (s *memStorage) GetURL(filename string) string { return filename }
+
+func (s *memStorage) Exists(_ context.Context, filename string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.objects[filename]
+	return ok, nil
+}
+
+func (s *memStorage) List(context.Context, string, func(*StorageObject) error) error {
+	return nil
+}
+
+// memTusRepo 内存断点续传仓库
+type memTusRepo struct {
+	uploads map[string]*TusUpload
+}
+
+func (r *memTusRepo) Create(_ context.Context, upload *TusUpload) error {
+	r.uploads[upload.ID] = upload
+	return nil
+}
+
+func (r *memTusRepo) Get(_ context.Context, id string) (*TusUpload, error) {
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, ErrUploadNotFound
+	}
+	copied := *upload
+	copied.Chunks = append([]string(nil), upload.Chunks...)
+	return &copied, nil
+}
+
+func (r *memTusRepo) UpdateOffset(_ context.Context, upload *TusUpload, expectedOffset int64) error {
+	current, ok := r.uploads[upload.ID]
+	if !ok || current.Completed() || current.Offset != expectedOffset {
+		return ErrOffsetMismatch
+	}
+	copied := *upload
+	r.uploads[upload.ID] = &copied
+	return nil
+}
+
+func (r *memTusRepo) Complete(_ context.Context, id, fileID string) error {
+	r.uploads[id].FileID = fileID
+	return nil
+}
+
+func (r *memTusRepo) Delete(_ context.Context, id string) error {
+	delete(r.uploads, id)
+	return nil
+}
+
+func (r *memTusRepo) ListExpired(context.Context, time.Time, int) ([]*TusUpload, error) {
+	return nil, nil
+}
+
+// failingReader 返回 data 后以 err 结束，模拟连接中途断开
+type failingReader struct {
+	data *strings.Reader
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data.Len() == 0 {
+		return 0, r.err
+	}
+	return r.data.Read(p)
+}
+
+func TestTusWriteChunkInterrupted(t *testing.T) {
+	storage := newMemStorage()
+	repo := &memTusRepo{uploads: map[string]*TusUpload{
+		"u1": {ID: "u1", UserID: 1, Filename: "resume.pdf", Length: 20, ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+	uc := NewTusUsecase(repo, nil, storage, &conf.Storage{}, log.NewStdLogger(io.Discard))
+	ctx := auth.NewContext(context.Background(), 1)
+	reset := errors.New("connection reset by peer")
+
+	// 接收 8 字节后断开：保留已接收的数据并推进偏移量
+	upload, err := uc.WriteChunk(ctx, "u1", 0, &failingReader{data: strings.NewReader("01234567"), err: reset})
+	if !errors.Is(err, reset) {
+		t.Fatalf("WriteChunk error = %v, want the read error", err)
+	}
+	if upload == nil || upload.Offset != 8 {
+		t.Fatalf("WriteChunk upload = %+v, want offset 8", upload)
+	}
+	if got, _ := uc.GetUpload(ctx, "u1"); got.Offset != 8 || len(got.Chunks) != 1 {
+		t.Fatalf("stored upload = %+v, want offset 8 with one chunk", got)
+	}
+	if data := storage.objects[upload.Chunks[0]]; string(data) != "01234567" {
+		t.Errorf("stored chunk = %q, want %q", data, "01234567")
+	}
+
+	// 未接收到数据就断开：偏移量不变，不留下分片
+	if upload, err := uc.WriteChunk(ctx, "u1", 8, &failingReader{data: strings.NewReader(""), err: reset}); !errors.Is(err, reset) || upload != nil {
+		t.Fatalf("WriteChunk = %+v, %v, want nil upload and the read error", upload, err)
+	}
+	if len(storage.objects) != 1 {
+		t.Errorf("storage holds %d objects, want 1", len(storage.objects))
+	}
+
+	// 从断点继续
+	upload, err = uc.WriteChunk(ctx, "u1", 8, &failingReader{data: strings.NewReader("89ab"), err: reset})
+	if !errors.Is(err, reset) || upload.Offset != 12 {
+		t.Fatalf("WriteChunk = %+v, %v, want offset 12", upload, err)
+	}
+	if _, err := uc.WriteChunk(ctx, "u1", 8, strings.NewReader("89ab")); !errors.Is(err, ErrOffsetMismatch) {
+		t.Errorf("WriteChunk at a stale offset error = %v, want ErrOffsetMismatch", err)
+	}
+	if got, _ := uc.GetUpload(ctx, "u1"); got.Offset != 12 || len(got.Chunks) != 2 {
+		t.Errorf("stored upload = %+v, want offset 12 with two chunks", got)
+	}
+}
@@ -0,0 +1,257 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+var ErrVersionNotFound = errors.New("file version not found")
+
+// FileVersion 文件的一个版本。版本创建后内容不再修改，只有扫描状态会更新；
+// 文件记录中的内容字段始终与当前版本相同
+type FileVersion struct {
+	ID           int64
+	FileID       string
+	Version      int32
+	Filename     string // 存储中的对象名
+	OriginalName string
+	Size         int64
+	MimeType     string
+	ContentHash  string
+	Status       string
+	ScanResult   string
+	RestoredFrom int32 // 通过恢复旧版本创建时，被恢复的版本号
+	UserID       int64
+	CreatedAt    time.Time
+}
+
+// UploadVersion 上传已有文件的新版本，校验规则与 UploadStream 相同。新版本成为当前版本，扫描通过前不能下载或解析。
+// 组织所有者和管理员可以为组织中其他成员的文件上传新版本，版本计入文件上传者的配额
+func (uc *FileUsecase) UploadVersion(ctx context.Context, in *UploadInput) (*v1.UploadVersionReply, error) {
+	// 先确认文件存在且可以修改，避免无效的传输
+	scope := Scope{UserID: in.UserID, OrgID: auth.OrgID(ctx), OrgRole: auth.OrgRole(ctx)}
+	existing, err := uc.repo.FindByID(ctx, in.FileID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.CanModify(existing) {
+		return nil, ErrPermissionDenied
+	}
+	scope = scope.Writable()
+	owned := *in
+	owned.UserID = existing.UserID
+	in = &owned
+
+	stored, err := uc.storeContent(ctx, in, false)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &FileVersion{
+		Filename:     stored.key,
+		OriginalName: in.Filename,
+		Size:         stored.size,
+		MimeType:     stored.mimeType,
+		ContentHash:  stored.hash,
+		Status:       FileStatusScanning,
+		UserID:       in.UserID,
+	}
+	file, err := uc.repo.AddVersion(ctx, in.FileID, scope, version)
+	if err != nil {
+		if !errors.Is(err, ErrFileNotFound) {
+			uc.log.WithContext(ctx).Errorf("failed to save file version: %v", err)
+		}
+		// 释放对内容的引用和预占的配额
+		uc.releaseContent(ctx, version.ContentHash, version.Filename)
+		uc.quotas.ReleaseVersion(ctx, in.UserID, version.Size)
+		return nil, err
+	}
+
+	uc.scans.Submit(file)
+	uc.log.WithContext(ctx).Infof("file version uploaded: file_id=%s, version=%d, hash=%s", file.FileID, version.Version, version.ContentHash)
+	return &v1.UploadVersionReply{
+		File:    uc.toProtoFileInfo(file),
+		Version: toProtoFileVersion(version, file.Version),
+	}, nil
+}
+
+// ListVersions 获取文件的所有版本
+func (uc *FileUsecase) ListVersions(ctx context.Context, req *v1.ListFileVersionsRequest) (*v1.ListFileVersionsReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	file, err := uc.repo.FindByID(ctx, req.FileId, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := uc.repo.ListVersions(ctx, file.FileID)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to list file versions: %v", err)
+		return nil, err
+	}
+
+	reply := &v1.ListFileVersionsReply{Versions: make([]*v1.FileVersion, len(versions))}
+	for i, version := range versions {
+		reply.Versions[i] = toProtoFileVersion(version, file.Version)
+	}
+	return reply, nil
+}
+
+// DownloadVersion 下载文件的指定版本，只有通过扫描的版本可以下载
+func (uc *FileUsecase) DownloadVersion(ctx context.Context, req *v1.DownloadFileVersionRequest) (*v1.DownloadFileReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	file, err := uc.StatContent(ctx, req.FileId, scope, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := uc.storage.Download(ctx, file.Filename)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to download file version: %v", err)
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("failed to read file content: %v", err)
+		return nil, fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	return &v1.DownloadFileReply{
+		Content:  content,
+		Filename: file.OriginalName,
+		MimeType: file.MimeType,
+	}, nil
+}
+
+// RestoreVersion 以旧版本的内容创建一个新版本并设为当前版本。内容不重新上传，只增加引用；
+// 被恢复的版本尚未通过扫描时重新扫描，包含恶意内容的版本不能恢复。新版本计入文件上传者的配额，
+// 组织所有者和管理员可以恢复组织中其他成员的文件
+func (uc *FileUsecase) RestoreVersion(ctx context.Context, req *v1.RestoreFileVersionRequest) (*v1.RestoreFileVersionReply, error) {
+	scope, err := ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := uc.repo.FindByID(ctx, req.FileId, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.CanModify(existing) {
+		return nil, ErrPermissionDenied
+	}
+	scope = scope.Writable()
+	userID := existing.UserID
+	source, err := uc.repo.FindVersion(ctx, req.FileId, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	if source.Status == FileStatusInfected {
+		return nil, ErrFileNotClean
+	}
+
+	// 内容寻址之前上传的版本没有引用计数，多个版本共用同一个存储对象
+	if source.ContentHash != "" {
+		blob := &Blob{Hash: source.ContentHash, StorageKey: source.Filename, Size: source.Size}
+		if _, err := uc.blobs.Acquire(ctx, blob, func(ctx context.Context) error {
+			// 引用记录缺失时内容仍应在存储中，由一致性检查修复记录
+			exists, err := uc.storage.Exists(ctx, source.Filename)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("content of version %d is missing from storage", source.Version)
+			}
+			return nil
+		}); err != nil {
+			uc.log.WithContext(ctx).Errorf("failed to acquire blob %s: %v", source.ContentHash, err)
+			return nil, fmt.Errorf("failed to restore file version: %w", err)
+		}
+	}
+	release := func() {
+		if source.ContentHash != "" {
+			uc.releaseContent(ctx, source.ContentHash, source.Filename)
+		}
+	}
+
+	if err := uc.quotas.ReserveVersion(ctx, userID, source.Size); err != nil {
+		if !errors.Is(err, ErrQuotaExceeded) {
+			uc.log.WithContext(ctx).Errorf("failed to reserve storage quota: %v", err)
+		}
+		release()
+		return nil, err
+	}
+
+	version := &FileVersion{
+		Filename:     source.Filename,
+		OriginalName: source.OriginalName,
+		Size:         source.Size,
+		MimeType:     source.MimeType,
+		ContentHash:  source.ContentHash,
+		Status:       source.Status,
+		ScanResult:   source.ScanResult,
+		RestoredFrom: source.Version,
+		UserID:       userID,
+	}
+	file, err := uc.repo.AddVersion(ctx, req.FileId, scope, version)
+	if err != nil {
+		if !errors.Is(err, ErrFileNotFound) {
+			uc.log.WithContext(ctx).Errorf("failed to save file version: %v", err)
+		}
+		release()
+		uc.quotas.ReleaseVersion(ctx, userID, version.Size)
+		return nil, err
+	}
+
+	if file.Status != FileStatusClean {
+		uc.scans.Submit(file)
+	}
+	uc.log.WithContext(ctx).Infof("file version restored: file_id=%s, version=%d, restored_from=%d", file.FileID, version.Version, source.Version)
+	return &v1.RestoreFileVersionReply{
+		File:    uc.toProtoFileInfo(file),
+		Version: toProtoFileVersion(version, file.Version),
+	}, nil
+}
+
+// fileAtVersion 返回内容字段替换为指定版本的文件副本
+func fileAtVersion(file *File, version *FileVersion) *File {
+	at := *file
+	at.Filename = version.Filename
+	at.OriginalName = version.OriginalName
+	at.Size = version.Size
+	at.MimeType = version.MimeType
+	at.ContentHash = version.ContentHash
+	at.Status = version.Status
+	at.ScanResult = version.ScanResult
+	at.Version = version.Version
+	return &at
+}
+
+// toProtoFileVersion 转换为proto文件版本，current 为文件的当前版本号
+func toProtoFileVersion(version *FileVersion, current int32) *v1.FileVersion {
+	return &v1.FileVersion{
+		Version:      version.Version,
+		OriginalName: version.OriginalName,
+		Size:         version.Size,
+		MimeType:     version.MimeType,
+		ContentHash:  version.ContentHash,
+		Status:       version.Status,
+		ScanResult:   version.ScanResult,
+		RestoredFrom: version.RestoredFrom,
+		Current:      version.Version == current,
+		CreatedAt:    timestamppb.New(version.CreatedAt),
+	}
+}
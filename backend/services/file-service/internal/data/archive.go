@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// FileBatchModel 压缩包批次数据模型
+type FileBatchModel struct {
+	ID          uint   `gorm:"primarykey"`
+	BatchID     string `gorm:"uniqueIndex;size:36;not null"`
+	UserID      int64  `gorm:"not null;index"`
+	ArchiveName string `gorm:"size:255"`
+	CreatedAt   time.Time
+}
+
+func (FileBatchModel) TableName() string {
+	return "file_batches"
+}
+
+// BatchEntryModel 压缩包条目数据模型
+type BatchEntryModel struct {
+	ID          uint   `gorm:"primarykey"`
+	BatchID     string `gorm:"size:36;not null;index"`
+	UserID      int64  `gorm:"not null"`
+	Name        string `gorm:"size:500;not null"`
+	Status      string `gorm:"size:20;not null"`
+	Reason      string `gorm:"size:500"`
+	FileID      string `gorm:"size:100;index"`
+	ParseStatus string `gorm:"size:20;index"`
+	ParseTaskID string `gorm:"size:64"`
+	ParseError  string `gorm:"size:1000"`
+	// ParseAttempts 解析超时后重新提交的次数
+	ParseAttempts int32 `gorm:"not null;default:0"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time `gorm:"index"`
+}
+
+func (BatchEntryModel) TableName() string {
+	return "file_batch_entries"
+}
+
+type batchRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewBatchRepo .
+func NewBatchRepo(data *Data, logger log.Logger) biz.BatchRepo {
+	return &batchRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *batchRepo) CreateBatch(ctx context.Context, batch *biz.Batch) error {
+	model := &FileBatchModel{
+		BatchID:     batch.BatchID,
+		UserID:      batch.UserID,
+		ArchiveName: batch.ArchiveName,
+	}
+	if err := r.data.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	batch.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (r *batchRepo) AddEntry(ctx context.Context, entry *biz.BatchEntry) error {
+	model := &BatchEntryModel{
+		BatchID:     entry.BatchID,
+		UserID:      entry.UserID,
+		Name:        truncate(entry.Name, 500),
+		Status:      entry.Status,
+		Reason:      truncate(entry.Reason, 500),
+		FileID:      entry.FileID,
+		ParseStatus: entry.ParseStatus,
+	}
+	if err := r.data.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	entry.ID = int64(model.ID)
+	entry.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *batchRepo) FindBatch(ctx context.Context, batchID string, userID int64) (*biz.Batch, error) {
+	var model FileBatchModel
+	if err := r.data.db.WithContext(ctx).Where("batch_id = ? AND user_id = ?", batchID, userID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, biz.ErrBatchNotFound
+		}
+		return nil, err
+	}
+
+	var entries []BatchEntryModel
+	if err := r.data.db.WithContext(ctx).Where("batch_id = ?", batchID).Order("id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	batch := &biz.Batch{
+		BatchID:     model.BatchID,
+		UserID:      model.UserID,
+		ArchiveName: model.ArchiveName,
+		CreatedAt:   model.CreatedAt,
+		Entries:     make([]*biz.BatchEntry, len(entries)),
+	}
+	for i := range entries {
+		batch.Entries[i] = toBizBatchEntry(&entries[i])
+	}
+	return batch, nil
+}
+
+// ListDispatchable 关联文件表，只返回文件已扫描结束、已删除或不存在的条目，仍在扫描的文件不占用批次
+func (r *batchRepo) ListDispatchable(ctx context.Context, limit int) ([]*biz.BatchEntry, error) {
+	var models []BatchEntryModel
+	if err := r.data.db.WithContext(ctx).Table("file_batch_entries AS e").
+		Select("e.*").
+		Joins("LEFT JOIN files AS f ON f.file_id = e.file_id").
+		Where("e.parse_status = ?", biz.ParseWaiting).
+		Where("f.id IS NULL OR f.deleted_at IS NOT NULL OR f.status IN ?", []string{biz.FileStatusClean, biz.FileStatusInfected}).
+		Order("e.id").Limit(limit).
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]*biz.BatchEntry, len(models))
+	for i := range models {
+		entries[i] = toBizBatchEntry(&models[i])
+	}
+	return entries, nil
+}
+
+func (r *batchRepo) UpdateParseStatus(ctx context.Context, fileID string, from []string, status, taskID, errMsg string) (bool, error) {
+	updates := map[string]interface{}{
+		"parse_status": status,
+		"parse_error":  truncate(errMsg, 1000),
+	}
+	if taskID != "" {
+		updates["parse_task_id"] = taskID
+	}
+	result := r.data.db.WithContext(ctx).Model(&BatchEntryModel{}).
+		Where("file_id = ? AND parse_status IN ?", fileID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ResetStaleParses 先将超过重新提交次数的 queued 条目和 processing 条目记为失败，再将其余 queued 条目放回等待状态。
+// 两步都是带状态和更新时间条件的更新，与解析结果的回写并发时不会覆盖已经更新的条目
+func (r *batchRepo) ResetStaleParses(ctx context.Context, updatedBefore time.Time, maxAttempts int32, errMsg string) (int64, int64, error) {
+	db := r.data.db.WithContext(ctx)
+	result := db.Model(&BatchEntryModel{}).
+		Where("updated_at < ?", updatedBefore).
+		Where("parse_status = ? OR (parse_status = ? AND parse_attempts >= ?)", biz.ParseProcessing, biz.ParseQueued, maxAttempts).
+		Updates(map[string]interface{}{
+			"parse_status": biz.ParseFailed,
+			"parse_error":  truncate(errMsg, 1000),
+		})
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	failed := result.RowsAffected
+
+	result = db.Model(&BatchEntryModel{}).
+		Where("updated_at < ? AND parse_status = ? AND parse_attempts < ?", updatedBefore, biz.ParseQueued, maxAttempts).
+		Updates(map[string]interface{}{
+			"parse_status":   biz.ParseWaiting,
+			"parse_attempts": gorm.Expr("parse_attempts + 1"),
+		})
+	if result.Error != nil {
+		return 0, failed, result.Error
+	}
+	return result.RowsAffected, failed, nil
+}
+
+func toBizBatchEntry(model *BatchEntryModel) *biz.BatchEntry {
+	return &biz.BatchEntry{
+		ID:            int64(model.ID),
+		BatchID:       model.BatchID,
+		UserID:        model.UserID,
+		Name:          model.Name,
+		Status:        model.Status,
+		Reason:        model.Reason,
+		FileID:        model.FileID,
+		ParseStatus:   model.ParseStatus,
+		ParseTaskID:   model.ParseTaskID,
+		ParseError:    model.ParseError,
+		ParseAttempts: model.ParseAttempts,
+		UpdatedAt:     model.UpdatedAt,
+	}
+}
+
+// truncate 按字符截断，避免超过列宽
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
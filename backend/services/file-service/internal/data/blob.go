@@ -0,0 +1,244 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// BlobModel 内容寻址存储数据模型
+type BlobModel struct {
+	Hash       string `gorm:"primaryKey;size:64"`
+	StorageKey string `gorm:"size:255;not null"`
+	Size       int64  `gorm:"not null"`
+	RefCount   int64  `gorm:"not null;default:0"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (BlobModel) TableName() string {
+	return "file_blobs"
+}
+
+// errBlobCreateConflict 并发上传相同内容时创建记录冲突，重试即可
+var errBlobCreateConflict = errors.New("blob create conflict")
+
+type blobRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewBlobRepo .
+func NewBlobRepo(data *Data, logger log.Logger) biz.BlobRepo {
+	return &blobRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *blobRepo) Acquire(ctx context.Context, blob *biz.Blob, store func(ctx context.Context) error) (bool, error) {
+	existed, err := r.acquire(ctx, blob, store)
+	if errors.Is(err, errBlobCreateConflict) {
+		// 另一个请求已创建了记录，此时一定走增加引用计数的分支
+		existed, err = r.acquire(ctx, blob, store)
+	}
+	return existed, err
+}
+
+// acquire 在事务中锁定记录，保证与 Release 串行执行
+func (r *blobRepo) acquire(ctx context.Context, blob *biz.Blob, store func(ctx context.Context) error) (bool, error) {
+	existed := false
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var model BlobModel
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", blob.Hash).First(&model).Error
+		switch {
+		case err == nil:
+			existed = true
+			// 同时更新 updated_at，一致性检查据此跳过刚被引用、文件记录还未保存的内容
+			return tx.Model(&BlobModel{}).Where("hash = ?", blob.Hash).
+				Update("ref_count", gorm.Expr("ref_count + 1")).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := store(ctx); err != nil {
+				return err
+			}
+			model = BlobModel{
+				Hash:       blob.Hash,
+				StorageKey: blob.StorageKey,
+				Size:       blob.Size,
+				RefCount:   1,
+			}
+			if err := tx.Create(&model).Error; err != nil {
+				r.log.WithContext(ctx).Warnf("failed to create blob %s: %v", blob.Hash, err)
+				return errBlobCreateConflict
+			}
+			return nil
+		default:
+			return err
+		}
+	})
+	return existed, err
+}
+
+func (r *blobRepo) Release(ctx context.Context, hash string, remove func(ctx context.Context) error) error {
+	return r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var model BlobModel
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&model).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if model.RefCount > 1 {
+			return tx.Model(&BlobModel{}).Where("hash = ?", hash).
+				UpdateColumn("ref_count", gorm.Expr("ref_count - 1")).Error
+		}
+
+		if err := tx.Where("hash = ?", hash).Delete(&BlobModel{}).Error; err != nil {
+			return err
+		}
+		// 存储对象删除失败时仍提交事务，残留的对象不再被引用
+		if err := remove(ctx); err != nil {
+			r.log.WithContext(ctx).Warnf("failed to remove blob %s from storage: %v", hash, err)
+		}
+		return nil
+	})
+}
+
+func (r *blobRepo) ListUpdatedBefore(ctx context.Context, after string, before time.Time, limit int) ([]*biz.Blob, error) {
+	var models []BlobModel
+	if err := r.data.db.WithContext(ctx).
+		Where("hash > ? AND updated_at < ?", after, before).
+		Order("hash ASC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	blobs := make([]*biz.Blob, len(models))
+	for i, model := range models {
+		blobs[i] = &biz.Blob{
+			Hash:       model.Hash,
+			StorageKey: model.StorageKey,
+			Size:       model.Size,
+			RefCount:   model.RefCount,
+			CreatedAt:  model.CreatedAt,
+			UpdatedAt:  model.UpdatedAt,
+		}
+	}
+	return blobs, nil
+}
+
+// Reconcile 在锁定记录的事务中统计引用版本数，与 Acquire、Release 串行执行
+func (r *blobRepo) Reconcile(ctx context.Context, hash string, before time.Time, remove func(ctx context.Context) error) (biz.BlobReconcileResult, error) {
+	result := biz.BlobConsistent
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var model BlobModel
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&model).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		// 加锁前刚被 Acquire 引用
+		if !model.UpdatedAt.Before(before) {
+			return nil
+		}
+
+		// 每个版本持有一个引用，文件记录与其当前版本共用同一个引用
+		var files int64
+		if err := tx.Model(&FileVersionModel{}).Where("content_hash = ?", hash).Count(&files).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case files == 0:
+			if err := tx.Where("hash = ?", hash).Delete(&BlobModel{}).Error; err != nil {
+				return err
+			}
+			if err := remove(ctx); err != nil {
+				// 残留的对象由孤立对象检查删除
+				r.log.WithContext(ctx).Warnf("failed to remove blob %s from storage: %v", hash, err)
+			}
+			result = biz.BlobRemoved
+		case files > model.RefCount:
+			if err := tx.Model(&BlobModel{}).Where("hash = ?", hash).Update("ref_count", files).Error; err != nil {
+				return err
+			}
+			result = biz.BlobRepaired
+		case files < model.RefCount:
+			result = biz.BlobOverCounted
+		}
+		return nil
+	})
+	return result, err
+}
+
+// RemoveOrphan 先插入引用计数为0的占位记录再删除对象。之后的 Acquire 锁定该哈希时会等待本事务结束；
+// 已在写入相同内容的 Acquire 持有间隙锁，插入会等到它提交后因主键冲突失败
+func (r *blobRepo) RemoveOrphan(ctx context.Context, hash, storageKey string, remove func(ctx context.Context) error) (bool, error) {
+	var count int64
+	if err := r.data.db.WithContext(ctx).Model(&BlobModel{}).Where("hash = ?", hash).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	removed := false
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		placeholder := &BlobModel{Hash: hash, StorageKey: storageKey}
+		if err := tx.Create(placeholder).Error; err != nil {
+			r.log.WithContext(ctx).Debugf("blob %s is being stored concurrently, skipped: %v", hash, err)
+			return nil
+		}
+		if err := remove(ctx); err != nil {
+			return err
+		}
+		removed = true
+		return tx.Where("hash = ?", hash).Delete(&BlobModel{}).Error
+	})
+	return removed, err
+}
+
+func (r *blobRepo) ListMissing(ctx context.Context, createdBefore time.Time, limit int) ([]string, error) {
+	var hashes []string
+	if err := r.data.db.WithContext(ctx).Model(&FileVersionModel{}).
+		Distinct("file_versions.content_hash").
+		Joins("LEFT JOIN file_blobs ON file_blobs.hash = file_versions.content_hash").
+		Where("file_versions.content_hash <> '' AND file_blobs.hash IS NULL AND file_versions.created_at < ?", createdBefore).
+		Limit(limit).Pluck("file_versions.content_hash", &hashes).Error; err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (r *blobRepo) Recreate(ctx context.Context, hash, storageKey string) error {
+	var stats struct {
+		Files int64
+		Size  int64
+	}
+	if err := r.data.db.WithContext(ctx).Model(&FileVersionModel{}).
+		Select("COUNT(*) AS files, COALESCE(MAX(size), 0) AS size").
+		Where("content_hash = ?", hash).
+		Scan(&stats).Error; err != nil {
+		return err
+	}
+	if stats.Files == 0 {
+		return nil
+	}
+
+	model := &BlobModel{
+		Hash:       hash,
+		StorageKey: storageKey,
+		Size:       stats.Size,
+		RefCount:   stats.Files,
+	}
+	return r.data.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(model).Error
+}
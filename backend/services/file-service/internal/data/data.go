@@ -1,23 +1,27 @@
 package data
 
 import (
+	"context"
 	"time"
 
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(NewData, NewFileRepo, NewStorageRepo)
+var ProviderSet = wire.NewSet(NewData, NewFileRepo, NewStorageRepo, NewTusUploadRepo, NewBlobRepo, NewScanner, NewQuotaRepo, NewBatchRepo, NewParseQueue, NewExportRepo, NewDiscovery, NewExportSources, NewOrganizationRepo, NewRevocations)
 
 // Data .
 type Data struct {
-	db *gorm.DB
+	db  *gorm.DB
+	rdb *redis.Client
 }
 
 // NewData .
@@ -45,13 +49,32 @@ func NewData(c *conf.Data, l log.Logger) (*Data, func(), error) {
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
 	// 自动迁移
-	if err := db.AutoMigrate(&FileModel{}); err != nil {
+	if err := db.AutoMigrate(&FileModel{}, &FileVersionModel{}, &TusUploadModel{}, &BlobModel{}, &StorageUsageModel{}, &FileBatchModel{}, &BatchEntryModel{}, &ExportModel{}); err != nil {
 		helper.Errorf("failed to migrate database: %v", err)
 		return nil, nil, err
 	}
+	if err := backfillVersions(db); err != nil {
+		helper.Errorf("failed to backfill file versions: %v", err)
+		return nil, nil, err
+	}
+
+	// 连接 Redis，用于与解析服务之间的任务队列
+	rdb := redis.NewClient(&redis.Options{
+		Network:      c.GetRedis().GetNetwork(),
+		Addr:         c.GetRedis().GetAddr(),
+		ReadTimeout:  c.GetRedis().GetReadTimeout().AsDuration(),
+		WriteTimeout: c.GetRedis().GetWriteTimeout().AsDuration(),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		// Redis 暂时不可用时不阻止服务启动，解析请求会在恢复后提交
+		helper.Warnf("failed to connect to redis: %v", err)
+	}
 
 	data := &Data{
-		db: db,
+		db:  db,
+		rdb: rdb,
 	}
 
 	cleanup := func() {
@@ -59,7 +82,13 @@ func NewData(c *conf.Data, l log.Logger) (*Data, func(), error) {
 		if sqlDB != nil {
 			sqlDB.Close()
 		}
+		rdb.Close()
 	}
 
 	return data, cleanup, nil
 }
+
+// NewRevocations 访问令牌吊销列表，由 user-service 在登出时写入同一个 Redis
+func NewRevocations(d *Data) *auth.Revocations {
+	return auth.NewRevocations(d.rdb)
+}
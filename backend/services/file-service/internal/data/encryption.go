@@ -0,0 +1,538 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// 加密对象的格式：
+//
+//	头部：magic(7) | version(1) | chunk_size(4) | key_id_len(1) | key_id | wrap_nonce(12) | wrapped_data_key(48)
+//	正文：按 chunk_size 切分的明文块，每块用数据密钥以 AES-256-GCM 单独加密，密文比明文多16字节
+//
+// 块的 nonce 由块序号和是否为最后一块组成，数据密钥每个对象不同，因此 nonce 不会重复；
+// 最后一块的标记可以发现对象在块边界被截断。分块加密使范围读取只需解密涉及的块。
+// 数据密钥由主密钥以 AES-256-GCM 加密，附加数据为头部和对象名，对象之间互换会被发现
+const (
+	encryptionMagic     = "\x00RHENC\x00"
+	encryptionVersion   = 1
+	encryptionChunkSize = 64 * 1024
+	dataKeySize         = 32
+	gcmNonceSize        = 12
+	gcmTagSize          = 16
+	wrappedKeySize      = dataKeySize + gcmTagSize
+	maxKeyIDLength      = 255
+	// maxHeaderSize 头部的最大长度
+	maxHeaderSize = len(encryptionMagic) + 1 + 4 + 1 + maxKeyIDLength + gcmNonceSize + wrappedKeySize
+
+	// maxConcurrentRewraps 同时在后台重新加密的对象数
+	maxConcurrentRewraps = 2
+	// rewrapTimeout 重新加密一个对象的超时
+	rewrapTimeout = 10 * time.Minute
+)
+
+var (
+	errPlaintextObject = errors.New("object is not encrypted")
+	errTruncatedObject = errors.New("encrypted object is truncated")
+)
+
+// encryptedStorage 在任意底层存储之上提供透明的信封加密。加密功能上线前写入的明文对象仍可读取，
+// 读取到明文对象或非当前主密钥加密的对象时在后台用当前主密钥重新加密
+type encryptedStorage struct {
+	biz.StorageRepo
+
+	keys      map[string]cipher.AEAD
+	activeKey string
+
+	rewrapping sync.Map      // 正在重新加密的对象
+	rewrapSem  chan struct{} // 限制同时重新加密的对象数
+	log        *log.Helper
+}
+
+func newEncryptedStorage(storage biz.StorageRepo, config *conf.EncryptionConfig, helper *log.Helper) (*encryptedStorage, error) {
+	keys := make(map[string]cipher.AEAD, len(config.GetKeys()))
+	for _, mk := range config.GetKeys() {
+		id := mk.GetId()
+		if id == "" || len(id) > maxKeyIDLength {
+			return nil, fmt.Errorf("invalid master key id %q", id)
+		}
+		if _, ok := keys[id]; ok {
+			return nil, fmt.Errorf("duplicate master key id %q", id)
+		}
+
+		encoded := mk.GetKey()
+		if mk.GetKeyEnv() != "" {
+			if value := os.Getenv(mk.GetKeyEnv()); value != "" {
+				encoded = value
+			}
+		}
+		if encoded == "" {
+			return nil, fmt.Errorf("master key %q is empty", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("master key %q is not valid base64: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %q must be 32 bytes, got %d", id, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		keys[id] = aead
+	}
+
+	activeKey := config.GetActiveKey()
+	if activeKey == "" {
+		activeKey = config.GetKeys()[0].GetId()
+	}
+	if _, ok := keys[activeKey]; !ok {
+		return nil, fmt.Errorf("active master key %q is not configured", activeKey)
+	}
+	helper.Infof("storage encryption enabled, active master key %s", activeKey)
+
+	return &encryptedStorage{
+		StorageRepo: storage,
+		keys:        keys,
+		activeKey:   activeKey,
+		rewrapSem:   make(chan struct{}, maxConcurrentRewraps),
+		log:         helper,
+	}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Upload 生成新的数据密钥，加密内容后写入底层存储
+func (s *encryptedStorage) Upload(ctx context.Context, filename string, content io.Reader) (string, error) {
+	header, aead, err := s.newHeader(filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.StorageRepo.Upload(ctx, filename, newEncryptReader(header, aead, content)); err != nil {
+		return "", err
+	}
+	return s.GetURL(filename), nil
+}
+
+// GetURL 底层存储的直接链接只能取到密文，加密存储不提供直接链接，下载需经过签名下载链接
+func (s *encryptedStorage) GetURL(filename string) string {
+	return ""
+}
+
+func (s *encryptedStorage) Download(ctx context.Context, filename string) (io.ReadCloser, error) {
+	rc, stale, err := s.open(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		s.scheduleRewrap(filename)
+	}
+	return rc, nil
+}
+
+// DownloadRange 先读取头部，再只读取和解密范围涉及的块
+func (s *encryptedStorage) DownloadRange(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	hrc, err := s.StorageRepo.DownloadRange(ctx, filename, 0, int64(maxHeaderSize))
+	if err != nil {
+		return nil, err
+	}
+	header, err := s.readHeader(bufio.NewReaderSize(hrc, maxHeaderSize), filename)
+	hrc.Close()
+	if errors.Is(err, errPlaintextObject) {
+		s.scheduleRewrap(filename)
+		return s.StorageRepo.DownloadRange(ctx, filename, offset, length)
+	}
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("failed to read encryption header of %s: %v", filename, err)
+		return nil, err
+	}
+	if header.keyID != s.activeKey {
+		s.scheduleRewrap(filename)
+	}
+
+	chunkSize := int64(header.chunkSize)
+	sealedSize := chunkSize + gcmTagSize
+	first := offset / chunkSize
+	sealedOffset := int64(header.size) + first*sealedSize
+	sealedLength := int64(-1)
+	if length > 0 {
+		last := (offset + length - 1) / chunkSize
+		sealedLength = (last - first + 1) * sealedSize
+	}
+
+	rc, err := s.StorageRepo.DownloadRange(ctx, filename, sealedOffset, sealedLength)
+	if err != nil {
+		return nil, err
+	}
+	reader := newDecryptReader(rc, rc, header, uint64(first), length)
+	reader.skip = int(offset - first*chunkSize)
+	return reader, nil
+}
+
+// open 打开对象并返回解密后的内容，stale 表示对象未加密或不是用当前主密钥加密的
+func (s *encryptedStorage) open(ctx context.Context, filename string) (io.ReadCloser, bool, error) {
+	rc, err := s.StorageRepo.Download(ctx, filename)
+	if err != nil {
+		return nil, false, err
+	}
+
+	br := bufio.NewReaderSize(rc, maxHeaderSize)
+	header, err := s.readHeader(br, filename)
+	if errors.Is(err, errPlaintextObject) {
+		return &limitedReadCloser{Reader: br, Closer: rc}, true, nil
+	}
+	if err != nil {
+		rc.Close()
+		s.log.WithContext(ctx).Errorf("failed to read encryption header of %s: %v", filename, err)
+		return nil, false, err
+	}
+	return newDecryptReader(br, rc, header, 0, -1), header.keyID != s.activeKey, nil
+}
+
+// scheduleRewrap 在后台用当前主密钥重新加密对象。同一对象只会有一个任务，并发任务已满时跳过，下次读取时再处理
+func (s *encryptedStorage) scheduleRewrap(filename string) {
+	if _, loaded := s.rewrapping.LoadOrStore(filename, struct{}{}); loaded {
+		return
+	}
+	select {
+	case s.rewrapSem <- struct{}{}:
+	default:
+		s.rewrapping.Delete(filename)
+		return
+	}
+
+	go func() {
+		defer func() {
+			<-s.rewrapSem
+			s.rewrapping.Delete(filename)
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), rewrapTimeout)
+		defer cancel()
+
+		rewrapped, err := s.rewrap(ctx, filename)
+		if err != nil {
+			s.log.WithContext(ctx).Warnf("failed to re-encrypt %s: %v", filename, err)
+			return
+		}
+		if rewrapped {
+			s.log.WithContext(ctx).Infof("re-encrypted %s with master key %s", filename, s.activeKey)
+		}
+	}()
+}
+
+// rewrap 解密对象并用新的数据密钥和当前主密钥重新写入。底层存储的覆盖写入是原子的，
+// 正在读取旧对象的请求不受影响；对象在此期间被删除时重新写入的对象由一致性检查清理
+func (s *encryptedStorage) rewrap(ctx context.Context, filename string) (bool, error) {
+	rc, stale, err := s.open(ctx, filename)
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+	if !stale {
+		return false, nil
+	}
+
+	header, aead, err := s.newHeader(filename)
+	if err != nil {
+		return false, err
+	}
+	if exists, err := s.StorageRepo.Exists(ctx, filename); err != nil || !exists {
+		return false, err
+	}
+	if _, err := s.StorageRepo.Upload(ctx, filename, newEncryptReader(header, aead, rc)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// encryptionHeader 解析后的对象头部
+type encryptionHeader struct {
+	keyID     string
+	chunkSize int
+	size      int // 头部长度
+	aead      cipher.AEAD
+}
+
+// newHeader 生成新的数据密钥，返回用当前主密钥加密数据密钥后的头部
+func (s *encryptedStorage) newHeader(filename string) ([]byte, cipher.AEAD, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := make([]byte, 0, maxHeaderSize)
+	header = append(header, encryptionMagic...)
+	header = append(header, encryptionVersion)
+	header = binary.BigEndian.AppendUint32(header, encryptionChunkSize)
+	header = append(header, byte(len(s.activeKey)))
+	header = append(header, s.activeKey...)
+
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	wrapped := s.keys[s.activeKey].Seal(nil, nonce, dataKey, wrapAAD(header, filename))
+	header = append(header, nonce...)
+	header = append(header, wrapped...)
+	return header, aead, nil
+}
+
+// readHeader 读取并解析头部，用头部记录的主密钥解出数据密钥。对象不以 magic 开头时返回 errPlaintextObject，
+// 且不消耗 r 中的数据
+func (s *encryptedStorage) readHeader(r *bufio.Reader, filename string) (*encryptionHeader, error) {
+	prefix, err := r.Peek(len(encryptionMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if string(prefix) != encryptionMagic {
+		return nil, errPlaintextObject
+	}
+
+	fixed := make([]byte, len(encryptionMagic)+1+4+1)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if version := fixed[len(encryptionMagic)]; version != encryptionVersion {
+		return nil, fmt.Errorf("unsupported encryption version %d", version)
+	}
+	chunkSize := int(binary.BigEndian.Uint32(fixed[len(encryptionMagic)+1:]))
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+
+	rest := make([]byte, int(fixed[len(fixed)-1])+gcmNonceSize+wrappedKeySize)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	keyID := string(rest[:fixed[len(fixed)-1]])
+	masterKey, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("object is encrypted with unknown master key %q", keyID)
+	}
+
+	nonce := rest[len(keyID) : len(keyID)+gcmNonceSize]
+	wrapped := rest[len(keyID)+gcmNonceSize:]
+	aad := wrapAAD(append(fixed, keyID...), filename)
+	dataKey, err := masterKey.Open(nil, nonce, wrapped, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key with master key %q: %w", keyID, err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptionHeader{
+		keyID:     keyID,
+		chunkSize: chunkSize,
+		size:      len(fixed) + len(rest),
+		aead:      aead,
+	}, nil
+}
+
+// wrapAAD 加密数据密钥时的附加数据
+func wrapAAD(header []byte, filename string) []byte {
+	aad := make([]byte, 0, len(header)+len(filename))
+	aad = append(aad, header...)
+	return append(aad, filename...)
+}
+
+// chunkNonce 第 index 块的 nonce
+func chunkNonce(nonce []byte, index uint64, last bool) []byte {
+	binary.BigEndian.PutUint64(nonce[3:11], index)
+	nonce[11] = 0
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptReader 输出头部和逐块加密后的内容
+type encryptReader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	pending []byte // 尚未输出的数据
+	plain   []byte // 多读一个字节，用于判断当前块是否为最后一块
+	sealed  []byte
+	nonce   []byte
+	carry   int // plain 开头已读入的下一块的字节数
+	index   uint64
+	done    bool
+}
+
+func newEncryptReader(header []byte, aead cipher.AEAD, src io.Reader) *encryptReader {
+	return &encryptReader{
+		src:     src,
+		aead:    aead,
+		pending: header,
+		plain:   make([]byte, encryptionChunkSize+1),
+		sealed:  make([]byte, 0, encryptionChunkSize+gcmTagSize),
+		nonce:   make([]byte, gcmNonceSize),
+	}
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.seal(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// seal 读取并加密下一块
+func (r *encryptReader) seal() error {
+	n, err := io.ReadFull(r.src, r.plain[r.carry:])
+	last := false
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return err
+	}
+
+	size := r.carry + n
+	if !last {
+		size = encryptionChunkSize
+	}
+	r.pending = r.aead.Seal(r.sealed[:0], chunkNonce(r.nonce, r.index, last), r.plain[:size], nil)
+	if !last {
+		r.plain[0] = r.plain[encryptionChunkSize]
+		r.carry = 1
+	}
+	r.index++
+	r.done = last
+	return nil
+}
+
+// decryptReader 从第 index 块开始逐块解密
+type decryptReader struct {
+	src       io.Reader
+	closer    io.Closer
+	aead      cipher.AEAD
+	index     uint64
+	skip      int   // 第一块中需要跳过的明文字节数
+	remaining int64 // 还需输出的明文字节数，小于0时读到对象末尾
+	sealed    []byte
+	buf       []byte
+	plain     []byte // 尚未输出的明文
+	nonce     []byte
+	last      bool
+}
+
+func newDecryptReader(src io.Reader, closer io.Closer, header *encryptionHeader, index uint64, length int64) *decryptReader {
+	return &decryptReader{
+		src:       src,
+		closer:    closer,
+		aead:      header.aead,
+		index:     index,
+		remaining: length,
+		sealed:    make([]byte, header.chunkSize+gcmTagSize),
+		buf:       make([]byte, 0, header.chunkSize),
+		nonce:     make([]byte, gcmNonceSize),
+	}
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	for len(r.plain) == 0 {
+		if r.last {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.remaining >= 0 && int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	if r.remaining > 0 {
+		r.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+// open 读取并解密下一块。完整长度的块可能是中间块也可能恰好是最后一块，两种 nonce 都尝试
+func (r *decryptReader) open() error {
+	n, err := io.ReadFull(r.src, r.sealed)
+	full := true
+	switch {
+	case errors.Is(err, io.EOF):
+		return errTruncatedObject
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		full = false
+	case err != nil:
+		return err
+	}
+
+	sealed := r.sealed[:n]
+	var plain []byte
+	if full {
+		plain, err = r.aead.Open(r.buf[:0], chunkNonce(r.nonce, r.index, false), sealed, nil)
+	}
+	if !full || err != nil {
+		plain, err = r.aead.Open(r.buf[:0], chunkNonce(r.nonce, r.index, true), sealed, nil)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt chunk %d: %w", r.index, err)
+		}
+		r.last = true
+	}
+
+	if r.skip > 0 {
+		if r.skip > len(plain) {
+			return errTruncatedObject
+		}
+		plain = plain[r.skip:]
+		r.skip = 0
+	}
+	r.plain = plain
+	r.index++
+	return nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.closer.Close()
+}
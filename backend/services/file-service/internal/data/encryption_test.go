@@ -0,0 +1,377 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// memStorage 内存中的底层存储
+type memStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{objects: make(map[string][]byte)}
+}
+
+func (m *memStorage) get(filename string) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.objects[filename]
+}
+
+func (m *memStorage) put(filename string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[filename] = data
+}
+
+func (m *memStorage) Upload(ctx context.Context, filename string, content io.Reader) (string, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	m.put(filename, data)
+	return "mem://" + filename, nil
+}
+
+func (m *memStorage) Download(ctx context.Context, filename string) (io.ReadCloser, error) {
+	return m.DownloadRange(ctx, filename, 0, -1)
+}
+
+func (m *memStorage) DownloadRange(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	data := m.get(filename)
+	if data == nil {
+		return nil, errors.New("not found")
+	}
+	offset = min(offset, int64(len(data)))
+	end := int64(len(data))
+	if length >= 0 {
+		end = min(end, offset+length)
+	}
+	return io.NopCloser(bytes.NewReader(data[offset:end])), nil
+}
+
+func (m *memStorage) Delete(ctx context.Context, filename string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, filename)
+	return nil
+}
+
+func (m *memStorage) GetURL(filename string) string {
+	return "mem://" + filename
+}
+
+func (m *memStorage) Exists(ctx context.Context, filename string) (bool, error) {
+	return m.get(filename) != nil, nil
+}
+
+func (m *memStorage) List(ctx context.Context, prefix string, fn func(obj *biz.StorageObject) error) error {
+	m.mu.Lock()
+	var keys []string
+	for k := range m.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	m.mu.Unlock()
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn(&biz.StorageObject{Key: k, Size: int64(len(m.get(k)))}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testMasterKey(seed byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{seed}, 32))
+}
+
+func newTestEncryptedStorage(t *testing.T, mem *memStorage, active string, ids ...string) *encryptedStorage {
+	t.Helper()
+	config := &conf.EncryptionConfig{ActiveKey: active}
+	for _, id := range ids {
+		config.Keys = append(config.Keys, &conf.EncryptionConfig_MasterKey{Id: id, Key: testMasterKey(id[len(id)-1])})
+	}
+	s, err := newEncryptedStorage(mem, config, log.NewHelper(log.NewStdLogger(io.Discard)))
+	if err != nil {
+		t.Fatalf("newEncryptedStorage: %v", err)
+	}
+	return s
+}
+
+// waitRewraps 等待后台的重新加密任务结束
+func waitRewraps(t *testing.T, s *encryptedStorage) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.rewrapSem) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("background re-encryption did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// testContent 可按偏移校验的内容
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i*7 + i/encryptionChunkSize)
+	}
+	return content
+}
+
+func download(t *testing.T, s *encryptedStorage, filename string) []byte {
+	t.Helper()
+	rc, err := s.Download(context.Background(), filename)
+	return readAllClose(t, rc, err)
+}
+
+func downloadRange(t *testing.T, s *encryptedStorage, filename string, offset, length int64) []byte {
+	t.Helper()
+	rc, err := s.DownloadRange(context.Background(), filename, offset, length)
+	return readAllClose(t, rc, err)
+}
+
+func readAllClose(t *testing.T, rc io.ReadCloser, err error) []byte {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return data
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	sizes := []int{0, 1, encryptionChunkSize - 1, encryptionChunkSize, encryptionChunkSize + 1, 3*encryptionChunkSize + 100}
+	for _, size := range sizes {
+		mem := newMemStorage()
+		s := newTestEncryptedStorage(t, mem, "k1", "k1")
+		content := testContent(size)
+		ctx := context.Background()
+
+		u, err := s.Upload(ctx, "resume.pdf", bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("Upload(%d bytes): %v", size, err)
+		}
+		if u != "" {
+			t.Errorf("Upload url = %q, encrypted objects have no direct url", u)
+		}
+
+		stored := mem.get("resume.pdf")
+		if !bytes.HasPrefix(stored, []byte(encryptionMagic)) {
+			t.Fatalf("stored object of %d bytes is not encrypted", size)
+		}
+		if size >= 16 && bytes.Contains(stored, content[:16]) {
+			t.Errorf("stored object of %d bytes contains plaintext", size)
+		}
+		chunks := max(1, (size+encryptionChunkSize-1)/encryptionChunkSize)
+		if want := 7 + 1 + 4 + 1 + 2 + gcmNonceSize + wrappedKeySize + size + chunks*gcmTagSize; len(stored) != want {
+			t.Errorf("stored size = %d, want %d", len(stored), want)
+		}
+
+		if got := download(t, s, "resume.pdf"); !bytes.Equal(got, content) {
+			t.Errorf("Download of %d bytes returned %d different bytes", size, len(got))
+		}
+	}
+}
+
+func TestEncryptionDownloadRange(t *testing.T) {
+	mem := newMemStorage()
+	s := newTestEncryptedStorage(t, mem, "k1", "k1")
+	content := testContent(3*encryptionChunkSize + 100)
+	if _, err := s.Upload(context.Background(), "resume.pdf", bytes.NewReader(content)); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		offset, length int64
+	}{
+		{"start", 0, 10},
+		{"within a chunk", 100, 1000},
+		{"across a chunk boundary", encryptionChunkSize - 5, 10},
+		{"exactly one chunk", encryptionChunkSize, encryptionChunkSize},
+		{"across several chunks", 10, 2*encryptionChunkSize + 20},
+		{"last byte", int64(len(content)) - 1, 1},
+		{"to end", 2*encryptionChunkSize + 50, -1},
+		{"whole object", 0, -1},
+		{"length past end", int64(len(content)) - 10, 100},
+		{"zero length", 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := content[tt.offset:]
+			if tt.length >= 0 && tt.length < int64(len(want)) {
+				want = want[:tt.length]
+			}
+			got := downloadRange(t, s, "resume.pdf", tt.offset, tt.length)
+			if !bytes.Equal(got, want) {
+				t.Errorf("DownloadRange(%d, %d) returned %d bytes, want %d matching bytes", tt.offset, tt.length, len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	mem := newMemStorage()
+	ctx := context.Background()
+	content := testContent(2*encryptionChunkSize + 10)
+
+	old := newTestEncryptedStorage(t, mem, "k1", "k1")
+	if _, err := old.Upload(ctx, "resume.pdf", bytes.NewReader(content)); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	before := mem.get("resume.pdf")
+
+	// 轮换后旧密钥加密的对象仍可读取，读取时在后台用新密钥重新加密
+	rotated := newTestEncryptedStorage(t, mem, "k2", "k1", "k2")
+	if got := downloadRange(t, rotated, "resume.pdf", encryptionChunkSize-3, 6); !bytes.Equal(got, content[encryptionChunkSize-3:encryptionChunkSize+3]) {
+		t.Errorf("range read with old key id = %v", got)
+	}
+	waitRewraps(t, rotated)
+
+	after := mem.get("resume.pdf")
+	if bytes.Equal(before, after) {
+		t.Fatal("object was not re-encrypted after reading with a rotated key")
+	}
+	if keyID := string(after[len(encryptionMagic)+6 : len(encryptionMagic)+8]); keyID != "k2" {
+		t.Errorf("re-encrypted object key id = %q, want k2", keyID)
+	}
+	if got := download(t, rotated, "resume.pdf"); !bytes.Equal(got, content) {
+		t.Error("content changed after re-encryption")
+	}
+	waitRewraps(t, rotated)
+	if !bytes.Equal(after, mem.get("resume.pdf")) {
+		t.Error("object with the active key was re-encrypted again")
+	}
+
+	// 旧密钥下线后，重新加密过的对象只需要新密钥
+	onlyNew := newTestEncryptedStorage(t, mem, "k2", "k2")
+	if got := download(t, onlyNew, "resume.pdf"); !bytes.Equal(got, content) {
+		t.Error("Download with only the new key failed")
+	}
+	onlyOld := newTestEncryptedStorage(t, mem, "k1", "k1")
+	if _, err := onlyOld.Download(ctx, "resume.pdf"); err == nil || !strings.Contains(err.Error(), `unknown master key "k2"`) {
+		t.Errorf("Download without the key error = %v", err)
+	}
+}
+
+func TestEncryptionPlaintextObject(t *testing.T) {
+	mem := newMemStorage()
+	content := []byte("legacy plaintext resume")
+	mem.put("legacy.pdf", content)
+
+	s := newTestEncryptedStorage(t, mem, "k1", "k1")
+	if got := downloadRange(t, s, "legacy.pdf", 7, 9); string(got) != "plaintext" {
+		t.Errorf("range read of plaintext object = %q", got)
+	}
+	waitRewraps(t, s)
+
+	if !bytes.HasPrefix(mem.get("legacy.pdf"), []byte(encryptionMagic)) {
+		t.Fatal("plaintext object was not encrypted after reading")
+	}
+	if got := download(t, s, "legacy.pdf"); !bytes.Equal(got, content) {
+		t.Errorf("Download after encryption = %q", got)
+	}
+}
+
+func TestEncryptionTampering(t *testing.T) {
+	ctx := context.Background()
+	content := testContent(2 * encryptionChunkSize)
+	headerSize := 7 + 1 + 4 + 1 + 2 + gcmNonceSize + wrappedKeySize
+
+	tests := []struct {
+		name   string
+		modify func(mem *memStorage)
+	}{
+		{
+			name: "truncated at a chunk boundary",
+			modify: func(mem *memStorage) {
+				data := mem.get("resume.pdf")
+				mem.put("resume.pdf", data[:headerSize+encryptionChunkSize+gcmTagSize])
+			},
+		},
+		{
+			name: "flipped ciphertext byte",
+			modify: func(mem *memStorage) {
+				data := bytes.Clone(mem.get("resume.pdf"))
+				data[headerSize+10] ^= 1
+				mem.put("resume.pdf", data)
+			},
+		},
+		{
+			name: "object moved to another name",
+			modify: func(mem *memStorage) {
+				data := mem.get("resume.pdf")
+				mem.put("resume.pdf", mem.get("other.pdf"))
+				mem.put("other.pdf", data)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := newMemStorage()
+			s := newTestEncryptedStorage(t, mem, "k1", "k1")
+			for _, name := range []string{"resume.pdf", "other.pdf"} {
+				if _, err := s.Upload(ctx, name, bytes.NewReader(content)); err != nil {
+					t.Fatalf("Upload: %v", err)
+				}
+			}
+			tt.modify(mem)
+
+			rc, err := s.Download(ctx, "resume.pdf")
+			if err == nil {
+				_, err = io.ReadAll(rc)
+				rc.Close()
+			}
+			if err == nil {
+				t.Fatal("reading a tampered object succeeded")
+			}
+		})
+	}
+}
+
+func TestNewEncryptedStorageInvalid(t *testing.T) {
+	key := testMasterKey(1)
+	tests := map[string]*conf.EncryptionConfig{
+		"empty key id":    {Keys: []*conf.EncryptionConfig_MasterKey{{Key: key}}},
+		"duplicate id":    {Keys: []*conf.EncryptionConfig_MasterKey{{Id: "a", Key: key}, {Id: "a", Key: key}}},
+		"empty key":       {Keys: []*conf.EncryptionConfig_MasterKey{{Id: "a"}}},
+		"invalid base64":  {Keys: []*conf.EncryptionConfig_MasterKey{{Id: "a", Key: "!!"}}},
+		"short key":       {Keys: []*conf.EncryptionConfig_MasterKey{{Id: "a", Key: base64.StdEncoding.EncodeToString([]byte("short"))}}},
+		"unknown active":  {Keys: []*conf.EncryptionConfig_MasterKey{{Id: "a", Key: key}}, ActiveKey: "b"},
+		"key id too long": {Keys: []*conf.EncryptionConfig_MasterKey{{Id: strings.Repeat("a", maxKeyIDLength+1), Key: key}}},
+	}
+	for name, config := range tests {
+		if _, err := newEncryptedStorage(newMemStorage(), config, log.NewHelper(log.NewStdLogger(io.Discard))); err == nil {
+			t.Errorf("%s: newEncryptedStorage succeeded", name)
+		}
+	}
+
+	t.Setenv("TEST_MASTER_KEY", testMasterKey(2))
+	config := &conf.EncryptionConfig{Keys: []*conf.EncryptionConfig_MasterKey{{Id: "env", Key: "ignored", KeyEnv: "TEST_MASTER_KEY"}}}
+	if _, err := newEncryptedStorage(newMemStorage(), config, log.NewHelper(log.NewStdLogger(io.Discard))); err != nil {
+		t.Errorf("newEncryptedStorage with key_env: %v", err)
+	}
+}
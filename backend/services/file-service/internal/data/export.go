@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// ExportModel 用户数据导出任务数据模型
+type ExportModel struct {
+	ID          uint   `gorm:"primarykey"`
+	ExportID    string `gorm:"uniqueIndex;size:36;not null"`
+	UserID      int64  `gorm:"not null;index"`
+	Status      string `gorm:"size:20;not null;index"`
+	StorageKey  string `gorm:"size:255"`
+	Size        int64  `gorm:"not null;default:0"`
+	FileCount   int32  `gorm:"not null;default:0"`
+	RecordCount int32  `gorm:"not null;default:0"`
+	Error       string `gorm:"size:1000"`
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (ExportModel) TableName() string {
+	return "file_exports"
+}
+
+type exportRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewExportRepo .
+func NewExportRepo(data *Data, logger log.Logger) biz.ExportRepo {
+	return &exportRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *exportRepo) Create(ctx context.Context, export *biz.Export) error {
+	model := &ExportModel{
+		ExportID: export.ExportID,
+		UserID:   export.UserID,
+		Status:   export.Status,
+	}
+	if err := r.data.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	export.CreatedAt = model.CreatedAt
+	export.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *exportRepo) Find(ctx context.Context, exportID string, userID int64) (*biz.Export, error) {
+	return r.first(r.data.db.WithContext(ctx).Where("export_id = ? AND user_id = ?", exportID, userID))
+}
+
+func (r *exportRepo) FindActive(ctx context.Context, userID int64) (*biz.Export, error) {
+	return r.first(r.data.db.WithContext(ctx).
+		Where("user_id = ? AND status IN ?", userID, []string{biz.ExportPending, biz.ExportRunning}).
+		Order("id DESC"))
+}
+
+func (r *exportRepo) first(query *gorm.DB) (*biz.Export, error) {
+	var model ExportModel
+	if err := query.First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, biz.ErrExportNotFound
+		}
+		return nil, err
+	}
+	return toBizExport(&model), nil
+}
+
+func (r *exportRepo) ListByStatus(ctx context.Context, status string, updatedBefore time.Time, limit int) ([]*biz.Export, error) {
+	return r.find(r.data.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", status, updatedBefore).
+		Order("id ASC").Limit(limit))
+}
+
+func (r *exportRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]*biz.Export, error) {
+	return r.find(r.data.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", biz.ExportCompleted, before).
+		Order("expires_at ASC").Limit(limit))
+}
+
+func (r *exportRepo) find(query *gorm.DB) ([]*biz.Export, error) {
+	var models []ExportModel
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+	exports := make([]*biz.Export, len(models))
+	for i := range models {
+		exports[i] = toBizExport(&models[i])
+	}
+	return exports, nil
+}
+
+// Update 条件更新，多个实例同时占有或结束同一任务时只有一个成功
+func (r *exportRepo) Update(ctx context.Context, export *biz.Export, from string) (bool, error) {
+	result := r.data.db.WithContext(ctx).Model(&ExportModel{}).
+		Where("export_id = ? AND status = ?", export.ExportID, from).
+		Updates(map[string]interface{}{
+			"status":       export.Status,
+			"storage_key":  export.StorageKey,
+			"size":         export.Size,
+			"file_count":   export.FileCount,
+			"record_count": export.RecordCount,
+			"error":        truncate(export.Error, 1000),
+			"completed_at": optionalTime(export.CompletedAt),
+			"expires_at":   optionalTime(export.ExpiresAt),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// optionalTime 零值时间保存为 NULL
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func toBizExport(model *ExportModel) *biz.Export {
+	export := &biz.Export{
+		ExportID:    model.ExportID,
+		UserID:      model.UserID,
+		Status:      model.Status,
+		StorageKey:  model.StorageKey,
+		Size:        model.Size,
+		FileCount:   model.FileCount,
+		RecordCount: model.RecordCount,
+		Error:       model.Error,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}
+	if model.CompletedAt != nil {
+		export.CompletedAt = *model.CompletedAt
+	}
+	if model.ExpiresAt != nil {
+		export.ExpiresAt = *model.ExpiresAt
+	}
+	return export
+}
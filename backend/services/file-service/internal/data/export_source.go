@@ -0,0 +1,124 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-kratos/kratos/contrib/registry/consul/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/hashicorp/consul/api"
+	ggrpc "google.golang.org/grpc"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	exportv1 "github.com/lyb88999/resume_helper/backend/shared/proto/export"
+)
+
+const (
+	defaultParserServiceEndpoint = "discovery:///parser-service"
+	defaultAIServiceEndpoint     = "discovery:///ai-service"
+)
+
+// NewDiscovery 创建服务发现，用于查找提供导出数据的服务
+func NewDiscovery(c *conf.Bootstrap) (registry.Discovery, error) {
+	consulConfig := api.DefaultConfig()
+	if c.Registry != nil && c.Registry.GetConsul() != nil {
+		consulConfig.Address = c.Registry.GetConsul().Address
+		consulConfig.Scheme = c.Registry.GetConsul().Scheme
+	}
+
+	consulClient, err := api.NewClient(consulConfig)
+	if err != nil {
+		return nil, err
+	}
+	return consul.New(consulClient), nil
+}
+
+// exportSource 通过 UserDataExportService 读取其他服务中的用户数据
+type exportSource struct {
+	name   string
+	client exportv1.UserDataExportServiceClient
+}
+
+// NewExportSources 连接 parser-service 和 ai-service，导出时依次读取解析结果和分析报告。
+// 调用以导出用户的身份携带访问令牌
+func NewExportSources(c *conf.Bootstrap, config *conf.Storage, discovery registry.Discovery, logger log.Logger) (biz.ExportSources, func(), error) {
+	helper := log.NewHelper(logger)
+	targets := []struct {
+		name     string
+		endpoint string
+		fallback string
+	}{
+		{"parse_results", config.GetExport().GetParserServiceEndpoint(), defaultParserServiceEndpoint},
+		{"analysis_reports", config.GetExport().GetAiServiceEndpoint(), defaultAIServiceEndpoint},
+	}
+
+	var sources biz.ExportSources
+	var conns []*ggrpc.ClientConn
+	cleanup := func() {
+		helper.Info("closing the export source connections")
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+	for _, target := range targets {
+		endpoint := target.endpoint
+		if endpoint == "" {
+			endpoint = target.fallback
+		}
+		conn, err := grpc.DialInsecure(
+			context.Background(),
+			grpc.WithEndpoint(endpoint),
+			grpc.WithDiscovery(discovery),
+			grpc.WithOptions(ggrpc.WithPerRPCCredentials(auth.NewCredentials(c.GetAuth().GetJwtSecret()))),
+		)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+		}
+		conns = append(conns, conn)
+		sources = append(sources, &exportSource{
+			name:   target.name,
+			client: exportv1.NewUserDataExportServiceClient(conn),
+		})
+	}
+	return sources, cleanup, nil
+}
+
+func (s *exportSource) Name() string {
+	return s.name
+}
+
+func (s *exportSource) Export(ctx context.Context, userID int64, fileIDs []string, fn func(record *biz.ExportRecord) error) error {
+	stream, err := s.client.ExportUserData(auth.NewContext(ctx, userID), &exportv1.ExportUserDataRequest{
+		FileIds: fileIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		record := &biz.ExportRecord{
+			Name:    msg.Name,
+			Content: msg.Content,
+		}
+		if msg.ModifiedAt != nil {
+			record.ModifiedAt = msg.ModifiedAt.AsTime()
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
@@ -21,8 +21,14 @@ type FileModel struct {
 	URL          string `gorm:"size:500"`
 	Status       string `gorm:"size:50;default:'uploaded'"`
 	UserID       int64  `gorm:"not null;index"`
+	OrgID        int64  `gorm:"not null;default:0;index"` // 所属组织，0表示只属于上传者
+	ContentHash  string `gorm:"size:64;index"`
+	ScanResult   string `gorm:"size:255"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"` // 移入回收站的时间，回收站中的文件不出现在普通查询中
+	BatchID      string         `gorm:"size:36;index"`
+	Version      int32          `gorm:"not null;default:1"` // 当前版本号，内容字段与该版本相同
 }
 
 func (FileModel) TableName() string {
@@ -52,13 +58,26 @@ func (r *fileRepo) Save(ctx context.Context, file *biz.File) (*biz.File, error)
 		URL:          file.URL,
 		Status:       file.Status,
 		UserID:       file.UserID,
+		OrgID:        file.OrgID,
+		ContentHash:  file.ContentHash,
+		ScanResult:   file.ScanResult,
+		BatchID:      file.BatchID,
+		Version:      1,
 	}
 
-	if err := r.data.db.WithContext(ctx).Create(model).Error; err != nil {
+	// 同时创建第一个版本
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		return tx.Create(newVersionModel(model, 0)).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	file.ID = int64(model.ID)
+	file.Version = model.Version
 	file.CreatedAt = model.CreatedAt
 	file.UpdatedAt = model.UpdatedAt
 	return file, nil
@@ -75,6 +94,11 @@ func (r *fileRepo) Update(ctx context.Context, file *biz.File) (*biz.File, error
 		URL:          file.URL,
 		Status:       file.Status,
 		UserID:       file.UserID,
+		OrgID:        file.OrgID,
+		ContentHash:  file.ContentHash,
+		ScanResult:   file.ScanResult,
+		BatchID:      file.BatchID,
+		Version:      file.Version,
 	}
 
 	if err := r.data.db.WithContext(ctx).Save(model).Error; err != nil {
@@ -85,35 +109,23 @@ func (r *fileRepo) Update(ctx context.Context, file *biz.File) (*biz.File, error
 	return file, nil
 }
 
-func (r *fileRepo) FindByID(ctx context.Context, fileID string, userID int64) (*biz.File, error) {
+func (r *fileRepo) FindByID(ctx context.Context, fileID string, scope biz.Scope) (*biz.File, error) {
 	var model FileModel
-	if err := r.data.db.WithContext(ctx).Where("file_id = ? AND user_id = ?", fileID, userID).First(&model).Error; err != nil {
+	if err := inScope(r.data.db.WithContext(ctx), scope).Where("file_id = ?", fileID).First(&model).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, biz.ErrFileNotFound
 		}
 		return nil, err
 	}
 
-	return &biz.File{
-		ID:           int64(model.ID),
-		FileID:       model.FileID,
-		Filename:     model.Filename,
-		OriginalName: model.OriginalName,
-		Size:         model.Size,
-		MimeType:     model.MimeType,
-		URL:          model.URL,
-		Status:       model.Status,
-		UserID:       model.UserID,
-		CreatedAt:    model.CreatedAt,
-		UpdatedAt:    model.UpdatedAt,
-	}, nil
+	return toBizFile(&model), nil
 }
 
 func (r *fileRepo) List(ctx context.Context, req *biz.ListFilesRequest) ([]*biz.File, int64, error) {
 	var models []FileModel
 	var total int64
 
-	query := r.data.db.WithContext(ctx).Model(&FileModel{}).Where("user_id = ?", req.UserID)
+	query := inScope(r.data.db.WithContext(ctx).Model(&FileModel{}), req.Scope)
 
 	// 添加筛选条件
 	if req.Type != "" {
@@ -141,27 +153,140 @@ func (r *fileRepo) List(ctx context.Context, req *biz.ListFilesRequest) ([]*biz.
 	}
 
 	files := make([]*biz.File, len(models))
-	for i, model := range models {
-		files[i] = &biz.File{
-			ID:           int64(model.ID),
-			FileID:       model.FileID,
-			Filename:     model.Filename,
-			OriginalName: model.OriginalName,
-			Size:         model.Size,
-			MimeType:     model.MimeType,
-			URL:          model.URL,
-			Status:       model.Status,
-			UserID:       model.UserID,
-			CreatedAt:    model.CreatedAt,
-			UpdatedAt:    model.UpdatedAt,
+	for i := range models {
+		files[i] = toBizFile(&models[i])
+	}
+
+	return files, total, nil
+}
+
+func (r *fileRepo) Delete(ctx context.Context, fileID string, scope biz.Scope) error {
+	result := inScope(r.data.db.WithContext(ctx), scope).Where("file_id = ?", fileID).Delete(&FileModel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrFileNotFound
+	}
+	return nil
+}
+
+func (r *fileRepo) FindByHash(ctx context.Context, userID int64, hash string) (*biz.File, error) {
+	var model FileModel
+	if err := r.data.db.WithContext(ctx).Where("user_id = ? AND content_hash = ?", userID, hash).Order("created_at DESC").First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, biz.ErrFileNotFound
 		}
+		return nil, err
 	}
 
+	return toBizFile(&model), nil
+}
+
+// UpdateStatus 同时更新该内容对应的版本，扫描期间上传了新版本时只有版本记录被更新
+func (r *fileRepo) UpdateStatus(ctx context.Context, fileID, hash, from, to, scanResult string) error {
+	updates := map[string]interface{}{"status": to, "scan_result": scanResult}
+	return r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		files := tx.Model(&FileModel{}).
+			Where("file_id = ? AND content_hash = ? AND status = ?", fileID, hash, from).
+			Updates(updates)
+		if files.Error != nil {
+			return files.Error
+		}
+		versions := tx.Model(&FileVersionModel{}).
+			Where("file_id = ? AND content_hash = ? AND status = ?", fileID, hash, from).
+			Updates(updates)
+		if versions.Error != nil {
+			return versions.Error
+		}
+		if files.RowsAffected == 0 && versions.RowsAffected == 0 {
+			return biz.ErrFileNotFound
+		}
+		return nil
+	})
+}
+
+// UpdateStatusByHash 回收站中的文件和历史版本同样更新，恢复后也不能下载恶意内容
+func (r *fileRepo) UpdateStatusByHash(ctx context.Context, hash, status, scanResult string) error {
+	updates := map[string]interface{}{"status": status, "scan_result": scanResult}
+	return r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&FileModel{}).Where("content_hash = ?", hash).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&FileVersionModel{}).Where("content_hash = ?", hash).Updates(updates).Error
+	})
+}
+
+func (r *fileRepo) ListByStatus(ctx context.Context, status string, updatedBefore time.Time, limit int) ([]*biz.File, error) {
+	var models []FileModel
+	if err := r.data.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", status, updatedBefore).
+		Order("updated_at ASC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	files := make([]*biz.File, len(models))
+	for i := range models {
+		files[i] = toBizFile(&models[i])
+	}
+	return files, nil
+}
+
+func (r *fileRepo) FindTrashed(ctx context.Context, fileID string, scope biz.Scope) (*biz.File, error) {
+	var model FileModel
+	if err := inScope(r.data.db.WithContext(ctx).Unscoped(), scope).
+		Where("file_id = ? AND deleted_at IS NOT NULL", fileID).
+		First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, biz.ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	return toBizFile(&model), nil
+}
+
+func (r *fileRepo) ListTrashed(ctx context.Context, scope biz.Scope, page, perPage int) ([]*biz.File, int64, error) {
+	var models []FileModel
+	var total int64
+
+	query := inScope(r.data.db.WithContext(ctx).Unscoped().Model(&FileModel{}), scope).
+		Where("deleted_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Offset(offset).Limit(perPage).Order("deleted_at DESC").Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+
+	files := make([]*biz.File, len(models))
+	for i := range models {
+		files[i] = toBizFile(&models[i])
+	}
 	return files, total, nil
 }
 
-func (r *fileRepo) Delete(ctx context.Context, fileID string, userID int64) error {
-	result := r.data.db.WithContext(ctx).Where("file_id = ? AND user_id = ?", fileID, userID).Delete(&FileModel{})
+func (r *fileRepo) ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*biz.File, error) {
+	var models []FileModel
+	if err := r.data.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	files := make([]*biz.File, len(models))
+	for i := range models {
+		files[i] = toBizFile(&models[i])
+	}
+	return files, nil
+}
+
+func (r *fileRepo) Restore(ctx context.Context, fileID string, scope biz.Scope) error {
+	result := inScope(r.data.db.WithContext(ctx).Unscoped().Model(&FileModel{}), scope).
+		Where("file_id = ? AND deleted_at IS NOT NULL", fileID).
+		Update("deleted_at", nil)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -170,3 +295,53 @@ func (r *fileRepo) Delete(ctx context.Context, fileID string, userID int64) erro
 	}
 	return nil
 }
+
+// Purge 带条件的删除保证与 Restore 和其他 Purge 互斥，只有一个调用会删除成功，版本记录一并删除
+func (r *fileRepo) Purge(ctx context.Context, fileID string, trashedBefore time.Time) error {
+	return r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().
+			Where("file_id = ? AND deleted_at IS NOT NULL AND deleted_at <= ?", fileID, trashedBefore).
+			Delete(&FileModel{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return biz.ErrFileNotFound
+		}
+		return tx.Where("file_id = ?", fileID).Delete(&FileVersionModel{}).Error
+	})
+}
+
+// inScope 限定为访问范围内的文件：用户上传的文件，以及用户所在组织的文件
+func inScope(db *gorm.DB, scope biz.Scope) *gorm.DB {
+	if scope.OrgID > 0 {
+		return db.Where("(user_id = ? OR org_id = ?)", scope.UserID, scope.OrgID)
+	}
+	return db.Where("user_id = ?", scope.UserID)
+}
+
+func toBizFile(model *FileModel) *biz.File {
+	var deletedAt time.Time
+	if model.DeletedAt.Valid {
+		deletedAt = model.DeletedAt.Time
+	}
+	return &biz.File{
+		ID:           int64(model.ID),
+		FileID:       model.FileID,
+		Filename:     model.Filename,
+		OriginalName: model.OriginalName,
+		Size:         model.Size,
+		MimeType:     model.MimeType,
+		URL:          model.URL,
+		Status:       model.Status,
+		UserID:       model.UserID,
+		OrgID:        model.OrgID,
+		ContentHash:  model.ContentHash,
+		ScanResult:   model.ScanResult,
+		CreatedAt:    model.CreatedAt,
+		UpdatedAt:    model.UpdatedAt,
+		DeletedAt:    deletedAt,
+		BatchID:      model.BatchID,
+		Version:      model.Version,
+	}
+}
@@ -0,0 +1,83 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	ggrpc "google.golang.org/grpc"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	orgv1 "github.com/lyb88999/resume_helper/backend/shared/proto/organization"
+)
+
+const (
+	defaultUserServiceEndpoint = "discovery:///user-service"
+	// orgSettingsTTL 组织设置的缓存时间，压缩包中的每个文件上传时都要检查文件类型
+	orgSettingsTTL = 30 * time.Second
+)
+
+type cachedOrgSettings struct {
+	allowedTypes []string
+	expiresAt    time.Time
+}
+
+// organizationRepo 通过 user-service 的 OrganizationSettingsService 读取组织设置
+type organizationRepo struct {
+	client orgv1.OrganizationSettingsServiceClient
+	mu     sync.Mutex
+	cache  map[int64]*cachedOrgSettings
+	log    *log.Helper
+}
+
+// NewOrganizationRepo 连接 user-service，以当前用户的身份读取其所在组织的设置
+func NewOrganizationRepo(c *conf.Bootstrap, config *conf.Storage, discovery registry.Discovery, logger log.Logger) (biz.OrganizationRepo, func(), error) {
+	helper := log.NewHelper(logger)
+	endpoint := config.GetUserServiceEndpoint()
+	if endpoint == "" {
+		endpoint = defaultUserServiceEndpoint
+	}
+	conn, err := grpc.DialInsecure(
+		context.Background(),
+		grpc.WithEndpoint(endpoint),
+		grpc.WithDiscovery(discovery),
+		grpc.WithOptions(ggrpc.WithPerRPCCredentials(auth.NewCredentials(c.GetAuth().GetJwtSecret()))),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+	}
+	cleanup := func() {
+		helper.Info("closing the user-service connection")
+		conn.Close()
+	}
+	return &organizationRepo{
+		client: orgv1.NewOrganizationSettingsServiceClient(conn),
+		cache:  make(map[int64]*cachedOrgSettings),
+		log:    helper,
+	}, cleanup, nil
+}
+
+func (r *organizationRepo) AllowedFileTypes(ctx context.Context, orgID int64) ([]string, error) {
+	now := time.Now()
+	r.mu.Lock()
+	cached, ok := r.cache[orgID]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.allowedTypes, nil
+	}
+
+	settings, err := r.client.GetOrganizationSettings(ctx, &orgv1.GetOrganizationSettingsRequest{OrgId: orgID})
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.cache[orgID] = &cachedOrgSettings{allowedTypes: settings.AllowedFileTypes, expiresAt: now.Add(orgSettingsTTL)}
+	r.mu.Unlock()
+	return settings.AllowedFileTypes, nil
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	defaultParseQueue  = "parser_tasks"
+	defaultResultQueue = "parser_results"
+)
+
+// parseQueue 基于 Redis 列表的解析任务队列：请求 LPUSH 到请求队列，由解析服务 BRPOP 消费；
+// 解析服务将任务状态 LPUSH 到结果队列
+type parseQueue struct {
+	rdb         *redis.Client
+	queue       string
+	resultQueue string
+	log         *log.Helper
+}
+
+// NewParseQueue .
+func NewParseQueue(data *Data, config *conf.Storage, logger log.Logger) biz.ParseQueue {
+	queue := config.GetArchive().GetParseQueue()
+	if queue == "" {
+		queue = defaultParseQueue
+	}
+	resultQueue := config.GetArchive().GetResultQueue()
+	if resultQueue == "" {
+		resultQueue = defaultResultQueue
+	}
+	return &parseQueue{
+		rdb:         data.rdb,
+		queue:       queue,
+		resultQueue: resultQueue,
+		log:         log.NewHelper(logger),
+	}
+}
+
+func (q *parseQueue) Publish(ctx context.Context, req *biz.ParseRequest) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return q.rdb.LPush(ctx, q.queue, payload).Err()
+}
+
+func (q *parseQueue) NextResult(ctx context.Context, wait time.Duration) (*biz.ParseResult, error) {
+	values, err := q.rdb.BRPop(ctx, wait, q.resultQueue).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// BRPOP 返回 [队列名, 值]
+	var result biz.ParseResult
+	if err := json.Unmarshal([]byte(values[1]), &result); err != nil {
+		return nil, fmt.Errorf("invalid parse result %q: %w", values[1], err)
+	}
+	return &result, nil
+}
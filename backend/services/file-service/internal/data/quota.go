@@ -0,0 +1,169 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// StorageUsageModel 用户存储用量数据模型
+type StorageUsageModel struct {
+	UserID    int64  `gorm:"primaryKey;autoIncrement:false"`
+	Plan      string `gorm:"size:50"`
+	UsedBytes int64  `gorm:"not null;default:0"`
+	FileCount int64  `gorm:"not null;default:0"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (StorageUsageModel) TableName() string {
+	return "user_storage_usage"
+}
+
+type quotaRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewQuotaRepo .
+func NewQuotaRepo(data *Data, logger log.Logger) biz.QuotaRepo {
+	return &quotaRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *quotaRepo) Get(ctx context.Context, userID int64) (*biz.StorageUsage, error) {
+	if err := r.ensure(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var model StorageUsageModel
+	if err := r.data.db.WithContext(ctx).Where("user_id = ?", userID).First(&model).Error; err != nil {
+		return nil, err
+	}
+	return &biz.StorageUsage{
+		UserID:    model.UserID,
+		Plan:      model.Plan,
+		UsedBytes: model.UsedBytes,
+		FileCount: model.FileCount,
+	}, nil
+}
+
+// Reserve 通过带条件的 UPDATE 原子地检查并增加用量，并发上传不会超出配额
+func (r *quotaRepo) Reserve(ctx context.Context, userID, bytes, files int64, limits biz.QuotaLimits) error {
+	if err := r.ensure(ctx, userID); err != nil {
+		return err
+	}
+
+	query := r.data.db.WithContext(ctx).Model(&StorageUsageModel{}).Where("user_id = ?", userID)
+	if limits.MaxBytes > 0 {
+		query = query.Where("used_bytes + ? <= ?", bytes, limits.MaxBytes)
+	}
+	if limits.MaxFiles > 0 {
+		query = query.Where("file_count + ? <= ?", files, limits.MaxFiles)
+	}
+	result := query.Updates(map[string]interface{}{
+		"used_bytes": gorm.Expr("used_bytes + ?", bytes),
+		"file_count": gorm.Expr("file_count + ?", files),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (r *quotaRepo) Release(ctx context.Context, userID, bytes, files int64) error {
+	return r.data.db.WithContext(ctx).Model(&StorageUsageModel{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"used_bytes": gorm.Expr("GREATEST(used_bytes - ?, 0)", bytes),
+			"file_count": gorm.Expr("GREATEST(file_count - ?, 0)", files),
+		}).Error
+}
+
+func (r *quotaRepo) SetPlan(ctx context.Context, userID int64, plan string) error {
+	if err := r.ensure(ctx, userID); err != nil {
+		return err
+	}
+	return r.data.db.WithContext(ctx).Model(&StorageUsageModel{}).Where("user_id = ?", userID).
+		Update("plan", plan).Error
+}
+
+func (r *quotaRepo) UsageByType(ctx context.Context, userID int64) ([]*biz.TypeUsage, error) {
+	var rows []struct {
+		MimeType  string
+		Bytes     int64
+		FileCount int64
+	}
+	// 所有版本都占用空间，按版本的类型统计
+	if err := r.data.db.WithContext(ctx).Model(&FileVersionModel{}).
+		Select("file_versions.mime_type, SUM(file_versions.size) AS bytes, COUNT(DISTINCT file_versions.file_id) AS file_count").
+		Joins("JOIN files ON files.file_id = file_versions.file_id").
+		Where("files.user_id = ? AND files.deleted_at IS NULL", userID).
+		Group("file_versions.mime_type").Order("bytes DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	usages := make([]*biz.TypeUsage, len(rows))
+	for i, row := range rows {
+		usages[i] = &biz.TypeUsage{MimeType: row.MimeType, Bytes: row.Bytes, FileCount: row.FileCount}
+	}
+	return usages, nil
+}
+
+func (r *quotaRepo) TrashUsage(ctx context.Context, userID int64) (*biz.TypeUsage, error) {
+	var usage biz.TypeUsage
+	if err := r.data.db.WithContext(ctx).Model(&FileVersionModel{}).
+		Select("COALESCE(SUM(file_versions.size), 0) AS bytes, COUNT(DISTINCT file_versions.file_id) AS file_count").
+		Joins("JOIN files ON files.file_id = file_versions.file_id").
+		Where("files.user_id = ? AND files.deleted_at IS NOT NULL", userID).
+		Scan(&usage).Error; err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// ensure 用户首次上传时创建用量记录，用量按配额功能上线前已上传的文件初始化。
+// 并发创建时只有一个成功，其余忽略
+func (r *quotaRepo) ensure(ctx context.Context, userID int64) error {
+	var count int64
+	if err := r.data.db.WithContext(ctx).Model(&StorageUsageModel{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var existing struct {
+		UsedBytes int64
+		FileCount int64
+	}
+	// 回收站中的文件和文件的所有版本同样占用配额
+	if err := r.data.db.WithContext(ctx).Model(&FileVersionModel{}).
+		Select("COALESCE(SUM(size), 0) AS used_bytes").
+		Where("user_id = ?", userID).
+		Scan(&existing).Error; err != nil {
+		return err
+	}
+	if err := r.data.db.WithContext(ctx).Unscoped().Model(&FileModel{}).
+		Where("user_id = ?", userID).
+		Count(&existing.FileCount).Error; err != nil {
+		return err
+	}
+
+	model := &StorageUsageModel{
+		UserID:    userID,
+		UsedBytes: existing.UsedBytes,
+		FileCount: existing.FileCount,
+	}
+	return r.data.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(model).Error
+}
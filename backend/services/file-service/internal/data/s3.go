@@ -0,0 +1,543 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	defaultS3Region   = "us-east-1"
+	defaultURLExpiry  = time.Hour
+	defaultPartSize   = 16 << 20
+	minPartSize       = 5 << 20 // S3 要求除最后一个分片外每个分片至少5MB
+	maxPresignExpiry  = 7 * 24 * time.Hour
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+	s3SigningAlgo     = "AWS4-HMAC-SHA256"
+	s3AmzDateLayout   = "20060102T150405Z"
+	s3ShortDateLayout = "20060102"
+)
+
+// s3Storage 兼容 S3 协议的对象存储（MinIO、AWS S3 等），使用路径风格访问 endpoint/bucket/key。
+// 文件不落本地磁盘，多个副本可以共享同一个存储桶
+type s3Storage struct {
+	client    *http.Client
+	endpoint  *url.URL
+	bucket    string
+	accessKey string
+	secretKey string
+	region    string
+	urlExpiry time.Duration
+	partSize  int64
+	log       *log.Helper
+}
+
+// s3Error S3 返回的错误
+type s3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("s3: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func newS3Storage(c *conf.Storage_MinIO, helper *log.Helper) (*s3Storage, error) {
+	endpoint := c.GetEndpoint()
+	if endpoint == "" || c.GetBucket() == "" {
+		return nil, errors.New("s3: endpoint and bucket are required")
+	}
+	if !strings.Contains(endpoint, "://") {
+		scheme := "http"
+		if c.GetSecure() {
+			scheme = "https"
+		}
+		endpoint = scheme + "://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3: invalid endpoint %q: %w", endpoint, err)
+	}
+
+	s := &s3Storage{
+		client:    &http.Client{},
+		endpoint:  u,
+		bucket:    c.GetBucket(),
+		accessKey: c.GetAccessKey(),
+		secretKey: c.GetSecretKey(),
+		region:    c.GetRegion(),
+		urlExpiry: c.GetUrlExpiry().AsDuration(),
+		partSize:  c.GetPartSize(),
+		log:       helper,
+	}
+	if s.region == "" {
+		s.region = defaultS3Region
+	}
+	if s.urlExpiry <= 0 {
+		s.urlExpiry = defaultURLExpiry
+	}
+	if s.urlExpiry > maxPresignExpiry {
+		s.urlExpiry = maxPresignExpiry
+	}
+	if s.partSize <= 0 {
+		s.partSize = defaultPartSize
+	}
+	if s.partSize < minPartSize {
+		s.partSize = minPartSize
+	}
+	return s, nil
+}
+
+// ensureBucket 存储桶不存在时创建
+func (s *s3Storage) ensureBucket(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, "", nil, nil, nil, -1)
+	if err == nil {
+		resp.Body.Close()
+		return nil
+	}
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	resp, err = s.do(ctx, http.MethodPut, "", nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	s.log.WithContext(ctx).Infof("created bucket %s", s.bucket)
+	return nil
+}
+
+// Upload 上传对象。内容不超过一个分片时直接 PUT，否则使用分片上传，内存中最多缓存一个分片
+func (s *s3Storage) Upload(ctx context.Context, filename string, content io.Reader) (string, error) {
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(content, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+
+	if int64(n) < s.partSize {
+		resp, err := s.do(ctx, http.MethodPut, filename, nil, nil, bytes.NewReader(buf[:n]), int64(n))
+		if err != nil {
+			s.log.WithContext(ctx).Errorf("failed to put object %s: %v", filename, err)
+			return "", err
+		}
+		resp.Body.Close()
+		return s.GetURL(filename), nil
+	}
+
+	if err := s.multipartUpload(ctx, filename, buf, content); err != nil {
+		s.log.WithContext(ctx).Errorf("failed to upload object %s: %v", filename, err)
+		return "", err
+	}
+	return s.GetURL(filename), nil
+}
+
+func (s *s3Storage) multipartUpload(ctx context.Context, key string, buf []byte, content io.Reader) error {
+	uploadID, err := s.createMultipartUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	var parts []completedPart
+	n := len(buf)
+	for partNumber := 1; n > 0; partNumber++ {
+		etag, err := s.uploadPart(ctx, key, uploadID, partNumber, buf[:n])
+		if err != nil {
+			s.abortMultipartUpload(key, uploadID)
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: etag})
+
+		n, err = io.ReadFull(content, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			s.abortMultipartUpload(key, uploadID)
+			return err
+		}
+	}
+
+	if err := s.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		s.abortMultipartUpload(key, uploadID)
+		return err
+	}
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *s3Storage) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("s3: decode CreateMultipartUpload response: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", errors.New("s3: empty upload id")
+	}
+	return result.UploadID, nil
+}
+
+func (s *s3Storage) uploadPart(ctx context.Context, key, uploadID string, partNumber int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	resp, err := s.do(ctx, http.MethodPut, key, query, nil, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *s3Storage) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}},
+		http.Header{"Content-Type": {"application/xml"}}, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	// CompleteMultipartUpload 可能在返回200后才在响应体中给出错误
+	return readBodyError(resp)
+}
+
+// readBodyError 读取并关闭响应体，响应体为 S3 错误时返回该错误
+func readBodyError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(data, []byte("<Error>")) {
+		s3Err := &s3Error{StatusCode: resp.StatusCode}
+		_ = xml.Unmarshal(data, s3Err)
+		return s3Err
+	}
+	return nil
+}
+
+// abortMultipartUpload 放弃分片上传，使用独立的context，保证请求被取消后仍能清理已上传的分片
+func (s *s3Storage) abortMultipartUpload(key, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil, 0)
+	if err != nil {
+		s.log.Warnf("failed to abort multipart upload %s: %v", key, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// Download 流式下载对象，调用方负责关闭
+func (s *s3Storage) Download(ctx context.Context, filename string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, filename, nil, nil, nil, -1)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("failed to get object %s: %v", filename, err)
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DownloadRange 通过 Range 请求读取对象的一部分
+func (s *s3Storage) DownloadRange(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		if length == 0 {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		byteRange += strconv.FormatInt(offset+length-1, 10)
+	}
+	header := http.Header{}
+	header.Set("Range", byteRange)
+
+	resp, err := s.do(ctx, http.MethodGet, filename, nil, header, nil, -1)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("failed to get object range %s (%s): %v", filename, byteRange, err)
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, filename string) error {
+	resp, err := s.do(ctx, http.MethodDelete, filename, nil, nil, nil, 0)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("failed to delete object %s: %v", filename, err)
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3Storage) Exists(ctx context.Context, filename string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, filename, nil, nil, nil, -1)
+	if err != nil {
+		var s3Err *s3Error
+		if errors.As(err, &s3Err) && s3Err.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// listBucketResult ListObjectsV2 的响应
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List 通过 ListObjectsV2 分页遍历对象
+func (s *s3Storage) List(ctx context.Context, prefix string, fn func(obj *biz.StorageObject) error) error {
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil, -1)
+		if err != nil {
+			return fmt.Errorf("failed to list objects: %w", err)
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode object list: %w", err)
+		}
+
+		for _, c := range result.Contents {
+			if err := fn(&biz.StorageObject{Key: c.Key, Size: c.Size, ModTime: c.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// GetURL 返回有效期为 url_expiry 的预签名下载链接
+func (s *s3Storage) GetURL(filename string) string {
+	now := time.Now().UTC()
+	u := s.objectURL(filename)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3SigningAlgo)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3AmzDateLayout))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.urlExpiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	header := http.Header{}
+	header.Set("Host", u.Host)
+	signature := s.signature(http.MethodGet, u, query, header, unsignedPayload, now)
+	query.Set("X-Amz-Signature", signature)
+
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// do 发送签名请求，非2xx响应转换为 *s3Error。size 为-1表示没有请求体
+func (s *s3Storage) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := s.objectURL(key)
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	now := time.Now().UTC()
+	req.Header.Set("Host", u.Host)
+	req.Header.Set("X-Amz-Date", now.Format(s3AmzDateLayout))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := signedHeaderNames(req.Header)
+	signature := s.signature(method, u, query, req.Header, unsignedPayload, now)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgo, s.accessKey, s.scope(now), strings.Join(signedHeaders, ";"), signature))
+	req.Header.Del("Host")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		s3Err := &s3Error{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode)}
+		if data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); len(data) > 0 {
+			_ = xml.Unmarshal(data, s3Err)
+		}
+		return nil, s3Err
+	}
+	return resp, nil
+}
+
+func (s *s3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+	if key != "" {
+		path += "/" + key
+	}
+	u.Path = path
+	u.RawPath = encodePath(path)
+	u.RawQuery = ""
+	return &u
+}
+
+func (s *s3Storage) scope(t time.Time) string {
+	return t.Format(s3ShortDateLayout) + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature 计算 AWS Signature Version 4 签名
+func (s *s3Storage) signature(method string, u *url.URL, query url.Values, header http.Header, payloadHash string, t time.Time) string {
+	names := signedHeaderNames(header)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteByte(':')
+		canonicalHeaders.WriteString(strings.TrimSpace(header.Get(name)))
+		canonicalHeaders.WriteByte('\n')
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		encodePath(u.Path),
+		canonicalQuery(query),
+		canonicalHeaders.String(),
+		strings.Join(names, ";"),
+		payloadHash,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3SigningAlgo,
+		t.Format(s3AmzDateLayout),
+		s.scope(t),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), t.Format(s3ShortDateLayout))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// signedHeaderNames 参与签名的请求头：host、x-amz-* 以及 content-type
+func signedHeaderNames(header http.Header) []string {
+	var names []string
+	for name := range header {
+		lower := strings.ToLower(name)
+		if lower == "host" || lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery 按键排序并使用 S3 的编码规则
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func encodePath(path string) string {
+	return uriEncode(path, false)
+}
+
+// uriEncode 只保留 A-Z a-z 0-9 - _ . ~，encodeSlash 为 false 时保留 /
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
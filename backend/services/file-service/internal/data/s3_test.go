@@ -0,0 +1,403 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// fakeS3 进程内的 S3 实现，支持测试用到的路径风格请求
+type fakeS3 struct {
+	mu       sync.Mutex
+	bucket   string
+	created  bool
+	objects  map[string][]byte
+	uploads  map[string]map[int][]byte
+	nextID   int
+	pageSize int
+	failPart int // 上传该编号的分片时返回500
+	aborted  []string
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{
+		bucket:   bucket,
+		objects:  make(map[string][]byte),
+		uploads:  make(map[string]map[int][]byte),
+		pageSize: 2,
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-access/") {
+		writeS3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != f.bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	query := r.URL.Query()
+
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !f.created {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			f.created = true
+		case http.MethodGet:
+			f.list(w, query.Get("prefix"), query.Get("continuation-token"))
+		}
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		if n == f.failPart {
+			writeS3Error(w, http.StatusInternalServerError, "InternalError")
+			return
+		}
+		parts[n], _ = io.ReadAll(r.Body)
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.complete(w, key, query.Get("uploadId"), r.Body)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		f.aborted = append(f.aborted, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[key], _ = io.ReadAll(r.Body)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodHead, r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, end, ok := parseRange(rng, int64(len(data)))
+			if !ok {
+				writeS3Error(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(data[start : end+1])
+			return
+		}
+		w.Write(data)
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) complete(w http.ResponseWriter, key, uploadID string, body io.Reader) {
+	parts, ok := f.uploads[uploadID]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	var req struct {
+		Parts []completedPart `xml:"Part"`
+	}
+	if err := xml.NewDecoder(body).Decode(&req); err != nil {
+		writeS3Error(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	var object []byte
+	for i, p := range req.Parts {
+		data, ok := parts[p.PartNumber]
+		if p.PartNumber != i+1 || !ok || p.ETag != fmt.Sprintf(`"etag-%d"`, p.PartNumber) {
+			// 与 S3 一致，分片错误时同样返回200并在响应体中给出错误
+			fmt.Fprint(w, "<Error><Code>InvalidPart</Code><Message>invalid part</Message></Error>")
+			return
+		}
+		object = append(object, data...)
+	}
+	f.objects[key] = object
+	delete(f.uploads, uploadID)
+	fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, token string) {
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) && k > token {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("<ListBucketResult>")
+	for i, k := range keys {
+		if i == f.pageSize {
+			fmt.Fprintf(&b, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[i-1])
+			break
+		}
+		fmt.Fprintf(&b, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2024-01-02T03:04:05Z</LastModified></Contents>",
+			k, len(f.objects[k]))
+	}
+	b.WriteString("</ListBucketResult>")
+	fmt.Fprint(w, b.String())
+}
+
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, 0, false
+	}
+	from, to, _ := strings.Cut(spec, "-")
+	start, err := strconv.ParseInt(from, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if to != "" {
+		if end, err = strconv.ParseInt(to, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end, true
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func newTestS3(t *testing.T) (*s3Storage, *fakeS3) {
+	t.Helper()
+	fake := newFakeS3("resumes")
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s, err := newS3Storage(&conf.Storage_MinIO{
+		Endpoint:  server.URL,
+		Bucket:    "resumes",
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+	}, log.NewHelper(log.NewStdLogger(io.Discard)))
+	if err != nil {
+		t.Fatalf("newS3Storage: %v", err)
+	}
+	if err := s.ensureBucket(context.Background()); err != nil {
+		t.Fatalf("ensureBucket: %v", err)
+	}
+	if !fake.created {
+		t.Fatal("ensureBucket did not create the bucket")
+	}
+	return s, fake
+}
+
+func readAll(t *testing.T, rc io.ReadCloser, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(data)
+}
+
+func TestS3Upload(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		partSize  int64
+		wantParts bool
+	}{
+		{name: "single put", size: 10, partSize: 16},
+		{name: "empty object", size: 0, partSize: 16},
+		{name: "exactly one part", size: 16, partSize: 16, wantParts: true},
+		{name: "multipart with short last part", size: 40, partSize: 16, wantParts: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestS3(t)
+			// 直接缩小分片大小，避免测试分配5MB的分片
+			s.partSize = tt.partSize
+			content := make([]byte, tt.size)
+			for i := range content {
+				content[i] = byte('a' + i%26)
+			}
+
+			u, err := s.Upload(context.Background(), "user/1/resume.pdf", bytes.NewReader(content))
+			if err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+			if !strings.Contains(u, "/resumes/user/1/resume.pdf?") || !strings.Contains(u, "X-Amz-Signature=") {
+				t.Errorf("Upload url = %q, want a presigned object url", u)
+			}
+			if got := fake.objects["user/1/resume.pdf"]; !bytes.Equal(got, content) {
+				t.Errorf("stored %q, want %q", got, content)
+			}
+			if tt.wantParts != (fake.nextID > 0) {
+				t.Errorf("multipart used = %v, want %v", fake.nextID > 0, tt.wantParts)
+			}
+			if len(fake.uploads) != 0 {
+				t.Errorf("%d multipart uploads left open", len(fake.uploads))
+			}
+		})
+	}
+}
+
+func TestS3UploadAbortsFailedMultipart(t *testing.T) {
+	s, fake := newTestS3(t)
+	s.partSize = 4
+	fake.failPart = 2
+
+	_, err := s.Upload(context.Background(), "broken.pdf", strings.NewReader("0123456789"))
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Upload error = %v, want 500 s3Error", err)
+	}
+	if len(fake.aborted) != 1 || fake.aborted[0] != "broken.pdf" || len(fake.uploads) != 0 {
+		t.Errorf("aborted = %v, open uploads = %d", fake.aborted, len(fake.uploads))
+	}
+	if _, ok := fake.objects["broken.pdf"]; ok {
+		t.Error("failed multipart upload left an object behind")
+	}
+}
+
+func TestS3DownloadRange(t *testing.T) {
+	s, fake := newTestS3(t)
+	fake.objects["doc.txt"] = []byte("0123456789")
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		offset, length int64
+		want           string
+	}{
+		{"prefix", 0, 4, "0123"},
+		{"middle", 3, 4, "3456"},
+		{"to end", 6, -1, "6789"},
+		{"past end is clipped", 8, 10, "89"},
+		{"zero length", 5, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := s.DownloadRange(ctx, "doc.txt", tt.offset, tt.length)
+			if got := readAll(t, rc, err); got != tt.want {
+				t.Errorf("DownloadRange(%d, %d) = %q, want %q", tt.offset, tt.length, got, tt.want)
+			}
+		})
+	}
+
+	rc, err := s.Download(ctx, "doc.txt")
+	if got := readAll(t, rc, err); got != "0123456789" {
+		t.Errorf("Download = %q", got)
+	}
+	_, err = s.DownloadRange(ctx, "doc.txt", 20, 5)
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) || s3Err.Code != "InvalidRange" {
+		t.Errorf("DownloadRange past the object error = %v, want InvalidRange", err)
+	}
+}
+
+func TestS3DeleteAndExists(t *testing.T) {
+	s, fake := newTestS3(t)
+	fake.objects["a b/简历.pdf"] = []byte("x")
+	ctx := context.Background()
+
+	if ok, err := s.Exists(ctx, "a b/简历.pdf"); err != nil || !ok {
+		t.Fatalf("Exists before delete = %v, %v", ok, err)
+	}
+	if err := s.Delete(ctx, "a b/简历.pdf"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if ok, err := s.Exists(ctx, "a b/简历.pdf"); err != nil || ok {
+		t.Errorf("Exists after delete = %v, %v", ok, err)
+	}
+	_, err := s.Download(ctx, "a b/简历.pdf")
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusNotFound || s3Err.Code != "NoSuchKey" {
+		t.Errorf("Download after delete error = %v, want NoSuchKey", err)
+	}
+}
+
+func TestS3List(t *testing.T) {
+	s, fake := newTestS3(t)
+	for _, k := range []string{"user/1/a", "user/1/b", "user/1/c", "user/1/d", "user/1/e", "user/2/a"} {
+		fake.objects[k] = []byte(k)
+	}
+	ctx := context.Background()
+
+	var keys []string
+	err := s.List(ctx, "user/1/", func(obj *biz.StorageObject) error {
+		if obj.Size != int64(len(obj.Key)) || !obj.ModTime.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("object %+v", obj)
+		}
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if got, want := strings.Join(keys, ","), "user/1/a,user/1/b,user/1/c,user/1/d,user/1/e"; got != want {
+		t.Errorf("List across pages = %s, want %s", got, want)
+	}
+
+	stop := errors.New("stop")
+	var visited int
+	err = s.List(ctx, "", func(*biz.StorageObject) error {
+		visited++
+		return stop
+	})
+	if !errors.Is(err, stop) || visited != 1 {
+		t.Errorf("List with callback error = %v after %d objects", err, visited)
+	}
+}
+
+func TestS3RejectsBadCredentials(t *testing.T) {
+	s, _ := newTestS3(t)
+	s.accessKey = "other"
+	_, err := s.Exists(context.Background(), "a")
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusForbidden {
+		t.Errorf("Exists with wrong credentials error = %v, want 403", err)
+	}
+}
@@ -0,0 +1,158 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	// clamdChunkSize INSTREAM 每个分片的大小，需小于 clamd 的 StreamMaxLength
+	clamdChunkSize = 64 << 10
+	// clamdDialTimeout 连接 clamd 的超时
+	clamdDialTimeout = 5 * time.Second
+)
+
+// NewScanner 创建恶意软件扫描器，未配置时不扫描
+func NewScanner(config *conf.Storage, logger log.Logger) (biz.Scanner, error) {
+	helper := log.NewHelper(logger)
+
+	scan := config.GetScan()
+	switch scan.GetType() {
+	case "", "none":
+		helper.Warn("malware scanning is disabled, all uploads are treated as clean")
+		return noopScanner{}, nil
+	case "clamd":
+		network, address, err := parseClamdAddress(scan.GetAddress())
+		if err != nil {
+			return nil, err
+		}
+		return &clamdScanner{network: network, address: address}, nil
+	default:
+		return nil, fmt.Errorf("unknown scanner type: %s", scan.GetType())
+	}
+}
+
+// noopScanner 不做扫描，所有内容都视为安全
+type noopScanner struct{}
+
+func (noopScanner) Scan(ctx context.Context, content io.Reader) (*biz.ScanResult, error) {
+	return &biz.ScanResult{}, nil
+}
+
+// clamdScanner 通过 clamd 的 INSTREAM 命令扫描，内容分片发送，不落盘
+type clamdScanner struct {
+	network string
+	address string
+}
+
+var (
+	// errClamdWrite 向 clamd 发送数据失败，clamd 可能已经返回了错误原因
+	errClamdWrite = errors.New("failed to send content to clamd")
+	// errClamdNoReply 没有读到 clamd 的响应
+	errClamdNoReply = errors.New("no reply from clamd")
+)
+
+// parseClamdAddress 解析 tcp://host:port、unix:///path、host:port 或 /path 形式的地址
+func parseClamdAddress(address string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp", strings.TrimPrefix(address, "tcp://"), nil
+	case strings.HasPrefix(address, "unix://"):
+		return "unix", strings.TrimPrefix(address, "unix://"), nil
+	case strings.HasPrefix(address, "/"):
+		return "unix", address, nil
+	case address != "":
+		return "tcp", address, nil
+	}
+	return "", "", errors.New("clamd address is required")
+}
+
+func (s *clamdScanner) Scan(ctx context.Context, content io.Reader) (*biz.ScanResult, error) {
+	dialer := &net.Dialer{Timeout: clamdDialTimeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// 上下文取消时中断阻塞的读写
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := s.stream(conn, content); err != nil {
+		if errors.Is(err, errClamdWrite) {
+			// 超过 StreamMaxLength 时 clamd 会先返回错误再断开连接，优先使用 clamd 的错误信息
+			if _, replyErr := readClamdReply(conn); replyErr != nil && !errors.Is(replyErr, errClamdNoReply) {
+				return nil, replyErr
+			}
+		}
+		return nil, err
+	}
+
+	return readClamdReply(conn)
+}
+
+// stream 发送 zINSTREAM 命令和内容分片，分片格式为4字节大端长度加数据，以长度0结束
+func (s *clamdScanner) stream(conn net.Conn, content io.Reader) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("%w: %v", errClamdWrite, err)
+	}
+
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := content.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := conn.Write(buf[:4+n]); werr != nil {
+				return fmt.Errorf("%w: %v", errClamdWrite, werr)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read content: %w", err)
+		}
+	}
+
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("%w: %v", errClamdWrite, err)
+	}
+	return nil
+}
+
+// readClamdReply 解析 clamd 的响应："stream: OK"、"stream: <特征名> FOUND" 或 "<原因> ERROR"
+func readClamdReply(conn net.Conn) (*biz.ScanResult, error) {
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return nil, fmt.Errorf("%w: %v", errClamdNoReply, err)
+	}
+	line := strings.TrimSpace(string(bytes.TrimRight(reply, "\x00")))
+	line = strings.TrimPrefix(line, "stream: ")
+
+	switch {
+	case line == "OK":
+		return &biz.ScanResult{}, nil
+	case strings.HasSuffix(line, " FOUND"):
+		return &biz.ScanResult{Infected: true, Signature: strings.TrimSuffix(line, " FOUND")}, nil
+	case strings.HasSuffix(line, " ERROR"):
+		return nil, fmt.Errorf("clamd error: %s", strings.TrimSuffix(line, " ERROR"))
+	}
+	return nil, fmt.Errorf("unexpected clamd reply: %q", line)
+}
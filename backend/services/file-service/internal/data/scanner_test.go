@@ -0,0 +1,217 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd 监听一个套接字，按 zINSTREAM 协议接收内容后由 reply 决定响应
+type fakeClamd struct {
+	listener net.Listener
+	maxLen   int                         // 模拟 StreamMaxLength，0表示不限制
+	reply    func(content []byte) string // 返回空串表示不响应直接断开
+	received chan []byte
+}
+
+func startFakeClamd(t *testing.T, network string, reply func([]byte) string) *fakeClamd {
+	t.Helper()
+	address := "127.0.0.1:0"
+	if network == "unix" {
+		address = filepath.Join(t.TempDir(), "clamd.sock")
+	}
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	f := &fakeClamd{listener: l, reply: reply, received: make(chan []byte, 1)}
+	go f.serve()
+	return f
+}
+
+func (f *fakeClamd) scanner() *clamdScanner {
+	return &clamdScanner{network: f.listener.Addr().Network(), address: f.listener.Addr().String()}
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.handle(conn)
+	}
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	cmd, err := r.ReadString(0)
+	if err != nil || cmd != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	var content []byte
+	size := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, size); err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint32(size)
+		if n == 0 {
+			break
+		}
+		if n > clamdChunkSize {
+			conn.Write([]byte("chunk too large ERROR\x00"))
+			return
+		}
+		chunk := make([]byte, n)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return
+		}
+		content = append(content, chunk...)
+		if f.maxLen > 0 && len(content) > f.maxLen {
+			// clamd 超限后先返回错误再断开，这里排空剩余数据再关闭，避免 RST 丢弃已发送的响应
+			conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			io.Copy(io.Discard, r)
+			return
+		}
+	}
+	f.received <- content
+
+	if reply := f.reply(content); reply != "" {
+		conn.Write([]byte(reply + "\x00"))
+	}
+}
+
+func TestClamdScan(t *testing.T) {
+	eicar := []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+	reply := func(content []byte) string {
+		switch {
+		case bytes.Contains(content, eicar):
+			return "stream: Eicar-Test-Signature FOUND"
+		case bytes.HasPrefix(content, []byte("broken")):
+			return "Can't allocate memory ERROR"
+		case bytes.HasPrefix(content, []byte("garbage")):
+			return "stream: WHAT"
+		case bytes.HasPrefix(content, []byte("silent")):
+			return ""
+		}
+		return "stream: OK"
+	}
+	large := bytes.Repeat([]byte("a"), 3*clamdChunkSize+17)
+
+	tests := []struct {
+		name          string
+		network       string
+		content       []byte
+		wantInfected  bool
+		wantSignature string
+		wantErr       string
+		wantNoReply   bool
+	}{
+		{name: "clean", network: "tcp", content: []byte("%PDF-1.7 resume")},
+		{name: "clean over unix socket", network: "unix", content: []byte("%PDF-1.7 resume")},
+		{name: "empty", network: "tcp", content: nil},
+		{name: "clean across chunks", network: "tcp", content: large},
+		{name: "found", network: "tcp", content: append([]byte("prefix "), eicar...), wantInfected: true, wantSignature: "Eicar-Test-Signature"},
+		{name: "error reply", network: "tcp", content: []byte("broken"), wantErr: "clamd error: Can't allocate memory"},
+		{name: "unexpected reply", network: "tcp", content: []byte("garbage"), wantErr: "unexpected clamd reply"},
+		{name: "no reply", network: "tcp", content: []byte("silent"), wantNoReply: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clamd := startFakeClamd(t, tt.network, reply)
+			result, err := clamd.scanner().Scan(context.Background(), bytes.NewReader(tt.content))
+
+			switch {
+			case tt.wantNoReply:
+				if !errors.Is(err, errClamdNoReply) {
+					t.Fatalf("Scan error = %v, want errClamdNoReply", err)
+				}
+				return
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Scan error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatalf("Scan: %v", err)
+			}
+			if result.Infected != tt.wantInfected || result.Signature != tt.wantSignature {
+				t.Errorf("Scan = %+v, want infected=%v signature=%q", result, tt.wantInfected, tt.wantSignature)
+			}
+			if got := <-clamd.received; !bytes.Equal(got, tt.content) {
+				t.Errorf("clamd received %d bytes, want %d", len(got), len(tt.content))
+			}
+		})
+	}
+}
+
+func TestClamdScanSizeLimit(t *testing.T) {
+	clamd := startFakeClamd(t, "tcp", func([]byte) string { return "stream: OK" })
+	clamd.maxLen = clamdChunkSize
+
+	_, err := clamd.scanner().Scan(context.Background(), bytes.NewReader(make([]byte, 4*clamdChunkSize)))
+	if err == nil || !strings.Contains(err.Error(), "clamd error: INSTREAM size limit exceeded.") {
+		t.Fatalf("Scan error = %v, want the size limit error from clamd", err)
+	}
+
+	// 未超限的内容仍然正常扫描
+	result, err := clamd.scanner().Scan(context.Background(), bytes.NewReader(make([]byte, clamdChunkSize)))
+	if err != nil || result.Infected {
+		t.Errorf("Scan within the limit = %+v, %v", result, err)
+	}
+}
+
+func TestClamdScanUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	s := &clamdScanner{network: "tcp", address: address}
+	if _, err := s.Scan(context.Background(), strings.NewReader("x")); err == nil || !strings.Contains(err.Error(), "failed to connect to clamd") {
+		t.Errorf("Scan error = %v, want connection failure", err)
+	}
+}
+
+func TestParseClamdAddress(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{in: "tcp://clamav:3310", wantNetwork: "tcp", wantAddress: "clamav:3310"},
+		{in: "clamav:3310", wantNetwork: "tcp", wantAddress: "clamav:3310"},
+		{in: "unix:///run/clamav/clamd.ctl", wantNetwork: "unix", wantAddress: "/run/clamav/clamd.ctl"},
+		{in: "/run/clamav/clamd.ctl", wantNetwork: "unix", wantAddress: "/run/clamav/clamd.ctl"},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		network, address, err := parseClamdAddress(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseClamdAddress(%q) error = %v", tt.in, err)
+			continue
+		}
+		if network != tt.wantNetwork || address != tt.wantAddress {
+			t.Errorf("parseClamdAddress(%q) = %s, %s, want %s, %s", tt.in, network, address, tt.wantNetwork, tt.wantAddress)
+		}
+	}
+}
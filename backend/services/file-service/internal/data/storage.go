@@ -2,10 +2,14 @@ package data
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 
@@ -18,22 +22,47 @@ type localStorage struct {
 	log      *log.Helper
 }
 
-// NewStorageRepo 创建存储仓库
-func NewStorageRepo(config *conf.Storage, logger log.Logger) biz.StorageRepo {
+// NewStorageRepo 创建存储仓库，配置了主密钥时对象加密后存储
+func NewStorageRepo(config *conf.Storage, logger log.Logger) (biz.StorageRepo, error) {
 	helper := log.NewHelper(logger)
 
+	storage, err := newBackendStorage(config, helper)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.GetEncryption().GetKeys()) == 0 {
+		helper.Warn("no master keys configured, stored files are not encrypted")
+		return storage, nil
+	}
+	return newEncryptedStorage(storage, config.GetEncryption(), helper)
+}
+
+// newBackendStorage 按配置创建底层存储
+func newBackendStorage(config *conf.Storage, helper *log.Helper) (biz.StorageRepo, error) {
 	switch config.Type {
 	case "local":
 		return &localStorage{
 			basePath: config.Local.Path,
 			log:      helper,
+		}, nil
+	case "minio", "s3":
+		storage, err := newS3Storage(config.Minio, helper)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := storage.ensureBucket(ctx); err != nil {
+			// 对象存储暂时不可用时不阻止服务启动，上传时会再次报错
+			helper.Warnf("failed to ensure bucket %s: %v", storage.bucket, err)
 		}
+		return storage, nil
 	default:
 		// 默认使用本地存储
 		return &localStorage{
 			basePath: "/tmp/uploads",
 			log:      helper,
-		}
+		}, nil
 	}
 }
 
@@ -46,18 +75,25 @@ func (s *localStorage) Upload(ctx context.Context, filename string, content io.R
 
 	filePath := filepath.Join(s.basePath, filename)
 
-	// 创建文件
-	file, err := os.Create(filePath)
+	// 先写入临时文件再重命名，覆盖已有文件时正在读取的请求不受影响
+	file, err := os.CreateTemp(s.basePath, ".upload-*")
 	if err != nil {
 		s.log.WithContext(ctx).Errorf("failed to create file: %v", err)
 		return "", err
 	}
-	defer file.Close()
+	tmpPath := file.Name()
 
 	// 写入内容
-	if _, err := io.Copy(file, content); err != nil {
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
 		s.log.WithContext(ctx).Errorf("failed to write file: %v", err)
-		os.Remove(filePath) // 清理失败的文件
+		os.Remove(tmpPath) // 清理失败的文件
 		return "", err
 	}
 
@@ -77,6 +113,29 @@ func (s *localStorage) Download(ctx context.Context, filename string) (io.ReadCl
 	return file, nil
 }
 
+func (s *localStorage) DownloadRange(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	file, err := s.Download(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	f := file.(*os.File)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		s.log.WithContext(ctx).Errorf("failed to seek file: %v", err)
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
+}
+
+// limitedReadCloser 限制读取长度，关闭时关闭底层文件
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
 func (s *localStorage) Delete(ctx context.Context, filename string) error {
 	filePath := filepath.Join(s.basePath, filename)
 
@@ -91,3 +150,41 @@ func (s *localStorage) Delete(ctx context.Context, filename string) error {
 func (s *localStorage) GetURL(filename string) string {
 	return fmt.Sprintf("/files/%s", filename)
 }
+
+func (s *localStorage) Exists(ctx context.Context, filename string) (bool, error) {
+	_, err := os.Stat(filepath.Join(s.basePath, filename))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List 遍历存储目录下的文件，不递归子目录
+func (s *localStorage) List(ctx context.Context, prefix string, fn func(obj *biz.StorageObject) error) error {
+	entries, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		if err := fn(&biz.StorageObject{Key: entry.Name(), Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
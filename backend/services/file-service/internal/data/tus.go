@@ -0,0 +1,168 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// TusUploadModel 断点续传上传数据模型
+type TusUploadModel struct {
+	ID           uint      `gorm:"primarykey"`
+	UploadID     string    `gorm:"uniqueIndex;size:64;not null"`
+	UserID       int64     `gorm:"not null;index"`
+	Filename     string    `gorm:"size:255;not null"`
+	UploadLength int64     `gorm:"not null"`
+	UploadOffset int64     `gorm:"not null;default:0"`
+	Metadata     string    `gorm:"type:text"` // JSON格式存储 Upload-Metadata
+	Chunks       string    `gorm:"type:text"` // JSON格式存储分片存储名列表
+	FileID       string    `gorm:"size:100"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (TusUploadModel) TableName() string {
+	return "file_tus_uploads"
+}
+
+type tusUploadRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewTusUploadRepo .
+func NewTusUploadRepo(data *Data, logger log.Logger) biz.TusUploadRepo {
+	return &tusUploadRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *tusUploadRepo) Create(ctx context.Context, upload *biz.TusUpload) error {
+	metadata, err := json.Marshal(upload.Metadata)
+	if err != nil {
+		return err
+	}
+	model := &TusUploadModel{
+		UploadID:     upload.ID,
+		UserID:       upload.UserID,
+		Filename:     upload.Filename,
+		UploadLength: upload.Length,
+		UploadOffset: upload.Offset,
+		Metadata:     string(metadata),
+		Chunks:       "[]",
+		ExpiresAt:    upload.ExpiresAt,
+	}
+	if err := r.data.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+
+	upload.CreatedAt = model.CreatedAt
+	upload.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *tusUploadRepo) Get(ctx context.Context, id string) (*biz.TusUpload, error) {
+	var model TusUploadModel
+	if err := r.data.db.WithContext(ctx).Where("upload_id = ?", id).First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, biz.ErrUploadNotFound
+		}
+		return nil, err
+	}
+	return r.toBiz(&model), nil
+}
+
+func (r *tusUploadRepo) UpdateOffset(ctx context.Context, upload *biz.TusUpload, expectedOffset int64) error {
+	chunks, err := json.Marshal(upload.Chunks)
+	if err != nil {
+		return err
+	}
+
+	// 以偏移量做乐观锁，保证并发写入同一偏移量时只有一个请求成功
+	result := r.data.db.WithContext(ctx).Model(&TusUploadModel{}).
+		Where("upload_id = ? AND upload_offset = ? AND file_id = ''", upload.ID, expectedOffset).
+		Updates(map[string]interface{}{
+			"upload_offset": upload.Offset,
+			"chunks":        string(chunks),
+			"expires_at":    upload.ExpiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrOffsetMismatch
+	}
+	return nil
+}
+
+func (r *tusUploadRepo) Complete(ctx context.Context, id, fileID string) error {
+	result := r.data.db.WithContext(ctx).Model(&TusUploadModel{}).
+		Where("upload_id = ? AND file_id = ''", id).
+		Updates(map[string]interface{}{
+			"file_id": fileID,
+			"chunks":  "[]",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrOffsetMismatch
+	}
+	return nil
+}
+
+func (r *tusUploadRepo) Delete(ctx context.Context, id string) error {
+	result := r.data.db.WithContext(ctx).Where("upload_id = ?", id).Delete(&TusUploadModel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrUploadNotFound
+	}
+	return nil
+}
+
+func (r *tusUploadRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]*biz.TusUpload, error) {
+	var models []TusUploadModel
+	if err := r.data.db.WithContext(ctx).Where("expires_at < ?", before).Order("expires_at ASC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	uploads := make([]*biz.TusUpload, len(models))
+	for i := range models {
+		uploads[i] = r.toBiz(&models[i])
+	}
+	return uploads, nil
+}
+
+func (r *tusUploadRepo) toBiz(model *TusUploadModel) *biz.TusUpload {
+	upload := &biz.TusUpload{
+		ID:        model.UploadID,
+		UserID:    model.UserID,
+		Filename:  model.Filename,
+		Length:    model.UploadLength,
+		Offset:    model.UploadOffset,
+		FileID:    model.FileID,
+		ExpiresAt: model.ExpiresAt,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+	if model.Metadata != "" {
+		if err := json.Unmarshal([]byte(model.Metadata), &upload.Metadata); err != nil {
+			r.log.Warnf("failed to unmarshal upload metadata %s: %v", model.UploadID, err)
+		}
+	}
+	if model.Chunks != "" {
+		if err := json.Unmarshal([]byte(model.Chunks), &upload.Chunks); err != nil {
+			r.log.Warnf("failed to unmarshal upload chunks %s: %v", model.UploadID, err)
+		}
+	}
+	return upload
+}
@@ -0,0 +1,164 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// FileVersionModel 文件版本数据模型，每个版本持有一个内容引用
+type FileVersionModel struct {
+	ID           uint   `gorm:"primarykey"`
+	FileID       string `gorm:"size:100;not null;uniqueIndex:idx_file_version"`
+	Version      int32  `gorm:"not null;uniqueIndex:idx_file_version"`
+	Filename     string `gorm:"size:255;not null"`
+	OriginalName string `gorm:"size:255;not null"`
+	Size         int64  `gorm:"not null"`
+	MimeType     string `gorm:"size:100"`
+	ContentHash  string `gorm:"size:64;index"`
+	Status       string `gorm:"size:50"`
+	ScanResult   string `gorm:"size:255"`
+	RestoredFrom int32  `gorm:"not null;default:0"`
+	UserID       int64  `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+func (FileVersionModel) TableName() string {
+	return "file_versions"
+}
+
+// backfillVersions 为引入版本之前上传的文件创建第一个版本，包括回收站中的文件
+func backfillVersions(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO file_versions
+		(file_id, version, filename, original_name, size, mime_type, content_hash, status, scan_result, restored_from, user_id, created_at)
+		SELECT f.file_id, 1, f.filename, f.original_name, f.size, f.mime_type, f.content_hash, f.status, f.scan_result, 0, f.user_id, f.created_at
+		FROM files f
+		WHERE NOT EXISTS (SELECT 1 FROM file_versions v WHERE v.file_id = f.file_id)`).Error
+}
+
+// newVersionModel 以文件记录的当前内容创建版本记录
+func newVersionModel(file *FileModel, restoredFrom int32) *FileVersionModel {
+	return &FileVersionModel{
+		FileID:       file.FileID,
+		Version:      file.Version,
+		Filename:     file.Filename,
+		OriginalName: file.OriginalName,
+		Size:         file.Size,
+		MimeType:     file.MimeType,
+		ContentHash:  file.ContentHash,
+		Status:       file.Status,
+		ScanResult:   file.ScanResult,
+		RestoredFrom: restoredFrom,
+		UserID:       file.UserID,
+	}
+}
+
+// AddVersion 锁定文件记录后分配版本号，并发添加版本时依次执行
+func (r *fileRepo) AddVersion(ctx context.Context, fileID string, scope biz.Scope, version *biz.FileVersion) (*biz.File, error) {
+	var file FileModel
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := inScope(tx.Clauses(clause.Locking{Strength: "UPDATE"}), scope).
+			Where("file_id = ?", fileID).First(&file).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return biz.ErrFileNotFound
+			}
+			return err
+		}
+
+		var latest int32
+		if err := tx.Model(&FileVersionModel{}).Where("file_id = ?", fileID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		file.Version = latest + 1
+		file.Filename = version.Filename
+		file.OriginalName = version.OriginalName
+		file.Size = version.Size
+		file.MimeType = version.MimeType
+		file.ContentHash = version.ContentHash
+		file.Status = version.Status
+		file.ScanResult = version.ScanResult
+		if err := tx.Save(&file).Error; err != nil {
+			return err
+		}
+
+		model := newVersionModel(&file, version.RestoredFrom)
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		version.ID = int64(model.ID)
+		version.FileID = model.FileID
+		version.Version = model.Version
+		version.CreatedAt = model.CreatedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toBizFile(&file), nil
+}
+
+func (r *fileRepo) ListVersions(ctx context.Context, fileID string) ([]*biz.FileVersion, error) {
+	var models []FileVersionModel
+	if err := r.data.db.WithContext(ctx).Where("file_id = ?", fileID).
+		Order("version DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	versions := make([]*biz.FileVersion, len(models))
+	for i := range models {
+		versions[i] = toBizFileVersion(&models[i])
+	}
+	return versions, nil
+}
+
+func (r *fileRepo) FindVersion(ctx context.Context, fileID string, version int32) (*biz.FileVersion, error) {
+	var model FileVersionModel
+	if err := r.data.db.WithContext(ctx).Where("file_id = ? AND version = ?", fileID, version).
+		First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, biz.ErrVersionNotFound
+		}
+		return nil, err
+	}
+	return toBizFileVersion(&model), nil
+}
+
+// ListUserVersions 版本记录在永久删除文件时一并删除，剩下的都属于未删除或在回收站中的文件
+func (r *fileRepo) ListUserVersions(ctx context.Context, userID int64) ([]*biz.FileVersion, error) {
+	var models []FileVersionModel
+	if err := r.data.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("file_id ASC, version ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	versions := make([]*biz.FileVersion, len(models))
+	for i := range models {
+		versions[i] = toBizFileVersion(&models[i])
+	}
+	return versions, nil
+}
+
+func toBizFileVersion(model *FileVersionModel) *biz.FileVersion {
+	return &biz.FileVersion{
+		ID:           int64(model.ID),
+		FileID:       model.FileID,
+		Version:      model.Version,
+		Filename:     model.Filename,
+		OriginalName: model.OriginalName,
+		Size:         model.Size,
+		MimeType:     model.MimeType,
+		ContentHash:  model.ContentHash,
+		Status:       model.Status,
+		ScanResult:   model.ScanResult,
+		RestoredFrom: model.RestoredFrom,
+		UserID:       model.UserID,
+		CreatedAt:    model.CreatedAt,
+	}
+}
@@ -0,0 +1,190 @@
+// Package filecheck 根据文件内容识别类型并做结构校验，不信任文件扩展名
+package filecheck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrTypeMismatch 文件内容与扩展名不符
+	ErrTypeMismatch = errors.New("file content does not match its extension")
+	// ErrExecutable 文件内容为可执行程序
+	ErrExecutable = errors.New("executable content is not allowed")
+	// ErrMalformed 文件结构损坏或无法解析
+	ErrMalformed = errors.New("malformed file")
+	// ErrZipBomb 解压后过大或压缩比异常
+	ErrZipBomb = errors.New("archive exceeds decompression limits")
+)
+
+// Kind 按内容识别的文件类型
+type Kind string
+
+const (
+	KindUnknown    Kind = ""
+	KindPDF        Kind = "pdf"
+	KindDOCX       Kind = "docx"
+	KindOOXML      Kind = "ooxml" // docx 以外的 Office Open XML，如 xlsx、pptx
+	KindZip        Kind = "zip"
+	KindOLE2       Kind = "ole2" // 旧版 Office 复合文档，如 doc
+	KindText       Kind = "text" // UTF-8 文本
+	KindExecutable Kind = "executable"
+)
+
+// sniffLen 识别类型时读取的文件头长度
+const sniffLen = 8 << 10
+
+const (
+	defaultMaxUncompressedSize = 100 << 20
+	defaultMaxCompressionRatio = 100
+	defaultMaxZipEntries       = 10000
+)
+
+// extensionKinds 扩展名允许的内容类型，不在表中的扩展名只拒绝可执行内容
+var extensionKinds = map[string][]Kind{
+	"pdf":      {KindPDF},
+	"docx":     {KindDOCX},
+	"doc":      {KindOLE2},
+	"md":       {KindText},
+	"markdown": {KindText},
+	"txt":      {KindText},
+	"eml":      {KindText},
+	"mbox":     {KindText},
+	"zip":      {KindZip, KindDOCX, KindOOXML},
+}
+
+var mimeTypes = map[Kind]string{
+	KindPDF:   "application/pdf",
+	KindDOCX:  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	KindOOXML: "application/zip",
+	KindZip:   "application/zip",
+	KindOLE2:  "application/msword",
+	KindText:  "text/plain; charset=utf-8",
+}
+
+// Limits zip 类文件的解压限制，零值使用默认值
+type Limits struct {
+	MaxUncompressedSize int64
+	MaxCompressionRatio int64
+	MaxZipEntries       int
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxUncompressedSize <= 0 {
+		l.MaxUncompressedSize = defaultMaxUncompressedSize
+	}
+	if l.MaxCompressionRatio <= 0 {
+		l.MaxCompressionRatio = defaultMaxCompressionRatio
+	}
+	if l.MaxZipEntries <= 0 {
+		l.MaxZipEntries = defaultMaxZipEntries
+	}
+	return l
+}
+
+// Result 校验结果
+type Result struct {
+	Kind     Kind
+	MimeType string // 按内容确定的 MIME 类型，未知类型为空
+}
+
+// Check 识别文件内容类型，检查与扩展名 ext（不含点）是否一致，并校验文件结构
+func Check(r io.ReaderAt, size int64, ext string, limits Limits) (*Result, error) {
+	limits = limits.withDefaults()
+
+	kind, err := detect(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if kind == KindExecutable {
+		return nil, ErrExecutable
+	}
+
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if allowed, ok := extensionKinds[ext]; ok && !containsKind(allowed, kind) {
+		detected := string(kind)
+		if kind == KindUnknown {
+			detected = "unknown"
+		}
+		return nil, fmt.Errorf("%w: .%s file contains %s data", ErrTypeMismatch, ext, detected)
+	}
+
+	if err := validate(r, size, kind, limits); err != nil {
+		return nil, err
+	}
+	return &Result{Kind: kind, MimeType: mimeTypes[kind]}, nil
+}
+
+// detect 根据文件头识别类型，zip 需要读取目录区分 docx 和普通压缩包
+func detect(r io.ReaderAt, size int64) (Kind, error) {
+	head := make([]byte, min(size, sniffLen))
+	if _, err := r.ReadAt(head, 0); err != nil && !errors.Is(err, io.EOF) {
+		return KindUnknown, err
+	}
+
+	switch {
+	case isExecutable(head):
+		return KindExecutable, nil
+	case bytes.Contains(head[:min(len(head), 1024)], []byte("%PDF-")):
+		// PDF 规范允许文件头前存在少量其他字节
+		return KindPDF, nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return zipKind(r, size), nil
+	case bytes.HasPrefix(head, ole2Magic):
+		return KindOLE2, nil
+	case looksLikeText(head, int64(len(head)) == size):
+		return KindText, nil
+	}
+	return KindUnknown, nil
+}
+
+var ole2Magic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// executableMagics 常见可执行文件和脚本的文件头
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),        // Linux ELF
+	{0xFE, 0xED, 0xFA, 0xCE}, // Mach-O 32位
+	{0xFE, 0xED, 0xFA, 0xCF}, // Mach-O 64位
+	{0xCE, 0xFA, 0xED, 0xFE}, // Mach-O 32位（小端）
+	{0xCF, 0xFA, 0xED, 0xFE}, // Mach-O 64位（小端）
+	{0xCA, 0xFE, 0xBA, 0xBE}, // Mach-O 通用二进制 / Java class
+	[]byte("#!"),             // 脚本
+	{0x00, 0x61, 0x73, 0x6D}, // WebAssembly
+}
+
+func isExecutable(head []byte) bool {
+	if isPE(head) {
+		return true
+	}
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPE Windows 可执行文件：MZ 头的 e_lfanew 指向 PE 签名，避免把以 MZ 开头的文本误判为程序
+func isPE(head []byte) bool {
+	if len(head) < 0x40 || !bytes.HasPrefix(head, []byte("MZ")) {
+		return false
+	}
+	offset := int(binary.LittleEndian.Uint32(head[0x3C:]))
+	if offset < 0x40 || offset+4 > len(head) {
+		return false
+	}
+	return bytes.Equal(head[offset:offset+4], []byte("PE\x00\x00"))
+}
+
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+package filecheck
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	contentTypesEntry = "[Content_Types].xml"
+	wordDocumentEntry = "word/document.xml"
+	// ratioCheckMinSize 小于该大小的 zip 条目不检查压缩比，小文件压缩比天然偏高
+	ratioCheckMinSize = 1 << 20
+	// maxControlRatio 文本中控制字符占比的上限
+	maxControlRatio = 0.01
+)
+
+// wordDocumentStream Word 复合文档中 WordDocument 流的目录项名称（UTF-16LE）
+var wordDocumentStream = utf16LE("WordDocument")
+
+// validate 按类型校验文件结构
+func validate(r io.ReaderAt, size int64, kind Kind, limits Limits) error {
+	switch kind {
+	case KindPDF:
+		return validatePDF(r, size)
+	case KindDOCX, KindOOXML, KindZip:
+		return validateZip(r, size, kind, limits)
+	case KindOLE2:
+		return validateOLE2(r, size)
+	case KindText:
+		return validateText(io.NewSectionReader(r, 0, size))
+	}
+	return nil
+}
+
+// validatePDF 解析交叉引用表和页面树，确认文件可以被解析
+func validatePDF(r io.ReaderAt, size int64) (err error) {
+	// 第三方解析库在遇到损坏的文件时可能 panic
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%w: invalid PDF structure: %v", ErrMalformed, p)
+		}
+	}()
+
+	reader, err := pdf.NewReader(r, size)
+	if err != nil {
+		if errors.Is(err, pdf.ErrInvalidPassword) {
+			return fmt.Errorf("%w: PDF is password protected", ErrMalformed)
+		}
+		return fmt.Errorf("%w: invalid PDF structure: %v", ErrMalformed, err)
+	}
+	if reader.NumPage() < 1 {
+		return fmt.Errorf("%w: PDF has no pages", ErrMalformed)
+	}
+	if reader.Page(1).V.IsNull() {
+		return fmt.Errorf("%w: PDF page tree is broken", ErrMalformed)
+	}
+	return nil
+}
+
+// zipKind 根据压缩包目录区分 docx、其他 OOXML 和普通 zip
+func zipKind(r io.ReaderAt, size int64) Kind {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return KindZip
+	}
+
+	var hasContentTypes, hasDocument bool
+	for _, f := range zr.File {
+		switch f.Name {
+		case contentTypesEntry:
+			hasContentTypes = true
+		case wordDocumentEntry:
+			hasDocument = true
+		}
+	}
+	switch {
+	case hasContentTypes && hasDocument:
+		return KindDOCX
+	case hasContentTypes:
+		return KindOOXML
+	}
+	return KindZip
+}
+
+// validateZip 检查条目数、解压大小和压缩比，并解压全部条目校验 CRC。
+// 不信任目录中声明的大小，实际解压的数据同样受限制
+func validateZip(r io.ReaderAt, size int64, kind Kind, limits Limits) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: invalid zip structure: %v", ErrMalformed, err)
+	}
+	if len(zr.File) > limits.MaxZipEntries {
+		return fmt.Errorf("%w: %d entries exceeds the limit of %d", ErrZipBomb, len(zr.File), limits.MaxZipEntries)
+	}
+
+	var declared uint64
+	for _, f := range zr.File {
+		declared += f.UncompressedSize64
+		if declared > uint64(limits.MaxUncompressedSize) {
+			return fmt.Errorf("%w: uncompressed size exceeds %d bytes", ErrZipBomb, limits.MaxUncompressedSize)
+		}
+		if f.UncompressedSize64 >= ratioCheckMinSize &&
+			f.UncompressedSize64 > f.CompressedSize64*uint64(limits.MaxCompressionRatio) {
+			return fmt.Errorf("%w: entry %s has a compression ratio above %d", ErrZipBomb, f.Name, limits.MaxCompressionRatio)
+		}
+	}
+	if declared >= ratioCheckMinSize && declared > uint64(size)*uint64(limits.MaxCompressionRatio) {
+		return fmt.Errorf("%w: compression ratio above %d", ErrZipBomb, limits.MaxCompressionRatio)
+	}
+
+	remaining := limits.MaxUncompressedSize
+	for _, f := range zr.File {
+		if f.Flags&0x1 != 0 {
+			return fmt.Errorf("%w: entry %s is encrypted", ErrMalformed, f.Name)
+		}
+		n, err := readZipEntry(f, kind, remaining)
+		if err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return nil
+}
+
+// readZipEntry 解压单个条目，docx 的关键 XML 同时检查格式是否正确
+func readZipEntry(f *zip.File, kind Kind, remaining int64) (int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, fmt.Errorf("%w: cannot open entry %s: %v", ErrMalformed, f.Name, err)
+	}
+	defer rc.Close()
+
+	// 多读一个字节用于判断实际大小是否超过声明值或剩余的解压额度
+	limit := min(int64(f.UncompressedSize64), remaining) + 1
+	counter := &countingReader{r: io.LimitReader(rc, limit)}
+
+	if kind == KindDOCX && (f.Name == contentTypesEntry || f.Name == wordDocumentEntry) {
+		if err := checkXML(counter); err != nil {
+			return 0, fmt.Errorf("%w: entry %s is not well-formed XML: %v", ErrMalformed, f.Name, err)
+		}
+	}
+	if _, err := io.Copy(io.Discard, counter); err != nil {
+		// 包括 zip.ErrChecksum 和 zip.ErrFormat
+		return 0, fmt.Errorf("%w: entry %s is corrupted: %v", ErrMalformed, f.Name, err)
+	}
+	if counter.n >= limit {
+		return 0, fmt.Errorf("%w: entry %s decompresses beyond the allowed size", ErrZipBomb, f.Name)
+	}
+	return counter.n, nil
+}
+
+func checkXML(r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// validateOLE2 检查复合文档头，并确认包含 Word 文档流（排除改名的 xls、ppt 等）
+func validateOLE2(r io.ReaderAt, size int64) error {
+	if size < 512 {
+		return fmt.Errorf("%w: compound document header is truncated", ErrMalformed)
+	}
+	header := make([]byte, 512)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("%w: cannot read compound document header: %v", ErrMalformed, err)
+	}
+
+	majorVersion := binary.LittleEndian.Uint16(header[26:])
+	byteOrder := binary.LittleEndian.Uint16(header[28:])
+	sectorShift := binary.LittleEndian.Uint16(header[30:])
+	if byteOrder != 0xFFFE ||
+		!(majorVersion == 3 && sectorShift == 9 || majorVersion == 4 && sectorShift == 12) {
+		return fmt.Errorf("%w: invalid compound document header", ErrMalformed)
+	}
+
+	found, err := containsBytes(io.NewSectionReader(r, 0, size), wordDocumentStream)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: compound document is not a Word document", ErrTypeMismatch)
+	}
+	return nil
+}
+
+// containsBytes 流式查找 pattern，相邻缓冲区之间保留重叠部分
+func containsBytes(r io.Reader, pattern []byte) (bool, error) {
+	buf := make([]byte, 64<<10)
+	keep := 0
+	for {
+		n, err := r.Read(buf[keep:])
+		window := buf[:keep+n]
+		if bytes.Contains(window, pattern) {
+			return true, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		keep = min(len(pattern)-1, len(window))
+		copy(buf, window[len(window)-keep:])
+	}
+}
+
+// validateText 整个文件必须是合法的 UTF-8 文本
+func validateText(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var total, control int64
+	for {
+		c, size, err := br.ReadRune()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if c == utf8.RuneError && size == 1 {
+			return fmt.Errorf("%w: text is not valid UTF-8", ErrMalformed)
+		}
+		if c == 0 {
+			return fmt.Errorf("%w: text contains NUL bytes", ErrMalformed)
+		}
+		total++
+		if isControl(c) {
+			control++
+		}
+	}
+	if total > 0 && float64(control)/float64(total) > maxControlRatio {
+		return fmt.Errorf("%w: text contains too many control characters", ErrMalformed)
+	}
+	return nil
+}
+
+// looksLikeText 文件头是否像 UTF-8 文本。complete 为 false 时文件头可能在多字节字符中间截断
+func looksLikeText(head []byte, complete bool) bool {
+	head = bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF"))
+	if !complete {
+		// 去掉末尾不完整的字符
+		for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(head[i]) {
+				if !utf8.FullRune(head[i:]) {
+					head = head[:i]
+				}
+				break
+			}
+		}
+	}
+	if len(head) == 0 || !utf8.Valid(head) || bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+
+	var total, control int
+	for _, c := range string(head) {
+		total++
+		if isControl(c) {
+			control++
+		}
+	}
+	return float64(control)/float64(total) <= maxControlRatio
+}
+
+// isControl 文本中不应出现的控制字符，制表、换行、换页和 ESC 除外
+func isControl(c rune) bool {
+	switch c {
+	case '\t', '\n', '\r', '\f', 0x1B:
+		return false
+	}
+	return c < 0x20 || c == 0x7F
+}
+
+func utf16LE(s string) []byte {
+	b := make([]byte, 0, len(s)*2)
+	for _, c := range s {
+		b = append(b, byte(c), 0)
+	}
+	return b
+}
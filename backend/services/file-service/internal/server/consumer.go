@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// consumerBackoff 读取结果队列出错后的等待时间
+const consumerBackoff = 5 * time.Second
+
+// ParseResultConsumer 持续读取解析服务回传的结果，更新批次中文件的解析状态
+type ParseResultConsumer struct {
+	uc  *biz.ArchiveUsecase
+	log *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewParseResultConsumer 创建解析结果消费者
+func NewParseResultConsumer(uc *biz.ArchiveUsecase, logger log.Logger) *ParseResultConsumer {
+	return &ParseResultConsumer{
+		uc:   uc,
+		log:  log.NewHelper(logger),
+		stop: make(chan struct{}),
+	}
+}
+
+// Start 启动消费循环，实现 transport.Server 接口
+func (c *ParseResultConsumer) Start(ctx context.Context) error {
+	c.log.Info("[Consumer] parse result consumer started")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for ctx.Err() == nil {
+		if _, err := c.uc.HandleNextResult(ctx); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			c.log.Errorf("[Consumer] failed to handle parse result: %v", err)
+			select {
+			case <-time.After(consumerBackoff):
+			case <-ctx.Done():
+			}
+		}
+	}
+	return nil
+}
+
+// Stop 停止消费循环
+func (c *ParseResultConsumer) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.log.Info("[Consumer] parse result consumer stopped")
+	return nil
+}
@@ -3,7 +3,9 @@ package server
 import (
 	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
 	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/service"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	filev1 "github.com/lyb88999/resume_helper/backend/shared/proto/file"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
@@ -11,11 +13,16 @@ import (
 )
 
 // NewGRPCServer new a gRPC server.
-func NewGRPCServer(c *conf.Server, fileService *service.FileService, logger log.Logger) *grpc.Server {
+func NewGRPCServer(c *conf.Server, bc *conf.Bootstrap, revocations *auth.Revocations, fileService *service.FileService, contentService *service.FileContentService, logger log.Logger) *grpc.Server {
+	secret := bc.GetAuth().GetJwtSecret()
+	authOpts := append(permissions(), auth.WithRevocations(revocations))
 	var opts = []grpc.ServerOption{
 		grpc.Middleware(
 			recovery.Recovery(),
+			auth.Server(secret, authOpts...),
 		),
+		// kratos 的中间件不作用于流式调用，上传、下载和导出由拦截器校验
+		grpc.StreamInterceptor(auth.StreamServerInterceptor(secret, authOpts...)),
 	}
 	if c.Grpc.Network != "" {
 		opts = append(opts, grpc.Network(c.Grpc.Network))
@@ -28,5 +35,6 @@ func NewGRPCServer(c *conf.Server, fileService *service.FileService, logger log.
 	}
 	srv := grpc.NewServer(opts...)
 	v1.RegisterFileServiceServer(srv, fileService)
+	filev1.RegisterFileContentServiceServer(srv, contentService)
 	return srv
 }
@@ -2,9 +2,11 @@ package server
 
 import (
 	"net/http"
+	"strings"
 
 	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
 	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/service"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 
 	"github.com/go-kratos/kratos/v2/log"
@@ -13,20 +15,27 @@ import (
 )
 
 // NewHTTPServer new an HTTP server.
-func NewHTTPServer(c *conf.Server, fileService *service.FileService, logger log.Logger) *khttp.Server {
+func NewHTTPServer(c *conf.Server, bc *conf.Bootstrap, revocations *auth.Revocations, fileService *service.FileService, tusService *service.TusService, logger log.Logger) *khttp.Server {
+	allowedHeaders := append(append([]string{}, service.TusAllowedHeaders...), service.DownloadAllowedHeaders...)
+	exposedHeaders := append(append([]string{}, service.TusExposedHeaders...), service.DownloadExposedHeaders...)
+	secret := bc.GetAuth().GetJwtSecret()
+	revoked := auth.WithRevocations(revocations)
 	var opts = []khttp.ServerOption{
 		khttp.Middleware(
 			recovery.Recovery(),
+			auth.Server(secret, append(permissions(), revoked)...),
 		),
 		// 添加CORS支持
 		khttp.Filter(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Access-Control-Allow-Origin", "*")
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, HEAD, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+strings.Join(allowedHeaders, ", "))
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 
-				if r.Method == "OPTIONS" {
+				// tus 客户端通过 OPTIONS 获取服务端能力，交给 tus 处理
+				if r.Method == "OPTIONS" && !strings.HasPrefix(r.URL.Path, service.TusBasePath) {
 					w.WriteHeader(http.StatusOK)
 					return
 				}
@@ -45,8 +54,28 @@ func NewHTTPServer(c *conf.Server, fileService *service.FileService, logger log.
 		opts = append(opts, khttp.Timeout(c.Http.Timeout.AsDuration()))
 	}
 	srv := khttp.NewServer(opts...)
+
+	// 签名下载链接的签名即授权，无需访问令牌。先于 API 路由注册，避免 /api/v1/files/{file_id}/url 等路由匹配到该前缀下的路径
+	srv.HandlePrefix(service.ContentBasePath, http.HandlerFunc(fileService.ServeContent))
+
+	// 导出压缩包的签名下载链接和直接导出，同样先于 /api/v1/exports/{export_id} 注册
+	srv.HandlePrefix(service.ExportContentBasePath, http.HandlerFunc(fileService.ServeExport))
+	srv.Handle(service.ExportStreamPath, auth.Handler(secret, http.HandlerFunc(fileService.StreamExportHTTP), revoked))
+
 	v1.RegisterFileServiceHTTPServer(srv, fileService)
 
+	// 流式上传（multipart/form-data 或原始请求体），文件内容直接写入存储
+	srv.Handle("/api/v1/files/upload/stream", auth.Handler(secret, http.HandlerFunc(fileService.UploadHTTP), revoked))
+
+	// 上传已有文件的新版本，请求格式与流式上传相同
+	srv.Handle(service.VersionUploadPath, auth.Handler(secret, http.HandlerFunc(fileService.UploadVersionHTTP), revoked))
+
+	// 上传 ZIP 压缩包，其中的文件归入同一批次并自动提交解析
+	srv.Handle(archiveUploadPath, auth.Handler(secret, http.HandlerFunc(fileService.UploadArchiveHTTP), append(permissions(), revoked)...))
+
+	// tus 断点续传
+	srv.HandlePrefix(service.TusBasePath, auth.Handler(secret, tusService, revoked))
+
 	// 添加健康检查端点
 	srv.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// UploadJanitor 定期清理过期的断点续传上传
+type UploadJanitor struct {
+	uc       *biz.TusUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewUploadJanitor 创建上传清理任务
+func NewUploadJanitor(uc *biz.TusUsecase, logger log.Logger) *UploadJanitor {
+	return &UploadJanitor{
+		uc:       uc,
+		interval: uc.CleanupInterval(),
+		log:      log.NewHelper(logger),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动清理循环，实现 transport.Server 接口
+func (j *UploadJanitor) Start(ctx context.Context) error {
+	j.log.Infof("[Janitor] upload cleanup started, interval: %s", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce(ctx)
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止清理循环
+func (j *UploadJanitor) Stop(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.log.Info("[Janitor] upload cleanup stopped")
+	return nil
+}
+
+func (j *UploadJanitor) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	count, err := j.uc.CleanupExpired(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] failed to clean up expired uploads: %v", err)
+		return
+	}
+	if count > 0 {
+		j.log.Infof("[Janitor] cleaned up %d expired uploads", count)
+	}
+}
+
+// ScanJanitor 定期重新扫描扫描失败或尚未扫描的文件
+type ScanJanitor struct {
+	uc       *biz.ScanUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewScanJanitor 创建扫描重试任务
+func NewScanJanitor(uc *biz.ScanUsecase, logger log.Logger) *ScanJanitor {
+	return &ScanJanitor{
+		uc:       uc,
+		interval: uc.RetryInterval(),
+		log:      log.NewHelper(logger),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动重试循环，实现 transport.Server 接口
+func (j *ScanJanitor) Start(ctx context.Context) error {
+	j.log.Infof("[Janitor] scan retry started, interval: %s", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce(ctx)
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止重试循环
+func (j *ScanJanitor) Stop(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.log.Info("[Janitor] scan retry stopped")
+	return nil
+}
+
+func (j *ScanJanitor) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	count, err := j.uc.RescanPending(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] failed to rescan pending files: %v", err)
+		return
+	}
+	if count > 0 {
+		j.log.Infof("[Janitor] rescanned %d pending files", count)
+	}
+}
+
+// ReconcileJanitor 定期清理过期的回收站文件并检查存储一致性
+type ReconcileJanitor struct {
+	uc       *biz.ReconcileUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewReconcileJanitor 创建一致性检查任务
+func NewReconcileJanitor(uc *biz.ReconcileUsecase, logger log.Logger) *ReconcileJanitor {
+	return &ReconcileJanitor{
+		uc:       uc,
+		interval: uc.Interval(),
+		log:      log.NewHelper(logger),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动检查循环，实现 transport.Server 接口
+func (j *ReconcileJanitor) Start(ctx context.Context) error {
+	j.log.Infof("[Janitor] storage reconciliation started, interval: %s", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce(ctx)
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止检查循环
+func (j *ReconcileJanitor) Stop(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.log.Info("[Janitor] storage reconciliation stopped")
+	return nil
+}
+
+func (j *ReconcileJanitor) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	report, err := j.uc.Reconcile(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] storage reconciliation failed: %v (%s)", err, report)
+		return
+	}
+	if !report.Empty() {
+		j.log.Infof("[Janitor] storage reconciliation finished: %s", report)
+	}
+}
+
+// BatchJanitor 定期将压缩包中已通过扫描的文件提交解析，并处理解析超时的条目
+type BatchJanitor struct {
+	uc       *biz.ArchiveUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewBatchJanitor 创建批次解析提交任务
+func NewBatchJanitor(uc *biz.ArchiveUsecase, logger log.Logger) *BatchJanitor {
+	return &BatchJanitor{
+		uc:       uc,
+		interval: uc.DispatchInterval(),
+		log:      log.NewHelper(logger),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动提交循环，实现 transport.Server 接口
+func (j *BatchJanitor) Start(ctx context.Context) error {
+	j.log.Infof("[Janitor] batch parse dispatch started, interval: %s", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce(ctx)
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止提交循环
+func (j *BatchJanitor) Stop(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.log.Info("[Janitor] batch parse dispatch stopped")
+	return nil
+}
+
+func (j *BatchJanitor) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	if err := j.uc.RecoverStale(ctx); err != nil {
+		j.log.Errorf("[Janitor] failed to recover stale batch entries: %v", err)
+	}
+	count, err := j.uc.Dispatch(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] failed to dispatch batch files for parsing: %v", err)
+	}
+	if count > 0 {
+		j.log.Infof("[Janitor] dispatched %d batch files for parsing", count)
+	}
+}
+
+// ExportJanitor 定期执行等待中的导出任务，并删除过了保留期的导出压缩包
+type ExportJanitor struct {
+	uc       *biz.ExportUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewExportJanitor 创建导出任务
+func NewExportJanitor(uc *biz.ExportUsecase, logger log.Logger) *ExportJanitor {
+	return &ExportJanitor{
+		uc:       uc,
+		interval: uc.PollInterval(),
+		log:      log.NewHelper(logger),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动导出循环，实现 transport.Server 接口
+func (j *ExportJanitor) Start(ctx context.Context) error {
+	j.log.Infof("[Janitor] export processing started, interval: %s", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce(ctx)
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止导出循环
+func (j *ExportJanitor) Stop(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	j.log.Info("[Janitor] export processing stopped")
+	return nil
+}
+
+// runOnce 导出任务的执行时间由用例单独限制，不受检查间隔约束
+func (j *ExportJanitor) runOnce(ctx context.Context) {
+	count, err := j.uc.ProcessPending(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] failed to process exports: %v", err)
+	}
+	if count > 0 {
+		j.log.Infof("[Janitor] processed %d exports", count)
+	}
+
+	removed, err := j.uc.RemoveExpired(ctx)
+	if err != nil {
+		j.log.Errorf("[Janitor] failed to remove expired exports: %v", err)
+	}
+	if removed > 0 {
+		j.log.Infof("[Janitor] removed %d expired exports", removed)
+	}
+}
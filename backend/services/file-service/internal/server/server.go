@@ -6,11 +6,13 @@ import (
 	"github.com/google/wire"
 	"github.com/hashicorp/consul/api"
 
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
 // ProviderSet is server providers.
-var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewRegistrar)
+var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewRegistrar, NewUploadJanitor, NewScanJanitor, NewReconcileJanitor, NewBatchJanitor, NewExportJanitor, NewParseResultConsumer)
 
 // NewRegistrar 创建服务注册器
 func NewRegistrar(c *conf.Bootstrap) registry.Registrar {
@@ -33,3 +35,18 @@ func NewRegistrar(c *conf.Bootstrap) registry.Registrar {
 	reg := consul.New(client)
 	return reg
 }
+
+// archiveUploadPath 直接注册的压缩包上传路由
+const archiveUploadPath = "/api/v1/files/archives"
+
+// permissions 需要特定权限的操作：压缩包批量上传需要批量筛选权限，设置存储套餐仅限管理员
+func permissions() []auth.Option {
+	return []auth.Option{
+		auth.WithPermission(auth.PermBatchScreening,
+			v1.FileService_UploadArchive_FullMethodName,
+			v1.OperationFileServiceGetBatchStatus,
+			archiveUploadPath,
+		),
+		auth.WithPermission(auth.PermManageUsers, v1.OperationFileServiceSetStoragePlan),
+	}
+}
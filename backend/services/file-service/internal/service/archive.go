@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+// UploadArchive 流式上传 ZIP 压缩包（gRPC client streaming），消息格式与 UploadStream 相同
+func (s *FileService) UploadArchive(stream v1.FileService_UploadArchiveServer) error {
+	ctx := stream.Context()
+
+	in, err := recvUploadInput(ctx, stream)
+	if err != nil {
+		return err
+	}
+	s.log.WithContext(ctx).Infof("压缩包上传请求: filename=%s, size=%d", in.Filename, in.Size)
+
+	batch, err := s.archives.UploadArchive(ctx, in)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("压缩包上传失败: %v", err)
+		return archiveError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("压缩包上传成功: batch_id=%s, entries=%d", batch.BatchID, len(batch.Entries))
+	return stream.SendAndClose(&v1.UploadArchiveReply{Batch: toProtoBatch(batch)})
+}
+
+// UploadArchiveHTTP 上传 ZIP 压缩包（HTTP），请求方式与 UploadHTTP 相同
+func (s *FileService) UploadArchiveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	in, err := s.readUploadInput(w, r, s.archives.MaxSize())
+	if err != nil {
+		khttp.DefaultErrorEncoder(w, r, archiveError(err))
+		return
+	}
+	s.log.WithContext(ctx).Infof("HTTP压缩包上传请求: filename=%s, size=%d", in.Filename, in.Size)
+
+	batch, err := s.archives.UploadArchive(ctx, in)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("HTTP压缩包上传失败: %v", err)
+		khttp.DefaultErrorEncoder(w, r, archiveError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).Infof("HTTP压缩包上传成功: batch_id=%s, entries=%d", batch.BatchID, len(batch.Entries))
+	if err := khttp.DefaultResponseEncoder(w, r, &v1.UploadArchiveReply{Batch: toProtoBatch(batch)}); err != nil {
+		s.log.WithContext(ctx).Errorf("写入压缩包上传响应失败: %v", err)
+	}
+}
+
+// GetBatchStatus 获取压缩包批次的处理结果和解析状态
+func (s *FileService) GetBatchStatus(ctx context.Context, req *v1.GetBatchStatusRequest) (*v1.GetBatchStatusReply, error) {
+	s.log.WithContext(ctx).Infof("获取批次状态请求: batch_id=%s", req.BatchId)
+
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := s.archives.GetBatch(ctx, req.BatchId, userID)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取批次状态失败: %v", err)
+		return nil, archiveError(err)
+	}
+
+	return &v1.GetBatchStatusReply{Batch: toProtoBatch(batch)}, nil
+}
+
+// toProtoBatch 转换为proto批次
+func toProtoBatch(batch *biz.Batch) *v1.Batch {
+	byStatus, byParseStatus := batch.Counts()
+	entries := make([]*v1.BatchEntry, len(batch.Entries))
+	for i, entry := range batch.Entries {
+		entries[i] = &v1.BatchEntry{
+			Name:        entry.Name,
+			Status:      entry.Status,
+			Reason:      entry.Reason,
+			FileId:      entry.FileID,
+			ParseStatus: entry.ParseStatus,
+			ParseTaskId: entry.ParseTaskID,
+			ParseError:  entry.ParseError,
+		}
+	}
+	return &v1.Batch{
+		BatchId:      batch.BatchID,
+		UserId:       batch.UserID,
+		ArchiveName:  batch.ArchiveName,
+		CreatedAt:    timestamppb.New(batch.CreatedAt),
+		Total:        int32(len(batch.Entries)),
+		StatusCounts: byStatus,
+		ParseCounts:  byParseStatus,
+		Finished:     batch.Finished(),
+		Entries:      entries,
+	}
+}
+
+// archiveError 将压缩包相关的业务错误转换为API错误
+func archiveError(err error) error {
+	switch {
+	case errors.Is(err, biz.ErrInvalidArchive):
+		return v1.ErrorInvalidArchive("%v", err)
+	case errors.Is(err, biz.ErrBatchNotFound):
+		return v1.ErrorBatchNotFound("batch not found")
+	case errors.Is(err, biz.ErrInvalidFileType):
+		return v1.ErrorFileFormatNotSupported("only .zip archives are accepted")
+	default:
+		return uploadError(err)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	filev1 "github.com/lyb88999/resume_helper/backend/shared/proto/file"
+)
+
+// contentChunkSize ReadFile 每条消息携带的内容大小
+const contentChunkSize = 256 << 10
+
+// FileContentService 文件内容服务实现，供其他服务按文件ID读写文件内容，
+// 调用方以文件所有者或其所在组织成员的身份携带访问令牌
+type FileContentService struct {
+	filev1.UnimplementedFileContentServiceServer
+	uc  *biz.FileUsecase
+	log *log.Helper
+}
+
+// NewFileContentService 创建文件内容服务实例
+func NewFileContentService(uc *biz.FileUsecase, logger log.Logger) *FileContentService {
+	return &FileContentService{
+		uc:  uc,
+		log: log.NewHelper(logger),
+	}
+}
+
+// StatFile 获取文件信息
+func (s *FileContentService) StatFile(ctx context.Context, req *filev1.ReadFileRequest) (*filev1.FileMeta, error) {
+	scope, err := biz.ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.FileId == "" || req.Version < 0 {
+		return nil, v1.ErrorInvalidUpload("file_id is required, version must not be negative")
+	}
+
+	file, err := s.uc.StatContent(ctx, req.FileId, scope, req.Version)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取文件信息失败: file_id=%s, %v", req.FileId, err)
+		return nil, fileError(err)
+	}
+	return toFileMeta(file), nil
+}
+
+// ReadFile 读取文件内容（gRPC server streaming），先发送文件信息，再依次发送内容分片
+func (s *FileContentService) ReadFile(req *filev1.ReadFileRequest, stream filev1.FileContentService_ReadFileServer) error {
+	ctx := stream.Context()
+	scope, err := biz.ScopeFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if req.FileId == "" || req.Version < 0 {
+		return v1.ErrorInvalidUpload("file_id is required, version must not be negative")
+	}
+	s.log.WithContext(ctx).Infof("读取文件内容请求: file_id=%s, version=%d, user_id=%d, org_id=%d", req.FileId, req.Version, scope.UserID, scope.OrgID)
+
+	file, content, err := s.uc.OpenContent(ctx, req.FileId, scope, req.Version)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("读取文件内容失败: %v", err)
+		return fileError(err)
+	}
+	defer content.Close()
+
+	if err := stream.Send(&filev1.ReadFileReply{Data: &filev1.ReadFileReply_Meta{Meta: toFileMeta(file)}}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, contentChunkSize)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			chunk := &filev1.ReadFileReply_Chunk{Chunk: buf[:n]}
+			if err := stream.Send(&filev1.ReadFileReply{Data: chunk}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			s.log.WithContext(ctx).Errorf("读取文件内容失败: file_id=%s, %v", file.FileID, err)
+			return err
+		}
+	}
+}
+
+// WriteFile 以访问令牌中用户的身份保存文件（gRPC client streaming），校验规则与 UploadStream 相同
+func (s *FileContentService) WriteFile(stream filev1.FileContentService_WriteFileServer) error {
+	ctx := stream.Context()
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return v1.ErrorInvalidUpload("missing file metadata")
+		}
+		return err
+	}
+	meta := first.GetMetadata()
+	if meta == nil {
+		return v1.ErrorInvalidUpload("first message must carry file metadata")
+	}
+	if meta.Filename == "" {
+		return v1.ErrorInvalidUpload("filename is required")
+	}
+	s.log.WithContext(ctx).Infof("保存文件请求: filename=%s, user_id=%d, size=%d", meta.Filename, userID, meta.Size)
+
+	reply, err := s.uc.UploadStream(ctx, &biz.UploadInput{
+		Filename: meta.Filename,
+		UserID:   userID,
+		Size:     meta.Size,
+		Content:  &writeFileReader{stream: stream},
+	})
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("保存文件失败: %v", err)
+		return uploadError(err)
+	}
+
+	info := reply.File
+	s.log.WithContext(ctx).Infof("保存文件成功: file_id=%s, size=%d", info.FileId, info.Size)
+	return stream.SendAndClose(&filev1.WriteFileReply{
+		File: &filev1.FileMeta{
+			FileId:      info.FileId,
+			Filename:    info.OriginalName,
+			MimeType:    info.MimeType,
+			Size:        info.Size,
+			ContentHash: info.ContentHash,
+			UserId:      info.UserId,
+			Version:     info.Version,
+		},
+		Status: info.Status,
+	})
+}
+
+// toFileMeta 转换为文件信息
+func toFileMeta(file *biz.File) *filev1.FileMeta {
+	return &filev1.FileMeta{
+		FileId:      file.FileID,
+		Filename:    file.OriginalName,
+		MimeType:    file.MimeType,
+		Size:        file.Size,
+		ContentHash: file.ContentHash,
+		UserId:      file.UserID,
+		Version:     file.Version,
+	}
+}
+
+// writeFileReader 将 WriteFile 请求流适配为 io.Reader，每次只持有一个分片
+type writeFileReader struct {
+	stream filev1.FileContentService_WriteFileServer
+	buf    []byte
+}
+
+func (r *writeFileReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		msg, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if msg.GetMetadata() != nil {
+			return 0, v1.ErrorInvalidUpload("metadata must only be sent in the first message")
+		}
+		r.buf = msg.GetChunk()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+// ContentBasePath 签名下载链接的路径前缀
+const ContentBasePath = biz.ContentBasePath
+
+// DownloadAllowedHeaders 下载链接需要允许的跨域请求头
+var DownloadAllowedHeaders = []string{"Range", "If-None-Match", "If-Modified-Since", "If-Range"}
+
+// DownloadExposedHeaders 下载链接需要暴露给浏览器的响应头
+var DownloadExposedHeaders = []string{"Content-Range", "Accept-Ranges", "Content-Length", "ETag", "Content-Disposition"}
+
+// GetDownloadURL 获取带签名的下载链接
+func (s *FileService) GetDownloadURL(ctx context.Context, req *v1.GetDownloadURLRequest) (*v1.GetDownloadURLReply, error) {
+	s.log.WithContext(ctx).Infof("获取下载链接请求: file_id=%s, attachment=%t", req.FileId, req.Attachment)
+
+	scope, err := biz.ScopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := s.downloads.GetDownloadURL(ctx, req.FileId, scope, req.Attachment)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取下载链接失败: %v", err)
+		return nil, fileError(err)
+	}
+
+	return &v1.GetDownloadURLReply{
+		Url:       signed.URL,
+		ExpiresAt: timestamppb.New(signed.ExpiresAt),
+	}, nil
+}
+
+// ServeContent 通过签名链接下载文件，支持 Range、HEAD 和 If-None-Match，内容从存储流式读取
+func (s *FileService) ServeContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	fileID, err := url.PathUnescape(strings.Trim(strings.TrimPrefix(r.URL.Path, biz.ContentBasePath), "/"))
+	if err != nil || fileID == "" {
+		khttp.DefaultErrorEncoder(w, r, v1.ErrorFileNotFound("file not found"))
+		return
+	}
+	query := r.URL.Query()
+	params := &biz.DownloadParams{
+		FileID:     fileID,
+		Attachment: query.Get("dl") == "1",
+		Signature:  query.Get("sig"),
+	}
+	// 参数格式错误时签名校验不会通过
+	params.UserID, _ = strconv.ParseInt(query.Get("uid"), 10, 64)
+	params.Expires, _ = strconv.ParseInt(query.Get("exp"), 10, 64)
+
+	download, err := s.downloads.Open(ctx, params)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("签名链接下载失败: file_id=%s, err=%v", fileID, err)
+		khttp.DefaultErrorEncoder(w, r, fileError(err))
+		return
+	}
+	defer download.Close()
+
+	s.log.WithContext(ctx).Infof("签名链接下载: file_id=%s, range=%q", fileID, r.Header.Get("Range"))
+	serveDownload(w, r, download)
+}
+
+// serveDownload 写入下载响应头并流式返回内容
+func serveDownload(w http.ResponseWriter, r *http.Request, download *biz.Download) {
+	header := w.Header()
+	header.Set("Content-Type", download.File.MimeType)
+	header.Set("Content-Disposition", download.Disposition())
+	header.Set("ETag", download.ETag)
+	header.Set("X-Content-Type-Options", "nosniff")
+	// 缓存不能超过链接的有效期
+	maxAge := max(int(time.Until(download.ExpiresAt).Seconds()), 0)
+	header.Set("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
+
+	http.ServeContent(w, r, "", download.File.UpdatedAt, download.Content)
+}
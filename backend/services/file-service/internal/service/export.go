@@ -0,0 +1,212 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+// ExportContentBasePath 导出压缩包签名下载链接的路径前缀
+const ExportContentBasePath = biz.ExportContentBasePath
+
+// ExportStreamPath 直接导出的 HTTP 路由
+const ExportStreamPath = "/api/v1/exports/stream"
+
+// exportChunkSize 直接导出时每条消息携带的内容大小
+const exportChunkSize = 256 << 10
+
+// StreamExport 直接导出用户的所有数据（gRPC server streaming）
+func (s *FileService) StreamExport(req *v1.StreamExportRequest, stream v1.FileService_StreamExportServer) error {
+	ctx := stream.Context()
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return err
+	}
+	s.log.WithContext(ctx).Infof("直接导出请求: user_id=%d", userID)
+
+	plan, err := s.exports.PlanExport(ctx, userID)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("直接导出失败: %v", err)
+		return exportError(err)
+	}
+
+	w := bufio.NewWriterSize(&exportChunkWriter{stream: stream}, exportChunkSize)
+	stats, err := s.exports.WriteExport(ctx, plan, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("直接导出失败: %v", err)
+		return v1.ErrorFileDownloadFailed("export failed: %v", err)
+	}
+
+	s.log.WithContext(ctx).Infof("直接导出成功: user_id=%d, files=%d, records=%d, size=%d", userID, stats.Files, stats.Records, stats.Size)
+	return nil
+}
+
+// exportChunkWriter 将写入的内容作为一条消息发送
+type exportChunkWriter struct {
+	stream v1.FileService_StreamExportServer
+}
+
+func (w *exportChunkWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&v1.StreamExportReply{Chunk: p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// StreamExportHTTP 直接导出用户的所有数据（HTTP），压缩包边生成边返回。
+// 响应开始后出错时中断连接，客户端不会收到不完整但看似正常结束的压缩包
+func (s *FileService) StreamExportHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		khttp.DefaultErrorEncoder(w, r, err)
+		return
+	}
+	s.log.WithContext(ctx).Infof("HTTP直接导出请求: user_id=%d", userID)
+
+	plan, err := s.exports.PlanExport(ctx, userID)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("HTTP直接导出失败: %v", err)
+		khttp.DefaultErrorEncoder(w, r, exportError(err))
+		return
+	}
+
+	filename := "export-" + time.Now().Format("20060102") + ".zip"
+	header := w.Header()
+	header.Set("Content-Type", "application/zip")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	stats, err := s.exports.WriteExport(ctx, plan, w)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("HTTP直接导出中断: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+	s.log.WithContext(ctx).Infof("HTTP直接导出成功: user_id=%d, files=%d, records=%d, size=%d", userID, stats.Files, stats.Records, stats.Size)
+}
+
+// CreateExport 创建后台导出任务
+func (s *FileService) CreateExport(ctx context.Context, req *v1.CreateExportRequest) (*v1.CreateExportReply, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.WithContext(ctx).Infof("创建导出任务请求: user_id=%d", userID)
+
+	export, err := s.exports.CreateExport(ctx, userID)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("创建导出任务失败: %v", err)
+		return nil, exportError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("创建导出任务成功: export_id=%s, status=%s", export.ExportID, export.Status)
+	return &v1.CreateExportReply{Export: toProtoExport(export, nil)}, nil
+}
+
+// GetExport 获取导出任务状态
+func (s *FileService) GetExport(ctx context.Context, req *v1.GetExportRequest) (*v1.GetExportReply, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	export, signed, err := s.exports.GetExport(ctx, req.ExportId, userID)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取导出任务失败: %v", err)
+		return nil, exportError(err)
+	}
+	return &v1.GetExportReply{Export: toProtoExport(export, signed)}, nil
+}
+
+// ServeExport 通过签名链接下载导出压缩包，支持 Range 请求
+func (s *FileService) ServeExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	exportID, err := url.PathUnescape(strings.Trim(strings.TrimPrefix(r.URL.Path, biz.ExportContentBasePath), "/"))
+	if err != nil || exportID == "" {
+		khttp.DefaultErrorEncoder(w, r, v1.ErrorExportNotFound("export not found"))
+		return
+	}
+	query := r.URL.Query()
+	params := &biz.ExportDownloadParams{
+		ExportID:  exportID,
+		Signature: query.Get("sig"),
+	}
+	// 参数格式错误时签名校验不会通过
+	params.UserID, _ = strconv.ParseInt(query.Get("uid"), 10, 64)
+	params.Expires, _ = strconv.ParseInt(query.Get("exp"), 10, 64)
+
+	download, err := s.exports.Open(ctx, params)
+	if err != nil {
+		s.log.WithContext(ctx).Warnf("导出压缩包下载失败: export_id=%s, err=%v", exportID, err)
+		khttp.DefaultErrorEncoder(w, r, exportError(err))
+		return
+	}
+	defer download.Close()
+
+	s.log.WithContext(ctx).Infof("导出压缩包下载: export_id=%s, range=%q", exportID, r.Header.Get("Range"))
+	serveDownload(w, r, download)
+}
+
+// exportError 将业务错误转换为带错误码的错误
+func exportError(err error) error {
+	switch {
+	case errors.Is(err, biz.ErrExportNotFound):
+		return v1.ErrorExportNotFound("export not found")
+	case errors.Is(err, biz.ErrExportTooLarge):
+		return v1.ErrorExportTooLarge("%v, create an export instead", err)
+	default:
+		return fileError(err)
+	}
+}
+
+func toProtoExport(export *biz.Export, signed *biz.SignedURL) *v1.Export {
+	pbExport := &v1.Export{
+		ExportId:    export.ExportID,
+		UserId:      export.UserID,
+		Status:      export.Status,
+		Size:        export.Size,
+		FileCount:   export.FileCount,
+		RecordCount: export.RecordCount,
+		Error:       export.Error,
+		CreatedAt:   timestamppb.New(export.CreatedAt),
+	}
+	if !export.CompletedAt.IsZero() {
+		pbExport.CompletedAt = timestamppb.New(export.CompletedAt)
+	}
+	if !export.ExpiresAt.IsZero() {
+		pbExport.ExpiresAt = timestamppb.New(export.ExpiresAt)
+	}
+	if signed != nil {
+		pbExport.DownloadUrl = signed.URL
+		pbExport.UrlExpiresAt = timestamppb.New(signed.ExpiresAt)
+	}
+	return pbExport
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/go-kratos/kratos/v2/log"
 
@@ -12,15 +13,23 @@ import (
 // FileService 文件服务实现
 type FileService struct {
 	v1.UnimplementedFileServiceServer
-	uc  *biz.FileUsecase
-	log *log.Helper
+	uc        *biz.FileUsecase
+	downloads *biz.DownloadUsecase
+	quotas    *biz.QuotaUsecase
+	archives  *biz.ArchiveUsecase
+	exports   *biz.ExportUsecase
+	log       *log.Helper
 }
 
 // NewFileService 创建文件服务实例
-func NewFileService(uc *biz.FileUsecase, logger log.Logger) *FileService {
+func NewFileService(uc *biz.FileUsecase, downloads *biz.DownloadUsecase, quotas *biz.QuotaUsecase, archives *biz.ArchiveUsecase, exports *biz.ExportUsecase, logger log.Logger) *FileService {
 	return &FileService{
-		uc:  uc,
-		log: log.NewHelper(logger),
+		uc:        uc,
+		downloads: downloads,
+		quotas:    quotas,
+		archives:  archives,
+		exports:   exports,
+		log:       log.NewHelper(logger),
 	}
 }
 
@@ -31,7 +40,7 @@ func (s *FileService) Upload(ctx context.Context, req *v1.UploadRequest) (*v1.Up
 	reply, err := s.uc.Upload(ctx, req)
 	if err != nil {
 		s.log.WithContext(ctx).Errorf("文件上传失败: %v", err)
-		return nil, err
+		return nil, uploadError(err)
 	}
 
 	s.log.WithContext(ctx).Infof("文件上传成功: file_id=%s", reply.File.FileId)
@@ -40,7 +49,7 @@ func (s *FileService) Upload(ctx context.Context, req *v1.UploadRequest) (*v1.Up
 
 // ListFiles 获取文件列表
 func (s *FileService) ListFiles(ctx context.Context, req *v1.ListFilesRequest) (*v1.ListFilesReply, error) {
-	s.log.WithContext(ctx).Infof("获取文件列表请求: user_id=%d, page=%d", req.UserId, req.Page)
+	s.log.WithContext(ctx).Infof("获取文件列表请求: page=%d", req.Page)
 
 	reply, err := s.uc.ListFiles(ctx, req)
 	if err != nil {
@@ -72,7 +81,7 @@ func (s *FileService) DownloadFile(ctx context.Context, req *v1.DownloadFileRequ
 	reply, err := s.uc.DownloadFile(ctx, req)
 	if err != nil {
 		s.log.WithContext(ctx).Errorf("下载文件失败: %v", err)
-		return nil, err
+		return nil, fileError(err)
 	}
 
 	s.log.WithContext(ctx).Infof("下载文件成功: filename=%s", reply.Filename)
@@ -86,9 +95,29 @@ func (s *FileService) DeleteFile(ctx context.Context, req *v1.DeleteFileRequest)
 	reply, err := s.uc.DeleteFile(ctx, req)
 	if err != nil {
 		s.log.WithContext(ctx).Errorf("删除文件失败: %v", err)
-		return nil, err
+		return nil, fileError(err)
 	}
 
-	s.log.WithContext(ctx).Infof("删除文件成功: file_id=%s", req.FileId)
+	s.log.WithContext(ctx).Infof("文件已移入回收站: file_id=%s", req.FileId)
 	return reply, nil
 }
+
+// fileError 将文件访问相关的业务错误转换为带错误码的错误
+func fileError(err error) error {
+	switch {
+	case errors.Is(err, biz.ErrFileNotFound):
+		return v1.ErrorFileNotFound("file not found")
+	case errors.Is(err, biz.ErrVersionNotFound):
+		return v1.ErrorVersionNotFound("file version not found")
+	case errors.Is(err, biz.ErrFileNotClean):
+		return v1.ErrorFileNotClean("file has not passed malware scanning")
+	case errors.Is(err, biz.ErrPermissionDenied):
+		return v1.ErrorPermissionDenied("only the uploader or an organization owner or admin can modify this file")
+	case errors.Is(err, biz.ErrInvalidSignature):
+		return v1.ErrorPermissionDenied("invalid download signature")
+	case errors.Is(err, biz.ErrLinkExpired):
+		return v1.ErrorDownloadLinkExpired("download link expired")
+	default:
+		return err
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+// GetStorageUsage 获取用户的存储用量和配额
+func (s *FileService) GetStorageUsage(ctx context.Context, req *v1.GetStorageUsageRequest) (*v1.GetStorageUsageReply, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.WithContext(ctx).Infof("获取存储用量请求: user_id=%d", userID)
+
+	usage, err := s.quotas.GetUsage(ctx, userID)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取存储用量失败: %v", err)
+		return nil, err
+	}
+
+	byType := make([]*v1.TypeUsage, len(usage.ByType))
+	for i, t := range usage.ByType {
+		byType[i] = &v1.TypeUsage{
+			MimeType:  t.MimeType,
+			Bytes:     t.Bytes,
+			FileCount: t.FileCount,
+		}
+	}
+	return &v1.GetStorageUsageReply{
+		UserId:     usage.UserID,
+		Plan:       usage.Plan,
+		UsedBytes:  usage.UsedBytes,
+		FileCount:  usage.FileCount,
+		MaxBytes:   usage.Limits.MaxBytes,
+		MaxFiles:   usage.Limits.MaxFiles,
+		ByType:     byType,
+		TrashBytes: usage.Trash.Bytes,
+		TrashFiles: usage.Trash.FileCount,
+	}, nil
+}
+
+// SetStoragePlan 设置用户的存储套餐
+func (s *FileService) SetStoragePlan(ctx context.Context, req *v1.SetStoragePlanRequest) (*v1.SetStoragePlanReply, error) {
+	s.log.WithContext(ctx).Infof("设置存储套餐请求: user_id=%d, plan=%s", req.UserId, req.Plan)
+
+	if err := s.quotas.SetPlan(ctx, req.UserId, req.Plan); err != nil {
+		s.log.WithContext(ctx).Errorf("设置存储套餐失败: %v", err)
+		if errors.Is(err, biz.ErrUnknownPlan) {
+			return nil, v1.ErrorUnknownStoragePlan("unknown storage plan: %s", req.Plan)
+		}
+		return nil, err
+	}
+
+	return &v1.SetStoragePlanReply{Success: true}, nil
+}
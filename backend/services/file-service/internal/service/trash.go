@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+)
+
+// ListTrash 获取回收站中的文件
+func (s *FileService) ListTrash(ctx context.Context, req *v1.ListTrashRequest) (*v1.ListTrashReply, error) {
+	s.log.WithContext(ctx).Infof("获取回收站文件请求: page=%d", req.Page)
+
+	reply, err := s.uc.ListTrash(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取回收站文件失败: %v", err)
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// RestoreFile 将文件移出回收站
+func (s *FileService) RestoreFile(ctx context.Context, req *v1.RestoreFileRequest) (*v1.RestoreFileReply, error) {
+	s.log.WithContext(ctx).Infof("恢复文件请求: file_id=%s", req.FileId)
+
+	reply, err := s.uc.RestoreFile(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("恢复文件失败: %v", err)
+		return nil, fileError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("恢复文件成功: file_id=%s", req.FileId)
+	return reply, nil
+}
+
+// PurgeFile 永久删除回收站中的文件
+func (s *FileService) PurgeFile(ctx context.Context, req *v1.PurgeFileRequest) (*v1.PurgeFileReply, error) {
+	s.log.WithContext(ctx).Infof("永久删除文件请求: file_id=%s", req.FileId)
+
+	reply, err := s.uc.PurgeFile(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("永久删除文件失败: %v", err)
+		return nil, fileError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("永久删除文件成功: file_id=%s", req.FileId)
+	return reply, nil
+}
+
+// EmptyTrash 清空回收站
+func (s *FileService) EmptyTrash(ctx context.Context, req *v1.EmptyTrashRequest) (*v1.EmptyTrashReply, error) {
+	s.log.WithContext(ctx).Infof("清空回收站请求")
+
+	reply, err := s.uc.EmptyTrash(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("清空回收站失败: %v", err)
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Infof("清空回收站成功: purged=%d", reply.Purged)
+	return reply, nil
+}
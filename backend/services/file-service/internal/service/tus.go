@@ -0,0 +1,256 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+const (
+	// TusBasePath tus 断点续传接口路径前缀
+	TusBasePath = "/api/v1/files/tus/"
+
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination"
+	// tusContentType PATCH 请求体的类型
+	tusContentType = "application/offset+octet-stream"
+	// fileIDHeader 上传完成后返回文件ID的响应头
+	fileIDHeader = "X-File-Id"
+)
+
+// TusExposedHeaders 浏览器客户端需要读取的响应头
+var TusExposedHeaders = []string{
+	"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size",
+	"Upload-Offset", "Upload-Length", "Upload-Expires", "Upload-Metadata", fileIDHeader,
+}
+
+// TusAllowedHeaders 浏览器客户端需要发送的请求头
+var TusAllowedHeaders = []string{
+	"Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", "Upload-Defer-Length", "X-HTTP-Method-Override",
+}
+
+// TusService 实现 tus 1.0 断点续传协议（creation、expiration、termination 扩展）。
+// 上传属于访问令牌中的用户，其他用户的上传视为不存在。
+//
+//	POST   /api/v1/files/tus/       创建上传，Upload-Metadata 中需包含 filename
+//	HEAD   /api/v1/files/tus/{id}   查询已接收的字节数
+//	PATCH  /api/v1/files/tus/{id}   从 Upload-Offset 处追加数据，全部接收后合并为普通文件，文件ID通过 X-File-Id 返回
+//	DELETE /api/v1/files/tus/{id}   终止上传
+type TusService struct {
+	uc  *biz.TusUsecase
+	log *log.Helper
+}
+
+// NewTusService 创建断点续传服务
+func NewTusService(uc *biz.TusUsecase, logger log.Logger) *TusService {
+	return &TusService{
+		uc:  uc,
+		log: log.NewHelper(logger),
+	}
+}
+
+// ServeHTTP 处理 tus 请求
+func (s *TusService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	method := r.Method
+	if override := r.Header.Get("X-HTTP-Method-Override"); override != "" && method == http.MethodPost {
+		method = strings.ToUpper(override)
+	}
+
+	if method == http.MethodOptions {
+		s.options(w)
+		return
+	}
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		http.Error(w, "unsupported tus version", http.StatusPreconditionFailed)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, TusBasePath), "/")
+	switch {
+	case id == "" && method == http.MethodPost:
+		s.create(w, r)
+	case id != "" && method == http.MethodHead:
+		s.head(w, r, id)
+	case id != "" && method == http.MethodPatch:
+		s.patch(w, r, id)
+	case id != "" && method == http.MethodDelete:
+		s.terminate(w, r, id)
+	default:
+		w.Header().Set("Allow", "OPTIONS, POST, HEAD, PATCH, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *TusService) options(w http.ResponseWriter) {
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	if limit := s.uc.MaxSize(); limit > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(limit, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *TusService) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		http.Error(w, "Upload-Defer-Length is not supported", http.StatusBadRequest)
+		return
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	metadata, err := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	upload, err := s.uc.CreateUpload(ctx, length, metadata)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("创建断点续传失败: %v", err)
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", TusBasePath+upload.ID)
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *TusService) head(w http.ResponseWriter, r *http.Request, id string) {
+	upload, err := s.uc.GetUpload(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if len(upload.Metadata) > 0 {
+		w.Header().Set("Upload-Metadata", formatTusMetadata(upload.Metadata))
+	}
+	s.writeUploadState(w, upload)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *TusService) patch(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+
+	if r.Header.Get("Content-Type") != tusContentType {
+		http.Error(w, "Content-Type must be "+tusContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	upload, err := s.uc.WriteChunk(ctx, id, offset, r.Body)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("断点续传写入失败: id=%s, offset=%d, err=%v", id, offset, err)
+		if upload != nil {
+			// 已保存中断前接收的数据，返回新的偏移量
+			w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		}
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	s.writeUploadState(w, upload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *TusService) terminate(w http.ResponseWriter, r *http.Request, id string) {
+	if err := s.uc.Terminate(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUploadState 未完成时返回过期时间，已完成时返回文件ID
+func (s *TusService) writeUploadState(w http.ResponseWriter, upload *biz.TusUpload) {
+	if upload.Completed() {
+		w.Header().Set(fileIDHeader, upload.FileID)
+		return
+	}
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+func (s *TusService) writeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, biz.ErrUploadNotFound):
+		http.Error(w, "upload not found", http.StatusNotFound)
+	case errors.Is(err, biz.ErrUploadExpired):
+		http.Error(w, "upload expired", http.StatusGone)
+	case errors.Is(err, biz.ErrOffsetMismatch):
+		http.Error(w, "upload offset mismatch", http.StatusConflict)
+	case errors.Is(err, biz.ErrFileSizeExceeded), errors.As(err, &maxBytesErr):
+		http.Error(w, "upload exceeds the maximum allowed size", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, biz.ErrQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, biz.ErrInvalidFileType):
+		http.Error(w, "file type is not allowed", http.StatusBadRequest)
+	case errors.Is(err, biz.ErrInvalidContent):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, biz.ErrInvalidUpload):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// parseTusMetadata 解析 Upload-Metadata：以逗号分隔的 "key base64(value)" 键值对，value 可省略
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("invalid Upload-Metadata")
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, errors.New("invalid Upload-Metadata value for key " + key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func formatTusMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + " " + base64.StdEncoding.EncodeToString([]byte(metadata[key]))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+const (
+	// maxFormFieldSize multipart 表单中普通字段的最大长度
+	maxFormFieldSize = 4 << 10
+	// multipartOverhead multipart 边界、字段等额外开销的上限
+	multipartOverhead = 1 << 20
+)
+
+// UploadStream 流式上传文件（gRPC client streaming）
+func (s *FileService) UploadStream(stream v1.FileService_UploadStreamServer) error {
+	ctx := stream.Context()
+
+	in, err := recvUploadInput(ctx, stream)
+	if err != nil {
+		return err
+	}
+	s.log.WithContext(ctx).Infof("流式上传请求: filename=%s, size=%d", in.Filename, in.Size)
+
+	reply, err := s.uc.UploadStream(ctx, in)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("流式上传失败: %v", err)
+		return uploadError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("流式上传成功: file_id=%s, size=%d", reply.File.FileId, reply.File.Size)
+	return stream.SendAndClose(reply)
+}
+
+// uploadStream UploadStream、UploadVersion 和 UploadArchive 共用的请求流
+type uploadStream interface {
+	Recv() (*v1.UploadStreamRequest, error)
+}
+
+// recvUploadInput 读取第一条消息中的元数据，文件内容从之后的消息中流式读取。
+// 文件属于访问令牌中的用户，元数据中的 user_id 被忽略
+func recvUploadInput(ctx context.Context, stream uploadStream) (*biz.UploadInput, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, v1.ErrorInvalidUpload("missing upload metadata")
+		}
+		return nil, err
+	}
+	meta := first.GetMetadata()
+	if meta == nil {
+		return nil, v1.ErrorInvalidUpload("first message must carry upload metadata")
+	}
+	if meta.Filename == "" {
+		return nil, v1.ErrorInvalidUpload("filename is required")
+	}
+
+	return &biz.UploadInput{
+		FileID:      meta.FileId,
+		Filename:    meta.Filename,
+		Title:       meta.Title,
+		Description: meta.Description,
+		UserID:      userID,
+		Size:        meta.Size,
+		Content:     &chunkReader{stream: stream},
+	}, nil
+}
+
+// chunkReader 将 gRPC 上传流适配为 io.Reader，每次只持有一个分片
+type chunkReader struct {
+	stream uploadStream
+	buf    []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		msg, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if msg.GetMetadata() != nil {
+			return 0, v1.ErrorInvalidUpload("metadata must only be sent in the first message")
+		}
+		r.buf = msg.GetChunk()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// UploadHTTP 流式上传文件（HTTP）。支持两种请求方式：
+//   - multipart/form-data：title、description 字段需位于 file 字段之前
+//   - 原始请求体：文件内容即请求体，filename、title 等通过查询参数传递
+//
+// 文件内容直接写入存储，不会整体读入内存
+func (s *FileService) UploadHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	in, err := s.readUploadInput(w, r, s.uc.MaxFileSize())
+	if err != nil {
+		khttp.DefaultErrorEncoder(w, r, err)
+		return
+	}
+	s.log.WithContext(ctx).Infof("HTTP流式上传请求: filename=%s, size=%d", in.Filename, in.Size)
+
+	reply, err := s.uc.UploadStream(ctx, in)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("HTTP流式上传失败: %v", err)
+		khttp.DefaultErrorEncoder(w, r, uploadError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).Infof("HTTP流式上传成功: file_id=%s, size=%d", reply.File.FileId, reply.File.Size)
+	if err := khttp.DefaultResponseEncoder(w, r, reply); err != nil {
+		s.log.WithContext(ctx).Errorf("写入上传响应失败: %v", err)
+	}
+}
+
+// readUploadInput 从查询参数和请求体读取上传请求，文件属于访问令牌中的用户。
+// limit 为文件大小上限，小于等于0表示不限制
+func (s *FileService) readUploadInput(w http.ResponseWriter, r *http.Request, limit int64) (*biz.UploadInput, error) {
+	userID, err := auth.UserID(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit+multipartOverhead)
+	}
+
+	query := r.URL.Query()
+	in := &biz.UploadInput{
+		Filename:    query.Get("filename"),
+		Title:       query.Get("title"),
+		Description: query.Get("description"),
+		UserID:      userID,
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := s.readMultipartUpload(r, in); err != nil {
+			return nil, err
+		}
+	} else {
+		in.Size = r.ContentLength
+		in.Content = r.Body
+	}
+
+	if in.Filename == "" || in.Content == nil {
+		return nil, v1.ErrorInvalidUpload("filename and file content are required")
+	}
+	return in, nil
+}
+
+// readMultipartUpload 读取 multipart 表单，遇到 file 字段即停止，文件内容由调用方流式读取
+func (s *FileService) readMultipartUpload(r *http.Request, in *biz.UploadInput) error {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return v1.ErrorInvalidUpload("invalid multipart request: %v", err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return v1.ErrorInvalidUpload("invalid multipart request: %v", err)
+		}
+
+		if part.FormName() == "file" {
+			if in.Filename == "" {
+				in.Filename = part.FileName()
+			}
+			in.Content = part
+			return nil
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize))
+		if err != nil {
+			return v1.ErrorInvalidUpload("invalid multipart field %s: %v", part.FormName(), err)
+		}
+		field := strings.TrimSpace(string(value))
+		switch part.FormName() {
+		case "filename":
+			in.Filename = field
+		case "title":
+			in.Title = field
+		case "description":
+			in.Description = field
+		case "size":
+			in.Size, _ = strconv.ParseInt(field, 10, 64)
+		}
+	}
+}
+
+// uploadError 将业务错误转换为带错误码的错误
+func uploadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var kerr *kerrors.Error
+	switch {
+	case errors.As(err, &kerr):
+		return kerr
+	case errors.Is(err, biz.ErrFileSizeExceeded), errors.As(err, &maxBytesErr):
+		return v1.ErrorFileSizeExceeded("file exceeds the maximum allowed size")
+	case errors.Is(err, biz.ErrInvalidFileType):
+		return v1.ErrorFileFormatNotSupported("file type is not allowed")
+	case errors.Is(err, biz.ErrEmptyFile):
+		return v1.ErrorInvalidUpload("file is empty")
+	case errors.Is(err, biz.ErrInvalidContent):
+		return v1.ErrorInvalidFileContent("%v", err)
+	case errors.Is(err, biz.ErrQuotaExceeded):
+		return v1.ErrorStorageQuotaExceeded("%v", err)
+	default:
+		return v1.ErrorFileUploadFailed("upload failed: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+
+	v1 "github.com/lyb88999/resume_helper/backend/services/file-service/api/file/v1"
+	"github.com/lyb88999/resume_helper/backend/services/file-service/internal/biz"
+)
+
+const (
+	// filesBasePath 文件接口的路径前缀
+	filesBasePath = "/api/v1/files/"
+	// versionUploadSuffix 上传新版本接口在文件路径之后的部分
+	versionUploadSuffix = "/versions/upload"
+)
+
+// VersionUploadPath 上传新版本的 HTTP 路由
+const VersionUploadPath = filesBasePath + "{file_id}" + versionUploadSuffix
+
+// UploadVersion 上传已有文件的新版本（gRPC client streaming），metadata.file_id 指定文件
+func (s *FileService) UploadVersion(stream v1.FileService_UploadVersionServer) error {
+	ctx := stream.Context()
+
+	in, err := recvUploadInput(ctx, stream)
+	if err != nil {
+		return err
+	}
+	if in.FileID == "" {
+		return v1.ErrorInvalidUpload("file_id is required")
+	}
+	s.log.WithContext(ctx).Infof("上传新版本请求: file_id=%s, filename=%s, size=%d", in.FileID, in.Filename, in.Size)
+
+	reply, err := s.uc.UploadVersion(ctx, in)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("上传新版本失败: %v", err)
+		return versionUploadError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("上传新版本成功: file_id=%s, version=%d", reply.File.FileId, reply.Version.Version)
+	return stream.SendAndClose(reply)
+}
+
+// UploadVersionHTTP 上传已有文件的新版本（HTTP），请求格式与 UploadHTTP 相同，文件ID位于路径中
+func (s *FileService) UploadVersionHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	fileID, err := url.PathUnescape(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, filesBasePath), versionUploadSuffix))
+	if err != nil || fileID == "" || strings.Contains(fileID, "/") {
+		khttp.DefaultErrorEncoder(w, r, v1.ErrorInvalidUpload("invalid file_id"))
+		return
+	}
+
+	in, err := s.readUploadInput(w, r, s.uc.MaxFileSize())
+	if err != nil {
+		khttp.DefaultErrorEncoder(w, r, err)
+		return
+	}
+	in.FileID = fileID
+	s.log.WithContext(ctx).Infof("HTTP上传新版本请求: file_id=%s, filename=%s, size=%d", in.FileID, in.Filename, in.Size)
+
+	reply, err := s.uc.UploadVersion(ctx, in)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("HTTP上传新版本失败: %v", err)
+		khttp.DefaultErrorEncoder(w, r, versionUploadError(err))
+		return
+	}
+
+	s.log.WithContext(ctx).Infof("HTTP上传新版本成功: file_id=%s, version=%d", reply.File.FileId, reply.Version.Version)
+	if err := khttp.DefaultResponseEncoder(w, r, reply); err != nil {
+		s.log.WithContext(ctx).Errorf("写入上传响应失败: %v", err)
+	}
+}
+
+// ListFileVersions 获取文件的所有版本
+func (s *FileService) ListFileVersions(ctx context.Context, req *v1.ListFileVersionsRequest) (*v1.ListFileVersionsReply, error) {
+	s.log.WithContext(ctx).Infof("获取文件版本请求: file_id=%s", req.FileId)
+
+	reply, err := s.uc.ListVersions(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("获取文件版本失败: %v", err)
+		return nil, fileError(err)
+	}
+	return reply, nil
+}
+
+// DownloadFileVersion 下载文件的指定版本
+func (s *FileService) DownloadFileVersion(ctx context.Context, req *v1.DownloadFileVersionRequest) (*v1.DownloadFileReply, error) {
+	s.log.WithContext(ctx).Infof("下载文件版本请求: file_id=%s, version=%d", req.FileId, req.Version)
+
+	reply, err := s.uc.DownloadVersion(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("下载文件版本失败: %v", err)
+		return nil, fileError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("下载文件版本成功: filename=%s", reply.Filename)
+	return reply, nil
+}
+
+// RestoreFileVersion 恢复文件的旧版本
+func (s *FileService) RestoreFileVersion(ctx context.Context, req *v1.RestoreFileVersionRequest) (*v1.RestoreFileVersionReply, error) {
+	s.log.WithContext(ctx).Infof("恢复文件版本请求: file_id=%s, version=%d", req.FileId, req.Version)
+
+	reply, err := s.uc.RestoreVersion(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("恢复文件版本失败: %v", err)
+		if errors.Is(err, biz.ErrQuotaExceeded) {
+			return nil, v1.ErrorStorageQuotaExceeded("%v", err)
+		}
+		return nil, fileError(err)
+	}
+
+	s.log.WithContext(ctx).Infof("恢复文件版本成功: file_id=%s, version=%d, restored_from=%d", req.FileId, reply.Version.Version, req.Version)
+	return reply, nil
+}
+
+// versionUploadError 目标文件不存在时返回 FILE_NOT_FOUND，无权修改时返回 PERMISSION_DENIED，其他错误与上传相同
+func versionUploadError(err error) error {
+	if errors.Is(err, biz.ErrFileNotFound) || errors.Is(err, biz.ErrPermissionDenied) {
+		return fileError(err)
+	}
+	return uploadError(err)
+}
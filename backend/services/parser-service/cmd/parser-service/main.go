@@ -12,6 +12,7 @@ import (
 	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
 	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/lyb88999/resume_helper/backend/services/parser-service/internal/server"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 
 	_ "go.uber.org/automaxprocs"
@@ -34,7 +35,7 @@ func init() {
 	flag.StringVar(&flagconf, "conf", "configs/config.local.yaml", "config path, eg: -conf config.yaml")
 }
 
-func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server, rr registry.Registrar) *kratos.App {
+func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server, tc *server.TaskConsumer, rr registry.Registrar) *kratos.App {
 	return kratos.New(
 		kratos.ID(id),
 		kratos.Name(Name),
@@ -44,6 +45,7 @@ func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server, rr registry.Reg
 		kratos.Server(
 			gs,
 			hs,
+			tc,
 		),
 		kratos.Registrar(rr),
 	)
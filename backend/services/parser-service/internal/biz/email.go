@@ -0,0 +1,636 @@
+package biz
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"golang.org/x/text/encoding/htmlindex"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	// maxMIMEDepth 嵌套 multipart 和转发邮件的最大层数
+	maxMIMEDepth = 5
+	// maxImportAttachments 一次导入处理的附件数上限
+	maxImportAttachments = 200
+	// importTaskTimeout 上传并解析单个附件的超时时间
+	importTaskTimeout = 5 * time.Minute
+)
+
+// 附件状态
+const (
+	AttachmentPending = "pending" // 已创建解析任务，等待上传和解析
+	AttachmentSkipped = "skipped" // 类型不支持、超过大小限制或超过附件数上限
+)
+
+// 个人信息字段的来源
+const (
+	ProvenanceDocument     = "document"      // 简历正文
+	ProvenanceEmailSender  = "email_sender"  // 邮件发件人
+	ProvenanceEmailSubject = "email_subject" // 邮件主题
+)
+
+var ErrInvalidEmail = errors.New("invalid email file")
+
+// emailAttachmentTypes 从邮件中提取的附件类型
+var emailAttachmentTypes = map[string]string{
+	"application/pdf": "pdf",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"text/plain": "txt",
+}
+
+// EmailSource 简历所在邮件的发件信息
+type EmailSource struct {
+	FromName    string `json:"from_name,omitempty"`
+	FromAddress string `json:"from_address,omitempty"`
+	Subject     string `json:"subject,omitempty"`
+	MessageID   string `json:"message_id,omitempty"`
+	Date        string `json:"date,omitempty"`
+}
+
+// ImportedMessage 导入的一封邮件
+type ImportedMessage struct {
+	Source      *EmailSource
+	Attachments []*ImportedAttachment
+	Error       string // 邮件无法解析时的原因
+}
+
+// ImportedAttachment 邮件中的一个附件
+type ImportedAttachment struct {
+	Filename string
+	TaskID   string
+	Status   string
+	Reason   string // 跳过的原因
+}
+
+// EmailUsecase 从 .eml 和 .mbox 文件中导入简历附件
+type EmailUsecase struct {
+	parser      *ParserUsecase
+	files       FileStore
+	tempDir     string
+	maxFileSize int64
+	decoder     *mime.WordDecoder
+	log         *log.Helper
+}
+
+// NewEmailUsecase 创建邮件导入用例
+func NewEmailUsecase(parser *ParserUsecase, files FileStore, config *conf.Parser, logger log.Logger) *EmailUsecase {
+	tempDir := config.GetFile().GetTempDir()
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	return &EmailUsecase{
+		parser:      parser,
+		files:       files,
+		tempDir:     tempDir,
+		maxFileSize: config.GetFile().GetMaxFileSize(),
+		decoder:     &mime.WordDecoder{CharsetReader: charsetReader},
+		log:         log.NewHelper(logger),
+	}
+}
+
+// emailJob 等待上传和解析的附件
+type emailJob struct {
+	task     *ParseTask
+	parser   DocumentParser
+	filename string
+	size     int64
+	source   *EmailSource
+}
+
+// ImportEmail 从 file-service 读取当前用户的 .eml 或 .mbox 文件，将其中的 PDF、DOCX、TXT 附件保存到临时目录并创建解析任务。
+// 附件在后台依次上传到文件服务并解析，解析结果中缺失的姓名和邮箱由发件人和主题补充
+func (uc *EmailUsecase) ImportEmail(ctx context.Context, fileID string, options *ParseOptions) ([]*ImportedMessage, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	file, content, err := uc.files.Open(ctx, fileID, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	if uc.maxFileSize > 0 && file.Size > uc.maxFileSize {
+		return nil, ErrFileTooLarge
+	}
+
+	var mbox bool
+	switch ExtractFileExtension(file.Filename) {
+	case "eml":
+	case "mbox":
+		mbox = true
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	// 邮件边读边拆分，不写入临时文件
+	body := io.Reader(content)
+	if uc.maxFileSize > 0 {
+		body = io.LimitReader(content, uc.maxFileSize)
+	}
+
+	var messages []*ImportedMessage
+	var jobs []*emailJob
+	err = splitMailbox(body, mbox, func(raw []byte) error {
+		msg, msgJobs := uc.readMessage(ctx, raw, userID, options, len(jobs))
+		messages = append(messages, msg)
+		jobs = append(jobs, msgJobs...)
+		return ctx.Err()
+	})
+	if err == nil && len(messages) == 0 {
+		err = fmt.Errorf("%w: no messages found", ErrInvalidEmail)
+	}
+	if err != nil {
+		uc.discard(jobs)
+		return nil, err
+	}
+
+	uc.log.Infof("Email import extracted %d attachments from %d messages", len(jobs), len(messages))
+	go uc.processJobs(userID, jobs)
+	return messages, nil
+}
+
+// readMessage 解析一封邮件并提取附件，queued 为本次导入已提取的附件数
+func (uc *EmailUsecase) readMessage(ctx context.Context, raw []byte, userID string, options *ParseOptions, queued int) (*ImportedMessage, []*emailJob) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return &ImportedMessage{Error: fmt.Sprintf("%v: %v", ErrInvalidEmail, err)}, nil
+	}
+
+	msg := &ImportedMessage{Source: uc.emailSource(m.Header)}
+	var parts []*mimeAttachment
+	walkErr := uc.walkPart(textHeader(m.Header), m.Body, 0, &parts)
+	if walkErr != nil {
+		msg.Error = walkErr.Error()
+	}
+
+	var jobs []*emailJob
+	for _, part := range parts {
+		att := &ImportedAttachment{Filename: part.filename}
+		msg.Attachments = append(msg.Attachments, att)
+
+		switch {
+		case part.fileType == "":
+			att.Status, att.Reason = AttachmentSkipped, "unsupported attachment type"
+			continue
+		case part.tooLarge:
+			att.Status, att.Reason = AttachmentSkipped, ErrFileTooLarge.Error()
+			continue
+		case queued+len(jobs) >= maxImportAttachments:
+			os.Remove(part.path)
+			att.Status, att.Reason = AttachmentSkipped, "too many attachments in one import"
+			continue
+		}
+		parser, ok := uc.parser.parsers[part.fileType]
+		if !ok {
+			os.Remove(part.path)
+			att.Status, att.Reason = AttachmentSkipped, ErrUnsupportedType.Error()
+			continue
+		}
+
+		task := &ParseTask{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			OrgID:     auth.OrgID(ctx),
+			FilePath:  part.path,
+			FileType:  part.fileType,
+			Status:    "pending",
+			Options:   options,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if _, err := uc.parser.repo.CreateTask(ctx, task); err != nil {
+			os.Remove(part.path)
+			att.Status, att.Reason = AttachmentSkipped, fmt.Sprintf("failed to create parse task: %v", err)
+			continue
+		}
+		att.TaskID = task.ID
+		att.Status = AttachmentPending
+		jobs = append(jobs, &emailJob{task: task, parser: parser, filename: part.filename, size: part.size, source: msg.Source})
+	}
+	return msg, jobs
+}
+
+// processJobs 依次上传并解析附件，同一次导入的附件不并发解析
+func (uc *EmailUsecase) processJobs(userID string, jobs []*emailJob) {
+	for _, job := range jobs {
+		uc.processJob(userID, job)
+	}
+}
+
+func (uc *EmailUsecase) processJob(userID string, job *emailJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), importTaskTimeout)
+	defer cancel()
+	defer os.Remove(job.task.FilePath)
+
+	file, err := uc.upload(ctx, userID, job)
+	if err != nil {
+		uc.log.Errorf("Failed to upload attachment %s of task %s: %v", job.filename, job.task.ID, err)
+		job.task.Status = "failed"
+		job.task.ErrorMsg = fmt.Sprintf("failed to upload attachment: %v", err)
+		job.task.UpdatedAt = time.Now()
+		if err := uc.parser.repo.UpdateTask(ctx, job.task); err != nil {
+			uc.log.Errorf("Failed to update task %s: %v", job.task.ID, err)
+		}
+		return
+	}
+	job.task.FileID = file.FileID
+	job.task.FileVersion = file.Version
+
+	uc.parser.processParseTask(ctx, job.task, job.parser, job.source.fill)
+}
+
+func (uc *EmailUsecase) upload(ctx context.Context, userID string, job *emailJob) (*StoredFile, error) {
+	f, err := os.Open(job.task.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// 附件保存到任务所属的组织
+	return uc.files.Upload(auth.NewOrgContext(ctx, job.task.OrgID), userID, job.filename, f, job.size)
+}
+
+// discard 导入失败时将已创建的任务标记为失败并删除临时文件
+func (uc *EmailUsecase) discard(jobs []*emailJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), importTaskTimeout)
+	defer cancel()
+	for _, job := range jobs {
+		os.Remove(job.task.FilePath)
+		job.task.Status = "failed"
+		job.task.ErrorMsg = "email import aborted"
+		job.task.UpdatedAt = time.Now()
+		if err := uc.parser.repo.UpdateTask(ctx, job.task); err != nil {
+			uc.log.Errorf("Failed to update task %s: %v", job.task.ID, err)
+		}
+	}
+}
+
+// emailSource 读取发件人、主题等信息，自动发送的邮件（如招聘网站通知）不使用发件人
+func (uc *EmailUsecase) emailSource(header mail.Header) *EmailSource {
+	source := &EmailSource{
+		Subject:   uc.decodeHeader(header.Get("Subject")),
+		MessageID: strings.Trim(header.Get("Message-Id"), "<> "),
+	}
+	if date, err := header.Date(); err == nil {
+		source.Date = date.Format(time.RFC3339)
+	}
+
+	parser := &mail.AddressParser{WordDecoder: uc.decoder}
+	if from, err := parser.Parse(header.Get("From")); err == nil && !isAutomatedSender(from.Address) {
+		source.FromName = strings.Trim(strings.TrimSpace(from.Name), `"'`)
+		source.FromAddress = strings.ToLower(from.Address)
+	}
+	return source
+}
+
+func (uc *EmailUsecase) decodeHeader(value string) string {
+	decoded, err := uc.decoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// mimeAttachment 从邮件中提取的附件，支持的类型写入临时文件
+type mimeAttachment struct {
+	filename string
+	fileType string // 为空表示不支持的类型
+	path     string
+	size     int64
+	tooLarge bool
+}
+
+// mimeHeader 邮件头和 MIME 分段头的公共部分
+type mimeHeader interface {
+	Get(key string) string
+}
+
+// textHeader 适配 mail.Header
+type textHeader mail.Header
+
+func (h textHeader) Get(key string) string {
+	return mail.Header(h).Get(key)
+}
+
+// walkPart 遍历 MIME 结构，收集带文件名的分段；正文和内嵌的不支持类型（如签名图片）忽略
+func (uc *EmailUsecase) walkPart(header mimeHeader, body io.Reader, depth int, out *[]*mimeAttachment) error {
+	if depth > maxMIMEDepth {
+		return fmt.Errorf("%w: mime structure nested too deeply", ErrInvalidEmail)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	body = transferDecoder(header.Get("Content-Transfer-Encoding"), body)
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+			}
+			if err := uc.walkPart(part.Header, part, depth+1, out); err != nil {
+				return err
+			}
+		}
+	case mediaType == "message/rfc822":
+		// 转发的邮件作为附件时，继续提取其中的附件
+		inner, err := mail.ReadMessage(body)
+		if err != nil {
+			return nil
+		}
+		return uc.walkPart(textHeader(inner.Header), inner.Body, depth+1, out)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = sanitizeFilename(uc.decodeHeader(filename))
+	if filename == "" {
+		return nil
+	}
+
+	fileType := ExtractFileExtension(filename)
+	if fileType == "" {
+		// 文件名没有扩展名时按 Content-Type 判断，并补上扩展名
+		if fileType = emailAttachmentTypes[mediaType]; fileType != "" {
+			filename += "." + fileType
+		}
+	} else if !isEmailAttachmentType(fileType) {
+		fileType = ""
+	}
+	if fileType == "" {
+		if disposition != "inline" {
+			*out = append(*out, &mimeAttachment{filename: filename})
+		}
+		return nil
+	}
+
+	att := &mimeAttachment{filename: filename, fileType: fileType}
+	if err := uc.saveAttachment(att, body); err != nil {
+		return err
+	}
+	*out = append(*out, att)
+	return nil
+}
+
+// saveAttachment 将附件内容写入临时文件，超过大小限制时只做标记
+func (uc *EmailUsecase) saveAttachment(att *mimeAttachment, body io.Reader) error {
+	if err := os.MkdirAll(uc.tempDir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(uc.tempDir, "email-*."+att.fileType)
+	if err != nil {
+		return err
+	}
+
+	if uc.maxFileSize > 0 {
+		body = io.LimitReader(body, uc.maxFileSize+1)
+	}
+	n, err := io.Copy(tmp, body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil || (uc.maxFileSize > 0 && n > uc.maxFileSize) {
+		os.Remove(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("%w: failed to read attachment %s: %v", ErrInvalidEmail, att.filename, err)
+		}
+		att.tooLarge = true
+		return nil
+	}
+	att.path = tmp.Name()
+	att.size = n
+	return nil
+}
+
+func isEmailAttachmentType(fileType string) bool {
+	for _, t := range emailAttachmentTypes {
+		if t == fileType {
+			return true
+		}
+	}
+	return false
+}
+
+// transferDecoder 按 Content-Transfer-Encoding 解码分段内容
+func transferDecoder(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// base64Cleaner 去掉 base64 内容中的换行和空白，部分邮件客户端会在行尾加空格
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	for {
+		n, err := c.r.Read(p)
+		kept := 0
+		for _, b := range p[:n] {
+			switch b {
+			case ' ', '\t', '\r', '\n':
+			default:
+				p[kept] = b
+				kept++
+			}
+		}
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// charsetReader 支持常见的中文邮件编码
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// sanitizeFilename 只保留文件名部分，去掉路径和控制字符
+func sanitizeFilename(name string) string {
+	name = strings.ToValidUTF8(name, "_")
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.TrimSpace(name)
+}
+
+// splitMailbox 逐封读取邮件。mbox 中以 "From " 开头且前一行为空的行是邮件分隔行，
+// 正文中转义的 ">From " 行去掉一个 '>'；eml 只去掉开头可能存在的分隔行
+func splitMailbox(r io.Reader, mbox bool, fn func(raw []byte) error) error {
+	br := bufio.NewReader(r)
+	var buf bytes.Buffer
+	first, prevBlank := true, true
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			isSeparator := bytes.HasPrefix(line, []byte("From ")) && (first || (mbox && prevBlank))
+			switch {
+			case isSeparator:
+				if buf.Len() > 0 {
+					if err := fn(bytes.Clone(buf.Bytes())); err != nil {
+						return err
+					}
+					buf.Reset()
+				}
+			case mbox && mboxEscaped.Match(line):
+				buf.Write(line[1:])
+			default:
+				buf.Write(line)
+			}
+			first = false
+			prevBlank = len(bytes.TrimRight(line, "\r\n")) == 0
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(bytes.TrimSpace(buf.Bytes())) > 0 {
+		return fn(buf.Bytes())
+	}
+	return nil
+}
+
+var mboxEscaped = regexp.MustCompile(`^>+From `)
+
+// isAutomatedSender 招聘网站等自动发送的邮件，发件人不是候选人
+func isAutomatedSender(address string) bool {
+	local, _, _ := strings.Cut(strings.ToLower(address), "@")
+	for _, prefix := range []string{"noreply", "no-reply", "no_reply", "donotreply", "do-not-reply", "notification", "notify", "mailer-daemon", "postmaster"} {
+		if strings.HasPrefix(local, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectNameSeparators 主题中常见的分隔符
+var subjectNameSeparators = regexp.MustCompile(`[\s\-_|/—–:：,，、()（）\[\]【】《》<>+]+`)
+
+// subjectNonNameSuffixes 以这些词结尾的片段是岗位或说明，不是姓名
+var subjectNonNameSuffixes = []string{
+	"简历", "应聘", "求职", "投递", "申请", "岗位", "职位", "经理", "工程师", "专员", "主管", "总监",
+	"助理", "设计师", "实习", "实习生", "校招", "社招", "开发", "测试", "运营", "产品", "本科", "硕士", "博士", "年经验",
+}
+
+// nameFromSubject 从主题中找出姓名，如 "应聘Java开发-张三-5年经验" 或 "张三的简历"
+func nameFromSubject(subject string) string {
+	for _, token := range subjectNameSeparators.Split(subject, -1) {
+		token = strings.TrimSuffix(token, "的简历")
+		token = strings.TrimSuffix(token, "的个人简历")
+		if isChineseName(token) {
+			return token
+		}
+	}
+	return ""
+}
+
+// isChineseName 2到4个汉字且不是岗位、学历等常见词
+func isChineseName(s string) bool {
+	n := utf8.RuneCountInString(s)
+	if n < 2 || n > 4 {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.Is(unicode.Han, r) {
+			return false
+		}
+	}
+	for _, suffix := range subjectNonNameSuffixes {
+		if strings.HasSuffix(s, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// fill 用发件人和主题补充解析结果中缺失的姓名和邮箱，并记录每个字段的来源
+func (s *EmailSource) fill(content *ParsedContent) {
+	if content.Metadata == nil {
+		content.Metadata = &ParseMetadata{}
+	}
+	content.Metadata.Email = s
+
+	if content.PersonalInfo == nil {
+		content.PersonalInfo = &PersonalInfo{}
+	}
+	info := content.PersonalInfo
+	if info.Provenance == nil {
+		info.Provenance = make(map[string]string)
+	}
+
+	switch {
+	case info.Name != "":
+		info.Provenance["name"] = ProvenanceDocument
+	case s.FromName != "" && !strings.Contains(s.FromName, "@"):
+		info.Name = s.FromName
+		info.Provenance["name"] = ProvenanceEmailSender
+	default:
+		if name := nameFromSubject(s.Subject); name != "" {
+			info.Name = name
+			info.Provenance["name"] = ProvenanceEmailSubject
+		}
+	}
+
+	switch {
+	case info.Email != "":
+		info.Provenance["email"] = ProvenanceDocument
+	case s.FromAddress != "":
+		info.Email = s.FromAddress
+		info.Provenance["email"] = ProvenanceEmailSender
+	}
+
+	if info.Phone != "" {
+		info.Provenance["phone"] = ProvenanceDocument
+	}
+	if len(info.Provenance) == 0 {
+		info.Provenance = nil
+	}
+}
@@ -4,13 +4,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// defaultTaskTimeout 读取文件并解析的默认超时时间
+const defaultTaskTimeout = 5 * time.Minute
+
+// exportPageSize 导出用户数据时每次读取的任务数
+const exportPageSize = 100
+
+const (
+	// defaultTasksPerPage 任务列表每页默认条数
+	defaultTasksPerPage = 20
+	// maxTasksPerPage 任务列表每页最大条数
+	maxTasksPerPage = 100
 )
 
 // 错误定义
@@ -18,6 +36,7 @@ var (
 	ErrTaskNotFound      = errors.New("task not found")
 	ErrUnsupportedType   = errors.New("unsupported file type")
 	ErrFileNotFound      = errors.New("file not found")
+	ErrFileNotClean      = errors.New("file has not passed malware scanning")
 	ErrParseTimeout      = errors.New("parse timeout")
 	ErrFileTooLarge      = errors.New("file too large")
 	ErrDocumentProtected = errors.New("document is password protected")
@@ -37,6 +56,10 @@ type ParseTask struct {
 	ID          string         `json:"id"`
 	ResumeID    string         `json:"resume_id"`
 	UserID      string         `json:"user_id"`
+	OrgID       int64          `json:"org_id,omitempty"` // 创建任务时用户所在的组织，组织成员都可以查看；0表示只属于创建者
+	FileID      string         `json:"file_id,omitempty"`
+	FileVersion int32          `json:"file_version,omitempty"` // 解析的文件版本，上传新版本后仍指向该版本
+	BatchID     string         `json:"batch_id,omitempty"`
 	FilePath    string         `json:"file_path"`
 	FileType    string         `json:"file_type"`
 	Status      string         `json:"status"` // pending, processing, completed, failed
@@ -71,6 +94,8 @@ type PersonalInfo struct {
 	Nationality string   `json:"nationality,omitempty"`
 	SocialLinks []string `json:"social_links,omitempty"`
 	AvatarURL   string   `json:"avatar_url,omitempty"`
+	// Provenance 字段来源，如 {"name": "email_sender"}，只记录可能来自简历正文以外的字段
+	Provenance map[string]string `json:"provenance,omitempty"`
 }
 
 // Education 教育背景
@@ -140,6 +165,8 @@ type ParseMetadata struct {
 	ParserVersion   string   `json:"parser_version,omitempty"`
 	Warnings        []string `json:"warnings,omitempty"`
 	ConfidenceScore int32    `json:"confidence_score,omitempty"`
+	// Email 通过邮件导入时简历所在邮件的发件信息
+	Email *EmailSource `json:"email,omitempty"`
 }
 
 // ParseTaskRepo 解析任务仓库接口
@@ -148,9 +175,31 @@ type ParseTaskRepo interface {
 	GetTask(ctx context.Context, taskID string) (*ParseTask, error)
 	UpdateTask(ctx context.Context, task *ParseTask) error
 	ListTasksByUser(ctx context.Context, userID string, limit, offset int) ([]*ParseTask, error)
+	// ListTasks 按创建时间倒序分页返回用户创建的任务和组织的任务，orgID 为0时只返回用户的任务，同时返回总数
+	ListTasks(ctx context.Context, userID string, orgID int64, limit, offset int) ([]*ParseTask, int64, error)
 	DeleteTask(ctx context.Context, taskID string) error
 }
 
+// StoredFile file-service 中的文件信息
+type StoredFile struct {
+	FileID   string
+	Version  int32
+	Filename string // 用户上传时的文件名
+	MimeType string
+	Size     int64
+}
+
+// FileStore file-service 客户端，按文件ID读写用户的文件
+type FileStore interface {
+	// Stat 返回用户文件指定版本的信息，version 为0表示当前版本。文件或版本不存在、文件不属于该用户时返回 ErrFileNotFound，
+	// 未通过扫描时返回 ErrFileNotClean
+	Stat(ctx context.Context, fileID, userID string, version int32) (*StoredFile, error)
+	// Open 读取用户文件指定版本的内容，错误与 Stat 相同，调用方负责关闭
+	Open(ctx context.Context, fileID, userID string, version int32) (*StoredFile, io.ReadCloser, error)
+	// Upload 以 userID 的身份上传文件，返回保存后的文件信息
+	Upload(ctx context.Context, userID, filename string, content io.Reader, size int64) (*StoredFile, error)
+}
+
 // DocumentParser 文档解析器接口
 type DocumentParser interface {
 	Parse(ctx context.Context, filePath string, options *ParseOptions) (*ParsedContent, error)
@@ -159,17 +208,33 @@ type DocumentParser interface {
 
 // ParserUsecase 解析用例
 type ParserUsecase struct {
-	repo    ParseTaskRepo
-	parsers map[string]DocumentParser
-	log     *log.Helper
+	repo        ParseTaskRepo
+	files       FileStore
+	parsers     map[string]DocumentParser
+	tempDir     string
+	maxFileSize int64
+	timeout     time.Duration
+	log         *log.Helper
 }
 
 // NewParserUsecase 创建解析用例
-func NewParserUsecase(repo ParseTaskRepo, logger log.Logger) *ParserUsecase {
+func NewParserUsecase(repo ParseTaskRepo, files FileStore, config *conf.Parser, logger log.Logger) *ParserUsecase {
+	tempDir := config.GetFile().GetTempDir()
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	timeout := time.Duration(config.GetTask().GetTimeoutSeconds()) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTaskTimeout
+	}
 	return &ParserUsecase{
-		repo:    repo,
-		parsers: make(map[string]DocumentParser),
-		log:     log.NewHelper(logger),
+		repo:        repo,
+		files:       files,
+		parsers:     make(map[string]DocumentParser),
+		tempDir:     tempDir,
+		maxFileSize: config.GetFile().GetMaxFileSize(),
+		timeout:     timeout,
+		log:         log.NewHelper(logger),
 	}
 }
 
@@ -178,14 +243,28 @@ func (uc *ParserUsecase) RegisterParser(fileType string, parser DocumentParser)
 	uc.parsers[fileType] = parser
 }
 
-// ParseDocument 解析文档
-func (uc *ParserUsecase) ParseDocument(ctx context.Context, filePath, fileType, resumeID, userID string, options *ParseOptions) (*ParseTask, error) {
-	// 验证文件是否存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil, ErrFileNotFound
+// ParseDocument 为当前用户解析 file-service 中文档的指定版本，version 为0时解析当前版本，fileType 为空时按文件名判断。
+// 文件归属和类型在创建任务前校验，任务记录实际解析的版本，文件内容在后台读取到临时目录后解析。
+// 用户可以解析自己的文件和所在组织的文件，任务归属用户当前所在的组织
+func (uc *ParserUsecase) ParseDocument(ctx context.Context, fileID string, version int32, fileType, resumeID string, options *ParseOptions) (*ParseTask, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证文件是否存在且属于该用户
+	file, err := uc.files.Stat(ctx, fileID, userID, version)
+	if err != nil {
+		return nil, err
+	}
+	if uc.maxFileSize > 0 && file.Size > uc.maxFileSize {
+		return nil, ErrFileTooLarge
 	}
 
 	// 检查是否支持该文件类型
+	if fileType == "" {
+		fileType = ExtractFileExtension(file.Filename)
+	}
 	parser, exists := uc.parsers[fileType]
 	if !exists {
 		return nil, ErrUnsupportedType
@@ -193,32 +272,116 @@ func (uc *ParserUsecase) ParseDocument(ctx context.Context, filePath, fileType,
 
 	// 创建解析任务
 	task := &ParseTask{
-		ID:        uuid.New().String(),
-		ResumeID:  resumeID,
-		UserID:    userID,
-		FilePath:  filePath,
-		FileType:  fileType,
-		Status:    "pending",
-		Progress:  0,
-		Options:   options,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:          uuid.New().String(),
+		ResumeID:    resumeID,
+		UserID:      userID,
+		OrgID:       auth.OrgID(ctx),
+		FileID:      fileID,
+		FileVersion: file.Version,
+		FileType:    fileType,
+		Status:      "pending",
+		Progress:    0,
+		Options:     options,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 
 	// 保存任务
-	task, err := uc.repo.CreateTask(ctx, task)
+	task, err = uc.repo.CreateTask(ctx, task)
 	if err != nil {
 		return nil, err
 	}
 
-	// 异步处理解析
-	go uc.processParseTask(context.Background(), task, parser)
+	// 异步读取文件并解析
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), uc.timeout)
+		defer cancel()
+		uc.fetchAndParse(ctx, task, parser, nil)
+	}()
 
 	return task, nil
 }
 
-// processParseTask 处理解析任务
-func (uc *ParserUsecase) processParseTask(ctx context.Context, task *ParseTask, parser DocumentParser) {
+// RunTask 创建解析任务，从 file-service 读取 task.FileID 的 task.FileVersion 版本并同步解析，用于队列提交的请求；
+// 任务ID由调用方生成，便于在执行前回报任务状态
+func (uc *ParserUsecase) RunTask(ctx context.Context, task *ParseTask) error {
+	parser, exists := uc.parsers[task.FileType]
+	if !exists {
+		return ErrUnsupportedType
+	}
+
+	task.Status = "pending"
+	task.Progress = 0
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+	task, err := uc.repo.CreateTask(ctx, task)
+	if err != nil {
+		return err
+	}
+
+	return uc.fetchAndParse(ctx, task, parser, nil)
+}
+
+// fetchAndParse 将任务的文件读取到临时目录并解析，解析结束后删除临时文件
+func (uc *ParserUsecase) fetchAndParse(ctx context.Context, task *ParseTask, parser DocumentParser, enrich func(*ParsedContent)) error {
+	path, err := uc.fetch(ctx, task)
+	if err != nil {
+		task.Status = "failed"
+		task.ErrorMsg = fmt.Sprintf("failed to fetch file: %v", err)
+		task.UpdatedAt = time.Now()
+		if err := uc.repo.UpdateTask(ctx, task); err != nil {
+			uc.log.Errorf("Failed to update task %s: %v", task.ID, err)
+		}
+		uc.log.Errorf("Failed to fetch file %s for task %s: %v", task.FileID, task.ID, err)
+		return err
+	}
+	defer os.Remove(path)
+
+	task.FilePath = path
+	return uc.processParseTask(ctx, task, parser, enrich)
+}
+
+// fetch 从 file-service 读取任务的文件并写入临时目录，返回临时文件路径，调用方负责删除。
+// 未指定版本时读取当前版本，并将实际读取的版本记录到任务。以任务所属组织的成员身份读取，可以读取组织的文件
+func (uc *ParserUsecase) fetch(ctx context.Context, task *ParseTask) (string, error) {
+	file, content, err := uc.files.Open(auth.NewOrgContext(ctx, task.OrgID), task.FileID, task.UserID, task.FileVersion)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	if uc.maxFileSize > 0 && file.Size > uc.maxFileSize {
+		return "", ErrFileTooLarge
+	}
+	task.FileVersion = file.Version
+
+	if err := os.MkdirAll(uc.tempDir, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(uc.tempDir, "task-*."+task.FileType)
+	if err != nil {
+		return "", err
+	}
+
+	body := io.Reader(content)
+	if uc.maxFileSize > 0 {
+		body = io.LimitReader(content, uc.maxFileSize+1)
+	}
+	n, err := io.Copy(tmp, body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && uc.maxFileSize > 0 && n > uc.maxFileSize {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// processParseTask 处理解析任务，enrich 不为空时用于在保存前补充解析结果
+func (uc *ParserUsecase) processParseTask(ctx context.Context, task *ParseTask, parser DocumentParser, enrich func(*ParsedContent)) error {
 	// 更新状态为处理中
 	task.Status = "processing"
 	task.Progress = 10
@@ -237,7 +400,7 @@ func (uc *ParserUsecase) processParseTask(ctx context.Context, task *ParseTask,
 		task.UpdatedAt = time.Now()
 		uc.repo.UpdateTask(ctx, task)
 		uc.log.Errorf("Parse failed for task %s: %v", task.ID, err)
-		return
+		return err
 	}
 
 	// 添加元数据
@@ -255,6 +418,11 @@ func (uc *ParserUsecase) processParseTask(ctx context.Context, task *ParseTask,
 	// 计算置信度
 	content.Metadata.ConfidenceScore = uc.calculateConfidence(content)
 
+	// 置信度只反映文档本身，补充的信息不计入
+	if enrich != nil {
+		enrich(content)
+	}
+
 	// 解析成功
 	task.Status = "completed"
 	task.Progress = 100
@@ -263,9 +431,11 @@ func (uc *ParserUsecase) processParseTask(ctx context.Context, task *ParseTask,
 
 	if err := uc.repo.UpdateTask(ctx, task); err != nil {
 		uc.log.Errorf("Failed to update task %s: %v", task.ID, err)
+		return fmt.Errorf("failed to save parse result: %w", err)
 	}
 
 	uc.log.Infof("Parse completed for task %s", task.ID)
+	return nil
 }
 
 // calculateConfidence 计算解析置信度
@@ -324,9 +494,39 @@ func (uc *ParserUsecase) calculateConfidence(content *ParsedContent) int32 {
 	return score
 }
 
-// GetParseStatus 获取解析状态
+// GetParseStatus 获取当前用户或其所在组织的解析任务状态，其他任务视为不存在
 func (uc *ParserUsecase) GetParseStatus(ctx context.Context, taskID string) (*ParseTask, error) {
-	return uc.repo.GetTask(ctx, taskID)
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	task, err := uc.repo.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	orgID := auth.OrgID(ctx)
+	if task.UserID != userID && (orgID == 0 || task.OrgID != orgID) {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// ListParseTasks 分页获取当前用户创建的和所在组织的解析任务，返回任务和总数
+func (uc *ParserUsecase) ListParseTasks(ctx context.Context, page, perPage int) ([]*ParseTask, int64, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultTasksPerPage
+	}
+	if perPage > maxTasksPerPage {
+		perPage = maxTasksPerPage
+	}
+	return uc.repo.ListTasks(ctx, userID, auth.OrgID(ctx), perPage, (page-1)*perPage)
 }
 
 // ListUserTasks 获取用户的解析任务列表
@@ -334,6 +534,43 @@ func (uc *ParserUsecase) ListUserTasks(ctx context.Context, userID string, limit
 	return uc.repo.ListTasksByUser(ctx, userID, limit, offset)
 }
 
+// ExportTasks 分页遍历当前用户的所有解析任务，用于导出用户数据。
+// 遍历期间新建的任务会使分页偏移，已返回过的任务不再重复返回
+func (uc *ParserUsecase) ExportTasks(ctx context.Context, fn func(task *ParseTask) error) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]struct{})
+	for offset := 0; ; offset += exportPageSize {
+		tasks, err := uc.repo.ListTasksByUser(ctx, userID, exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if _, ok := seen[task.ID]; ok {
+				continue
+			}
+			seen[task.ID] = struct{}{}
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		if len(tasks) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// currentUser 返回访问令牌中的用户ID，格式与任务记录中的 user_id 相同
+func currentUser(ctx context.Context) (string, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(userID, 10), nil
+}
+
 // CleanText 清洗文本
 func (uc *ParserUsecase) CleanText(text string) string {
 	// 移除多余空白
@@ -7,11 +7,33 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/ledongthuc/pdf"
 	"github.com/unidoc/unioffice/document"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/skills"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/timeline"
 )
 
+// skillTaxonomy 内置技能本体，用于识别并归一化简历中的技能写法
+var skillTaxonomy = sync.OnceValues(skills.LoadBundled)
+
+// skillCategoryNames 技能分类的展示名称，顺序即输出顺序
+var skillCategoryNames = []struct {
+	category string
+	name     string
+}{
+	{skills.CategoryLanguage, "编程语言"},
+	{skills.CategoryFramework, "框架"},
+	{skills.CategoryDatabase, "数据库"},
+	{skills.CategoryDevOps, "运维与基础设施"},
+	{skills.CategoryCloud, "云平台"},
+	{skills.CategoryTool, "工具"},
+	{skills.CategoryDomain, "技术领域"},
+	{skills.CategorySoft, "软技能"},
+}
+
 // TextParser 文本解析器
 type TextParser struct{}
 
@@ -143,6 +165,8 @@ func (p *TextParser) parseEducationSection(lines []string, start, end int) *Educ
 		}
 	}
 
+	edu.StartDate, edu.EndDate = extractDateRange(section)
+
 	if edu.School != "" || edu.Major != "" {
 		return edu
 	}
@@ -196,41 +220,53 @@ func (p *TextParser) parseExperienceSection(lines []string, start, end int) *Exp
 		}
 	}
 
+	exp.StartDate, exp.EndDate = extractDateRange(section)
+
 	if exp.Company != "" || exp.Position != "" {
 		return exp
 	}
 	return nil
 }
 
-// extractSkills 提取技能
+// extractDateRange 提取并归一化起止时间，如 "2019年3月 - 至今" → "2019-03", "present"
+func extractDateRange(section string) (string, string) {
+	period, ok := timeline.FindRange(section)
+	if !ok {
+		return "", ""
+	}
+	return period.StartString(), period.EndString()
+}
+
+// extractSkills 提取技能，技能名称统一为技能本体中的规范名称（如 "golang"、"Go语言" 均记为 "Go"）
 func (p *TextParser) extractSkills(text string) *Skills {
 	skills := &Skills{
 		Categories: []*SkillCategory{},
 	}
 
-	// 技术技能关键词
-	techSkills := []string{
-		"Java", "Python", "JavaScript", "Go", "C++", "C#", "PHP", "Ruby",
-		"React", "Vue", "Angular", "Spring", "Django", "Flask",
-		"MySQL", "PostgreSQL", "MongoDB", "Redis",
-		"Docker", "Kubernetes", "Git", "Linux",
+	taxonomy, err := skillTaxonomy()
+	if err != nil {
+		return skills
 	}
 
-	var foundSkills []*SkillItem
-	for _, skill := range techSkills {
-		if strings.Contains(text, skill) {
-			foundSkills = append(foundSkills, &SkillItem{
-				Name:  skill,
-				Level: "熟练",
-			})
+	byCategory := make(map[string][]*SkillItem)
+	for _, id := range taxonomy.Extract(text) {
+		skill, ok := taxonomy.Get(id)
+		if !ok {
+			continue
 		}
+		byCategory[skill.Category] = append(byCategory[skill.Category], &SkillItem{
+			Name:  skill.Name,
+			Level: "熟练",
+		})
 	}
 
-	if len(foundSkills) > 0 {
-		skills.Categories = append(skills.Categories, &SkillCategory{
-			Category: "技术技能",
-			Skills:   foundSkills,
-		})
+	for _, c := range skillCategoryNames {
+		if items := byCategory[c.category]; len(items) > 0 {
+			skills.Categories = append(skills.Categories, &SkillCategory{
+				Category: c.name,
+				Skills:   items,
+			})
+		}
 	}
 
 	return skills
@@ -287,6 +323,8 @@ func (p *TextParser) parseProjectSection(lines []string, start, end int) *Projec
 		}
 	}
 
+	project.StartDate, project.EndDate = extractDateRange(section)
+
 	if project.Name != "" {
 		return project
 	}
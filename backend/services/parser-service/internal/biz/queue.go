@@ -0,0 +1,154 @@
+package biz
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	defaultMaxConcurrent = 10
+	// requestWait 每次等待解析请求的时长
+	requestWait = 5 * time.Second
+	// reportTimeout 回报任务状态的超时时间，与任务本身的超时无关
+	reportTimeout = 5 * time.Second
+)
+
+// ParseRequest file-service 通过请求队列提交的解析请求，文件内容按文件ID和用户ID从 file-service 读取
+type ParseRequest struct {
+	BatchID  string `json:"batch_id"`
+	FileID   string `json:"file_id"`
+	Version  int32  `json:"version"` // 要解析的版本，0 表示当前版本
+	UserID   int64  `json:"user_id"`
+	OrgID    int64  `json:"org_id,omitempty"` // 文件所属的组织，任务同样归属该组织
+	Filename string `json:"filename"`
+	FileType string `json:"file_type"`
+}
+
+// ParseResult 通过结果队列回传给 file-service 的任务状态
+type ParseResult struct {
+	BatchID string `json:"batch_id"`
+	FileID  string `json:"file_id"`
+	TaskID  string `json:"task_id"`
+	Status  string `json:"status"` // processing, completed, failed
+	Error   string `json:"error,omitempty"`
+}
+
+// TaskQueue 解析任务队列
+type TaskQueue interface {
+	// NextRequest 等待下一条解析请求，wait 内没有请求时返回 nil
+	NextRequest(ctx context.Context, wait time.Duration) (*ParseRequest, error)
+	PublishResult(ctx context.Context, result *ParseResult) error
+}
+
+// QueueUsecase 消费解析请求队列：读取文件、执行解析并回报任务状态
+type QueueUsecase struct {
+	parser  *ParserUsecase
+	queue   TaskQueue
+	slots   chan struct{}
+	running sync.WaitGroup
+	log     *log.Helper
+}
+
+// NewQueueUsecase 创建队列消费用例
+func NewQueueUsecase(parser *ParserUsecase, queue TaskQueue, config *conf.Parser, logger log.Logger) *QueueUsecase {
+	maxConcurrent := int(config.GetTask().GetMaxConcurrent())
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultMaxConcurrent
+	}
+
+	return &QueueUsecase{
+		parser: parser,
+		queue:  queue,
+		slots:  make(chan struct{}, maxConcurrent),
+		log:    log.NewHelper(logger),
+	}
+}
+
+// HandleNext 等待下一条解析请求并在后台处理，没有请求时返回 false。
+// 同时处理的请求达到上限时先等待空闲，不会取出无法立即处理的请求
+func (uc *QueueUsecase) HandleNext(ctx context.Context) (bool, error) {
+	select {
+	case uc.slots <- struct{}{}:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	req, err := uc.queue.NextRequest(ctx, requestWait)
+	if err != nil || req == nil {
+		<-uc.slots
+		return false, err
+	}
+
+	uc.running.Add(1)
+	go func() {
+		defer uc.running.Done()
+		defer func() { <-uc.slots }()
+		uc.process(req)
+	}()
+	return true, nil
+}
+
+// Wait 等待正在处理的请求结束，ctx 结束时不再等待
+func (uc *QueueUsecase) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		uc.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// process 处理一条解析请求，每个阶段的状态都回报到结果队列
+func (uc *QueueUsecase) process(req *ParseRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), uc.parser.timeout)
+	defer cancel()
+
+	task := &ParseTask{
+		ID:          uuid.New().String(),
+		UserID:      strconv.FormatInt(req.UserID, 10),
+		OrgID:       req.OrgID,
+		FileID:      req.FileID,
+		FileVersion: req.Version,
+		BatchID:     req.BatchID,
+		FileType:    strings.ToLower(req.FileType),
+	}
+	uc.report(req, task.ID, "processing", nil)
+
+	if err := uc.parser.RunTask(ctx, task); err != nil {
+		uc.report(req, task.ID, "failed", err)
+		return
+	}
+	uc.report(req, task.ID, "completed", nil)
+}
+
+// report 回报任务状态，回报失败只记录日志，file-service 会保留上一个状态
+func (uc *QueueUsecase) report(req *ParseRequest, taskID, status string, taskErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+
+	result := &ParseResult{
+		BatchID: req.BatchID,
+		FileID:  req.FileID,
+		TaskID:  taskID,
+		Status:  status,
+	}
+	if taskErr != nil {
+		result.Error = taskErr.Error()
+	}
+	if err := uc.queue.PublishResult(ctx, result); err != nil {
+		uc.log.Errorf("Failed to report parse status %s for file %s: %v", status, req.FileID, err)
+	}
+}
@@ -1,20 +1,26 @@
 package data
 
 import (
+	"context"
+	"time"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(NewData, NewParseTaskRepo)
+var ProviderSet = wire.NewSet(NewData, NewParseTaskRepo, NewTaskQueue, NewDiscovery, NewFileStore, NewRevocations)
 
 // Data .
 type Data struct {
-	db *gorm.DB
+	db  *gorm.DB
+	rdb *redis.Client
 }
 
 // NewData .
@@ -32,14 +38,35 @@ func NewData(c *conf.Data, logger log.Logger) (*Data, func(), error) {
 		log.Fatalf("failed to migrate database: %v", err)
 	}
 
+	// 连接 Redis，用于接收 file-service 提交的解析请求
+	rdb := redis.NewClient(&redis.Options{
+		Network:      c.GetRedis().GetNetwork(),
+		Addr:         c.GetRedis().GetAddr(),
+		ReadTimeout:  c.GetRedis().GetReadTimeout().AsDuration(),
+		WriteTimeout: c.GetRedis().GetWriteTimeout().AsDuration(),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		// Redis 暂时不可用时不阻止服务启动，队列消费会在恢复后继续
+		log.Warnf("failed to connect to redis: %v", err)
+	}
+
 	cleanup := func() {
 		log.Info("closing the data resources")
 		if sqlDB, err := db.DB(); err == nil {
 			sqlDB.Close()
 		}
+		rdb.Close()
 	}
 
 	return &Data{
-		db: db,
+		db:  db,
+		rdb: rdb,
 	}, cleanup, nil
 }
+
+// NewRevocations 访问令牌吊销列表，由 user-service 在登出时写入同一个 Redis
+func NewRevocations(d *Data) *auth.Revocations {
+	return auth.NewRevocations(d.rdb)
+}
@@ -0,0 +1,239 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/go-kratos/kratos/contrib/registry/consul/v2"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/hashicorp/consul/api"
+	ggrpc "google.golang.org/grpc"
+
+	"github.com/lyb88999/resume_helper/backend/services/parser-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	filev1 "github.com/lyb88999/resume_helper/backend/shared/proto/file"
+)
+
+const (
+	defaultFileServiceEndpoint = "discovery:///file-service"
+	// uploadChunkSize 上传时每条消息携带的内容大小
+	uploadChunkSize = 256 << 10
+)
+
+// fileStore 通过 file-service 的 FileContentService 读写文件，每次调用携带代表文件所有者的访问令牌
+type fileStore struct {
+	client filev1.FileContentServiceClient
+	log    *log.Helper
+}
+
+// NewDiscovery 创建服务发现，用于查找 file-service
+func NewDiscovery(c *conf.Bootstrap) (registry.Discovery, error) {
+	consulConfig := api.DefaultConfig()
+	if c.Registry != nil && c.Registry.GetConsul() != nil {
+		consulConfig.Address = c.Registry.GetConsul().Address
+		consulConfig.Scheme = c.Registry.GetConsul().Scheme
+	} else {
+		consulConfig.Address = "consul:8500" // 默认值
+		consulConfig.Scheme = "http"
+	}
+
+	consulClient, err := api.NewClient(consulConfig)
+	if err != nil {
+		return nil, err
+	}
+	return consul.New(consulClient), nil
+}
+
+// NewFileStore 创建 file-service 客户端
+func NewFileStore(c *conf.Bootstrap, config *conf.Parser, discovery registry.Discovery, logger log.Logger) (biz.FileStore, func(), error) {
+	endpoint := config.GetFile().GetFileServiceEndpoint()
+	if endpoint == "" {
+		endpoint = defaultFileServiceEndpoint
+	}
+
+	conn, err := grpc.DialInsecure(
+		context.Background(),
+		grpc.WithEndpoint(endpoint),
+		grpc.WithDiscovery(discovery),
+		grpc.WithOptions(ggrpc.WithPerRPCCredentials(auth.NewCredentials(c.GetAuth().GetJwtSecret()))),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to file service %s: %w", endpoint, err)
+	}
+
+	helper := log.NewHelper(logger)
+	cleanup := func() {
+		helper.Info("closing the file service connection")
+		conn.Close()
+	}
+	return &fileStore{
+		client: filev1.NewFileContentServiceClient(conn),
+		log:    helper,
+	}, cleanup, nil
+}
+
+func (s *fileStore) Stat(ctx context.Context, fileID, userID string, version int32) (*biz.StoredFile, error) {
+	ctx, err := ownerContext(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := s.client.StatFile(ctx, &filev1.ReadFileRequest{FileId: fileID, Version: version})
+	if err != nil {
+		return nil, fileServiceError(err)
+	}
+	return toStoredFile(meta), nil
+}
+
+func (s *fileStore) Open(ctx context.Context, fileID, userID string, version int32) (*biz.StoredFile, io.ReadCloser, error) {
+	ctx, err := ownerContext(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := s.client.ReadFile(ctx, &filev1.ReadFileRequest{FileId: fileID, Version: version})
+	if err != nil {
+		cancel()
+		return nil, nil, fileServiceError(err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		cancel()
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("file service closed the stream without file metadata")
+		}
+		return nil, nil, fileServiceError(err)
+	}
+	meta := first.GetMeta()
+	if meta == nil {
+		cancel()
+		return nil, nil, errors.New("file service sent content before file metadata")
+	}
+
+	return toStoredFile(meta), &contentReader{stream: stream, cancel: cancel}, nil
+}
+
+func (s *fileStore) Upload(ctx context.Context, userID, filename string, content io.Reader, size int64) (*biz.StoredFile, error) {
+	uid, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id %q", userID)
+	}
+
+	stream, err := s.client.WriteFile(auth.NewContext(ctx, uid))
+	if err != nil {
+		return nil, fileServiceError(err)
+	}
+	metadata := &filev1.WriteFileMetadata{Filename: filename, Size: size}
+	if err := stream.Send(&filev1.WriteFileRequest{Data: &filev1.WriteFileRequest_Metadata{Metadata: metadata}}); err != nil {
+		return nil, s.closeUpload(stream, err)
+	}
+
+	buf := make([]byte, uploadChunkSize)
+	for {
+		n, rerr := content.Read(buf)
+		if n > 0 {
+			chunk := &filev1.WriteFileRequest_Chunk{Chunk: buf[:n]}
+			if err := stream.Send(&filev1.WriteFileRequest{Data: chunk}); err != nil {
+				return nil, s.closeUpload(stream, err)
+			}
+		}
+		if errors.Is(rerr, io.EOF) {
+			break
+		}
+		if rerr != nil {
+			stream.CloseSend()
+			return nil, rerr
+		}
+	}
+
+	reply, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, fileServiceError(err)
+	}
+	if reply.GetFile().GetFileId() == "" {
+		return nil, errors.New("invalid upload response: missing file_id")
+	}
+	return toStoredFile(reply.GetFile()), nil
+}
+
+// closeUpload 发送失败时服务端通常已经返回了错误，通过 CloseAndRecv 取出真正的原因
+func (s *fileStore) closeUpload(stream filev1.FileContentService_WriteFileClient, sendErr error) error {
+	if !errors.Is(sendErr, io.EOF) {
+		return sendErr
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return fileServiceError(err)
+	}
+	return sendErr
+}
+
+// contentReader 将 ReadFile 响应流适配为 io.ReadCloser，每次只持有一个分片
+type contentReader struct {
+	stream filev1.FileContentService_ReadFileClient
+	cancel context.CancelFunc
+	buf    []byte
+}
+
+func (r *contentReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		msg, err := r.stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, io.EOF
+			}
+			return 0, fileServiceError(err)
+		}
+		r.buf = msg.GetChunk()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close 结束读取，未读完时服务端停止发送
+func (r *contentReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// ownerContext 以文件所有者的身份调用 file-service，后台任务没有来自请求的访问令牌
+func ownerContext(ctx context.Context, userID string) (context.Context, error) {
+	uid, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil || uid <= 0 {
+		// 无法对应到 file-service 的用户，等同于文件不属于该用户
+		return nil, biz.ErrFileNotFound
+	}
+	return auth.NewContext(ctx, uid), nil
+}
+
+func toStoredFile(meta *filev1.FileMeta) *biz.StoredFile {
+	return &biz.StoredFile{
+		FileID:   meta.FileId,
+		Version:  meta.Version,
+		Filename: meta.Filename,
+		MimeType: meta.MimeType,
+		Size:     meta.Size,
+	}
+}
+
+// fileServiceError 将 file-service 的错误码转换为业务错误，其他错误保留原因
+func fileServiceError(err error) error {
+	se := kerrors.FromError(err)
+	switch se.Reason {
+	case "FILE_NOT_FOUND", "VERSION_NOT_FOUND":
+		return biz.ErrFileNotFound
+	case "FILE_NOT_CLEAN":
+		return biz.ErrFileNotClean
+	case "":
+		return fmt.Errorf("file service: %w", err)
+	default:
+		return fmt.Errorf("file service rejected the request: %s: %s", se.Reason, se.Message)
+	}
+}
@@ -12,9 +12,13 @@ import (
 
 // ParseTaskModel 解析任务数据模型
 type ParseTaskModel struct {
-	ID          string     `gorm:"primaryKey;size:32" json:"id"`
+	ID          string     `gorm:"primaryKey;size:36" json:"id"`
 	ResumeID    string     `gorm:"size:32;not null;index" json:"resume_id"`
 	UserID      string     `gorm:"size:32;not null;index" json:"user_id"`
+	OrgID       int64      `gorm:"not null;default:0;index" json:"org_id"` // 所属组织，0表示只属于创建者
+	FileID      string     `gorm:"size:36;index" json:"file_id"`           // 通过 file-service 提交时的文件ID
+	FileVersion int32      `gorm:"default:0" json:"file_version"`          // 解析的文件版本
+	BatchID     string     `gorm:"size:36;index" json:"batch_id"`          // 文件所属的压缩包批次
 	FilePath    string     `gorm:"size:500;not null" json:"file_path"`
 	FileType    string     `gorm:"size:10;not null" json:"file_type"`
 	Status      string     `gorm:"size:20;not null;default:'pending'" json:"status"` // pending, processing, completed, failed
@@ -53,16 +57,20 @@ func (r *parseTaskRepo) CreateTask(ctx context.Context, task *biz.ParseTask) (*b
 	}
 
 	po := &ParseTaskModel{
-		ID:        task.ID,
-		ResumeID:  task.ResumeID,
-		UserID:    task.UserID,
-		FilePath:  task.FilePath,
-		FileType:  task.FileType,
-		Status:    task.Status,
-		Progress:  task.Progress,
-		Options:   string(optionsBytes),
-		CreatedAt: task.CreatedAt,
-		UpdatedAt: task.UpdatedAt,
+		ID:          task.ID,
+		ResumeID:    task.ResumeID,
+		UserID:      task.UserID,
+		OrgID:       task.OrgID,
+		FileID:      task.FileID,
+		FileVersion: task.FileVersion,
+		BatchID:     task.BatchID,
+		FilePath:    task.FilePath,
+		FileType:    task.FileType,
+		Status:      task.Status,
+		Progress:    task.Progress,
+		Options:     string(optionsBytes),
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
 	}
 
 	if err := r.data.db.WithContext(ctx).Create(po).Error; err != nil {
@@ -103,6 +111,13 @@ func (r *parseTaskRepo) UpdateTask(ctx context.Context, task *biz.ParseTask) err
 		"updated_at": time.Now(),
 	}
 
+	if task.FileID != "" {
+		updates["file_id"] = task.FileID
+	}
+	if task.FileVersion > 0 {
+		updates["file_version"] = task.FileVersion
+	}
+
 	if task.Status == "completed" || task.Status == "failed" {
 		now := time.Now()
 		updates["completed_at"] = &now
@@ -133,6 +148,30 @@ func (r *parseTaskRepo) ListTasksByUser(ctx context.Context, userID string, limi
 	return tasks, nil
 }
 
+func (r *parseTaskRepo) ListTasks(ctx context.Context, userID string, orgID int64, limit, offset int) ([]*biz.ParseTask, int64, error) {
+	query := r.data.db.WithContext(ctx).Model(&ParseTaskModel{})
+	if orgID > 0 {
+		query = query.Where("(user_id = ? OR org_id = ?)", userID, orgID)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var pos []ParseTaskModel
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&pos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	tasks := make([]*biz.ParseTask, len(pos))
+	for i := range pos {
+		tasks[i] = r.poToBiz(&pos[i])
+	}
+	return tasks, total, nil
+}
+
 func (r *parseTaskRepo) DeleteTask(ctx context.Context, taskID string) error {
 	return r.data.db.WithContext(ctx).
 		Where("id = ?", taskID).
@@ -145,6 +184,10 @@ func (r *parseTaskRepo) poToBiz(po *ParseTaskModel) *biz.ParseTask {
 		ID:          po.ID,
 		ResumeID:    po.ResumeID,
 		UserID:      po.UserID,
+		OrgID:       po.OrgID,
+		FileID:      po.FileID,
+		FileVersion: po.FileVersion,
+		BatchID:     po.BatchID,
 		FilePath:    po.FilePath,
 		FileType:    po.FileType,
 		Status:      po.Status,
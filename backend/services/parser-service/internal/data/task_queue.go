@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lyb88999/resume_helper/backend/services/parser-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	defaultRequestQueue = "parser_tasks"
+	defaultResultQueue  = "parser_results"
+)
+
+// taskQueue 基于 Redis 列表的解析任务队列：file-service 将请求 LPUSH 到请求队列，这里 BRPOP 消费；
+// 任务状态 LPUSH 到结果队列，由 file-service 消费
+type taskQueue struct {
+	rdb         *redis.Client
+	queue       string
+	resultQueue string
+	log         *log.Helper
+}
+
+// NewTaskQueue 创建解析任务队列
+func NewTaskQueue(data *Data, config *conf.Parser, logger log.Logger) biz.TaskQueue {
+	queue := config.GetTask().GetQueueName()
+	if queue == "" {
+		queue = defaultRequestQueue
+	}
+	resultQueue := config.GetTask().GetResultQueue()
+	if resultQueue == "" {
+		resultQueue = defaultResultQueue
+	}
+	return &taskQueue{
+		rdb:         data.rdb,
+		queue:       queue,
+		resultQueue: resultQueue,
+		log:         log.NewHelper(logger),
+	}
+}
+
+func (q *taskQueue) NextRequest(ctx context.Context, wait time.Duration) (*biz.ParseRequest, error) {
+	values, err := q.rdb.BRPop(ctx, wait, q.queue).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// BRPOP 返回 [队列名, 值]
+	var req biz.ParseRequest
+	if err := json.Unmarshal([]byte(values[1]), &req); err != nil {
+		return nil, fmt.Errorf("invalid parse request %q: %w", values[1], err)
+	}
+	return &req, nil
+}
+
+func (q *taskQueue) PublishResult(ctx context.Context, result *biz.ParseResult) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return q.rdb.LPush(ctx, q.resultQueue, payload).Err()
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/parser-service/internal/biz"
+)
+
+// consumerBackoff 读取请求队列出错后的等待时间
+const consumerBackoff = 5 * time.Second
+
+// TaskConsumer 持续读取 file-service 提交的解析请求
+type TaskConsumer struct {
+	uc  *biz.QueueUsecase
+	log *log.Helper
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewTaskConsumer 创建解析请求消费者
+func NewTaskConsumer(uc *biz.QueueUsecase, logger log.Logger) *TaskConsumer {
+	return &TaskConsumer{
+		uc:   uc,
+		log:  log.NewHelper(logger),
+		stop: make(chan struct{}),
+	}
+}
+
+// Start 启动消费循环，实现 transport.Server 接口
+func (c *TaskConsumer) Start(ctx context.Context) error {
+	c.log.Info("[Consumer] parse task consumer started")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for ctx.Err() == nil {
+		if _, err := c.uc.HandleNext(ctx); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			c.log.Errorf("[Consumer] failed to receive parse task: %v", err)
+			select {
+			case <-time.After(consumerBackoff):
+			case <-ctx.Done():
+			}
+		}
+	}
+	return nil
+}
+
+// Stop 停止接收新的请求，并等待正在处理的任务结束
+func (c *TaskConsumer) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	if err := c.uc.Wait(ctx); err != nil {
+		c.log.Warnf("[Consumer] stopped before running parse tasks finished: %v", err)
+		return nil
+	}
+	c.log.Info("[Consumer] parse task consumer stopped")
+	return nil
+}
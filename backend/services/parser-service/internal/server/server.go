@@ -6,11 +6,13 @@ import (
 	"github.com/google/wire"
 	"github.com/hashicorp/consul/api"
 
+	v1 "github.com/lyb88999/resume_helper/backend/services/parser-service/api/parser/v1"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
 // ProviderSet is server providers.
-var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewRegistrar)
+var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewRegistrar, NewTaskConsumer)
 
 // NewRegistrar 创建服务注册器
 func NewRegistrar(c *conf.Bootstrap) registry.Registrar {
@@ -32,3 +34,8 @@ func NewRegistrar(c *conf.Bootstrap) registry.Registrar {
 	r := consul.New(consulClient)
 	return r
 }
+
+// publicOperations 健康检查无需访问令牌
+func publicOperations() auth.Option {
+	return auth.WithPublic(v1.OperationParserServiceHealth)
+}
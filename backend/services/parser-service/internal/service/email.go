@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/lyb88999/resume_helper/backend/services/parser-service/api/parser/v1"
+	"github.com/lyb88999/resume_helper/backend/services/parser-service/internal/biz"
+)
+
+// ImportEmail 从 .eml 或 .mbox 文件导入简历附件
+func (s *ParserService) ImportEmail(ctx context.Context, req *pb.ImportEmailRequest) (*pb.ImportEmailReply, error) {
+	var options *biz.ParseOptions
+	if req.Options != nil {
+		options = &biz.ParseOptions{
+			ExtractImages:  req.Options.ExtractImages,
+			CleanText:      req.Options.CleanText,
+			TargetLanguage: req.Options.TargetLanguage,
+			SkipSections:   req.Options.SkipSections,
+		}
+	}
+
+	messages, err := s.email.ImportEmail(ctx, req.FileId, options)
+	if err != nil {
+		return nil, importError(err)
+	}
+
+	reply := &pb.ImportEmailReply{}
+	for _, msg := range messages {
+		pbMsg := &pb.ImportedMessage{
+			Source: convertEmailSource(msg.Source),
+			Error:  msg.Error,
+		}
+		for _, att := range msg.Attachments {
+			pbMsg.Attachments = append(pbMsg.Attachments, &pb.ImportedAttachment{
+				Filename: att.Filename,
+				TaskId:   att.TaskID,
+				Status:   att.Status,
+				Reason:   att.Reason,
+			})
+			if att.TaskID != "" {
+				reply.TaskCount++
+			}
+		}
+		reply.Messages = append(reply.Messages, pbMsg)
+	}
+	return reply, nil
+}
+
+// convertEmailSource 转换邮件发件信息为protobuf格式
+func convertEmailSource(source *biz.EmailSource) *pb.EmailSource {
+	if source == nil {
+		return nil
+	}
+	return &pb.EmailSource{
+		FromName:    source.FromName,
+		FromAddress: source.FromAddress,
+		Subject:     source.Subject,
+		MessageId:   source.MessageID,
+		Date:        source.Date,
+	}
+}
+
+// importError 将导入相关的业务错误转换为API错误
+func importError(err error) error {
+	switch {
+	case errors.Is(err, biz.ErrFileNotFound):
+		return pb.ErrorFileNotFound("file not found")
+	case errors.Is(err, biz.ErrFileNotClean):
+		return pb.ErrorFileNotClean("file has not passed malware scanning")
+	case errors.Is(err, biz.ErrUnsupportedType):
+		return pb.ErrorUnsupportedFileType("only .eml and .mbox files can be imported")
+	case errors.Is(err, biz.ErrFileTooLarge):
+		return pb.ErrorFileTooLarge("file exceeds the maximum allowed size")
+	case errors.Is(err, biz.ErrInvalidEmail):
+		return pb.ErrorInvalidEmail("%v", err)
+	default:
+		return err
+	}
+}
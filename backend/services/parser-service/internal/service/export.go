@@ -0,0 +1,68 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/lyb88999/resume_helper/backend/services/parser-service/internal/biz"
+	exportv1 "github.com/lyb88999/resume_helper/backend/shared/proto/export"
+)
+
+// ExportService 用户数据导出服务，供 file-service 生成导出压缩包，每个解析任务导出为一个 JSON 文件
+type ExportService struct {
+	exportv1.UnimplementedUserDataExportServiceServer
+	uc *biz.ParserUsecase
+}
+
+// NewExportService 创建用户数据导出服务
+func NewExportService(uc *biz.ParserUsecase) *ExportService {
+	return &ExportService{uc: uc}
+}
+
+// exportedTask 导出的解析任务，不包含解析时使用的临时文件路径
+type exportedTask struct {
+	TaskID      string             `json:"task_id"`
+	ResumeID    string             `json:"resume_id,omitempty"`
+	FileID      string             `json:"file_id,omitempty"`
+	FileVersion int32              `json:"file_version,omitempty"`
+	BatchID     string             `json:"batch_id,omitempty"`
+	FileType    string             `json:"file_type"`
+	Status      string             `json:"status"`
+	Error       string             `json:"error,omitempty"`
+	Options     *biz.ParseOptions  `json:"options,omitempty"`
+	Result      *biz.ParsedContent `json:"result,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+}
+
+// ExportUserData 导出访问令牌中用户的所有解析任务及其解析结果
+func (s *ExportService) ExportUserData(req *exportv1.ExportUserDataRequest, stream exportv1.UserDataExportService_ExportUserDataServer) error {
+	return s.uc.ExportTasks(stream.Context(), func(task *biz.ParseTask) error {
+		content, err := json.MarshalIndent(&exportedTask{
+			TaskID:      task.ID,
+			ResumeID:    task.ResumeID,
+			FileID:      task.FileID,
+			FileVersion: task.FileVersion,
+			BatchID:     task.BatchID,
+			FileType:    task.FileType,
+			Status:      task.Status,
+			Error:       task.ErrorMsg,
+			Options:     task.Options,
+			Result:      task.Result,
+			CreatedAt:   task.CreatedAt,
+			UpdatedAt:   task.UpdatedAt,
+			CompletedAt: task.CompletedAt,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		return stream.Send(&exportv1.ExportRecord{
+			Name:       task.ID + ".json",
+			Content:    content,
+			ModifiedAt: timestamppb.New(task.UpdatedAt),
+		})
+	})
+}
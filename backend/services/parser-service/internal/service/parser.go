@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	v1 "github.com/lyb88999/resume_helper/backend/services/parser-service/api/parser/v1"
 	"time"
@@ -15,11 +16,12 @@ import (
 // ParserService 解析服务实现
 type ParserService struct {
 	v1.UnimplementedParserServiceServer
-	uc *biz.ParserUsecase
+	uc    *biz.ParserUsecase
+	email *biz.EmailUsecase
 }
 
 // NewParserService 创建解析服务
-func NewParserService(uc *biz.ParserUsecase) *ParserService {
+func NewParserService(uc *biz.ParserUsecase, email *biz.EmailUsecase) *ParserService {
 	// 注册解析器
 	uc.RegisterParser("txt", biz.NewTextParser())
 	uc.RegisterParser("md", biz.NewMarkdownParser())
@@ -29,7 +31,8 @@ func NewParserService(uc *biz.ParserUsecase) *ParserService {
 	uc.RegisterParser("doc", biz.NewDocxParser())
 
 	return &ParserService{
-		uc: uc,
+		uc:    uc,
+		email: email,
 	}
 }
 
@@ -46,9 +49,9 @@ func (s *ParserService) ParseDocument(ctx context.Context, req *pb.ParseDocument
 	}
 
 	// 调用业务逻辑
-	task, err := s.uc.ParseDocument(ctx, req.FilePath, req.FileType, req.ResumeId, req.UserId, options)
+	task, err := s.uc.ParseDocument(ctx, req.FileId, req.FileVersion, req.FileType, req.ResumeId, options)
 	if err != nil {
-		return nil, err
+		return nil, parseError(err)
 	}
 
 	// 转换响应
@@ -76,15 +79,7 @@ func (s *ParserService) GetParseStatus(ctx context.Context, req *pb.GetParseStat
 		return nil, err
 	}
 
-	reply := &pb.GetParseStatusReply{
-		TaskId:    task.ID,
-		Status:    task.Status,
-		Progress:  int32(task.Progress),
-		Message:   task.ErrorMsg,
-		CreatedAt: timestamppb.New(task.CreatedAt),
-		UpdatedAt: timestamppb.New(task.UpdatedAt),
-	}
-
+	reply := toTaskStatus(task)
 	if task.Result != nil {
 		reply.Content = s.convertParsedContent(task.Result)
 	}
@@ -92,6 +87,38 @@ func (s *ParserService) GetParseStatus(ctx context.Context, req *pb.GetParseStat
 	return reply, nil
 }
 
+func (s *ParserService) ListParseTasks(ctx context.Context, req *pb.ListParseTasksRequest) (*pb.ListParseTasksReply, error) {
+	tasks, total, err := s.uc.ListParseTasks(ctx, int(req.Page), int(req.PerPage))
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &pb.ListParseTasksReply{
+		Tasks: make([]*pb.GetParseStatusReply, len(tasks)),
+		Total: total,
+	}
+	for i, task := range tasks {
+		reply.Tasks[i] = toTaskStatus(task)
+	}
+	return reply, nil
+}
+
+// toTaskStatus 转换任务状态，不包含解析结果
+func toTaskStatus(task *biz.ParseTask) *pb.GetParseStatusReply {
+	return &pb.GetParseStatusReply{
+		TaskId:      task.ID,
+		Status:      task.Status,
+		Progress:    int32(task.Progress),
+		Message:     task.ErrorMsg,
+		CreatedAt:   timestamppb.New(task.CreatedAt),
+		UpdatedAt:   timestamppb.New(task.UpdatedAt),
+		FileId:      task.FileID,
+		FileVersion: task.FileVersion,
+		UserId:      task.UserID,
+		OrgId:       task.OrgID,
+	}
+}
+
 func (s *ParserService) Health(ctx context.Context, req *pb.HealthRequest) (*pb.HealthReply, error) {
 	return &pb.HealthReply{
 		Status:    "ok",
@@ -118,6 +145,7 @@ func (s *ParserService) convertParsedContent(content *biz.ParsedContent) *pb.Par
 			Nationality: content.PersonalInfo.Nationality,
 			SocialLinks: content.PersonalInfo.SocialLinks,
 			AvatarUrl:   content.PersonalInfo.AvatarURL,
+			Provenance:  content.PersonalInfo.Provenance,
 		}
 	}
 
@@ -199,8 +227,25 @@ func (s *ParserService) convertParsedContent(content *biz.ParsedContent) *pb.Par
 			ParserVersion:   content.Metadata.ParserVersion,
 			Warnings:        content.Metadata.Warnings,
 			ConfidenceScore: content.Metadata.ConfidenceScore,
+			Email:           convertEmailSource(content.Metadata.Email),
 		}
 	}
 
 	return result
 }
+
+// parseError 将创建解析任务时的业务错误转换为API错误
+func parseError(err error) error {
+	switch {
+	case errors.Is(err, biz.ErrFileNotFound):
+		return pb.ErrorFileNotFound("file not found")
+	case errors.Is(err, biz.ErrFileNotClean):
+		return pb.ErrorFileNotClean("file has not passed malware scanning")
+	case errors.Is(err, biz.ErrUnsupportedType):
+		return pb.ErrorUnsupportedFileType("unsupported file type")
+	case errors.Is(err, biz.ErrFileTooLarge):
+		return pb.ErrorFileTooLarge("file exceeds the maximum allowed size")
+	default:
+		return err
+	}
+}
@@ -0,0 +1,288 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// 账号令牌的用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+const (
+	// defaultVerifyEmailExpire 未配置 verify_email_expire 时邮箱验证链接的有效期
+	defaultVerifyEmailExpire = 24 * time.Hour
+	// defaultResetPasswordExpire 未配置 reset_password_expire 时重置密码链接的有效期
+	defaultResetPasswordExpire = 30 * time.Minute
+	// defaultLinkBaseURL 未配置 mail.link_base_url 时邮件中链接的前缀
+	defaultLinkBaseURL = "http://localhost:3000"
+	// resendInterval 同一用途的邮件最短发送间隔
+	resendInterval = time.Minute
+)
+
+var (
+	ErrInvalidAccountToken  = errors.BadRequest("ACCOUNT_TOKEN_INVALID", "链接无效或已过期")
+	ErrEmailAlreadyVerified = errors.BadRequest("EMAIL_ALREADY_VERIFIED", "邮箱已验证")
+	ErrTooManyRequests      = errors.New(429, "TOO_MANY_REQUESTS", "请求过于频繁，请稍后再试")
+)
+
+// AccountToken 邮箱验证和重置密码使用的一次性令牌，服务端只保存哈希。
+// Email 为签发时的邮箱，邮箱变更后令牌失效
+type AccountToken struct {
+	ID        uint64
+	TokenHash string
+	UserID    uint64
+	Purpose   string
+	Email     string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// AccountTokenRepo 账号令牌存储接口
+type AccountTokenRepo interface {
+	// CreateAccountToken 保存令牌，同一用户同一用途之前未使用的令牌随之失效
+	CreateAccountToken(ctx context.Context, token *AccountToken) error
+	// GetAccountToken 按令牌哈希查询，不存在时返回 ErrInvalidAccountToken
+	GetAccountToken(ctx context.Context, tokenHash string) (*AccountToken, error)
+	// GetLatestAccountToken 查询用户某一用途最近签发的令牌，没有时返回 nil
+	GetLatestAccountToken(ctx context.Context, userID uint64, purpose string) (*AccountToken, error)
+	// UseAccountToken 将未使用的令牌标记为已使用，返回是否标记成功
+	UseAccountToken(ctx context.Context, id uint64) (bool, error)
+}
+
+// Mail 待发送的纯文本邮件
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口，实现见 data 层（smtp、log、file）
+type Mailer interface {
+	Send(ctx context.Context, mail *Mail) error
+}
+
+// AccountUsecase 邮箱验证和重置密码
+type AccountUsecase struct {
+	users  UserRepo
+	repo   AccountTokenRepo
+	tokens *TokenUsecase
+	mailer Mailer
+	auth   *conf.Auth
+	log    *log.Helper
+}
+
+// NewAccountUsecase 创建账号业务逻辑实例
+func NewAccountUsecase(users UserRepo, repo AccountTokenRepo, tokens *TokenUsecase, mailer Mailer, auth *conf.Auth, logger log.Logger) *AccountUsecase {
+	return &AccountUsecase{
+		users:  users,
+		repo:   repo,
+		tokens: tokens,
+		mailer: mailer,
+		auth:   auth,
+		log:    log.NewHelper(logger),
+	}
+}
+
+// SendVerification 向当前用户的邮箱发送验证邮件
+func (uc *AccountUsecase) SendVerification(ctx context.Context) error {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := uc.users.GetUserByID(ctx, uint64(userID))
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	if err := uc.checkResendInterval(ctx, user.ID, TokenPurposeVerifyEmail); err != nil {
+		return err
+	}
+	return uc.sendVerification(ctx, user)
+}
+
+// sendVerification 签发邮箱验证令牌并发送验证邮件
+func (uc *AccountUsecase) sendVerification(ctx context.Context, user *User) error {
+	expire := durationOr(uc.auth.GetVerifyEmailExpire().AsDuration(), defaultVerifyEmailExpire)
+	token, err := uc.issue(ctx, user, TokenPurposeVerifyEmail, expire)
+	if err != nil {
+		return err
+	}
+	return uc.mailer.Send(ctx, &Mail{
+		To:      user.Email,
+		Subject: "验证你的邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请打开以下链接验证你的邮箱，链接%s内有效：\n\n%s\n\n如果你没有注册账号，请忽略本邮件。\n",
+			user.Nickname, formatExpire(expire), mailLink(uc.auth, "/verify-email", token)),
+	})
+}
+
+// VerifyEmail 使用邮件中的令牌完成邮箱验证
+func (uc *AccountUsecase) VerifyEmail(ctx context.Context, token string) error {
+	t, err := uc.use(ctx, token, TokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	ok, err := uc.users.MarkEmailVerified(ctx, t.UserID, t.Email)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// 签发令牌后邮箱已变更
+		return ErrInvalidAccountToken
+	}
+	return nil
+}
+
+// ForgotPassword 向邮箱发送重置密码邮件。邮箱未注册、发送过于频繁或发送失败时同样返回成功，
+// 不暴露邮箱是否已注册
+func (uc *AccountUsecase) ForgotPassword(ctx context.Context, email string) error {
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+	user, err := uc.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+	if err := uc.checkResendInterval(ctx, user.ID, TokenPurposeResetPassword); err != nil {
+		uc.log.WithContext(ctx).Infof("重置密码邮件发送过于频繁: user_id=%d", user.ID)
+		return nil
+	}
+
+	expire := durationOr(uc.auth.GetResetPasswordExpire().AsDuration(), defaultResetPasswordExpire)
+	token, err := uc.issue(ctx, user, TokenPurposeResetPassword, expire)
+	if err != nil {
+		return err
+	}
+	err = uc.mailer.Send(ctx, &Mail{
+		To:      user.Email,
+		Subject: "重置你的密码",
+		Body: fmt.Sprintf("%s，你好：\n\n请打开以下链接设置新密码，链接%s内有效且只能使用一次：\n\n%s\n\n如果你没有申请重置密码，请忽略本邮件，你的密码不会改变。\n",
+			user.Nickname, formatExpire(expire), mailLink(uc.auth, "/reset-password", token)),
+	})
+	if err != nil {
+		uc.log.WithContext(ctx).Errorf("发送重置密码邮件失败: user_id=%d, %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword 使用邮件中的令牌设置新密码，并登出该用户的所有会话。
+// 能收到邮件说明用户拥有该邮箱，邮箱同时视为已验证
+func (uc *AccountUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if len(newPassword) < 6 {
+		return ErrPasswordTooShort
+	}
+	t, err := uc.use(ctx, token, TokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+	if err := uc.users.UpdatePassword(ctx, t.UserID, string(hashedPassword)); err != nil {
+		return err
+	}
+	if _, err := uc.users.MarkEmailVerified(ctx, t.UserID, t.Email); err != nil {
+		return err
+	}
+	if _, err := uc.tokens.RevokeUser(ctx, t.UserID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// issue 签发账号令牌，返回明文令牌
+func (uc *AccountUsecase) issue(ctx context.Context, user *User, purpose string, expire time.Duration) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = uc.repo.CreateAccountToken(ctx, &AccountToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(expire),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// use 校验令牌的用途和有效期并将其标记为已使用
+func (uc *AccountUsecase) use(ctx context.Context, token, purpose string) (*AccountToken, error) {
+	if token == "" {
+		return nil, ErrInvalidAccountToken
+	}
+	t, err := uc.repo.GetAccountToken(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if t.Purpose != purpose || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return nil, ErrInvalidAccountToken
+	}
+	ok, err := uc.repo.UseAccountToken(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidAccountToken
+	}
+	return t, nil
+}
+
+// checkResendInterval 限制同一用途邮件的发送频率
+func (uc *AccountUsecase) checkResendInterval(ctx context.Context, userID uint64, purpose string) error {
+	latest, err := uc.repo.GetLatestAccountToken(ctx, userID, purpose)
+	if err != nil {
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < resendInterval {
+		return ErrTooManyRequests
+	}
+	return nil
+}
+
+// mailLink 生成邮件中指向前端页面的链接
+func mailLink(c *conf.Auth, path, token string) string {
+	base := c.GetMail().GetLinkBaseUrl()
+	if base == "" {
+		base = defaultLinkBaseURL
+	}
+	return strings.TrimRight(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// formatExpire 以小时或分钟表示有效期
+func formatExpire(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d小时", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d分钟", int(d.Minutes()))
+}
@@ -0,0 +1,537 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// memMailer 把邮件保存在内存中
+type memMailer struct {
+	mu   sync.Mutex
+	sent []*Mail
+}
+
+func (m *memMailer) Send(ctx context.Context, mail *Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+var mailLinkPattern = regexp.MustCompile(`https?://\S+`)
+
+// lastToken 取出最后一封邮件的收件人和链接中的令牌
+func (m *memMailer) lastToken(t *testing.T) (string, string) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no mail was sent")
+	}
+	mail := m.sent[len(m.sent)-1]
+	link, err := url.Parse(mailLinkPattern.FindString(mail.Body))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("mail body has no token link: %q", mail.Body)
+	}
+	return mail.To, link.Query().Get("token")
+}
+
+func (m *memMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent)
+}
+
+// memUserRepo 内存中的用户仓库
+type memUserRepo struct {
+	mu    sync.Mutex
+	users map[uint64]*User
+}
+
+func (r *memUserRepo) CreateUser(ctx context.Context, user *User) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.ID = uint64(len(r.users) + 1)
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *memUserRepo) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *memUserRepo) GetUserByID(ctx context.Context, id uint64) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	copied := *u
+	return &copied, nil
+}
+
+func (r *memUserRepo) UpdateUser(ctx context.Context, user *User) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *memUserRepo) CheckEmailExists(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetUserByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *memUserRepo) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[id].Password = password
+	return nil
+}
+
+func (r *memUserRepo) MarkEmailVerified(ctx context.Context, id uint64, email string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.users[id]
+	if u.Email != email {
+		return false, nil
+	}
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	return true, nil
+}
+
+// memAccountTokenRepo 内存中的账号令牌仓库，与数据库实现一样，新令牌使之前未使用的令牌失效
+type memAccountTokenRepo struct {
+	mu     sync.Mutex
+	tokens []*AccountToken
+}
+
+func (r *memAccountTokenRepo) CreateAccountToken(ctx context.Context, token *AccountToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.UserID == token.UserID && t.Purpose == token.Purpose && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	token.ID = uint64(len(r.tokens) + 1)
+	token.CreatedAt = now
+	copied := *token
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *memAccountTokenRepo) GetAccountToken(ctx context.Context, tokenHash string) (*AccountToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, ErrInvalidAccountToken
+}
+
+func (r *memAccountTokenRepo) GetLatestAccountToken(ctx context.Context, userID uint64, purpose string) (*AccountToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.tokens) - 1; i >= 0; i-- {
+		if t := r.tokens[i]; t.UserID == userID && t.Purpose == purpose {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memAccountTokenRepo) UseAccountToken(ctx context.Context, id uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.tokens[id-1]
+	if t.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+// age 把令牌的签发和过期时间提前 d，模拟时间流逝
+func (r *memAccountTokenRepo) age(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		t.CreatedAt = t.CreatedAt.Add(-d)
+		t.ExpiresAt = t.ExpiresAt.Add(-d)
+	}
+}
+
+// memTokenRepo 内存中的刷新令牌仓库和访问令牌吊销列表
+type memTokenRepo struct {
+	mu      sync.Mutex
+	tokens  []*RefreshToken
+	revoked map[string]bool
+}
+
+func (r *memTokenRepo) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = uint64(len(r.tokens) + 1)
+	copied := *token
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *memTokenRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, ErrInvalidRefreshToken
+}
+
+func (r *memTokenRepo) MarkRefreshTokenUsed(ctx context.Context, id uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.tokens[id-1]
+	if t.UsedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (r *memTokenRepo) RevokeSession(ctx context.Context, sessionID string) ([]*RefreshToken, error) {
+	return r.revoke(func(t *RefreshToken) bool { return t.SessionID == sessionID }), nil
+}
+
+func (r *memTokenRepo) RevokeUserSessions(ctx context.Context, userID uint64) ([]*RefreshToken, error) {
+	return r.revoke(func(t *RefreshToken) bool { return t.UserID == userID }), nil
+}
+
+func (r *memTokenRepo) revoke(match func(t *RefreshToken) bool) []*RefreshToken {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var live []*RefreshToken
+	for _, t := range r.tokens {
+		if !match(t) || t.RevokedAt != nil {
+			continue
+		}
+		if t.AccessExpiresAt.After(now) {
+			copied := *t
+			live = append(live, &copied)
+		}
+		t.RevokedAt = &now
+	}
+	return live
+}
+
+func (r *memTokenRepo) ListAccessTokens(ctx context.Context, userID uint64) ([]*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var live []*RefreshToken
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt == nil && t.AccessExpiresAt.After(time.Now()) {
+			copied := *t
+			live = append(live, &copied)
+		}
+	}
+	return live, nil
+}
+
+func (r *memTokenRepo) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoked[jti] = true
+	return nil
+}
+
+func (r *memTokenRepo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.revoked[jti], nil
+}
+
+// noOrgRepo 用户都不属于任何组织，其余方法不会被调用
+type noOrgRepo struct {
+	OrganizationRepo
+}
+
+func (noOrgRepo) GetMembership(ctx context.Context, userID uint64) (*Member, error) {
+	return nil, nil
+}
+
+type accountFixture struct {
+	accounts *AccountUsecase
+	tokens   *TokenUsecase
+	users    *memUserRepo
+	repo     *memAccountTokenRepo
+	mailer   *memMailer
+	user     *User
+}
+
+func newAccountFixture(t *testing.T) *accountFixture {
+	t.Helper()
+	c := &conf.Auth{
+		JwtSecret:           "test-secret",
+		VerifyEmailExpire:   durationpb.New(time.Hour),
+		ResetPasswordExpire: durationpb.New(30 * time.Minute),
+		Mail:                &conf.MailConfig{LinkBaseUrl: "https://resume.example.com/"},
+	}
+	logger := log.NewStdLogger(io.Discard)
+	f := &accountFixture{
+		users:  &memUserRepo{users: make(map[uint64]*User)},
+		repo:   &memAccountTokenRepo{},
+		mailer: &memMailer{},
+	}
+	f.tokens = NewTokenUsecase(&memTokenRepo{revoked: make(map[string]bool)}, f.users, noOrgRepo{}, c, logger)
+	f.accounts = NewAccountUsecase(f.users, f.repo, f.tokens, f.mailer, c, logger)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.user, _ = f.users.CreateUser(context.Background(), &User{Email: "zhangsan@example.com", Nickname: "张三", Password: string(hashed)})
+	return f
+}
+
+func (f *accountFixture) userContext() context.Context {
+	return auth.NewContext(context.Background(), int64(f.user.ID))
+}
+
+func TestVerifyEmail(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := f.userContext()
+
+	if err := f.accounts.SendVerification(ctx); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	to, token := f.mailer.lastToken(t)
+	if to != f.user.Email {
+		t.Errorf("mail sent to %s, want %s", to, f.user.Email)
+	}
+	if body := f.mailer.sent[0].Body; !regexp.MustCompile(`https://resume\.example\.com/verify-email\?token=`).MatchString(body) {
+		t.Errorf("mail link does not use link_base_url: %q", body)
+	}
+
+	if err := f.accounts.VerifyEmail(context.Background(), token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if u, _ := f.users.GetUserByID(ctx, f.user.ID); u.EmailVerifiedAt == nil {
+		t.Error("email not marked verified")
+	}
+	// 令牌只能使用一次
+	if err := f.accounts.VerifyEmail(context.Background(), token); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Errorf("second VerifyEmail error = %v, want ErrInvalidAccountToken", err)
+	}
+	if err := f.accounts.SendVerification(ctx); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("SendVerification after verifying error = %v, want ErrEmailAlreadyVerified", err)
+	}
+}
+
+func TestVerifyEmailInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, f *accountFixture, token string) string
+	}{
+		{
+			name: "expired",
+			setup: func(t *testing.T, f *accountFixture, token string) string {
+				f.repo.age(time.Hour + time.Second)
+				return token
+			},
+		},
+		{
+			name:  "empty",
+			setup: func(t *testing.T, f *accountFixture, token string) string { return "" },
+		},
+		{
+			name:  "unknown",
+			setup: func(t *testing.T, f *accountFixture, token string) string { return token + "x" },
+		},
+		{
+			name: "email changed after sending",
+			setup: func(t *testing.T, f *accountFixture, token string) string {
+				u, _ := f.users.GetUserByID(context.Background(), f.user.ID)
+				u.Email = "new@example.com"
+				f.users.UpdateUser(context.Background(), u)
+				return token
+			},
+		},
+		{
+			name: "superseded by a newer mail",
+			setup: func(t *testing.T, f *accountFixture, token string) string {
+				f.repo.age(resendInterval)
+				if err := f.accounts.SendVerification(f.userContext()); err != nil {
+					t.Fatalf("SendVerification: %v", err)
+				}
+				return token
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			if err := f.accounts.SendVerification(f.userContext()); err != nil {
+				t.Fatalf("SendVerification: %v", err)
+			}
+			_, token := f.mailer.lastToken(t)
+
+			if err := f.accounts.VerifyEmail(context.Background(), tt.setup(t, f, token)); !errors.Is(err, ErrInvalidAccountToken) {
+				t.Errorf("VerifyEmail error = %v, want ErrInvalidAccountToken", err)
+			}
+			if u, _ := f.users.GetUserByID(context.Background(), f.user.ID); u.EmailVerifiedAt != nil {
+				t.Error("email marked verified with an invalid token")
+			}
+		})
+	}
+}
+
+func TestSendVerificationResendInterval(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := f.userContext()
+
+	if err := f.accounts.SendVerification(ctx); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	if err := f.accounts.SendVerification(ctx); !errors.Is(err, ErrTooManyRequests) {
+		t.Errorf("immediate resend error = %v, want ErrTooManyRequests", err)
+	}
+	f.repo.age(resendInterval)
+	if err := f.accounts.SendVerification(ctx); err != nil {
+		t.Errorf("resend after the interval: %v", err)
+	}
+	if n := f.mailer.count(); n != 2 {
+		t.Errorf("sent %d mails, want 2", n)
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	if err := f.accounts.ForgotPassword(ctx, "not-an-email"); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("ForgotPassword with invalid email error = %v", err)
+	}
+	// 未注册的邮箱同样返回成功，但不发送邮件
+	if err := f.accounts.ForgotPassword(ctx, "nobody@example.com"); err != nil || f.mailer.count() != 0 {
+		t.Errorf("ForgotPassword for unknown email = %v, sent %d mails", err, f.mailer.count())
+	}
+	if err := f.accounts.ForgotPassword(ctx, f.user.Email); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	// 频繁请求不暴露限流，也不再发送
+	if err := f.accounts.ForgotPassword(ctx, f.user.Email); err != nil || f.mailer.count() != 1 {
+		t.Errorf("repeated ForgotPassword = %v, sent %d mails, want 1", err, f.mailer.count())
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	// 两台设备上的登录会话
+	var sessions []*TokenPair
+	for i := 0; i < 2; i++ {
+		pair, err := f.tokens.Issue(ctx, f.user)
+		if err != nil {
+			t.Fatalf("Issue: %v", err)
+		}
+		sessions = append(sessions, pair)
+	}
+
+	if err := f.accounts.ForgotPassword(ctx, f.user.Email); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	_, token := f.mailer.lastToken(t)
+
+	if err := f.accounts.ResetPassword(ctx, token, "short"); !errors.Is(err, ErrPasswordTooShort) {
+		t.Fatalf("ResetPassword with short password error = %v", err)
+	}
+	if err := f.accounts.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Errorf("reset token accepted for email verification: %v", err)
+	}
+	if err := f.accounts.ResetPassword(ctx, token, "new-password"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+
+	u, _ := f.users.GetUserByID(ctx, f.user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("new-password")) != nil {
+		t.Error("password was not updated")
+	}
+	if u.EmailVerifiedAt == nil {
+		t.Error("email not marked verified after reset")
+	}
+
+	// 重置前签发的会话全部失效
+	for i, pair := range sessions {
+		if _, err := f.tokens.Verify(ctx, pair.AccessToken); !errors.Is(err, auth.ErrTokenRevoked) {
+			t.Errorf("session %d access token error = %v, want ErrTokenRevoked", i, err)
+		}
+		if _, err := f.tokens.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("session %d refresh error = %v, want ErrInvalidRefreshToken", i, err)
+		}
+	}
+
+	// 令牌只能使用一次
+	if err := f.accounts.ResetPassword(ctx, token, "another-password"); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Errorf("second ResetPassword error = %v, want ErrInvalidAccountToken", err)
+	}
+}
+
+func TestResetPasswordExpired(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+	pair, err := f.tokens.Issue(ctx, f.user)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if err := f.accounts.ForgotPassword(ctx, f.user.Email); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	_, token := f.mailer.lastToken(t)
+	f.repo.age(30*time.Minute + time.Second)
+
+	if err := f.accounts.ResetPassword(ctx, token, "new-password"); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("ResetPassword with expired token error = %v, want ErrInvalidAccountToken", err)
+	}
+	u, _ := f.users.GetUserByID(ctx, f.user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("old-password")) != nil {
+		t.Error("password changed with an expired token")
+	}
+	if _, err := f.tokens.Verify(ctx, pair.AccessToken); err != nil {
+		t.Errorf("session revoked by a failed reset: %v", err)
+	}
+}
@@ -3,4 +3,4 @@ package biz
 import "github.com/google/wire"
 
 // ProviderSet is biz providers.
-var ProviderSet = wire.NewSet(NewUserUsecase)
\ No newline at end of file
+var ProviderSet = wire.NewSet(NewUserUsecase, NewTokenUsecase, NewAccountUsecase, NewRoleUsecase, NewOrganizationUsecase)
\ No newline at end of file
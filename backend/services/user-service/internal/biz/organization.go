@@ -0,0 +1,546 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// 组织成员角色
+const (
+	OrgRoleOwner  = auth.OrgRoleOwner  // 所有者：管理设置、成员和成员角色
+	OrgRoleAdmin  = auth.OrgRoleAdmin  // 管理员：管理设置、邀请和移除普通成员
+	OrgRoleMember = auth.OrgRoleMember // 成员：使用组织共享的文件、解析结果和分析报告
+)
+
+const (
+	// defaultInvitationExpire 未配置 invitation_expire 时组织邀请链接的有效期
+	defaultInvitationExpire = 7 * 24 * time.Hour
+	// maxOrgNameLength 组织名称的最大长度（字符）
+	maxOrgNameLength = 100
+	// maxAllowedFileTypes 组织允许的文件类型数上限
+	maxAllowedFileTypes = 20
+)
+
+// fileTypePattern 文件扩展名（不含点）
+var fileTypePattern = regexp.MustCompile(`^[a-z0-9]{1,10}$`)
+
+var (
+	ErrOrganizationNotFound    = errors.NotFound("ORGANIZATION_NOT_FOUND", "组织不存在")
+	ErrNotInOrganization       = errors.NotFound("NOT_IN_ORGANIZATION", "你不属于任何组织")
+	ErrAlreadyInOrganization   = errors.Conflict("ALREADY_IN_ORGANIZATION", "已加入其他组织，请先退出")
+	ErrOrgPermissionDenied     = errors.Forbidden("ORGANIZATION_PERMISSION_DENIED", "没有管理该组织的权限")
+	ErrInvalidOrgName          = errors.BadRequest("INVALID_ORGANIZATION_NAME", "组织名称不能为空且不能超过100个字符")
+	ErrInvalidOrgSettings      = errors.BadRequest("INVALID_ORGANIZATION_SETTINGS", "组织设置无效")
+	ErrInvalidOrgRole          = errors.BadRequest("INVALID_ORGANIZATION_ROLE", "组织角色不存在")
+	ErrLastOwner               = errors.BadRequest("LAST_OWNER", "组织至少需要一个所有者，请先将其他成员设为所有者")
+	ErrMemberNotFound          = errors.NotFound("MEMBER_NOT_FOUND", "成员不存在")
+	ErrAlreadyMember           = errors.Conflict("ALREADY_MEMBER", "该用户已是组织成员")
+	ErrInvitationNotFound      = errors.NotFound("INVITATION_NOT_FOUND", "邀请不存在")
+	ErrInvalidInvitation       = errors.BadRequest("INVITATION_INVALID", "邀请链接无效或已过期")
+	ErrInvitationEmailMismatch = errors.Forbidden("INVITATION_EMAIL_MISMATCH", "邀请发送给了其他邮箱")
+)
+
+// Organization 组织及其设置
+type Organization struct {
+	ID               uint64
+	Name             string
+	AllowedFileTypes []string // 允许上传的文件扩展名，为空时只受 file-service 的全局配置限制
+	AIMonthlyQuota   int32    // 每个自然月可发起的 AI 分析次数，0表示不限制
+	CreatedBy        uint64
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// Member 组织成员，每个用户最多属于一个组织
+type Member struct {
+	OrgID     uint64
+	UserID    uint64
+	Role      string
+	Email     string
+	Nickname  string
+	CreatedAt time.Time
+}
+
+// Invitation 组织邀请，只保存令牌的哈希
+type Invitation struct {
+	ID         uint64
+	OrgID      uint64
+	Email      string
+	Role       string
+	TokenHash  string
+	InvitedBy  uint64
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// OrganizationRepo 组织、成员和邀请的存储接口
+type OrganizationRepo interface {
+	// CreateOrganization 在同一事务中创建组织并将 owner 设为所有者
+	CreateOrganization(ctx context.Context, org *Organization, owner uint64) error
+	// GetOrganization 不存在时返回 ErrOrganizationNotFound
+	GetOrganization(ctx context.Context, id uint64) (*Organization, error)
+	UpdateOrganization(ctx context.Context, org *Organization) error
+	// DeleteOrganization 删除组织及其未处理的邀请，组织拥有的文件等数据仍归上传者
+	DeleteOrganization(ctx context.Context, id uint64) error
+	// GetMembership 查询用户所在组织的成员记录，不属于任何组织时返回 nil
+	GetMembership(ctx context.Context, userID uint64) (*Member, error)
+	// ListMembers 按加入时间返回组织的所有成员
+	ListMembers(ctx context.Context, orgID uint64) ([]*Member, error)
+	// UpdateMemberRole 成员不存在时返回 ErrMemberNotFound
+	UpdateMemberRole(ctx context.Context, orgID, userID uint64, role string) error
+	// RemoveMember 成员不存在时返回 ErrMemberNotFound
+	RemoveMember(ctx context.Context, orgID, userID uint64) error
+	// CreateInvitation 保存邀请，同一组织发给同一邮箱的未处理邀请随之失效
+	CreateInvitation(ctx context.Context, inv *Invitation) error
+	// GetInvitation 按令牌哈希查询，不存在时返回 ErrInvalidInvitation
+	GetInvitation(ctx context.Context, tokenHash string) (*Invitation, error)
+	// ListInvitations 返回组织未接受、未撤销且未过期的邀请
+	ListInvitations(ctx context.Context, orgID uint64) ([]*Invitation, error)
+	// RevokeInvitation 撤销未处理的邀请，不存在时返回 ErrInvitationNotFound
+	RevokeInvitation(ctx context.Context, orgID, id uint64) error
+	// AcceptInvitation 在同一事务中将未处理的邀请标记为已接受并添加成员，邀请已被处理时返回 ErrInvalidInvitation
+	AcceptInvitation(ctx context.Context, id uint64, member *Member) error
+}
+
+// OrganizationUsecase 组织、成员和邀请的管理。成员变更后吊销相关用户的访问令牌，
+// 客户端刷新后取得带有新组织信息的访问令牌
+type OrganizationUsecase struct {
+	repo   OrganizationRepo
+	users  UserRepo
+	tokens *TokenUsecase
+	mailer Mailer
+	auth   *conf.Auth
+	log    *log.Helper
+}
+
+// NewOrganizationUsecase 创建组织业务逻辑实例
+func NewOrganizationUsecase(repo OrganizationRepo, users UserRepo, tokens *TokenUsecase, mailer Mailer, auth *conf.Auth, logger log.Logger) *OrganizationUsecase {
+	return &OrganizationUsecase{
+		repo:   repo,
+		users:  users,
+		tokens: tokens,
+		mailer: mailer,
+		auth:   auth,
+		log:    log.NewHelper(logger),
+	}
+}
+
+// Create 创建组织，当前用户成为所有者
+func (uc *OrganizationUsecase) Create(ctx context.Context, name string) (*Organization, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name, err = validateOrgName(name)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.repo.GetMembership(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if member != nil {
+		return nil, ErrAlreadyInOrganization
+	}
+
+	org := &Organization{Name: name, CreatedBy: uint64(userID)}
+	if err := uc.repo.CreateOrganization(ctx, org, uint64(userID)); err != nil {
+		return nil, err
+	}
+	uc.log.WithContext(ctx).Infof("创建组织: org_id=%d, owner=%d", org.ID, userID)
+	uc.refreshTokens(ctx, uint64(userID))
+	return org, nil
+}
+
+// Current 返回当前用户所在的组织和成员记录
+func (uc *OrganizationUsecase) Current(ctx context.Context) (*Organization, *Member, error) {
+	member, err := uc.membership(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	org, err := uc.repo.GetOrganization(ctx, member.OrgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return org, member, nil
+}
+
+// UpdateSettings 更新组织名称和设置，需要所有者或管理员
+func (uc *OrganizationUsecase) UpdateSettings(ctx context.Context, name string, allowedFileTypes []string, aiMonthlyQuota int32) (*Organization, error) {
+	member, err := uc.manager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := uc.repo.GetOrganization(ctx, member.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		if org.Name, err = validateOrgName(name); err != nil {
+			return nil, err
+		}
+	}
+	if org.AllowedFileTypes, err = normalizeFileTypes(allowedFileTypes); err != nil {
+		return nil, err
+	}
+	if aiMonthlyQuota < 0 {
+		return nil, ErrInvalidOrgSettings
+	}
+	org.AIMonthlyQuota = aiMonthlyQuota
+
+	if err := uc.repo.UpdateOrganization(ctx, org); err != nil {
+		return nil, err
+	}
+	uc.log.WithContext(ctx).Infof("更新组织设置: org_id=%d, operator=%d", org.ID, member.UserID)
+	return org, nil
+}
+
+// Settings 返回组织设置，供其他服务读取。只能读取访问令牌中的组织
+func (uc *OrganizationUsecase) Settings(ctx context.Context, orgID uint64) (*Organization, error) {
+	if _, err := auth.UserID(ctx); err != nil {
+		return nil, err
+	}
+	if orgID == 0 || auth.OrgID(ctx) != int64(orgID) {
+		return nil, ErrNotInOrganization
+	}
+	return uc.repo.GetOrganization(ctx, orgID)
+}
+
+// ListMembers 返回当前用户所在组织的成员
+func (uc *OrganizationUsecase) ListMembers(ctx context.Context) ([]*Member, error) {
+	member, err := uc.membership(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.ListMembers(ctx, member.OrgID)
+}
+
+// UpdateMemberRole 修改成员在组织中的角色，只有所有者可以操作，组织至少保留一个所有者
+func (uc *OrganizationUsecase) UpdateMemberRole(ctx context.Context, userID uint64, role string) error {
+	if !validOrgRole(role) {
+		return ErrInvalidOrgRole
+	}
+	operator, err := uc.membership(ctx)
+	if err != nil {
+		return err
+	}
+	if operator.Role != OrgRoleOwner {
+		return ErrOrgPermissionDenied
+	}
+	target, err := uc.member(ctx, operator.OrgID, userID)
+	if err != nil {
+		return err
+	}
+	if target.Role == role {
+		return nil
+	}
+	if target.Role == OrgRoleOwner {
+		if err := uc.checkOtherOwner(ctx, operator.OrgID, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := uc.repo.UpdateMemberRole(ctx, operator.OrgID, userID, role); err != nil {
+		return err
+	}
+	uc.log.WithContext(ctx).Infof("修改组织成员角色: org_id=%d, user_id=%d, %s -> %s, operator=%d",
+		operator.OrgID, userID, target.Role, role, operator.UserID)
+	uc.refreshTokens(ctx, userID)
+	return nil
+}
+
+// RemoveMember 移除成员，需要所有者或管理员，管理员只能移除普通成员。退出组织使用 Leave
+func (uc *OrganizationUsecase) RemoveMember(ctx context.Context, userID uint64) error {
+	operator, err := uc.manager(ctx)
+	if err != nil {
+		return err
+	}
+	if operator.UserID == userID {
+		return ErrOrgPermissionDenied
+	}
+	target, err := uc.member(ctx, operator.OrgID, userID)
+	if err != nil {
+		return err
+	}
+	if operator.Role != OrgRoleOwner && target.Role != OrgRoleMember {
+		return ErrOrgPermissionDenied
+	}
+
+	if err := uc.repo.RemoveMember(ctx, operator.OrgID, userID); err != nil {
+		return err
+	}
+	uc.log.WithContext(ctx).Infof("移除组织成员: org_id=%d, user_id=%d, operator=%d", operator.OrgID, userID, operator.UserID)
+	uc.refreshTokens(ctx, userID)
+	return nil
+}
+
+// Leave 退出当前组织。最后一个成员退出时删除组织；还有其他成员时最后一个所有者不能退出
+func (uc *OrganizationUsecase) Leave(ctx context.Context) error {
+	member, err := uc.membership(ctx)
+	if err != nil {
+		return err
+	}
+	members, err := uc.repo.ListMembers(ctx, member.OrgID)
+	if err != nil {
+		return err
+	}
+
+	if len(members) == 1 {
+		if err := uc.repo.DeleteOrganization(ctx, member.OrgID); err != nil {
+			return err
+		}
+		uc.log.WithContext(ctx).Infof("最后一个成员退出，删除组织: org_id=%d, user_id=%d", member.OrgID, member.UserID)
+	} else {
+		if member.Role == OrgRoleOwner {
+			if err := uc.checkOtherOwner(ctx, member.OrgID, member.UserID); err != nil {
+				return err
+			}
+		}
+		if err := uc.repo.RemoveMember(ctx, member.OrgID, member.UserID); err != nil {
+			return err
+		}
+		uc.log.WithContext(ctx).Infof("退出组织: org_id=%d, user_id=%d", member.OrgID, member.UserID)
+	}
+	uc.refreshTokens(ctx, member.UserID)
+	return nil
+}
+
+// Invite 邀请邮箱加入组织并发送邀请邮件，需要所有者或管理员。只有所有者可以邀请管理员
+func (uc *OrganizationUsecase) Invite(ctx context.Context, email, role string) (*Invitation, error) {
+	if role == "" {
+		role = OrgRoleMember
+	}
+	if role != OrgRoleMember && role != OrgRoleAdmin {
+		return nil, ErrInvalidOrgRole
+	}
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+	operator, err := uc.manager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if role == OrgRoleAdmin && operator.Role != OrgRoleOwner {
+		return nil, ErrOrgPermissionDenied
+	}
+	org, err := uc.repo.GetOrganization(ctx, operator.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkNotMember(ctx, org.ID, email); err != nil {
+		return nil, err
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	expire := durationOr(uc.auth.GetInvitationExpire().AsDuration(), defaultInvitationExpire)
+	inv := &Invitation{
+		OrgID:     org.ID,
+		Email:     email,
+		Role:      role,
+		TokenHash: hashToken(token),
+		InvitedBy: operator.UserID,
+		ExpiresAt: time.Now().Add(expire),
+	}
+	if err := uc.repo.CreateInvitation(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	err = uc.mailer.Send(ctx, &Mail{
+		To:      email,
+		Subject: fmt.Sprintf("邀请你加入「%s」", org.Name),
+		Body: fmt.Sprintf("你好：\n\n%s 邀请你加入组织「%s」，共享候选人简历和分析结果。请登录后打开以下链接接受邀请，链接%s内有效：\n\n%s\n\n如果你不认识邀请人，请忽略本邮件。\n",
+			operator.Nickname, org.Name, formatExpire(expire), mailLink(uc.auth, "/invitations/accept", token)),
+	})
+	if err != nil {
+		// 邀请已保存，可以重新邀请以再次发送
+		uc.log.WithContext(ctx).Errorf("发送组织邀请邮件失败: org_id=%d, invitation_id=%d, %v", org.ID, inv.ID, err)
+	}
+	uc.log.WithContext(ctx).Infof("邀请加入组织: org_id=%d, invitation_id=%d, role=%s, operator=%d", org.ID, inv.ID, role, operator.UserID)
+	return inv, nil
+}
+
+// ListInvitations 返回组织未处理的邀请，需要所有者或管理员
+func (uc *OrganizationUsecase) ListInvitations(ctx context.Context) ([]*Invitation, error) {
+	operator, err := uc.manager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.ListInvitations(ctx, operator.OrgID)
+}
+
+// RevokeInvitation 撤销邀请，需要所有者或管理员
+func (uc *OrganizationUsecase) RevokeInvitation(ctx context.Context, id uint64) error {
+	operator, err := uc.manager(ctx)
+	if err != nil {
+		return err
+	}
+	return uc.repo.RevokeInvitation(ctx, operator.OrgID, id)
+}
+
+// AcceptInvitation 当前用户接受邀请加入组织，邀请必须发给当前用户的邮箱
+func (uc *OrganizationUsecase) AcceptInvitation(ctx context.Context, token string) (*Organization, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, ErrInvalidInvitation
+	}
+	inv, err := uc.repo.GetInvitation(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if inv.AcceptedAt != nil || inv.RevokedAt != nil || time.Now().After(inv.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+	user, err := uc.users.GetUserByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(user.Email, inv.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+	member, err := uc.repo.GetMembership(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil {
+		return nil, ErrAlreadyInOrganization
+	}
+
+	if err := uc.repo.AcceptInvitation(ctx, inv.ID, &Member{OrgID: inv.OrgID, UserID: user.ID, Role: inv.Role}); err != nil {
+		return nil, err
+	}
+	uc.log.WithContext(ctx).Infof("接受组织邀请: org_id=%d, user_id=%d, role=%s", inv.OrgID, user.ID, inv.Role)
+	uc.refreshTokens(ctx, user.ID)
+	return uc.repo.GetOrganization(ctx, inv.OrgID)
+}
+
+// membership 当前用户的成员记录，以数据库为准而不是访问令牌
+func (uc *OrganizationUsecase) membership(ctx context.Context) (*Member, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.repo.GetMembership(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrNotInOrganization
+	}
+	return member, nil
+}
+
+// manager 当前用户必须是组织的所有者或管理员
+func (uc *OrganizationUsecase) manager(ctx context.Context) (*Member, error) {
+	member, err := uc.membership(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != OrgRoleOwner && member.Role != OrgRoleAdmin {
+		return nil, ErrOrgPermissionDenied
+	}
+	return member, nil
+}
+
+// member 查询组织中的成员
+func (uc *OrganizationUsecase) member(ctx context.Context, orgID, userID uint64) (*Member, error) {
+	member, err := uc.repo.GetMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil || member.OrgID != orgID {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// checkOtherOwner 除 userID 外组织中还有其他所有者
+func (uc *OrganizationUsecase) checkOtherOwner(ctx context.Context, orgID, userID uint64) error {
+	members, err := uc.repo.ListMembers(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.Role == OrgRoleOwner && m.UserID != userID {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
+
+// checkNotMember 邮箱对应的用户不能已是该组织的成员
+func (uc *OrganizationUsecase) checkNotMember(ctx context.Context, orgID uint64, email string) error {
+	user, err := uc.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+	member, err := uc.repo.GetMembership(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if member != nil && member.OrgID == orgID {
+		return ErrAlreadyMember
+	}
+	return nil
+}
+
+// refreshTokens 吊销用户现有的访问令牌，使其刷新后取得新的组织信息。
+// 吊销失败时原访问令牌到期前仍使用原组织
+func (uc *OrganizationUsecase) refreshTokens(ctx context.Context, userID uint64) {
+	if err := uc.tokens.RevokeAccessTokens(ctx, userID); err != nil {
+		uc.log.WithContext(ctx).Errorf("组织成员变更后吊销访问令牌失败: user_id=%d, %v", userID, err)
+	}
+}
+
+func validOrgRole(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin || role == OrgRoleMember
+}
+
+func validateOrgName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxOrgNameLength {
+		return "", ErrInvalidOrgName
+	}
+	return name, nil
+}
+
+// normalizeFileTypes 统一为不含点的小写扩展名并去重
+func normalizeFileTypes(types []string) ([]string, error) {
+	if len(types) > maxAllowedFileTypes {
+		return nil, ErrInvalidOrgSettings
+	}
+	seen := make(map[string]bool, len(types))
+	normalized := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "."))
+		if !fileTypePattern.MatchString(t) {
+			return nil, ErrInvalidOrgSettings
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
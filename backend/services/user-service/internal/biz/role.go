@@ -0,0 +1,163 @@
+package biz
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	// defaultRoleChangesPerPage 角色变更记录每页默认条数
+	defaultRoleChangesPerPage = 20
+	// maxRoleChangesPerPage 角色变更记录每页最大条数
+	maxRoleChangesPerPage = 100
+	// maxRoleReasonLength 变更原因的最大长度（字符），与 role_changes.reason 列一致
+	maxRoleReasonLength = 255
+	// bootstrapAdminReason 按 auth.admin_emails 设为管理员时记录的原因
+	bootstrapAdminReason = "配置的初始管理员（auth.admin_emails）"
+)
+
+var (
+	ErrInvalidRole         = errors.BadRequest("INVALID_ROLE", "角色不存在")
+	ErrCannotChangeOwnRole = errors.BadRequest("CANNOT_CHANGE_OWN_ROLE", "不能修改自己的角色")
+	ErrRoleChanged         = errors.Conflict("ROLE_CHANGED", "用户角色已被修改，请刷新后重试")
+	ErrRoleReasonTooLong   = errors.BadRequest("ROLE_REASON_TOO_LONG", "变更原因不能超过255个字符")
+)
+
+// RoleChange 角色变更的审计记录。ChangedBy 为执行变更的管理员，0 表示按配置自动变更
+type RoleChange struct {
+	ID        uint64
+	UserID    uint64
+	OldRole   string
+	NewRole   string
+	ChangedBy uint64
+	Reason    string
+	CreatedAt time.Time
+}
+
+// RoleRepo 用户角色和角色变更记录的存储接口
+type RoleRepo interface {
+	// ChangeRole 在同一事务中更新用户角色并写入变更记录，用户当前角色不是 OldRole 时返回 ErrRoleChanged
+	ChangeRole(ctx context.Context, change *RoleChange) error
+	// ListRoleChanges 按时间倒序分页查询用户的角色变更记录
+	ListRoleChanges(ctx context.Context, userID uint64, offset, limit int) ([]*RoleChange, int64, error)
+}
+
+// RoleUsecase 用户角色管理，每次变更都记录审计日志
+type RoleUsecase struct {
+	users  UserRepo
+	repo   RoleRepo
+	tokens *TokenUsecase
+	auth   *conf.Auth
+	log    *log.Helper
+}
+
+// NewRoleUsecase 创建角色业务逻辑实例
+func NewRoleUsecase(users UserRepo, repo RoleRepo, tokens *TokenUsecase, auth *conf.Auth, logger log.Logger) *RoleUsecase {
+	return &RoleUsecase{
+		users:  users,
+		repo:   repo,
+		tokens: tokens,
+		auth:   auth,
+		log:    log.NewHelper(logger),
+	}
+}
+
+// SetRole 由管理员设置用户的角色。角色写在访问令牌中，变更后吊销该用户现有的访问令牌，
+// 客户端用刷新令牌换取按新角色签发的访问令牌
+func (uc *RoleUsecase) SetRole(ctx context.Context, userID uint64, role, reason string) (*User, error) {
+	operator, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	// 避免管理员误操作后失去管理权限
+	if uint64(operator) == userID {
+		return nil, ErrCannotChangeOwnRole
+	}
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxRoleReasonLength {
+		return nil, ErrRoleReasonTooLong
+	}
+
+	user, err := uc.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	if err := uc.change(ctx, user, role, uint64(operator), reason); err != nil {
+		return nil, err
+	}
+
+	if err := uc.tokens.RevokeAccessTokens(ctx, userID); err != nil {
+		// 角色已变更，吊销失败时原访问令牌到期前仍使用原角色
+		uc.log.WithContext(ctx).Errorf("角色变更后吊销访问令牌失败: user_id=%d, %v", userID, err)
+	}
+	return user, nil
+}
+
+// ListRoleChanges 分页查询用户的角色变更记录，返回记录和总数
+func (uc *RoleUsecase) ListRoleChanges(ctx context.Context, userID uint64, page, perPage int) ([]*RoleChange, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultRoleChangesPerPage
+	}
+	if perPage > maxRoleChangesPerPage {
+		perPage = maxRoleChangesPerPage
+	}
+	if _, err := uc.users.GetUserByID(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	return uc.repo.ListRoleChanges(ctx, userID, (page-1)*perPage, perPage)
+}
+
+// bootstrapAdmin 邮箱在 auth.admin_emails 中的用户设为管理员，用于部署后产生第一个管理员
+func (uc *RoleUsecase) bootstrapAdmin(ctx context.Context, user *User) error {
+	if user.Role == auth.RoleAdmin || !uc.isBootstrapAdmin(user.Email) {
+		return nil
+	}
+	if err := uc.change(ctx, user, auth.RoleAdmin, 0, bootstrapAdminReason); err != nil {
+		return err
+	}
+	uc.log.WithContext(ctx).Infof("按配置设为管理员: user_id=%d", user.ID)
+	return nil
+}
+
+func (uc *RoleUsecase) isBootstrapAdmin(email string) bool {
+	for _, e := range uc.auth.GetAdminEmails() {
+		if strings.EqualFold(strings.TrimSpace(e), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// change 更新角色并写入审计记录
+func (uc *RoleUsecase) change(ctx context.Context, user *User, role string, operator uint64, reason string) error {
+	change := &RoleChange{
+		UserID:    user.ID,
+		OldRole:   user.Role,
+		NewRole:   role,
+		ChangedBy: operator,
+		Reason:    reason,
+	}
+	if err := uc.repo.ChangeRole(ctx, change); err != nil {
+		return err
+	}
+	uc.log.WithContext(ctx).Infof("用户角色变更: user_id=%d, %s -> %s, operator=%d", user.ID, change.OldRole, role, operator)
+	user.Role = role
+	return nil
+}
@@ -0,0 +1,302 @@
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+const (
+	// defaultAccessExpire 未配置 jwt_expire 时访问令牌的有效期
+	defaultAccessExpire = 15 * time.Minute
+	// defaultRefreshExpire 未配置 refresh_expire 时刷新令牌的有效期
+	defaultRefreshExpire = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.Unauthorized("INVALID_TOKEN", "刷新令牌无效或已过期")
+	ErrRefreshTokenReused  = errors.Unauthorized("REFRESH_TOKEN_REUSED", "刷新令牌已被使用，该会话已失效，请重新登录")
+)
+
+// RefreshToken 服务端保存的刷新令牌。同一次登录轮换出的刷新令牌属于同一个会话（SessionID），
+// 每个刷新令牌记录与其一同签发的访问令牌，吊销会话时据此吊销访问令牌
+type RefreshToken struct {
+	ID              uint64
+	TokenHash       string
+	UserID          uint64
+	SessionID       string
+	AccessJTI       string
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	UsedAt          *time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// TokenPair 一次签发的访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// TokenRepo 刷新令牌和访问令牌吊销列表的存储接口
+type TokenRepo interface {
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	// GetRefreshToken 按令牌哈希查询，不存在时返回 ErrInvalidRefreshToken
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// MarkRefreshTokenUsed 将未使用且未吊销的刷新令牌标记为已使用，返回是否标记成功
+	MarkRefreshTokenUsed(ctx context.Context, id uint64) (bool, error)
+	// RevokeSession 吊销会话中的所有刷新令牌，返回访问令牌尚未过期的记录
+	RevokeSession(ctx context.Context, sessionID string) ([]*RefreshToken, error)
+	// RevokeUserSessions 吊销用户所有会话的刷新令牌，返回访问令牌尚未过期的记录
+	RevokeUserSessions(ctx context.Context, userID uint64) ([]*RefreshToken, error)
+	// ListAccessTokens 返回用户访问令牌尚未过期的记录，不吊销刷新令牌
+	ListAccessTokens(ctx context.Context, userID uint64) ([]*RefreshToken, error)
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// TokenUsecase 访问令牌和刷新令牌的签发、轮换与吊销
+type TokenUsecase struct {
+	repo  TokenRepo
+	users UserRepo
+	orgs  OrganizationRepo
+	auth  *conf.Auth
+	log   *log.Helper
+}
+
+// NewTokenUsecase 创建令牌业务逻辑实例
+func NewTokenUsecase(repo TokenRepo, users UserRepo, orgs OrganizationRepo, auth *conf.Auth, logger log.Logger) *TokenUsecase {
+	return &TokenUsecase{
+		repo:  repo,
+		users: users,
+		orgs:  orgs,
+		auth:  auth,
+		log:   log.NewHelper(logger),
+	}
+}
+
+// Issue 为新的登录会话签发访问令牌和刷新令牌
+func (uc *TokenUsecase) Issue(ctx context.Context, user *User) (*TokenPair, error) {
+	sessionID, err := auth.NewID()
+	if err != nil {
+		return nil, err
+	}
+	return uc.issue(ctx, user, sessionID)
+}
+
+// Refresh 轮换刷新令牌：旧令牌标记为已使用，在同一会话中签发新的访问令牌和刷新令牌。
+// 已使用的令牌再次出现说明令牌可能被盗用，吊销整个会话。新的访问令牌使用用户当前的角色和组织
+func (uc *TokenUsecase) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	token, err := uc.repo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if token.UsedAt != nil {
+		return nil, uc.revokeReusedSession(ctx, token)
+	}
+
+	ok, err := uc.repo.MarkRefreshTokenUsed(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 并发请求已使用或吊销了该令牌
+		return nil, uc.revokeReusedSession(ctx, token)
+	}
+
+	user, err := uc.users.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if err == ErrUserNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return uc.issue(ctx, user, token.SessionID)
+}
+
+// Logout 登出当前会话，吊销当前访问令牌和会话中的刷新令牌
+func (uc *TokenUsecase) Logout(ctx context.Context) error {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return auth.ErrUnauthenticated
+	}
+	if claims.SessionID != "" {
+		tokens, err := uc.repo.RevokeSession(ctx, claims.SessionID)
+		if err != nil {
+			return err
+		}
+		if err := uc.revokeAccessTokens(ctx, tokens); err != nil {
+			return err
+		}
+	}
+	return uc.repo.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// LogoutAll 登出所有设备，吊销当前用户所有会话的令牌，返回被吊销的会话数
+func (uc *TokenUsecase) LogoutAll(ctx context.Context) (int, error) {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return 0, auth.ErrUnauthenticated
+	}
+	sessions, err := uc.RevokeUser(ctx, uint64(claims.UserID))
+	if err != nil {
+		return 0, err
+	}
+	if err := uc.repo.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return 0, err
+	}
+	return sessions, nil
+}
+
+// RevokeUser 吊销用户所有会话的访问令牌和刷新令牌，返回被吊销的会话数
+func (uc *TokenUsecase) RevokeUser(ctx context.Context, userID uint64) (int, error) {
+	tokens, err := uc.repo.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := uc.revokeAccessTokens(ctx, tokens); err != nil {
+		return 0, err
+	}
+
+	sessions := make(map[string]struct{})
+	for _, token := range tokens {
+		sessions[token.SessionID] = struct{}{}
+	}
+	return len(sessions), nil
+}
+
+// RevokeAccessTokens 吊销用户所有未过期的访问令牌但保留刷新令牌，
+// 客户端刷新后取得按当前角色和组织签发的访问令牌
+func (uc *TokenUsecase) RevokeAccessTokens(ctx context.Context, userID uint64) error {
+	tokens, err := uc.repo.ListAccessTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return uc.revokeAccessTokens(ctx, tokens)
+}
+
+// Verify 校验访问令牌的签名、有效期和吊销状态
+func (uc *TokenUsecase) Verify(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := auth.ParseToken(uc.auth.GetJwtSecret(), token)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := uc.repo.IsAccessTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, auth.ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// issue 在会话中签发一对令牌，访问令牌中写入用户所在的组织和组织角色
+func (uc *TokenUsecase) issue(ctx context.Context, user *User, sessionID string) (*TokenPair, error) {
+	claims := &auth.Claims{UserID: int64(user.ID), SessionID: sessionID, Role: user.Role}
+	member, err := uc.orgs.GetMembership(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil {
+		claims.OrgID = int64(member.OrgID)
+		claims.OrgRole = member.Role
+	}
+	accessToken, err := auth.Sign(uc.auth.GetJwtSecret(), claims, uc.accessExpire())
+	if err != nil {
+		return nil, fmt.Errorf("生成token失败: %w", err)
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	record := &RefreshToken{
+		TokenHash:       hashToken(refreshToken),
+		UserID:          user.ID,
+		SessionID:       sessionID,
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       time.Now().Add(uc.refreshExpire()),
+	}
+	if err := uc.repo.CreateRefreshToken(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  record.AccessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// revokeReusedSession 吊销重复使用的刷新令牌所在的会话
+func (uc *TokenUsecase) revokeReusedSession(ctx context.Context, token *RefreshToken) error {
+	uc.log.WithContext(ctx).Warnf("刷新令牌被重复使用，吊销会话: user_id=%d, session_id=%s", token.UserID, token.SessionID)
+	tokens, err := uc.repo.RevokeSession(ctx, token.SessionID)
+	if err != nil {
+		return err
+	}
+	if err := uc.revokeAccessTokens(ctx, tokens); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// revokeAccessTokens 吊销与刷新令牌一同签发的访问令牌
+func (uc *TokenUsecase) revokeAccessTokens(ctx context.Context, tokens []*RefreshToken) error {
+	for _, token := range tokens {
+		if err := uc.repo.RevokeAccessToken(ctx, token.AccessJTI, token.AccessExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *TokenUsecase) accessExpire() time.Duration {
+	if d := uc.auth.GetJwtExpire().AsDuration(); d > 0 {
+		return d
+	}
+	return defaultAccessExpire
+}
+
+func (uc *TokenUsecase) refreshExpire() time.Duration {
+	if d := uc.auth.GetRefreshExpire().AsDuration(); d > 0 {
+		return d
+	}
+	return defaultRefreshExpire
+}
+
+// newOpaqueToken 生成不透明的随机令牌（刷新令牌、邮件链接中的令牌），服务端只保存其哈希
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
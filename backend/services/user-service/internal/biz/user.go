@@ -3,15 +3,16 @@ package biz
 import (
 	"context"
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 
 	v1 "github.com/lyb88999/resume_helper/api/user/v1"
-	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 )
 
 // User 业务层用户模型
@@ -23,6 +24,9 @@ type User struct {
 	Avatar    string    `json:"avatar"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Role            string     `json:"role"`
 }
 
 // UserRepo 用户仓库接口
@@ -32,6 +36,9 @@ type UserRepo interface {
 	GetUserByID(ctx context.Context, id uint64) (*User, error)
 	UpdateUser(ctx context.Context, user *User) (*User, error)
 	CheckEmailExists(ctx context.Context, email string) (bool, error)
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	// MarkEmailVerified 用户的邮箱仍为 email 时标记为已验证，返回邮箱是否匹配
+	MarkEmailVerified(ctx context.Context, id uint64, email string) (bool, error)
 }
 
 // 错误定义
@@ -41,21 +48,26 @@ var (
 	ErrInvalidPassword  = errors.Unauthorized("INVALID_PASSWORD", "密码错误")
 	ErrInvalidEmail     = errors.BadRequest("INVALID_EMAIL", "邮箱格式错误")
 	ErrPasswordTooShort = errors.BadRequest("PASSWORD_TOO_SHORT", "密码长度不能少于6位")
+	ErrForbidden        = errors.Forbidden("FORBIDDEN", "无权访问其他用户的信息")
 )
 
 // UserUsecase 用户业务逻辑
 type UserUsecase struct {
-	repo UserRepo
-	auth *conf.Auth
-	log  *log.Helper
+	repo     UserRepo
+	tokens   *TokenUsecase
+	accounts *AccountUsecase
+	roles    *RoleUsecase
+	log      *log.Helper
 }
 
 // NewUserUsecase 创建用户业务逻辑实例
-func NewUserUsecase(repo UserRepo, auth *conf.Auth, logger log.Logger) *UserUsecase {
+func NewUserUsecase(repo UserRepo, tokens *TokenUsecase, accounts *AccountUsecase, roles *RoleUsecase, logger log.Logger) *UserUsecase {
 	return &UserUsecase{
-		repo: repo,
-		auth: auth,
-		log:  log.NewHelper(logger),
+		repo:     repo,
+		tokens:   tokens,
+		accounts: accounts,
+		roles:    roles,
+		log:      log.NewHelper(logger),
 	}
 }
 
@@ -86,6 +98,7 @@ func (uc *UserUsecase) Register(ctx context.Context, req *v1.RegisterRequest) (*
 		Email:    req.Email,
 		Password: string(hashedPassword),
 		Nickname: req.Nickname,
+		Role:     auth.RoleCandidate,
 	}
 
 	createdUser, err := uc.repo.CreateUser(ctx, user)
@@ -93,6 +106,11 @@ func (uc *UserUsecase) Register(ctx context.Context, req *v1.RegisterRequest) (*
 		return nil, err
 	}
 
+	// 验证邮件发送失败不影响注册，用户可以稍后重新发送
+	if err := uc.accounts.sendVerification(ctx, createdUser); err != nil {
+		uc.log.WithContext(ctx).Warnf("发送验证邮件失败: user_id=%d, %v", createdUser.ID, err)
+	}
+
 	return &v1.RegisterReply{
 		Id:        createdUser.ID,
 		Email:     createdUser.Email,
@@ -117,28 +135,40 @@ func (uc *UserUsecase) Login(ctx context.Context, req *v1.LoginRequest) (*v1.Log
 		return nil, ErrInvalidPassword
 	}
 
-	// 生成JWT token
-	token, expiresAt, err := uc.generateToken(user.ID)
+	// 配置的初始管理员在登录时获得管理员角色
+	if err := uc.roles.bootstrapAdmin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// 开始新的登录会话，签发访问令牌和刷新令牌
+	tokens, err := uc.tokens.Issue(ctx, user)
 	if err != nil {
 		return nil, err
 	}
 
 	return &v1.LoginReply{
-		Token:     token,
-		ExpiresAt: uint64(expiresAt),
+		Token:            tokens.AccessToken,
+		ExpiresAt:        uint64(tokens.AccessExpiresAt.Unix()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: uint64(tokens.RefreshExpiresAt.Unix()),
 		User: &v1.UserInfo{
-			Id:        user.ID,
-			Email:     user.Email,
-			Nickname:  user.Nickname,
-			Avatar:    user.Avatar,
-			CreatedAt: user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+			Id:            user.ID,
+			Email:         user.Email,
+			Nickname:      user.Nickname,
+			Avatar:        user.Avatar,
+			CreatedAt:     user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:     user.UpdatedAt.Format(time.RFC3339),
+			EmailVerified: user.EmailVerifiedAt != nil,
+			Role:          user.Role,
 		},
 	}, nil
 }
 
-// GetUserInfo 获取用户信息
+// GetUserInfo 获取用户信息，只能获取当前登录用户自己的信息
 func (uc *UserUsecase) GetUserInfo(ctx context.Context, userID uint64) (*v1.GetUserInfoReply, error) {
+	if err := uc.checkOwner(ctx, userID); err != nil {
+		return nil, err
+	}
 	user, err := uc.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -146,18 +176,23 @@ func (uc *UserUsecase) GetUserInfo(ctx context.Context, userID uint64) (*v1.GetU
 
 	return &v1.GetUserInfoReply{
 		User: &v1.UserInfo{
-			Id:        user.ID,
-			Email:     user.Email,
-			Nickname:  user.Nickname,
-			Avatar:    user.Avatar,
-			CreatedAt: user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+			Id:            user.ID,
+			Email:         user.Email,
+			Nickname:      user.Nickname,
+			Avatar:        user.Avatar,
+			CreatedAt:     user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:     user.UpdatedAt.Format(time.RFC3339),
+			EmailVerified: user.EmailVerifiedAt != nil,
+			Role:          user.Role,
 		},
 	}, nil
 }
 
-// UpdateUserInfo 更新用户信息
+// UpdateUserInfo 更新用户信息，只能更新当前登录用户自己的信息
 func (uc *UserUsecase) UpdateUserInfo(ctx context.Context, userID uint64, req *v1.UpdateUserInfoRequest) error {
+	if err := uc.checkOwner(ctx, userID); err != nil {
+		return err
+	}
 	user := &User{
 		ID:       userID,
 		Nickname: req.Nickname,
@@ -168,29 +203,22 @@ func (uc *UserUsecase) UpdateUserInfo(ctx context.Context, userID uint64, req *v
 	return err
 }
 
-// generateToken 生成JWT token
-func (uc *UserUsecase) generateToken(userID uint64) (string, int64, error) {
-	expiresAt := time.Now().Add(uc.auth.JwtExpire.AsDuration()).Unix()
-
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     expiresAt,
-		"iat":     time.Now().Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(uc.auth.JwtSecret))
+// checkOwner 请求的用户ID必须是访问令牌中的用户
+func (uc *UserUsecase) checkOwner(ctx context.Context, userID uint64) error {
+	current, err := auth.UserID(ctx)
 	if err != nil {
-		return "", 0, fmt.Errorf("生成token失败: %w", err)
+		return err
 	}
-
-	return tokenString, expiresAt, nil
+	if uint64(current) != userID {
+		return ErrForbidden
+	}
+	return nil
 }
 
 // validateRegisterRequest 验证注册请求
 func (uc *UserUsecase) validateRegisterRequest(req *v1.RegisterRequest) error {
-	if req.Email == "" {
-		return ErrInvalidEmail
+	if err := validateEmail(req.Email); err != nil {
+		return err
 	}
 	if len(req.Password) < 6 {
 		return ErrPasswordTooShort
@@ -200,3 +228,23 @@ func (uc *UserUsecase) validateRegisterRequest(req *v1.RegisterRequest) error {
 	}
 	return nil
 }
+
+// maxEmailLength 与 users.email 列的长度一致
+const maxEmailLength = 100
+
+// validateEmail 校验邮箱格式：必须是不带显示名的单个地址（local@domain），域名至少包含一个点
+func validateEmail(email string) error {
+	if email == "" || len(email) > maxEmailLength {
+		return ErrInvalidEmail
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return ErrInvalidEmail
+	}
+	return nil
+}
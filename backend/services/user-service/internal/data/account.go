@@ -0,0 +1,114 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+)
+
+// AccountTokenModel 邮箱验证和重置密码令牌表，只保存令牌的 SHA-256 哈希
+type AccountTokenModel struct {
+	ID        uint64    `gorm:"primarykey"`
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	UserID    uint64    `gorm:"index:idx_account_tokens_user_purpose;not null"`
+	Purpose   string    `gorm:"index:idx_account_tokens_user_purpose;size:20;not null"`
+	Email     string    `gorm:"size:100;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// TableName 表名
+func (AccountTokenModel) TableName() string {
+	return "account_tokens"
+}
+
+type accountTokenRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewAccountTokenRepo creates a new account token repository.
+func NewAccountTokenRepo(data *Data, logger log.Logger) biz.AccountTokenRepo {
+	return &accountTokenRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *accountTokenRepo) CreateAccountToken(ctx context.Context, token *biz.AccountToken) error {
+	m := &AccountTokenModel{
+		TokenHash: token.TokenHash,
+		UserID:    token.UserID,
+		Purpose:   token.Purpose,
+		Email:     token.Email,
+		ExpiresAt: token.ExpiresAt,
+	}
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 之前发送的链接失效，只有最新的邮件有效
+		if err := tx.Model(&AccountTokenModel{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(m).Error
+	})
+	if err != nil {
+		return fmt.Errorf("保存账号令牌失败: %w", err)
+	}
+	token.ID = m.ID
+	token.CreatedAt = m.CreatedAt
+	return nil
+}
+
+func (r *accountTokenRepo) GetAccountToken(ctx context.Context, tokenHash string) (*biz.AccountToken, error) {
+	m := &AccountTokenModel{}
+	if err := r.data.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, biz.ErrInvalidAccountToken
+		}
+		return nil, fmt.Errorf("查询账号令牌失败: %w", err)
+	}
+	return toBizAccountToken(m), nil
+}
+
+func (r *accountTokenRepo) GetLatestAccountToken(ctx context.Context, userID uint64, purpose string) (*biz.AccountToken, error) {
+	m := &AccountTokenModel{}
+	err := r.data.db.WithContext(ctx).Where("user_id = ? AND purpose = ?", userID, purpose).
+		Order("id DESC").First(m).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询账号令牌失败: %w", err)
+	}
+	return toBizAccountToken(m), nil
+}
+
+func (r *accountTokenRepo) UseAccountToken(ctx context.Context, id uint64) (bool, error) {
+	result := r.data.db.WithContext(ctx).Model(&AccountTokenModel{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("更新账号令牌失败: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func toBizAccountToken(m *AccountTokenModel) *biz.AccountToken {
+	return &biz.AccountToken{
+		ID:        m.ID,
+		TokenHash: m.TokenHash,
+		UserID:    m.UserID,
+		Purpose:   m.Purpose,
+		Email:     m.Email,
+		ExpiresAt: m.ExpiresAt,
+		UsedAt:    m.UsedAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
@@ -15,7 +15,7 @@ import (
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(NewData, NewUserRepo)
+var ProviderSet = wire.NewSet(NewData, NewUserRepo, NewTokenRepo, NewRevocations, NewAccountTokenRepo, NewMailer, NewRoleRepo, NewOrganizationRepo)
 
 // Data represents the data layer.
 type Data struct {
@@ -35,7 +35,7 @@ func NewData(c *conf.Data, logger log.Logger) (*Data, func(), error) {
 	}
 
 	// 自动迁移数据库表
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &RefreshTokenModel{}, &AccountTokenModel{}, &RoleChangeModel{}, &OrganizationModel{}, &MemberModel{}, &InvitationModel{}); err != nil {
 		return nil, nil, err
 	}
 
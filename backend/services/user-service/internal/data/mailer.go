@@ -0,0 +1,131 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+// defaultMailFrom 未配置 mail.from 时的发件人
+const defaultMailFrom = "no-reply@localhost"
+
+// NewMailer 按 auth.mail.type 创建邮件发送器，未配置时只记录日志
+func NewMailer(c *conf.Auth, logger log.Logger) (biz.Mailer, error) {
+	mc := c.GetMail()
+	from := mc.GetFrom()
+	if from == "" {
+		from = defaultMailFrom
+	}
+	helper := log.NewHelper(logger)
+
+	switch mc.GetType() {
+	case "", "log":
+		return &logMailer{log: helper}, nil
+	case "file":
+		dir := mc.GetDropDir()
+		if dir == "" {
+			return nil, fmt.Errorf("mail.drop_dir 未配置")
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建邮件目录失败: %w", err)
+		}
+		return &fileMailer{dir: dir, from: from, log: helper}, nil
+	case "smtp":
+		sc := mc.GetSmtp()
+		if sc.GetAddr() == "" {
+			return nil, fmt.Errorf("mail.smtp.addr 未配置")
+		}
+		host, _, err := net.SplitHostPort(sc.GetAddr())
+		if err != nil {
+			return nil, fmt.Errorf("mail.smtp.addr 格式错误: %w", err)
+		}
+		var a smtp.Auth
+		if sc.GetUsername() != "" {
+			a = smtp.PlainAuth("", sc.GetUsername(), sc.GetPassword(), host)
+		}
+		return &smtpMailer{addr: sc.GetAddr(), auth: a, from: from}, nil
+	default:
+		return nil, fmt.Errorf("不支持的邮件发送类型: %s", mc.GetType())
+	}
+}
+
+// logMailer 只把邮件内容写入日志，用于开发环境
+type logMailer struct {
+	log *log.Helper
+}
+
+func (m *logMailer) Send(ctx context.Context, mail *biz.Mail) error {
+	m.log.WithContext(ctx).Infof("发送邮件: to=%s, subject=%s\n%s", mail.To, mail.Subject, mail.Body)
+	return nil
+}
+
+// fileMailer 把邮件写成 .eml 文件，用于测试环境检查邮件内容
+type fileMailer struct {
+	dir  string
+	from string
+	log  *log.Helper
+}
+
+func (m *fileMailer) Send(ctx context.Context, mail *biz.Mail) error {
+	msg, err := buildMessage(m.from, mail)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	if err := os.WriteFile(filepath.Join(m.dir, name), msg, 0o644); err != nil {
+		return fmt.Errorf("写入邮件文件失败: %w", err)
+	}
+	m.log.WithContext(ctx).Infof("邮件已写入: %s, to=%s", name, mail.To)
+	return nil
+}
+
+// smtpMailer 通过 SMTP 服务器发送邮件，服务器支持时 net/smtp 自动使用 STARTTLS
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail *biz.Mail) error {
+	msg, err := buildMessage(m.from, mail)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{mail.To}, msg); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// buildMessage 生成 UTF-8 纯文本邮件，主题使用 RFC 2047 编码，正文使用 quoted-printable 编码
+func buildMessage(from string, mail *biz.Mail) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", mail.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write(bytes.ReplaceAll([]byte(mail.Body), []byte("\n"), []byte("\r\n"))); err != nil {
+		return nil, fmt.Errorf("编码邮件正文失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("编码邮件正文失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
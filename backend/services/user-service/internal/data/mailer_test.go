@@ -0,0 +1,87 @@
+package data
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+)
+
+func TestNewMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mails")
+	tests := []struct {
+		name    string
+		config  *conf.MailConfig
+		wantErr bool
+	}{
+		{name: "default is log", config: nil},
+		{name: "log", config: &conf.MailConfig{Type: "log"}},
+		{name: "file", config: &conf.MailConfig{Type: "file", DropDir: dir}},
+		{name: "file without dir", config: &conf.MailConfig{Type: "file"}, wantErr: true},
+		{name: "smtp", config: &conf.MailConfig{Type: "smtp", Smtp: &conf.MailConfig_SMTP{Addr: "smtp.example.com:587", Username: "u"}}},
+		{name: "smtp without addr", config: &conf.MailConfig{Type: "smtp"}, wantErr: true},
+		{name: "smtp without port", config: &conf.MailConfig{Type: "smtp", Smtp: &conf.MailConfig_SMTP{Addr: "smtp.example.com"}}, wantErr: true},
+		{name: "unknown", config: &conf.MailConfig{Type: "sendmail"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMailer(&conf.Auth{Mail: tt.config}, log.NewStdLogger(io.Discard))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewMailer error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewMailer(&conf.Auth{Mail: &conf.MailConfig{Type: "file", DropDir: dir, From: "hr@example.com"}}, log.NewStdLogger(io.Discard))
+	if err != nil {
+		t.Fatalf("NewMailer: %v", err)
+	}
+
+	link := "https://resume.example.com/reset-password?token=" + strings.Repeat("Ab0-_", 12)
+	body := "张三，你好：\n\n请打开以下链接设置新密码：\n\n" + link + "\n"
+	if err := mailer.Send(context.Background(), &biz.Mail{To: "zhangsan@example.com", Subject: "重置你的密码", Body: body}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("wrote %d mail files, want 1", len(files))
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatalf("mail file is not a valid message: %v", err)
+	}
+	if from, to := msg.Header.Get("From"), msg.Header.Get("To"); from != "hr@example.com" || to != "zhangsan@example.com" {
+		t.Errorf("From = %q, To = %q", from, to)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "重置你的密码" {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got := strings.ReplaceAll(string(decoded), "\r\n", "\n"); got != body {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+}
@@ -0,0 +1,357 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+)
+
+// OrganizationModel 组织表
+type OrganizationModel struct {
+	ID               uint64 `gorm:"primarykey"`
+	Name             string `gorm:"size:100;not null"`
+	AllowedFileTypes string `gorm:"size:255"` // 逗号分隔的扩展名
+	AIMonthlyQuota   int32  `gorm:"not null;default:0"`
+	CreatedBy        uint64 `gorm:"index;not null"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// TableName 表名
+func (OrganizationModel) TableName() string {
+	return "organizations"
+}
+
+// MemberModel 组织成员表，user_id 唯一保证每个用户最多属于一个组织
+type MemberModel struct {
+	ID        uint64 `gorm:"primarykey"`
+	OrgID     uint64 `gorm:"index;not null"`
+	UserID    uint64 `gorm:"uniqueIndex;not null"`
+	Role      string `gorm:"size:20;not null"`
+	CreatedAt time.Time
+}
+
+// TableName 表名
+func (MemberModel) TableName() string {
+	return "org_members"
+}
+
+// InvitationModel 组织邀请表，只保存令牌的 SHA-256 哈希
+type InvitationModel struct {
+	ID         uint64    `gorm:"primarykey"`
+	OrgID      uint64    `gorm:"index:idx_org_invitations_org_email;not null"`
+	Email      string    `gorm:"index:idx_org_invitations_org_email;size:100;not null"`
+	Role       string    `gorm:"size:20;not null"`
+	TokenHash  string    `gorm:"uniqueIndex;size:64;not null"`
+	InvitedBy  uint64    `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// TableName 表名
+func (InvitationModel) TableName() string {
+	return "org_invitations"
+}
+
+// memberRow 成员及其用户信息
+type memberRow struct {
+	OrgID     uint64
+	UserID    uint64
+	Role      string
+	Email     string
+	Nickname  string
+	CreatedAt time.Time
+}
+
+type organizationRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewOrganizationRepo creates a new organization repository.
+func NewOrganizationRepo(data *Data, logger log.Logger) biz.OrganizationRepo {
+	return &organizationRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *organizationRepo) CreateOrganization(ctx context.Context, org *biz.Organization, owner uint64) error {
+	m := &OrganizationModel{
+		Name:             org.Name,
+		AllowedFileTypes: strings.Join(org.AllowedFileTypes, ","),
+		AIMonthlyQuota:   org.AIMonthlyQuota,
+		CreatedBy:        org.CreatedBy,
+	}
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return addMember(tx, &biz.Member{OrgID: m.ID, UserID: owner, Role: biz.OrgRoleOwner})
+	})
+	if err != nil {
+		if err == biz.ErrAlreadyInOrganization {
+			return err
+		}
+		return fmt.Errorf("创建组织失败: %w", err)
+	}
+	org.ID = m.ID
+	org.CreatedAt = m.CreatedAt
+	org.UpdatedAt = m.UpdatedAt
+	return nil
+}
+
+func (r *organizationRepo) GetOrganization(ctx context.Context, id uint64) (*biz.Organization, error) {
+	m := &OrganizationModel{}
+	if err := r.data.db.WithContext(ctx).First(m, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, biz.ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("查询组织失败: %w", err)
+	}
+	return toBizOrganization(m), nil
+}
+
+func (r *organizationRepo) UpdateOrganization(ctx context.Context, org *biz.Organization) error {
+	now := time.Now()
+	err := r.data.db.WithContext(ctx).Model(&OrganizationModel{}).Where("id = ?", org.ID).
+		Updates(map[string]interface{}{
+			"name":               org.Name,
+			"allowed_file_types": strings.Join(org.AllowedFileTypes, ","),
+			"ai_monthly_quota":   org.AIMonthlyQuota,
+			"updated_at":         now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("更新组织失败: %w", err)
+	}
+	org.UpdatedAt = now
+	return nil
+}
+
+func (r *organizationRepo) DeleteOrganization(ctx context.Context, id uint64) error {
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("org_id = ?", id).Delete(&MemberModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("org_id = ?", id).Delete(&InvitationModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&OrganizationModel{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("删除组织失败: %w", err)
+	}
+	return nil
+}
+
+func (r *organizationRepo) GetMembership(ctx context.Context, userID uint64) (*biz.Member, error) {
+	var rows []*memberRow
+	if err := r.members(ctx).Where("m.user_id = ?", userID).Limit(1).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return toBizMember(rows[0]), nil
+}
+
+func (r *organizationRepo) ListMembers(ctx context.Context, orgID uint64) ([]*biz.Member, error) {
+	var rows []*memberRow
+	if err := r.members(ctx).Where("m.org_id = ?", orgID).Order("m.id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	members := make([]*biz.Member, len(rows))
+	for i, row := range rows {
+		members[i] = toBizMember(row)
+	}
+	return members, nil
+}
+
+func (r *organizationRepo) UpdateMemberRole(ctx context.Context, orgID, userID uint64, role string) error {
+	result := r.data.db.WithContext(ctx).Model(&MemberModel{}).
+		Where("org_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return fmt.Errorf("更新成员角色失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrMemberNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepo) RemoveMember(ctx context.Context, orgID, userID uint64) error {
+	result := r.data.db.WithContext(ctx).Where("org_id = ? AND user_id = ?", orgID, userID).Delete(&MemberModel{})
+	if result.Error != nil {
+		return fmt.Errorf("移除组织成员失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrMemberNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepo) CreateInvitation(ctx context.Context, inv *biz.Invitation) error {
+	m := &InvitationModel{
+		OrgID:     inv.OrgID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		TokenHash: inv.TokenHash,
+		InvitedBy: inv.InvitedBy,
+		ExpiresAt: inv.ExpiresAt,
+	}
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 之前发送的邀请失效，只有最新的邮件有效
+		if err := pendingInvitations(tx).
+			Where("org_id = ? AND email = ?", inv.OrgID, inv.Email).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(m).Error
+	})
+	if err != nil {
+		return fmt.Errorf("保存组织邀请失败: %w", err)
+	}
+	inv.ID = m.ID
+	inv.CreatedAt = m.CreatedAt
+	return nil
+}
+
+func (r *organizationRepo) GetInvitation(ctx context.Context, tokenHash string) (*biz.Invitation, error) {
+	m := &InvitationModel{}
+	if err := r.data.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, biz.ErrInvalidInvitation
+		}
+		return nil, fmt.Errorf("查询组织邀请失败: %w", err)
+	}
+	return toBizInvitation(m), nil
+}
+
+func (r *organizationRepo) ListInvitations(ctx context.Context, orgID uint64) ([]*biz.Invitation, error) {
+	var records []*InvitationModel
+	err := pendingInvitations(r.data.db.WithContext(ctx)).
+		Where("org_id = ? AND expires_at > ?", orgID, time.Now()).
+		Order("id DESC").Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询组织邀请失败: %w", err)
+	}
+	invitations := make([]*biz.Invitation, len(records))
+	for i, m := range records {
+		invitations[i] = toBizInvitation(m)
+	}
+	return invitations, nil
+}
+
+func (r *organizationRepo) RevokeInvitation(ctx context.Context, orgID, id uint64) error {
+	result := pendingInvitations(r.data.db.WithContext(ctx)).
+		Where("id = ? AND org_id = ?", id, orgID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("撤销组织邀请失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepo) AcceptInvitation(ctx context.Context, id uint64, member *biz.Member) error {
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 以未处理为条件更新，同一邀请只能接受一次
+		result := pendingInvitations(tx).Where("id = ?", id).Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return biz.ErrInvalidInvitation
+		}
+		return addMember(tx, member)
+	})
+	if err != nil {
+		if err == biz.ErrInvalidInvitation || err == biz.ErrAlreadyInOrganization {
+			return err
+		}
+		return fmt.Errorf("接受组织邀请失败: %w", err)
+	}
+	return nil
+}
+
+// members 成员连接用户表的查询
+func (r *organizationRepo) members(ctx context.Context) *gorm.DB {
+	return r.data.db.WithContext(ctx).Table("org_members AS m").
+		Select("m.org_id, m.user_id, m.role, u.email, u.nickname, m.created_at").
+		Joins("JOIN users AS u ON u.id = m.user_id")
+}
+
+// addMember 在事务中添加成员，用户已属于某个组织时返回 ErrAlreadyInOrganization
+func addMember(tx *gorm.DB, member *biz.Member) error {
+	var count int64
+	if err := tx.Model(&MemberModel{}).Where("user_id = ?", member.UserID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return biz.ErrAlreadyInOrganization
+	}
+	m := &MemberModel{OrgID: member.OrgID, UserID: member.UserID, Role: member.Role}
+	if err := tx.Create(m).Error; err != nil {
+		return err
+	}
+	member.CreatedAt = m.CreatedAt
+	return nil
+}
+
+// pendingInvitations 未接受且未撤销的邀请
+func pendingInvitations(db *gorm.DB) *gorm.DB {
+	return db.Model(&InvitationModel{}).Where("accepted_at IS NULL AND revoked_at IS NULL")
+}
+
+func toBizOrganization(m *OrganizationModel) *biz.Organization {
+	var types []string
+	if m.AllowedFileTypes != "" {
+		types = strings.Split(m.AllowedFileTypes, ",")
+	}
+	return &biz.Organization{
+		ID:               m.ID,
+		Name:             m.Name,
+		AllowedFileTypes: types,
+		AIMonthlyQuota:   m.AIMonthlyQuota,
+		CreatedBy:        m.CreatedBy,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+	}
+}
+
+func toBizMember(row *memberRow) *biz.Member {
+	return &biz.Member{
+		OrgID:     row.OrgID,
+		UserID:    row.UserID,
+		Role:      row.Role,
+		Email:     row.Email,
+		Nickname:  row.Nickname,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func toBizInvitation(m *InvitationModel) *biz.Invitation {
+	return &biz.Invitation{
+		ID:         m.ID,
+		OrgID:      m.OrgID,
+		Email:      m.Email,
+		Role:       m.Role,
+		TokenHash:  m.TokenHash,
+		InvitedBy:  m.InvitedBy,
+		ExpiresAt:  m.ExpiresAt,
+		AcceptedAt: m.AcceptedAt,
+		RevokedAt:  m.RevokedAt,
+		CreatedAt:  m.CreatedAt,
+	}
+}
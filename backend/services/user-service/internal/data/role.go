@@ -0,0 +1,100 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/models"
+)
+
+// RoleChangeModel 角色变更审计表，只追加不修改
+type RoleChangeModel struct {
+	ID        uint64 `gorm:"primarykey"`
+	UserID    uint64 `gorm:"index;not null"`
+	OldRole   string `gorm:"size:20;not null"`
+	NewRole   string `gorm:"size:20;not null"`
+	ChangedBy uint64 `gorm:"index;not null"`
+	Reason    string `gorm:"size:255"`
+	CreatedAt time.Time
+}
+
+// TableName 表名
+func (RoleChangeModel) TableName() string {
+	return "role_changes"
+}
+
+type roleRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewRoleRepo creates a new role repository.
+func NewRoleRepo(data *Data, logger log.Logger) biz.RoleRepo {
+	return &roleRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *roleRepo) ChangeRole(ctx context.Context, change *biz.RoleChange) error {
+	m := &RoleChangeModel{
+		UserID:    change.UserID,
+		OldRole:   change.OldRole,
+		NewRole:   change.NewRole,
+		ChangedBy: change.ChangedBy,
+		Reason:    change.Reason,
+	}
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 以原角色为条件更新，并发的变更只有一个成功
+		result := tx.Model(&models.User{}).Where("id = ? AND role = ?", change.UserID, change.OldRole).
+			Updates(map[string]interface{}{"role": change.NewRole, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return biz.ErrRoleChanged
+		}
+		return tx.Create(m).Error
+	})
+	if err != nil {
+		if err == biz.ErrRoleChanged {
+			return err
+		}
+		return fmt.Errorf("更新用户角色失败: %w", err)
+	}
+	change.ID = m.ID
+	change.CreatedAt = m.CreatedAt
+	return nil
+}
+
+func (r *roleRepo) ListRoleChanges(ctx context.Context, userID uint64, offset, limit int) ([]*biz.RoleChange, int64, error) {
+	query := r.data.db.WithContext(ctx).Model(&RoleChangeModel{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询角色变更记录失败: %w", err)
+	}
+	var records []*RoleChangeModel
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询角色变更记录失败: %w", err)
+	}
+
+	changes := make([]*biz.RoleChange, len(records))
+	for i, m := range records {
+		changes[i] = &biz.RoleChange{
+			ID:        m.ID,
+			UserID:    m.UserID,
+			OldRole:   m.OldRole,
+			NewRole:   m.NewRole,
+			ChangedBy: m.ChangedBy,
+			Reason:    m.Reason,
+			CreatedAt: m.CreatedAt,
+		}
+	}
+	return changes, total, nil
+}
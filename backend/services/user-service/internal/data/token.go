@@ -0,0 +1,158 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"gorm.io/gorm"
+
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
+)
+
+// RefreshTokenModel 刷新令牌表，只保存令牌的 SHA-256 哈希
+type RefreshTokenModel struct {
+	ID              uint64    `gorm:"primarykey"`
+	TokenHash       string    `gorm:"uniqueIndex;size:64;not null"`
+	UserID          uint64    `gorm:"index;not null"`
+	SessionID       string    `gorm:"index;size:32;not null"`
+	AccessJTI       string    `gorm:"column:access_jti;size:32;not null"`
+	AccessExpiresAt time.Time `gorm:"not null"`
+	ExpiresAt       time.Time `gorm:"index;not null"`
+	UsedAt          *time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// TableName 表名
+func (RefreshTokenModel) TableName() string {
+	return "refresh_tokens"
+}
+
+type tokenRepo struct {
+	data        *Data
+	revocations *auth.Revocations
+	log         *log.Helper
+}
+
+// NewTokenRepo creates a new token repository.
+func NewTokenRepo(data *Data, revocations *auth.Revocations, logger log.Logger) biz.TokenRepo {
+	return &tokenRepo{
+		data:        data,
+		revocations: revocations,
+		log:         log.NewHelper(logger),
+	}
+}
+
+// NewRevocations 创建访问令牌吊销列表，与其他服务共用同一个 Redis
+func NewRevocations(data *Data) *auth.Revocations {
+	return auth.NewRevocations(data.rdb)
+}
+
+func (r *tokenRepo) CreateRefreshToken(ctx context.Context, token *biz.RefreshToken) error {
+	m := &RefreshTokenModel{
+		TokenHash:       token.TokenHash,
+		UserID:          token.UserID,
+		SessionID:       token.SessionID,
+		AccessJTI:       token.AccessJTI,
+		AccessExpiresAt: token.AccessExpiresAt,
+		ExpiresAt:       token.ExpiresAt,
+	}
+	if err := r.data.db.WithContext(ctx).Create(m).Error; err != nil {
+		return fmt.Errorf("保存刷新令牌失败: %w", err)
+	}
+	token.ID = m.ID
+	token.CreatedAt = m.CreatedAt
+	return nil
+}
+
+func (r *tokenRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*biz.RefreshToken, error) {
+	m := &RefreshTokenModel{}
+	if err := r.data.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, biz.ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("查询刷新令牌失败: %w", err)
+	}
+	return toBizRefreshToken(m), nil
+}
+
+func (r *tokenRepo) MarkRefreshTokenUsed(ctx context.Context, id uint64) (bool, error) {
+	result := r.data.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("更新刷新令牌失败: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *tokenRepo) RevokeSession(ctx context.Context, sessionID string) ([]*biz.RefreshToken, error) {
+	return r.revoke(ctx, "session_id", sessionID)
+}
+
+func (r *tokenRepo) RevokeUserSessions(ctx context.Context, userID uint64) ([]*biz.RefreshToken, error) {
+	return r.revoke(ctx, "user_id", userID)
+}
+
+// revoke 吊销 column 等于 value 且尚未吊销的刷新令牌，并返回其中访问令牌尚未过期的记录
+func (r *tokenRepo) revoke(ctx context.Context, column string, value interface{}) ([]*biz.RefreshToken, error) {
+	now := time.Now()
+	var models []*RefreshTokenModel
+	err := r.data.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(column+" = ? AND revoked_at IS NULL AND access_expires_at > ?", value, now).
+			Find(&models).Error; err != nil {
+			return err
+		}
+		return tx.Model(&RefreshTokenModel{}).Where(column+" = ? AND revoked_at IS NULL", value).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("吊销刷新令牌失败: %w", err)
+	}
+
+	tokens := make([]*biz.RefreshToken, len(models))
+	for i, m := range models {
+		tokens[i] = toBizRefreshToken(m)
+	}
+	return tokens, nil
+}
+
+func (r *tokenRepo) ListAccessTokens(ctx context.Context, userID uint64) ([]*biz.RefreshToken, error) {
+	var models []*RefreshTokenModel
+	if err := r.data.db.WithContext(ctx).Where("user_id = ? AND access_expires_at > ?", userID, time.Now()).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询访问令牌失败: %w", err)
+	}
+
+	tokens := make([]*biz.RefreshToken, len(models))
+	for i, m := range models {
+		tokens[i] = toBizRefreshToken(m)
+	}
+	return tokens, nil
+}
+
+func (r *tokenRepo) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return r.revocations.Revoke(ctx, jti, expiresAt)
+}
+
+func (r *tokenRepo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return r.revocations.IsRevoked(ctx, jti)
+}
+
+func toBizRefreshToken(m *RefreshTokenModel) *biz.RefreshToken {
+	return &biz.RefreshToken{
+		ID:              m.ID,
+		TokenHash:       m.TokenHash,
+		UserID:          m.UserID,
+		SessionID:       m.SessionID,
+		AccessJTI:       m.AccessJTI,
+		AccessExpiresAt: m.AccessExpiresAt,
+		ExpiresAt:       m.ExpiresAt,
+		UsedAt:          m.UsedAt,
+		RevokedAt:       m.RevokedAt,
+		CreatedAt:       m.CreatedAt,
+	}
+}
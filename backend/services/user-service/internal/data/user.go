@@ -31,6 +31,7 @@ func (r *userRepo) CreateUser(ctx context.Context, user *biz.User) (*biz.User, e
 		Email:    user.Email,
 		Password: user.Password,
 		Nickname: user.Nickname,
+		Role:     user.Role,
 	}
 
 	if err := r.data.db.WithContext(ctx).Create(u).Error; err != nil {
@@ -43,6 +44,7 @@ func (r *userRepo) CreateUser(ctx context.Context, user *biz.User) (*biz.User, e
 		Email:     u.Email,
 		Password:  u.Password,
 		Nickname:  u.Nickname,
+		Role:      u.Role,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}, nil
@@ -58,13 +60,15 @@ func (r *userRepo) GetUserByEmail(ctx context.Context, email string) (*biz.User,
 	}
 
 	return &biz.User{
-		ID:        u.ID,
-		Email:     u.Email,
-		Password:  u.Password,
-		Nickname:  u.Nickname,
-		Avatar:    u.Avatar,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:              u.ID,
+		Email:           u.Email,
+		Password:        u.Password,
+		Nickname:        u.Nickname,
+		Avatar:          u.Avatar,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		Role:            u.Role,
 	}, nil
 }
 
@@ -78,13 +82,15 @@ func (r *userRepo) GetUserByID(ctx context.Context, id uint64) (*biz.User, error
 	}
 
 	return &biz.User{
-		ID:        u.ID,
-		Email:     u.Email,
-		Password:  u.Password,
-		Nickname:  u.Nickname,
-		Avatar:    u.Avatar,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:              u.ID,
+		Email:           u.Email,
+		Password:        u.Password,
+		Nickname:        u.Nickname,
+		Avatar:          u.Avatar,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		Role:            u.Role,
 	}, nil
 }
 
@@ -111,13 +117,15 @@ func (r *userRepo) UpdateUser(ctx context.Context, user *biz.User) (*biz.User, e
 	}
 
 	return &biz.User{
-		ID:        u.ID,
-		Email:     u.Email,
-		Password:  u.Password,
-		Nickname:  u.Nickname,
-		Avatar:    u.Avatar,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:              u.ID,
+		Email:           u.Email,
+		Password:        u.Password,
+		Nickname:        u.Nickname,
+		Avatar:          u.Avatar,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		Role:            u.Role,
 	}, nil
 }
 
@@ -128,3 +136,33 @@ func (r *userRepo) CheckEmailExists(ctx context.Context, email string) (bool, er
 	}
 	return count > 0, nil
 }
+
+func (r *userRepo) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	result := r.data.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"password": password, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("更新密码失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return biz.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) MarkEmailVerified(ctx context.Context, id uint64, email string) (bool, error) {
+	u := &models.User{}
+	if err := r.data.db.WithContext(ctx).Where("id = ? AND email = ?", id, email).First(u).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if u.EmailVerifiedAt != nil {
+		return true, nil
+	}
+
+	if err := r.data.db.WithContext(ctx).Model(u).Update("email_verified_at", time.Now()).Error; err != nil {
+		return false, fmt.Errorf("更新邮箱验证状态失败: %w", err)
+	}
+	return true, nil
+}
@@ -8,15 +8,19 @@ import (
 
 	v1 "github.com/lyb88999/resume_helper/api/user/v1"
 	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/service"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	orgv1 "github.com/lyb88999/resume_helper/backend/shared/proto/organization"
+	userv1 "github.com/lyb88999/resume_helper/backend/shared/proto/user"
 )
 
 // NewGRPCServer new a gRPC server.
-func NewGRPCServer(c *conf.Server, userService *service.UserService, logger log.Logger) *grpc.Server {
+func NewGRPCServer(c *conf.Server, ac *conf.Auth, revocations *auth.Revocations, userService *service.UserService, tokenService *service.TokenService, orgService *service.OrganizationService, orgSettingsService *service.OrganizationSettingsService, logger log.Logger) *grpc.Server {
 	var opts = []grpc.ServerOption{
 		grpc.Middleware(
 			recovery.Recovery(),
 			tracing.Server(),
+			auth.Server(ac.GetJwtSecret(), publicOperations(), adminOperations(), auth.WithRevocations(revocations)),
 		),
 	}
 	if c.Grpc.Network != "" {
@@ -30,5 +34,8 @@ func NewGRPCServer(c *conf.Server, userService *service.UserService, logger log.
 	}
 	srv := grpc.NewServer(opts...)
 	v1.RegisterUserServiceServer(srv, userService)
+	userv1.RegisterUserServiceServer(srv, tokenService)
+	v1.RegisterOrganizationServiceServer(srv, orgService)
+	orgv1.RegisterOrganizationSettingsServiceServer(srv, orgSettingsService)
 	return srv
 }
@@ -12,6 +12,7 @@ import (
 
 	v1 "github.com/lyb88999/resume_helper/api/user/v1"
 	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/service"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
 )
 
@@ -31,12 +32,13 @@ func CORS() middleware.Middleware {
 }
 
 // NewHTTPServer new an HTTP server.
-func NewHTTPServer(c *conf.Server, userService *service.UserService, logger log.Logger) *khttp.Server {
+func NewHTTPServer(c *conf.Server, ac *conf.Auth, revocations *auth.Revocations, userService *service.UserService, orgService *service.OrganizationService, logger log.Logger) *khttp.Server {
 	var opts = []khttp.ServerOption{
 		khttp.Middleware(
 			recovery.Recovery(),
 			tracing.Server(),
 			CORS(),
+			auth.Server(ac.GetJwtSecret(), publicOperations(), adminOperations(), auth.WithRevocations(revocations)),
 		),
 		khttp.Filter(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -67,6 +69,7 @@ func NewHTTPServer(c *conf.Server, userService *service.UserService, logger log.
 	}
 	srv := khttp.NewServer(opts...)
 	v1.RegisterUserServiceHTTPServer(srv, userService)
+	v1.RegisterOrganizationServiceHTTPServer(srv, orgService)
 
 	// 添加健康检查端点
 	srv.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -6,7 +6,10 @@ import (
 	"github.com/google/wire"
 	"github.com/hashicorp/consul/api"
 
+	v1 "github.com/lyb88999/resume_helper/api/user/v1"
+	"github.com/lyb88999/resume_helper/backend/shared/pkg/auth"
 	"github.com/lyb88999/resume_helper/backend/shared/proto/conf"
+	userv1 "github.com/lyb88999/resume_helper/backend/shared/proto/user"
 )
 
 // ProviderSet is server providers.
@@ -31,3 +34,24 @@ func NewRegistrar(c *conf.Bootstrap) registry.Registrar {
 	r := consul.New(consulClient)
 	return r
 }
+
+// publicOperations 注册、登录、刷新令牌以及邮件链接对应的接口无需访问令牌，VerifyToken 由其他服务调用，校验的是请求中的令牌
+func publicOperations() auth.Option {
+	return auth.WithPublic(
+		v1.OperationUserServiceRegister,
+		v1.OperationUserServiceLogin,
+		v1.OperationUserServiceRefreshToken,
+		v1.OperationUserServiceVerifyEmail,
+		v1.OperationUserServiceForgotPassword,
+		v1.OperationUserServiceResetPassword,
+		userv1.UserService_VerifyToken_FullMethodName,
+	)
+}
+
+// adminOperations 管理用户角色需要管理用户的权限
+func adminOperations() auth.Option {
+	return auth.WithPermission(auth.PermManageUsers,
+		v1.OperationUserServiceSetUserRole,
+		v1.OperationUserServiceListRoleChanges,
+	)
+}
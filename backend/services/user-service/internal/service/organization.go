@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	v1 "github.com/lyb88999/resume_helper/api/user/v1"
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+	orgv1 "github.com/lyb88999/resume_helper/backend/shared/proto/organization"
+)
+
+// OrganizationService 组织服务实现
+type OrganizationService struct {
+	v1.UnimplementedOrganizationServiceServer
+
+	orgs *biz.OrganizationUsecase
+	log  *log.Helper
+}
+
+// NewOrganizationService 创建组织服务实例
+func NewOrganizationService(orgs *biz.OrganizationUsecase, logger log.Logger) *OrganizationService {
+	return &OrganizationService{
+		orgs: orgs,
+		log:  log.NewHelper(logger),
+	}
+}
+
+// CreateOrganization 创建组织
+func (s *OrganizationService) CreateOrganization(ctx context.Context, req *v1.CreateOrganizationRequest) (*v1.OrganizationReply, error) {
+	org, err := s.orgs.Create(ctx, req.Name)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("创建组织失败: %v", err)
+		return nil, err
+	}
+	return &v1.OrganizationReply{Organization: toOrganizationInfo(org), MyRole: biz.OrgRoleOwner}, nil
+}
+
+// GetCurrentOrganization 查询当前用户所在的组织
+func (s *OrganizationService) GetCurrentOrganization(ctx context.Context, req *v1.GetCurrentOrganizationRequest) (*v1.OrganizationReply, error) {
+	org, member, err := s.orgs.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.OrganizationReply{Organization: toOrganizationInfo(org), MyRole: member.Role}, nil
+}
+
+// UpdateOrganization 更新组织名称和设置
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, req *v1.UpdateOrganizationRequest) (*v1.OrganizationReply, error) {
+	org, err := s.orgs.UpdateSettings(ctx, req.Name, req.AllowedFileTypes, req.AiMonthlyQuota)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("更新组织失败: %v", err)
+		return nil, err
+	}
+	_, member, err := s.orgs.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.OrganizationReply{Organization: toOrganizationInfo(org), MyRole: member.Role}, nil
+}
+
+// LeaveOrganization 退出当前组织
+func (s *OrganizationService) LeaveOrganization(ctx context.Context, req *v1.LeaveOrganizationRequest) (*emptypb.Empty, error) {
+	if err := s.orgs.Leave(ctx); err != nil {
+		s.log.WithContext(ctx).Errorf("退出组织失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ListMembers 查询组织成员
+func (s *OrganizationService) ListMembers(ctx context.Context, req *v1.ListMembersRequest) (*v1.ListMembersReply, error) {
+	members, err := s.orgs.ListMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply := &v1.ListMembersReply{Members: make([]*v1.Member, len(members))}
+	for i, m := range members {
+		reply.Members[i] = &v1.Member{
+			UserId:   m.UserID,
+			Email:    m.Email,
+			Nickname: m.Nickname,
+			Role:     m.Role,
+			JoinedAt: m.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return reply, nil
+}
+
+// UpdateMemberRole 修改成员角色
+func (s *OrganizationService) UpdateMemberRole(ctx context.Context, req *v1.UpdateMemberRoleRequest) (*emptypb.Empty, error) {
+	if err := s.orgs.UpdateMemberRole(ctx, req.UserId, req.Role); err != nil {
+		s.log.WithContext(ctx).Errorf("修改成员角色失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// RemoveMember 移除成员
+func (s *OrganizationService) RemoveMember(ctx context.Context, req *v1.RemoveMemberRequest) (*emptypb.Empty, error) {
+	if err := s.orgs.RemoveMember(ctx, req.UserId); err != nil {
+		s.log.WithContext(ctx).Errorf("移除组织成员失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// CreateInvitation 邀请邮箱加入组织
+func (s *OrganizationService) CreateInvitation(ctx context.Context, req *v1.CreateInvitationRequest) (*v1.CreateInvitationReply, error) {
+	inv, err := s.orgs.Invite(ctx, req.Email, req.Role)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("邀请加入组织失败: %v", err)
+		return nil, err
+	}
+	return &v1.CreateInvitationReply{Invitation: toInvitationInfo(inv)}, nil
+}
+
+// ListInvitations 查询未处理的邀请
+func (s *OrganizationService) ListInvitations(ctx context.Context, req *v1.ListInvitationsRequest) (*v1.ListInvitationsReply, error) {
+	invitations, err := s.orgs.ListInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply := &v1.ListInvitationsReply{Invitations: make([]*v1.Invitation, len(invitations))}
+	for i, inv := range invitations {
+		reply.Invitations[i] = toInvitationInfo(inv)
+	}
+	return reply, nil
+}
+
+// RevokeInvitation 撤销邀请
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, req *v1.RevokeInvitationRequest) (*emptypb.Empty, error) {
+	if err := s.orgs.RevokeInvitation(ctx, req.Id); err != nil {
+		s.log.WithContext(ctx).Errorf("撤销组织邀请失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// AcceptInvitation 接受邀请
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, req *v1.AcceptInvitationRequest) (*v1.OrganizationReply, error) {
+	org, err := s.orgs.AcceptInvitation(ctx, req.Token)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("接受组织邀请失败: %v", err)
+		return nil, err
+	}
+	_, member, err := s.orgs.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.OrganizationReply{Organization: toOrganizationInfo(org), MyRole: member.Role}, nil
+}
+
+// OrganizationSettingsService 供其他服务读取组织设置（shared/proto/organization）
+type OrganizationSettingsService struct {
+	orgv1.UnimplementedOrganizationSettingsServiceServer
+
+	orgs *biz.OrganizationUsecase
+	log  *log.Helper
+}
+
+// NewOrganizationSettingsService 创建组织设置服务实例
+func NewOrganizationSettingsService(orgs *biz.OrganizationUsecase, logger log.Logger) *OrganizationSettingsService {
+	return &OrganizationSettingsService{
+		orgs: orgs,
+		log:  log.NewHelper(logger),
+	}
+}
+
+// GetOrganizationSettings 查询组织设置
+func (s *OrganizationSettingsService) GetOrganizationSettings(ctx context.Context, req *orgv1.GetOrganizationSettingsRequest) (*orgv1.OrganizationSettings, error) {
+	if req.OrgId < 0 {
+		return nil, biz.ErrNotInOrganization
+	}
+	org, err := s.orgs.Settings(ctx, uint64(req.OrgId))
+	if err != nil {
+		return nil, err
+	}
+	return &orgv1.OrganizationSettings{
+		OrgId:            int64(org.ID),
+		AllowedFileTypes: org.AllowedFileTypes,
+		AiMonthlyQuota:   org.AIMonthlyQuota,
+	}, nil
+}
+
+func toOrganizationInfo(org *biz.Organization) *v1.Organization {
+	return &v1.Organization{
+		Id:               org.ID,
+		Name:             org.Name,
+		AllowedFileTypes: org.AllowedFileTypes,
+		AiMonthlyQuota:   org.AIMonthlyQuota,
+		CreatedBy:        org.CreatedBy,
+		CreatedAt:        org.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        org.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toInvitationInfo(inv *biz.Invitation) *v1.Invitation {
+	return &v1.Invitation{
+		Id:        inv.ID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		InvitedBy: inv.InvitedBy,
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+	}
+}
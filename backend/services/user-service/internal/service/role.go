@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/lyb88999/resume_helper/api/user/v1"
+)
+
+// SetUserRole 设置用户角色
+func (s *UserService) SetUserRole(ctx context.Context, req *v1.SetUserRoleRequest) (*v1.SetUserRoleReply, error) {
+	s.log.WithContext(ctx).Infof("设置用户角色请求: id=%d, role=%s", req.Id, req.Role)
+
+	user, err := s.roles.SetRole(ctx, req.Id, req.Role, req.Reason)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("设置用户角色失败: %v", err)
+		return nil, err
+	}
+
+	return &v1.SetUserRoleReply{
+		User: &v1.UserInfo{
+			Id:            user.ID,
+			Email:         user.Email,
+			Nickname:      user.Nickname,
+			Avatar:        user.Avatar,
+			CreatedAt:     user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:     user.UpdatedAt.Format(time.RFC3339),
+			EmailVerified: user.EmailVerifiedAt != nil,
+			Role:          user.Role,
+		},
+	}, nil
+}
+
+// ListRoleChanges 查询用户的角色变更记录
+func (s *UserService) ListRoleChanges(ctx context.Context, req *v1.ListRoleChangesRequest) (*v1.ListRoleChangesReply, error) {
+	changes, total, err := s.roles.ListRoleChanges(ctx, req.Id, int(req.Page), int(req.PerPage))
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("查询角色变更记录失败: %v", err)
+		return nil, err
+	}
+
+	reply := &v1.ListRoleChangesReply{
+		Changes: make([]*v1.RoleChange, len(changes)),
+		Total:   total,
+	}
+	for i, c := range changes {
+		reply.Changes[i] = &v1.RoleChange{
+			Id:        c.ID,
+			UserId:    c.UserID,
+			OldRole:   c.OldRole,
+			NewRole:   c.NewRole,
+			ChangedBy: c.ChangedBy,
+			Reason:    c.Reason,
+			CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return reply, nil
+}
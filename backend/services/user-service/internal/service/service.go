@@ -3,4 +3,4 @@ package service
 import "github.com/google/wire"
 
 // ProviderSet is service providers.
-var ProviderSet = wire.NewSet(NewUserService)
\ No newline at end of file
+var ProviderSet = wire.NewSet(NewUserService, NewTokenService, NewOrganizationService, NewOrganizationSettingsService)
\ No newline at end of file
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/lyb88999/resume_helper/backend/services/user-service/internal/biz"
+	userv1 "github.com/lyb88999/resume_helper/backend/shared/proto/user"
+)
+
+// TokenService 供其他服务调用的用户服务（shared/proto/user），目前只提供 VerifyToken
+type TokenService struct {
+	userv1.UnimplementedUserServiceServer
+
+	tokens *biz.TokenUsecase
+	log    *log.Helper
+}
+
+// NewTokenService 创建令牌校验服务实例
+func NewTokenService(tokens *biz.TokenUsecase, logger log.Logger) *TokenService {
+	return &TokenService{
+		tokens: tokens,
+		log:    log.NewHelper(logger),
+	}
+}
+
+// VerifyToken 校验访问令牌，令牌无效、过期或已吊销时 valid 为 false
+func (s *TokenService) VerifyToken(ctx context.Context, req *userv1.VerifyTokenRequest) (*userv1.VerifyTokenResponse, error) {
+	claims, err := s.tokens.Verify(ctx, req.Token)
+	if err != nil {
+		e := kerrors.FromError(err)
+		if e.Code >= 500 {
+			s.log.WithContext(ctx).Errorf("校验令牌失败: %v", err)
+			return nil, err
+		}
+		return &userv1.VerifyTokenResponse{Code: e.Code, Message: e.Message}, nil
+	}
+
+	return &userv1.VerifyTokenResponse{
+		Code:        200,
+		Message:     "ok",
+		UserId:      uint64(claims.UserID),
+		Valid:       true,
+		SessionId:   claims.SessionID,
+		ExpiresAt:   timestamppb.New(claims.ExpiresAt.Time),
+		Role:        claims.Role,
+		Permissions: claims.Permissions,
+	}, nil
+}
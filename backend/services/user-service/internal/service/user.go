@@ -14,15 +14,21 @@ import (
 type UserService struct {
 	v1.UnimplementedUserServiceServer
 
-	uc  *biz.UserUsecase
-	log *log.Helper
+	uc       *biz.UserUsecase
+	tokens   *biz.TokenUsecase
+	accounts *biz.AccountUsecase
+	roles    *biz.RoleUsecase
+	log      *log.Helper
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(uc *biz.UserUsecase, logger log.Logger) *UserService {
+func NewUserService(uc *biz.UserUsecase, tokens *biz.TokenUsecase, accounts *biz.AccountUsecase, roles *biz.RoleUsecase, logger log.Logger) *UserService {
 	return &UserService{
-		uc:  uc,
-		log: log.NewHelper(logger),
+		uc:       uc,
+		tokens:   tokens,
+		accounts: accounts,
+		roles:    roles,
+		log:      log.NewHelper(logger),
 	}
 }
 
@@ -54,6 +60,79 @@ func (s *UserService) Login(ctx context.Context, req *v1.LoginRequest) (*v1.Logi
 	return reply, nil
 }
 
+// RefreshToken 轮换刷新令牌
+func (s *UserService) RefreshToken(ctx context.Context, req *v1.RefreshTokenRequest) (*v1.RefreshTokenReply, error) {
+	tokens, err := s.tokens.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("刷新令牌失败: %v", err)
+		return nil, err
+	}
+
+	return &v1.RefreshTokenReply{
+		Token:            tokens.AccessToken,
+		ExpiresAt:        uint64(tokens.AccessExpiresAt.Unix()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: uint64(tokens.RefreshExpiresAt.Unix()),
+	}, nil
+}
+
+// Logout 登出当前会话
+func (s *UserService) Logout(ctx context.Context, req *v1.LogoutRequest) (*emptypb.Empty, error) {
+	if err := s.tokens.Logout(ctx); err != nil {
+		s.log.WithContext(ctx).Errorf("登出失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// LogoutAll 登出所有设备
+func (s *UserService) LogoutAll(ctx context.Context, req *v1.LogoutAllRequest) (*v1.LogoutAllReply, error) {
+	sessions, err := s.tokens.LogoutAll(ctx)
+	if err != nil {
+		s.log.WithContext(ctx).Errorf("登出所有设备失败: %v", err)
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Infof("登出所有设备成功: sessions=%d", sessions)
+	return &v1.LogoutAllReply{Sessions: int32(sessions)}, nil
+}
+
+// SendVerificationEmail 重新发送邮箱验证邮件
+func (s *UserService) SendVerificationEmail(ctx context.Context, req *v1.SendVerificationEmailRequest) (*emptypb.Empty, error) {
+	if err := s.accounts.SendVerification(ctx); err != nil {
+		s.log.WithContext(ctx).Errorf("发送验证邮件失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// VerifyEmail 验证邮箱
+func (s *UserService) VerifyEmail(ctx context.Context, req *v1.VerifyEmailRequest) (*emptypb.Empty, error) {
+	if err := s.accounts.VerifyEmail(ctx, req.Token); err != nil {
+		s.log.WithContext(ctx).Errorf("验证邮箱失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ForgotPassword 发送重置密码邮件
+func (s *UserService) ForgotPassword(ctx context.Context, req *v1.ForgotPasswordRequest) (*emptypb.Empty, error) {
+	if err := s.accounts.ForgotPassword(ctx, req.Email); err != nil {
+		s.log.WithContext(ctx).Errorf("发送重置密码邮件失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ResetPassword 重置密码
+func (s *UserService) ResetPassword(ctx context.Context, req *v1.ResetPasswordRequest) (*emptypb.Empty, error) {
+	if err := s.accounts.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		s.log.WithContext(ctx).Errorf("重置密码失败: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
 // GetUserInfo 获取用户信息
 func (s *UserService) GetUserInfo(ctx context.Context, req *v1.GetUserInfoRequest) (*v1.GetUserInfoReply, error) {
 	s.log.WithContext(ctx).Infof("获取用户信息请求: id=%d", req.Id)
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// authorizationKey 携带访问令牌的请求头
+const authorizationKey = "Authorization"
+
+// serviceTokenExpiry 服务间调用签发的令牌有效期，只在调用开始时校验
+const serviceTokenExpiry = 5 * time.Minute
+
+// Option 认证选项
+type Option func(*options)
+
+type options struct {
+	public      map[string]struct{}
+	permissions map[string]string
+	revocations *Revocations
+}
+
+// WithPublic 无需认证的操作，如 "/api.user.v1.UserService/Login"
+func WithPublic(operations ...string) Option {
+	return func(o *options) {
+		for _, operation := range operations {
+			o.public[operation] = struct{}{}
+		}
+	}
+}
+
+// WithPermission 调用这些操作需要令牌中包含该权限，否则返回 ErrForbidden。
+// gRPC 操作为完整方法名，直接注册的 HTTP 路由（Handler）为请求路径
+func WithPermission(permission string, operations ...string) Option {
+	return func(o *options) {
+		for _, operation := range operations {
+			o.permissions[operation] = permission
+		}
+	}
+}
+
+// WithRevocations 拒绝已吊销（如已登出）的访问令牌
+func WithRevocations(r *Revocations) Option {
+	return func(o *options) {
+		o.revocations = r
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{public: make(map[string]struct{}), permissions: make(map[string]string)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) isPublic(operation string) bool {
+	_, ok := o.public[operation]
+	return ok
+}
+
+// authenticate 校验 Authorization 头中的令牌，配置了吊销列表时同时检查是否已吊销，
+// 操作需要权限时检查令牌中的权限
+func (o *options) authenticate(ctx context.Context, secret, operation, header string) (*Claims, error) {
+	claims, err := parseAuthorization(secret, header)
+	if err != nil {
+		return nil, err
+	}
+	if o.revocations != nil {
+		if err := o.revocations.check(ctx, claims); err != nil {
+			return nil, err
+		}
+	}
+	if permission, ok := o.permissions[operation]; ok && !claims.HasPermission(permission) {
+		return nil, ErrForbidden
+	}
+	return claims, nil
+}
+
+// Server 校验 Authorization 头中的访问令牌并把用户ID放入 context，
+// 适用于 HTTP 路由和 gRPC 一元调用
+func Server(secret string, opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrUnauthenticated
+			}
+			if o.isPublic(tr.Operation()) {
+				return handler(ctx, req)
+			}
+			claims, err := o.authenticate(ctx, secret, tr.Operation(), tr.RequestHeader().Get(authorizationKey))
+			if err != nil {
+				return nil, err
+			}
+			return handler(NewClaimsContext(ctx, claims), req)
+		}
+	}
+}
+
+// StreamServerInterceptor 校验 gRPC 流式调用的访问令牌。
+// kratos 的流式中间件无法替换 handler 使用的 context，因此以拦截器实现
+func StreamServerInterceptor(secret string, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if o.isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx := ss.Context()
+		var header string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(authorizationKey); len(values) > 0 {
+				header = values[0]
+			}
+		}
+		claims, err := o.authenticate(ctx, secret, info.FullMethod, header)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: NewClaimsContext(ctx, claims)})
+	}
+}
+
+// serverStream 替换流的 context
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// Handler 校验直接注册的 HTTP 路由（不经过 kratos 中间件）的访问令牌，
+// 认证失败时按 kratos 的错误格式返回。CORS 预检请求不携带凭证，直接放行
+func Handler(secret string, next http.Handler, opts ...Option) http.Handler {
+	o := newOptions(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		claims, err := o.authenticate(r.Context(), secret, r.URL.Path, r.Header.Get(authorizationKey))
+		if err != nil {
+			khttp.DefaultErrorEncoder(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewClaimsContext(r.Context(), claims)))
+	})
+}
+
+// Credentials 服务间 gRPC 调用的凭证：为 context 中的用户签发短期访问令牌，
+// 被调用的服务像对待客户端请求一样校验令牌并取得用户和组织。当前请求已认证时沿用其角色，
+// 后台任务等只有用户ID的 context 以求职者身份调用。
+// context 中没有用户时不携带令牌，由被调用的服务拒绝
+type Credentials struct {
+	secret string
+}
+
+// NewCredentials 创建服务间调用凭证，通过 grpc.WithPerRPCCredentials 使用
+func NewCredentials(secret string) *Credentials {
+	return &Credentials{secret: secret}
+}
+
+func (c *Credentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	userID, ok := FromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	claims := &Claims{UserID: userID, OrgID: OrgID(ctx)}
+	if current, ok := ClaimsFromContext(ctx); ok && current.UserID == userID {
+		claims.Role = current.Role
+		claims.OrgRole = current.OrgRole
+	}
+	token, err := Sign(c.secret, claims, serviceTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity 服务间使用内网明文连接
+func (c *Credentials) RequireTransportSecurity() bool {
+	return false
+}
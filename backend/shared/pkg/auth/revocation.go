@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// revokedKeyPrefix 已吊销访问令牌在 Redis 中的键前缀，后接令牌的 jti
+const revokedKeyPrefix = "auth:revoked:"
+
+// ErrRevocationUnavailable 无法查询吊销列表时拒绝请求，避免已登出的令牌继续可用
+var ErrRevocationUnavailable = kerrors.ServiceUnavailable("TOKEN_CHECK_UNAVAILABLE", "access token revocation list is unavailable")
+
+// Revocations 已吊销访问令牌的列表，按 jti 保存在各服务共用的 Redis 中。
+// 每条记录在令牌过期时自动删除，列表大小不超过有效期内被吊销的令牌数
+type Revocations struct {
+	rdb redis.UniversalClient
+}
+
+// NewRevocations 创建吊销列表
+func NewRevocations(rdb redis.UniversalClient) *Revocations {
+	return &Revocations{rdb: rdb}
+}
+
+// Revoke 吊销访问令牌直到其过期，已过期的令牌无需记录
+func (r *Revocations) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	if err := r.rdb.Set(ctx, revokedKeyPrefix+jti, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token %s: %w", jti, err)
+	}
+	return nil
+}
+
+// IsRevoked 查询访问令牌是否已被吊销
+func (r *Revocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	err := r.rdb.Get(ctx, revokedKeyPrefix+jti).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check token %s: %w", jti, err)
+	}
+	return true, nil
+}
+
+// Verify 校验令牌的签名、有效期和吊销状态，供 VerifyToken 等需要完整校验的场景使用
+func (r *Revocations) Verify(ctx context.Context, secret, token string) (*Claims, error) {
+	claims, err := ParseToken(secret, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.check(ctx, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// check 已吊销的令牌与无效令牌一样返回 ErrTokenRevoked
+func (r *Revocations) check(ctx context.Context, claims *Claims) error {
+	revoked, err := r.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return ErrRevocationUnavailable
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+// 用户角色，未分配角色的用户为求职者
+const (
+	RoleCandidate = "candidate" // 求职者
+	RoleRecruiter = "recruiter" // 招聘者
+	RoleCoach     = "coach"     // 求职辅导
+	RoleAdmin     = "admin"     // 管理员
+)
+
+// 权限，由角色决定，签发访问令牌时写入令牌
+const (
+	PermBatchScreening  = "batch_screening"  // 批量上传和筛选简历
+	PermManageKnowledge = "manage_knowledge" // 维护技能库、检查规则等知识库内容
+	PermManageUsers     = "manage_users"     // 管理用户的角色和存储套餐
+)
+
+// 组织角色，签发访问令牌时写入 org_role
+const (
+	OrgRoleOwner  = "owner"  // 所有者
+	OrgRoleAdmin  = "admin"  // 管理员
+	OrgRoleMember = "member" // 成员
+)
+
+// ErrForbidden 令牌有效但角色没有所需权限
+var ErrForbidden = kerrors.Forbidden("FORBIDDEN", "permission denied")
+
+// rolePermissions 各角色拥有的权限，管理员拥有所有权限
+var rolePermissions = map[string][]string{
+	RoleCandidate: nil,
+	RoleRecruiter: {PermBatchScreening},
+	RoleCoach:     {PermManageKnowledge},
+	RoleAdmin:     {PermBatchScreening, PermManageKnowledge, PermManageUsers},
+}
+
+// Roles 所有角色
+func Roles() []string {
+	return []string{RoleCandidate, RoleRecruiter, RoleCoach, RoleAdmin}
+}
+
+// ValidRole 是否为已定义的角色
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions 角色拥有的权限
+func Permissions(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
+
+// HasPermission 令牌中是否包含该权限
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Require 检查当前用户是否拥有权限，用于无法在中间件中按操作配置的场景
+func Require(ctx context.Context, permission string) error {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !claims.HasPermission(permission) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// IsOrgManager 当前用户是否为所在组织的所有者或管理员，以令牌中的 org_role 为准
+func IsOrgManager(ctx context.Context) bool {
+	role := OrgRole(ctx)
+	return role == OrgRoleOwner || role == OrgRoleAdmin
+}
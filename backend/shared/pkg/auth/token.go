@@ -0,0 +1,165 @@
+// Package auth 校验 user-service 签发的 JWT 访问令牌，并把令牌中的用户ID和组织ID放入 context。
+// 各服务只从 context 中取得当前用户，不信任请求参数中的 user_id。
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// reason 认证失败的错误码
+const reason = "UNAUTHORIZED"
+
+var (
+	ErrMissingToken    = kerrors.Unauthorized(reason, "access token is missing")
+	ErrInvalidToken    = kerrors.Unauthorized(reason, "access token is invalid")
+	ErrTokenExpired    = kerrors.Unauthorized(reason, "access token has expired")
+	ErrTokenRevoked    = kerrors.Unauthorized(reason, "access token has been revoked")
+	ErrUnauthenticated = kerrors.Unauthorized(reason, "request is not authenticated")
+)
+
+// Claims 访问令牌的内容，user_id 为 user-service 中的用户ID，
+// sid 为登录会话（同一次登录轮换出的刷新令牌共用），jti 用于吊销单个令牌，
+// role 为签发时的角色，perms 为该角色的权限，org_id 和 org_role 为用户所在的组织及其在组织中的角色
+type Claims struct {
+	UserID      int64    `json:"user_id"`
+	SessionID   string   `json:"sid,omitempty"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"perms,omitempty"`
+	OrgID       int64    `json:"org_id,omitempty"`
+	OrgRole     string   `json:"org_role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Sign 为 claims 生成 jti、签发时间和过期时间，按角色填入权限，并签发 HS256 访问令牌
+func Sign(secret string, claims *Claims, expire time.Duration) (string, error) {
+	if secret == "" {
+		return "", errors.New("jwt secret is not configured")
+	}
+	if claims.Role == "" {
+		claims.Role = RoleCandidate
+	}
+	claims.Permissions = Permissions(claims.Role)
+	jti, err := NewID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims.ID = jti
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(expire))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, nil
+}
+
+// NewID 生成随机的令牌ID
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ParseToken 校验签名和有效期，只接受 HS256。未配置密钥时拒绝所有令牌
+func ParseToken(secret, token string) (*Claims, error) {
+	if secret == "" {
+		return nil, ErrInvalidToken
+	}
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+	if claims.UserID <= 0 {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// parseAuthorization 从 Authorization 头中取出 Bearer 令牌并校验
+func parseAuthorization(secret, header string) (*Claims, error) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrMissingToken
+	}
+	return ParseToken(secret, strings.TrimSpace(token))
+}
+
+type (
+	userKey   struct{}
+	orgKey    struct{}
+	claimsKey struct{}
+)
+
+// NewContext 返回携带用户ID的 context
+func NewContext(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// FromContext 取出通过认证的用户ID
+func FromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userKey{}).(int64)
+	return userID, ok && userID > 0
+}
+
+// NewOrgContext 返回携带组织ID的 context，用于后台任务以资源所属组织的身份调用其他服务
+func NewOrgContext(ctx context.Context, orgID int64) context.Context {
+	return context.WithValue(ctx, orgKey{}, orgID)
+}
+
+// OrgID 取出用户所在的组织ID，不属于任何组织时返回0
+func OrgID(ctx context.Context) int64 {
+	orgID, _ := ctx.Value(orgKey{}).(int64)
+	return orgID
+}
+
+// OrgRole 取出访问令牌中用户在所在组织的角色，未认证或不属于任何组织时返回空
+func OrgRole(ctx context.Context) string {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.OrgID == 0 {
+		return ""
+	}
+	return claims.OrgRole
+}
+
+// NewClaimsContext 返回携带令牌内容、用户ID和组织ID的 context，由认证中间件在校验令牌后调用
+func NewClaimsContext(ctx context.Context, claims *Claims) context.Context {
+	ctx = NewOrgContext(NewContext(ctx, claims.UserID), claims.OrgID)
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext 取出通过认证的访问令牌内容，用于登出等需要 jti 和 sid 的操作
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// UserID 取出通过认证的用户ID，未认证时返回 ErrUnauthenticated
+func UserID(ctx context.Context) (int64, error) {
+	userID, ok := FromContext(ctx)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+	return userID, nil
+}
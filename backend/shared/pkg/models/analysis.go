@@ -35,14 +35,15 @@ type SuggestionList []Suggestion
 
 // Suggestion 优化建议
 type Suggestion struct {
-	Section     string          `json:"section"`     // 章节：experience, education, skills, etc.
-	Level       SuggestionLevel `json:"level"`       // 严重程度
-	Type        SuggestionType  `json:"type"`        // 建议类型
-	Title       string          `json:"title"`       // 建议标题
-	Description string          `json:"description"` // 详细描述
-	Examples    []string        `json:"examples"`    // 示例
-	Location    SuggestionLoc   `json:"location"`    // 位置信息
-	Priority    int             `json:"priority"`    // 优先级 1-10
+	Section     string          `json:"section"`           // 章节：experience, education, skills, etc.
+	Level       SuggestionLevel `json:"level"`             // 严重程度
+	Type        SuggestionType  `json:"type"`              // 建议类型
+	Title       string          `json:"title"`             // 建议标题
+	Description string          `json:"description"`       // 详细描述
+	Examples    []string        `json:"examples"`          // 示例
+	Location    SuggestionLoc   `json:"location"`          // 位置信息
+	Priority    int             `json:"priority"`          // 优先级 1-10
+	RuleID      string          `json:"rule_id,omitempty"` // 规则检查（lint）产生的建议对应的规则ID
 }
 
 // SuggestionLevel 建议级别
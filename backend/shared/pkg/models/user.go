@@ -17,6 +17,12 @@ type User struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// EmailVerifiedAt 邮箱验证时间，为空表示未验证
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Role 用户角色：candidate、recruiter、coach、admin
+	Role string `gorm:"size:20;not null;default:candidate" json:"role"`
 }
 
 // UserStatus 用户状态
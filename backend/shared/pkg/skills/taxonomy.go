@@ -0,0 +1,495 @@
+// Package skills 提供技能本体（taxonomy）：规范技能ID、中英文别名、分类以及父子关系，
+// 用于统一简历技能与职位要求（JD）中的技能写法，例如 "Golang"、"go语言"、"GO" 均归一为 "go"。
+package skills
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 技能分类
+const (
+	CategoryLanguage  = "language"  // 编程语言
+	CategoryFramework = "framework" // 框架与库
+	CategoryDatabase  = "database"  // 数据库与存储
+	CategoryDevOps    = "devops"    // 运维与基础设施
+	CategoryCloud     = "cloud"     // 云平台
+	CategoryTool      = "tool"      // 工具
+	CategoryDomain    = "domain"    // 领域/能力，一般作为其他技能的父节点
+	CategorySoft      = "soft"      // 软技能
+)
+
+// 错误定义
+var (
+	ErrInvalidSkill  = errors.New("技能定义无效")
+	ErrSkillNotFound = errors.New("技能不存在")
+	ErrUnknownParent = errors.New("父技能不存在")
+	ErrParentCycle   = errors.New("父子关系存在循环")
+	ErrAliasConflict = errors.New("别名已被其他技能使用")
+)
+
+//go:embed skills.json
+var bundledData []byte
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.\-]*$`)
+
+// Skill 技能定义
+type Skill struct {
+	ID       string   `json:"id"`                // 规范ID，如 go、kubernetes
+	Name     string   `json:"name"`              // 规范名称（英文）
+	NameZh   string   `json:"name_zh,omitempty"` // 中文名称
+	Category string   `json:"category"`          // 分类
+	Parent   string   `json:"parent,omitempty"`  // 父技能ID，如 kubernetes → container-orchestration
+	Aliases  []string `json:"aliases,omitempty"` // 别名（中英文）
+	// CaseSensitive 从自由文本中提取时只匹配规范名称的原始大小写，
+	// 用于 Go、R 这类容易与普通单词混淆的技能
+	CaseSensitive bool `json:"case_sensitive,omitempty"`
+}
+
+// Terms 返回技能的所有写法（ID、名称、中文名称、别名）
+func (s *Skill) Terms() []string {
+	terms := []string{s.ID, s.Name}
+	if s.NameZh != "" {
+		terms = append(terms, s.NameZh)
+	}
+	return append(terms, s.Aliases...)
+}
+
+func (s *Skill) clone() *Skill {
+	c := *s
+	c.Aliases = append([]string(nil), s.Aliases...)
+	return &c
+}
+
+// Taxonomy 技能本体，并发安全
+type Taxonomy struct {
+	mu       sync.RWMutex
+	skills   map[string]*Skill
+	index    map[string]string   // 归一化写法 → 技能ID
+	children map[string][]string // 父技能ID → 子技能ID
+}
+
+// LoadBundled 加载内置技能数据集
+func LoadBundled() (*Taxonomy, error) {
+	var list []*Skill
+	if err := json.Unmarshal(bundledData, &list); err != nil {
+		return nil, fmt.Errorf("解析内置技能数据失败: %w", err)
+	}
+	return New(list)
+}
+
+// New 根据技能列表创建技能本体。列表顺序无关，父技能可以出现在子技能之后
+func New(list []*Skill) (*Taxonomy, error) {
+	t := &Taxonomy{
+		skills:   make(map[string]*Skill, len(list)),
+		index:    make(map[string]string),
+		children: make(map[string][]string),
+	}
+
+	for _, s := range list {
+		if err := validate(s); err != nil {
+			return nil, err
+		}
+		if _, ok := t.skills[s.ID]; ok {
+			return nil, fmt.Errorf("%w: 技能ID重复: %s", ErrInvalidSkill, s.ID)
+		}
+		t.skills[s.ID] = s.clone()
+	}
+
+	for _, s := range t.skills {
+		if s.Parent == "" {
+			continue
+		}
+		if _, ok := t.skills[s.Parent]; !ok {
+			return nil, fmt.Errorf("%w: %s → %s", ErrUnknownParent, s.ID, s.Parent)
+		}
+		if t.createsCycle(s.ID, s.Parent) {
+			return nil, fmt.Errorf("%w: %s", ErrParentCycle, s.ID)
+		}
+		t.children[s.Parent] = append(t.children[s.Parent], s.ID)
+	}
+
+	for _, s := range t.skills {
+		if err := t.indexSkill(s); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Get 获取技能定义
+func (t *Taxonomy) Get(id string) (*Skill, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s, ok := t.skills[id]
+	if !ok {
+		return nil, false
+	}
+	return s.clone(), true
+}
+
+// List 按ID排序返回技能列表，category 为空时返回全部
+func (t *Taxonomy) List(category string) []*Skill {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := make([]*Skill, 0, len(t.skills))
+	for _, s := range t.skills {
+		if category == "" || s.Category == category {
+			list = append(list, s.clone())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Lookup 将一个技能写法解析为技能定义，如 "Golang" → go
+func (t *Taxonomy) Lookup(term string) (*Skill, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	id, ok := t.index[normalizeTerm(term)]
+	if !ok {
+		return nil, false
+	}
+	return t.skills[id].clone(), true
+}
+
+// Normalize 将技能写法列表归一为去重后的技能ID，无法识别的写法原样返回在 unknown 中
+func (t *Taxonomy) Normalize(terms []string) (ids []string, unknown []string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+		id, ok := t.index[normalizeTerm(term)]
+		if !ok {
+			unknown = append(unknown, term)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, unknown
+}
+
+// Extract 从自由文本（如职位描述、简历正文）中提取出现的技能ID，按首次出现位置排序
+func (t *Taxonomy) Extract(text string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	lower := strings.ToLower(foldWidth(text))
+	folded := foldWidth(text)
+	first := make(map[string]int)
+
+	for _, s := range t.skills {
+		pos := -1
+		if s.CaseSensitive {
+			pos = indexTerm(folded, s.Name)
+			if s.NameZh != "" {
+				pos = minPos(pos, indexTerm(lower, strings.ToLower(s.NameZh)))
+			}
+			for _, alias := range s.Aliases {
+				// 区分大小写的技能只在别名足够长、不易误判时才按小写匹配
+				if utf8.RuneCountInString(alias) > 3 {
+					pos = minPos(pos, indexTerm(lower, strings.ToLower(alias)))
+				}
+			}
+		} else {
+			for _, term := range s.Terms() {
+				pos = minPos(pos, indexTerm(lower, strings.ToLower(term)))
+			}
+		}
+		if pos >= 0 {
+			first[s.ID] = pos
+		}
+	}
+
+	ids := make([]string, 0, len(first))
+	for id := range first {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if first[ids[i]] != first[ids[j]] {
+			return first[ids[i]] < first[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Ancestors 返回技能的所有祖先ID，由近及远
+func (t *Taxonomy) Ancestors(id string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var ancestors []string
+	for s, ok := t.skills[id]; ok && s.Parent != ""; s, ok = t.skills[s.Parent] {
+		ancestors = append(ancestors, s.Parent)
+	}
+	return ancestors
+}
+
+// Children 返回技能的直接子技能ID
+func (t *Taxonomy) Children(id string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	children := append([]string(nil), t.children[id]...)
+	sort.Strings(children)
+	return children
+}
+
+// IsA 判断技能 id 是否等于或属于 ancestor，如 IsA("kubernetes", "container-orchestration") 为 true
+func (t *Taxonomy) IsA(id, ancestor string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.isA(id, ancestor)
+}
+
+// Match 计算已有技能对要求技能的覆盖情况。已有技能等于要求技能或是其子技能时视为满足，
+// 例如要求 "容器编排" 时，掌握 Kubernetes 即满足
+func (t *Taxonomy) Match(have, want []string) (matched, missing []string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, required := range want {
+		ok := false
+		for _, id := range have {
+			if t.isA(id, required) {
+				ok = true
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, required)
+		} else {
+			missing = append(missing, required)
+		}
+	}
+	return matched, missing
+}
+
+// Upsert 新增或更新技能定义。校验失败时本体保持不变
+func (t *Taxonomy) Upsert(s *Skill) error {
+	if err := validate(s); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if s.Parent != "" {
+		if _, ok := t.skills[s.Parent]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownParent, s.Parent)
+		}
+		if t.createsCycle(s.ID, s.Parent) {
+			return fmt.Errorf("%w: %s → %s", ErrParentCycle, s.ID, s.Parent)
+		}
+	}
+	for _, term := range s.Terms() {
+		if id, ok := t.index[normalizeTerm(term)]; ok && id != s.ID {
+			return fmt.Errorf("%w: %q → %s", ErrAliasConflict, term, id)
+		}
+	}
+
+	if old, ok := t.skills[s.ID]; ok {
+		for key, id := range t.index {
+			if id == old.ID {
+				delete(t.index, key)
+			}
+		}
+		if old.Parent != "" {
+			t.children[old.Parent] = removeString(t.children[old.Parent], old.ID)
+		}
+	}
+
+	skill := s.clone()
+	t.skills[skill.ID] = skill
+	if skill.Parent != "" {
+		t.children[skill.Parent] = append(t.children[skill.Parent], skill.ID)
+	}
+	return t.indexSkill(skill)
+}
+
+// Remove 删除技能。存在子技能时不允许删除
+func (t *Taxonomy) Remove(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.skills[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSkillNotFound, id)
+	}
+	if len(t.children[id]) > 0 {
+		return fmt.Errorf("%w: %s 存在子技能", ErrInvalidSkill, id)
+	}
+
+	for key, v := range t.index {
+		if v == id {
+			delete(t.index, key)
+		}
+	}
+	if s.Parent != "" {
+		t.children[s.Parent] = removeString(t.children[s.Parent], id)
+	}
+	delete(t.skills, id)
+	delete(t.children, id)
+	return nil
+}
+
+func (t *Taxonomy) isA(id, ancestor string) bool {
+	for s, ok := t.skills[id]; ok; s, ok = t.skills[s.Parent] {
+		if s.ID == ancestor {
+			return true
+		}
+		if s.Parent == "" {
+			break
+		}
+	}
+	return false
+}
+
+// createsCycle 判断把 id 的父技能设为 parent 是否会形成循环
+func (t *Taxonomy) createsCycle(id, parent string) bool {
+	seen := map[string]bool{id: true}
+	for cur := parent; cur != ""; {
+		if seen[cur] {
+			return true
+		}
+		seen[cur] = true
+		s, ok := t.skills[cur]
+		if !ok {
+			return false
+		}
+		cur = s.Parent
+	}
+	return false
+}
+
+func (t *Taxonomy) indexSkill(s *Skill) error {
+	for _, term := range s.Terms() {
+		key := normalizeTerm(term)
+		if key == "" {
+			continue
+		}
+		if id, ok := t.index[key]; ok && id != s.ID {
+			return fmt.Errorf("%w: %q 同时属于 %s 和 %s", ErrAliasConflict, term, id, s.ID)
+		}
+		t.index[key] = s.ID
+	}
+	return nil
+}
+
+func validate(s *Skill) error {
+	if s == nil {
+		return fmt.Errorf("%w: 技能为空", ErrInvalidSkill)
+	}
+	if !idPattern.MatchString(s.ID) {
+		return fmt.Errorf("%w: ID只能包含小写字母、数字和 +#.- : %q", ErrInvalidSkill, s.ID)
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("%w: 名称不能为空: %s", ErrInvalidSkill, s.ID)
+	}
+	if s.Category == "" {
+		return fmt.Errorf("%w: 分类不能为空: %s", ErrInvalidSkill, s.ID)
+	}
+	if s.Parent == s.ID {
+		return fmt.Errorf("%w: %s", ErrParentCycle, s.ID)
+	}
+	return nil
+}
+
+// normalizeTerm 归一化技能写法：全角转半角、转小写、合并空白
+func normalizeTerm(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(foldWidth(term))), " ")
+}
+
+// foldWidth 将全角ASCII字符转为半角，如 "Ｃ＋＋" → "C++"
+func foldWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 0xFF01 && r <= 0xFF5E {
+			return r - 0xFEE0
+		}
+		if r == 0x3000 {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// indexTerm 返回 term 在 text 中首次独立出现的位置。ASCII写法要求前后不是字母、数字，
+// 避免 "Java" 命中 "JavaScript"、"C" 命中 "C++"
+func indexTerm(text, term string) int {
+	if term == "" {
+		return -1
+	}
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return -1
+		}
+		start := offset + i
+		end := start + len(term)
+		if boundaryBefore(text, start) && boundaryAfter(text, end) {
+			return start
+		}
+		offset = start + 1
+	}
+	return -1
+}
+
+func boundaryBefore(text string, start int) bool {
+	if start == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:start])
+	return !isWordRune(r)
+}
+
+func boundaryAfter(text string, end int) bool {
+	if end >= len(text) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(r) && r != '+' && r != '#'
+}
+
+// isWordRune 判断是否为ASCII单词字符。中文等非ASCII字符视为边界，"熟悉Go语言" 可以命中 Go
+func isWordRune(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func minPos(a, b int) int {
+	if a < 0 {
+		return b
+	}
+	if b < 0 || a < b {
+		return a
+	}
+	return b
+}
+
+func removeString(list []string, s string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
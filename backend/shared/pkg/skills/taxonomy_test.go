@@ -0,0 +1,274 @@
+package skills
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func bundled(t *testing.T) *Taxonomy {
+	t.Helper()
+	tax, err := LoadBundled()
+	if err != nil {
+		t.Fatalf("LoadBundled: %v", err)
+	}
+	return tax
+}
+
+func TestLoadBundled(t *testing.T) {
+	tax := bundled(t)
+	if len(tax.List("")) == 0 {
+		t.Fatal("bundled taxonomy is empty")
+	}
+	for _, s := range tax.List(CategoryLanguage) {
+		if s.Category != CategoryLanguage {
+			t.Errorf("List(%q) returned %s with category %s", CategoryLanguage, s.ID, s.Category)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tax := bundled(t)
+	tests := []struct {
+		term   string
+		wantID string
+		wantOK bool
+	}{
+		{"Golang", "go", true},
+		{"go语言", "go", true},
+		{"GO", "go", true},
+		{"  go   lang ", "go", true},
+		{"K8S", "kubernetes", true},
+		{"容器编排", "container-orchestration", true},
+		{"ＪａｖａＳｃｒｉｐｔ", "javascript", true},
+		{"cobol-2000", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			s, ok := tax.Lookup(tt.term)
+			if ok != tt.wantOK {
+				t.Fatalf("Lookup(%q) ok = %v, want %v", tt.term, ok, tt.wantOK)
+			}
+			if ok && s.ID != tt.wantID {
+				t.Errorf("Lookup(%q) = %s, want %s", tt.term, s.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tax := bundled(t)
+	ids, unknown := tax.Normalize([]string{"Golang", "go", "k8s", " ", "Kubernetes", "COBOL"})
+	if want := []string{"go", "kubernetes"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	if want := []string{"COBOL"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("unknown = %v, want %v", unknown, want)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tax := bundled(t)
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"ordered by first occurrence", "熟悉Kubernetes和Go语言，了解MySQL", []string{"kubernetes", "go", "mysql"}},
+		{"java does not match javascript", "精通JavaScript", []string{"javascript"}},
+		{"c does not match c++", "熟悉C++开发", []string{"cpp"}},
+		{"case sensitive name", "Let's go to the office", nil},
+		{"case sensitive name in exact case", "使用 Go 开发", []string{"go"}},
+		{"long alias of case sensitive skill", "5 years of golang", []string{"go"}},
+		{"full width text", "熟悉Ｄｏｃｋｅｒ", []string{"docker"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tax.Extract(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHierarchy(t *testing.T) {
+	tax := bundled(t)
+
+	ancestors := tax.Ancestors("kubernetes")
+	if len(ancestors) == 0 || ancestors[0] != "container-orchestration" {
+		t.Errorf("Ancestors(kubernetes) = %v, want container-orchestration first", ancestors)
+	}
+	tests := []struct {
+		id, ancestor string
+		want         bool
+	}{
+		{"kubernetes", "kubernetes", true},
+		{"kubernetes", "container-orchestration", true},
+		{"go", "software-development", true},
+		{"container-orchestration", "kubernetes", false},
+		{"java", "frontend-development", false},
+		{"unknown", "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := tax.IsA(tt.id, tt.ancestor); got != tt.want {
+			t.Errorf("IsA(%s, %s) = %v, want %v", tt.id, tt.ancestor, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tax := bundled(t)
+	matched, missing := tax.Match(
+		[]string{"kubernetes", "go"},
+		[]string{"container-orchestration", "go", "java"},
+	)
+	if want := []string{"container-orchestration", "go"}; !reflect.DeepEqual(matched, want) {
+		t.Errorf("matched = %v, want %v", matched, want)
+	}
+	if want := []string{"java"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    []*Skill
+		wantErr error
+	}{
+		{
+			name: "parent after child",
+			list: []*Skill{
+				{ID: "b", Name: "B", Category: CategoryTool, Parent: "a"},
+				{ID: "a", Name: "A", Category: CategoryDomain},
+			},
+		},
+		{
+			name:    "invalid id",
+			list:    []*Skill{{ID: "Bad ID", Name: "Bad", Category: CategoryTool}},
+			wantErr: ErrInvalidSkill,
+		},
+		{
+			name:    "missing name",
+			list:    []*Skill{{ID: "a", Category: CategoryTool}},
+			wantErr: ErrInvalidSkill,
+		},
+		{
+			name: "duplicate id",
+			list: []*Skill{
+				{ID: "a", Name: "A", Category: CategoryTool},
+				{ID: "a", Name: "A2", Category: CategoryTool},
+			},
+			wantErr: ErrInvalidSkill,
+		},
+		{
+			name:    "unknown parent",
+			list:    []*Skill{{ID: "a", Name: "A", Category: CategoryTool, Parent: "missing"}},
+			wantErr: ErrUnknownParent,
+		},
+		{
+			name: "cycle",
+			list: []*Skill{
+				{ID: "a", Name: "A", Category: CategoryTool, Parent: "b"},
+				{ID: "b", Name: "B", Category: CategoryTool, Parent: "a"},
+			},
+			wantErr: ErrParentCycle,
+		},
+		{
+			name: "alias conflict",
+			list: []*Skill{
+				{ID: "a", Name: "A", Category: CategoryTool, Aliases: []string{"shared"}},
+				{ID: "b", Name: "B", Category: CategoryTool, Aliases: []string{"Shared"}},
+			},
+			wantErr: ErrAliasConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.list)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("New error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpsertAndRemove(t *testing.T) {
+	tax, err := New([]*Skill{
+		{ID: "root", Name: "Root", Category: CategoryDomain},
+		{ID: "a", Name: "A", Category: CategoryTool, Parent: "root", Aliases: []string{"alpha"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// 更新后旧别名失效，新别名生效
+	if err := tax.Upsert(&Skill{ID: "a", Name: "A", Category: CategoryTool, Parent: "root", Aliases: []string{"aleph"}}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if _, ok := tax.Lookup("alpha"); ok {
+		t.Error("old alias still resolves after Upsert")
+	}
+	if s, ok := tax.Lookup("aleph"); !ok || s.ID != "a" {
+		t.Errorf("Lookup(aleph) = %v, %v", s, ok)
+	}
+
+	tests := []struct {
+		name    string
+		skill   *Skill
+		wantErr error
+	}{
+		{"unknown parent", &Skill{ID: "b", Name: "B", Category: CategoryTool, Parent: "missing"}, ErrUnknownParent},
+		{"cycle", &Skill{ID: "root", Name: "Root", Category: CategoryDomain, Parent: "a"}, ErrParentCycle},
+		{"alias conflict", &Skill{ID: "b", Name: "B", Category: CategoryTool, Aliases: []string{"ALEPH"}}, ErrAliasConflict},
+		{"self parent", &Skill{ID: "b", Name: "B", Category: CategoryTool, Parent: "b"}, ErrParentCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tax.Upsert(tt.skill); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Upsert error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	// 校验失败时本体保持不变
+	if s, ok := tax.Get("root"); !ok || s.Parent != "" {
+		t.Errorf("root changed after failed Upsert: %+v", s)
+	}
+
+	if err := tax.Remove("root"); !errors.Is(err, ErrInvalidSkill) {
+		t.Errorf("Remove(root) with children error = %v, want ErrInvalidSkill", err)
+	}
+	if err := tax.Remove("a"); err != nil {
+		t.Fatalf("Remove(a): %v", err)
+	}
+	if _, ok := tax.Lookup("aleph"); ok {
+		t.Error("alias still resolves after Remove")
+	}
+	if children := tax.Children("root"); len(children) != 0 {
+		t.Errorf("Children(root) = %v after Remove", children)
+	}
+	if err := tax.Remove("a"); !errors.Is(err, ErrSkillNotFound) {
+		t.Errorf("Remove(a) twice error = %v, want ErrSkillNotFound", err)
+	}
+}
+
+func TestGetReturnsCopy(t *testing.T) {
+	tax := bundled(t)
+	s, ok := tax.Get("go")
+	if !ok {
+		t.Fatal("Get(go) not found")
+	}
+	s.Aliases[0] = "changed"
+	s.Name = "changed"
+	if again, _ := tax.Get("go"); again.Name == "changed" || again.Aliases[0] == "changed" {
+		t.Error("modifying the returned skill changed the taxonomy")
+	}
+}
@@ -0,0 +1,267 @@
+package timeline
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind 经历类型
+type Kind string
+
+const (
+	KindWork      Kind = "work"
+	KindEducation Kind = "education"
+)
+
+// 重叠类型
+const (
+	OverlapWork          = "work_work"      // 两段全职工作重叠
+	OverlapEducationWork = "education_work" // 在读期间从事全职工作
+)
+
+// 默认阈值
+const (
+	DefaultGapThresholdMonths     = 6
+	DefaultOverlapToleranceMonths = 1
+)
+
+// partTimeKeywords 用于识别实习、兼职等非全职经历
+var partTimeKeywords = []string{"实习", "兼职", "intern", "part-time", "part time", "parttime", "volunteer", "志愿"}
+
+// IsPartTime 根据职位、公司等文本判断是否为实习/兼职经历
+func IsPartTime(texts ...string) bool {
+	for _, text := range texts {
+		lower := strings.ToLower(text)
+		for _, keyword := range partTimeKeywords {
+			if strings.Contains(lower, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Entry 时间线上的一段经历
+type Entry struct {
+	Kind     Kind
+	Label    string // 展示名称，如 "某某科技 后端工程师"
+	Period   Period
+	FullTime bool // 仅对工作经历有效
+}
+
+// Options 分析选项
+type Options struct {
+	GapThresholdMonths     int       // 超过该月数的空窗期会被报告，默认6个月
+	OverlapToleranceMonths int       // 不超过该月数的重叠视为正常交接，默认1个月，小于0表示不允许重叠
+	Now                    time.Time // 计算"至今"时使用的当前时间，默认 time.Now()
+}
+
+// Tenure 单段工作经历的任期
+type Tenure struct {
+	Label    string `json:"label"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Months   int    `json:"months"`
+	FullTime bool   `json:"full_time"`
+}
+
+// Gap 空窗期
+type Gap struct {
+	After  string `json:"after"`  // 空窗期前的经历，为空表示时间线开始前
+	Before string `json:"before"` // 空窗期后的经历，为空表示持续至今
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Months int    `json:"months"`
+}
+
+// Overlap 经历重叠
+type Overlap struct {
+	Type   string `json:"type"` // work_work, education_work
+	First  string `json:"first"`
+	Second string `json:"second"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Months int    `json:"months"`
+}
+
+// Report 时间线分析结果
+type Report struct {
+	TotalExperienceMonths int       `json:"total_experience_months"` // 全职工作总月数，重叠部分不重复计算
+	TotalExperienceYears  float64   `json:"total_experience_years"`  // 保留一位小数
+	AverageTenureMonths   float64   `json:"average_tenure_months"`   // 全职工作平均任期
+	Tenures               []Tenure  `json:"tenures"`
+	Gaps                  []Gap     `json:"gaps"`
+	Overlaps              []Overlap `json:"overlaps"`
+}
+
+// Analyze 分析经历时间线
+func Analyze(entries []Entry, opts Options) *Report {
+	if opts.GapThresholdMonths <= 0 {
+		opts.GapThresholdMonths = DefaultGapThresholdMonths
+	}
+	if opts.OverlapToleranceMonths < 0 {
+		opts.OverlapToleranceMonths = 0
+	} else if opts.OverlapToleranceMonths == 0 {
+		opts.OverlapToleranceMonths = DefaultOverlapToleranceMonths
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	sorted := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if !e.Period.Start.IsZero() {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Period.Start.Before(sorted[j].Period.Start)
+	})
+
+	report := &Report{
+		Tenures:  []Tenure{},
+		Gaps:     []Gap{},
+		Overlaps: []Overlap{},
+	}
+
+	var fullTime []Entry
+	var education []Entry
+	for _, e := range sorted {
+		switch e.Kind {
+		case KindWork:
+			report.Tenures = append(report.Tenures, Tenure{
+				Label:    e.Label,
+				Start:    e.Period.StartString(),
+				End:      e.Period.EndString(),
+				Months:   e.Period.Months(opts.Now),
+				FullTime: e.FullTime,
+			})
+			if e.FullTime {
+				fullTime = append(fullTime, e)
+			}
+		case KindEducation:
+			education = append(education, e)
+		}
+	}
+
+	// 工作年限：全职工作区间取并集
+	report.TotalExperienceMonths = unionMonths(fullTime, opts.Now)
+	report.TotalExperienceYears = math.Round(float64(report.TotalExperienceMonths)/12*10) / 10
+	if len(fullTime) > 0 {
+		total := 0
+		for _, e := range fullTime {
+			total += e.Period.Months(opts.Now)
+		}
+		report.AverageTenureMonths = math.Round(float64(total)/float64(len(fullTime))*10) / 10
+	}
+
+	// 重叠：全职工作之间、在读期间的全职工作
+	for i := 0; i < len(fullTime); i++ {
+		for j := i + 1; j < len(fullTime); j++ {
+			if o, ok := overlap(OverlapWork, fullTime[i], fullTime[j], opts); ok {
+				report.Overlaps = append(report.Overlaps, o)
+			}
+		}
+	}
+	for _, edu := range education {
+		for _, work := range fullTime {
+			if o, ok := overlap(OverlapEducationWork, edu, work, opts); ok {
+				report.Overlaps = append(report.Overlaps, o)
+			}
+		}
+	}
+
+	// 空窗期：在读或全职工作都不算空窗，第一段经历之前不计
+	report.Gaps = findGaps(append(append([]Entry(nil), education...), fullTime...), opts)
+
+	return report
+}
+
+func overlap(kind string, a, b Entry, opts Options) (Overlap, bool) {
+	start := a.Period.Start
+	if b.Period.Start.After(start) {
+		start = b.Period.Start
+	}
+	end := a.Period.EndOr(opts.Now)
+	if bEnd := b.Period.EndOr(opts.Now); bEnd.Before(end) {
+		end = bEnd
+	}
+
+	// 两段经历都覆盖的月数，前一段的结束月与后一段的开始月相同时为1个月
+	months := monthIndex(end) - monthIndex(start) + 1
+	if months <= opts.OverlapToleranceMonths {
+		return Overlap{}, false
+	}
+	return Overlap{
+		Type:   kind,
+		First:  a.Label,
+		Second: b.Label,
+		Start:  FormatDate(start),
+		End:    FormatDate(end),
+		Months: months,
+	}, true
+}
+
+func findGaps(entries []Entry, opts Options) []Gap {
+	gaps := []Gap{}
+	if len(entries) == 0 {
+		return gaps
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Period.Start.Before(entries[j].Period.Start)
+	})
+
+	covered := entries[0]
+	coveredEnd := covered.Period.EndOr(opts.Now)
+	for _, e := range entries[1:] {
+		// 空窗月数：上一段结束月与下一段开始月之间完整的月份
+		if months := monthIndex(e.Period.Start) - monthIndex(coveredEnd) - 1; months > opts.GapThresholdMonths {
+			gaps = append(gaps, Gap{
+				After:  covered.Label,
+				Before: e.Label,
+				Start:  FormatDate(coveredEnd.AddDate(0, 1, 0)),
+				End:    FormatDate(e.Period.Start.AddDate(0, -1, 0)),
+				Months: months,
+			})
+		}
+		if end := e.Period.EndOr(opts.Now); end.After(coveredEnd) {
+			covered = e
+			coveredEnd = end
+		}
+	}
+
+	// 最后一段经历结束后至今的空窗
+	now := monthOf(opts.Now)
+	if months := monthIndex(now) - monthIndex(coveredEnd); months > opts.GapThresholdMonths {
+		gaps = append(gaps, Gap{
+			After:  covered.Label,
+			Start:  FormatDate(coveredEnd.AddDate(0, 1, 0)),
+			End:    Present,
+			Months: months,
+		})
+	}
+	return gaps
+}
+
+// unionMonths 按开始时间排序的经历覆盖的月数，与 Period.Months 一样包含开始月和结束月，重叠的月份只计算一次
+func unionMonths(entries []Entry, now time.Time) int {
+	total := 0
+	curStart, curEnd := 0, -1
+	for _, e := range entries {
+		start, end := monthIndex(e.Period.Start), monthIndex(e.Period.EndOr(now))
+		if end < start {
+			continue
+		}
+		if start > curEnd {
+			total += curEnd - curStart + 1
+			curStart, curEnd = start, end
+			continue
+		}
+		if end > curEnd {
+			curEnd = end
+		}
+	}
+	return total + curEnd - curStart + 1
+}
@@ -0,0 +1,212 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+func mustRange(t *testing.T, start, end string) Period {
+	t.Helper()
+	p, err := ParseRange(start, end)
+	if err != nil {
+		t.Fatalf("ParseRange(%q, %q): %v", start, end, err)
+	}
+	return p
+}
+
+func work(t *testing.T, label, start, end string) Entry {
+	return Entry{Kind: KindWork, Label: label, Period: mustRange(t, start, end), FullTime: true}
+}
+
+func TestPeriodMonths(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		start, end string
+		want       int
+	}{
+		{"full year", "2019.01", "2019.12", 12},
+		{"same month", "2020.03", "2020.03", 1},
+		{"across years", "2019.11", "2020.02", 4},
+		{"ongoing includes current month", "2024.01", "至今", 6},
+		{"empty end is ongoing", "2024.06", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustRange(t, tt.start, tt.end).Months(now); got != tt.want {
+				t.Errorf("Months() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if got := (Period{}).Months(now); got != 0 {
+		t.Errorf("zero Period Months() = %d, want 0", got)
+	}
+}
+
+func TestAnalyzeExperience(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		entries     []Entry
+		wantMonths  int
+		wantYears   float64
+		wantAverage float64
+	}{
+		{
+			name: "back to back jobs",
+			entries: []Entry{
+				work(t, "A", "2019.01", "2019.12"),
+				work(t, "B", "2020.01", "2020.12"),
+			},
+			wantMonths:  24,
+			wantYears:   2,
+			wantAverage: 12,
+		},
+		{
+			name: "overlapping jobs counted once",
+			entries: []Entry{
+				work(t, "A", "2019.01", "2019.12"),
+				work(t, "B", "2019.07", "2020.06"),
+			},
+			wantMonths:  18,
+			wantYears:   1.5,
+			wantAverage: 12,
+		},
+		{
+			name: "single month role",
+			entries: []Entry{
+				work(t, "A", "2021.05", "2021.05"),
+			},
+			wantMonths:  1,
+			wantYears:   0.1,
+			wantAverage: 1,
+		},
+		{
+			name: "part-time excluded",
+			entries: []Entry{
+				work(t, "A", "2019.01", "2019.12"),
+				{Kind: KindWork, Label: "实习", Period: mustRange(t, "2018.01", "2018.06")},
+			},
+			wantMonths:  12,
+			wantYears:   1,
+			wantAverage: 12,
+		},
+		{
+			name:        "no entries",
+			wantMonths:  0,
+			wantYears:   0,
+			wantAverage: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Analyze(tt.entries, Options{Now: now})
+			if report.TotalExperienceMonths != tt.wantMonths {
+				t.Errorf("TotalExperienceMonths = %d, want %d", report.TotalExperienceMonths, tt.wantMonths)
+			}
+			if report.TotalExperienceYears != tt.wantYears {
+				t.Errorf("TotalExperienceYears = %v, want %v", report.TotalExperienceYears, tt.wantYears)
+			}
+			if report.AverageTenureMonths != tt.wantAverage {
+				t.Errorf("AverageTenureMonths = %v, want %v", report.AverageTenureMonths, tt.wantAverage)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOverlaps(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		entries    []Entry
+		tolerance  int
+		wantMonths []int
+	}{
+		{
+			name:       "back to back jobs do not overlap",
+			entries:    []Entry{work(t, "A", "2019.01", "2019.12"), work(t, "B", "2020.01", "2020.12")},
+			wantMonths: nil,
+		},
+		{
+			name:       "shared handover month is tolerated",
+			entries:    []Entry{work(t, "A", "2019.01", "2019.12"), work(t, "B", "2019.12", "2020.12")},
+			wantMonths: nil,
+		},
+		{
+			name:       "shared handover month reported without tolerance",
+			entries:    []Entry{work(t, "A", "2019.01", "2019.12"), work(t, "B", "2019.12", "2020.12")},
+			tolerance:  -1,
+			wantMonths: []int{1},
+		},
+		{
+			name:       "six month overlap",
+			entries:    []Entry{work(t, "A", "2019.01", "2019.12"), work(t, "B", "2019.07", "2020.06")},
+			wantMonths: []int{6},
+		},
+		{
+			name: "working while studying",
+			entries: []Entry{
+				{Kind: KindEducation, Label: "大学", Period: mustRange(t, "2016.09", "2020.06")},
+				work(t, "A", "2020.01", "2021.12"),
+			},
+			wantMonths: []int{6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Analyze(tt.entries, Options{Now: now, OverlapToleranceMonths: tt.tolerance})
+			if len(report.Overlaps) != len(tt.wantMonths) {
+				t.Fatalf("got %d overlaps %+v, want %d", len(report.Overlaps), report.Overlaps, len(tt.wantMonths))
+			}
+			for i, o := range report.Overlaps {
+				if o.Months != tt.wantMonths[i] {
+					t.Errorf("overlap %d Months = %d, want %d", i, o.Months, tt.wantMonths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeGaps(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    []Gap
+	}{
+		{
+			name:    "back to back jobs have no gap",
+			entries: []Entry{work(t, "A", "2019.01", "2019.12"), work(t, "B", "2020.01", "至今")},
+			want:    []Gap{},
+		},
+		{
+			name:    "gap of exactly the threshold is not reported",
+			entries: []Entry{work(t, "A", "2019.01", "2019.12"), work(t, "B", "2020.07", "至今")},
+			want:    []Gap{},
+		},
+		{
+			name:    "gap between jobs",
+			entries: []Entry{work(t, "A", "2019.01", "2019.12"), work(t, "B", "2020.08", "至今")},
+			want:    []Gap{{After: "A", Before: "B", Start: "2020-01", End: "2020-07", Months: 7}},
+		},
+		{
+			name:    "gap until now",
+			entries: []Entry{work(t, "A", "2019.01", "2023.10")},
+			want:    []Gap{{After: "A", Start: "2023-11", End: Present, Months: 8}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Analyze(tt.entries, Options{Now: now}).Gaps
+			if len(got) != len(tt.want) {
+				t.Fatalf("got gaps %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("gap %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package timeline 提供简历时间线分析：把各种写法的日期区间归一化为月份粒度，
+// 计算工作年限与每段经历的任期，并检测空窗期、全职工作重叠以及在读期间全职工作等问题。
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Present 归一化后"至今"的写法
+const Present = "present"
+
+// dateLayout 归一化后的日期格式（月份粒度）
+const dateLayout = "2006-01"
+
+// ErrInvalidDate 日期无法识别
+var ErrInvalidDate = errors.New("无法识别的日期")
+
+// Period 时间区间，按月份粒度表示
+type Period struct {
+	Start   time.Time // 开始月份（当月1日）
+	End     time.Time // 结束月份（当月1日），Ongoing 为 true 时为零值
+	Ongoing bool      // 是否至今
+}
+
+// EndOr 返回结束月份，至今的区间返回 now 所在月份
+func (p Period) EndOr(now time.Time) time.Time {
+	if p.Ongoing || p.End.IsZero() {
+		return monthOf(now)
+	}
+	return p.End
+}
+
+// Months 返回区间覆盖的月数，开始月和结束月都计算在内：2019.01-2019.12 为12个月，
+// 同月开始和结束为1个月
+func (p Period) Months(now time.Time) int {
+	if p.Start.IsZero() {
+		return 0
+	}
+	if months := monthIndex(p.EndOr(now)) - monthIndex(p.Start) + 1; months > 0 {
+		return months
+	}
+	return 0
+}
+
+// StartString 归一化的开始日期，如 "2019-03"
+func (p Period) StartString() string {
+	return FormatDate(p.Start)
+}
+
+// EndString 归一化的结束日期，如 "2021-06"，至今时为 "present"
+func (p Period) EndString() string {
+	if p.Ongoing {
+		return Present
+	}
+	return FormatDate(p.End)
+}
+
+// FormatDate 格式化为月份粒度的日期，零值返回空字符串
+func FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+var (
+	presentWords = []string{"至今", "今", "现在", "目前", "迄今", "present", "now", "current", "today", "till now", "to date"}
+
+	monthNames = map[string]time.Month{
+		"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+		"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+		"sep": time.September, "sept": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+	}
+
+	// 2019.03 / 2019-3 / 2019/03 / 2019.03.15 / 2019年3月 / 2019年
+	yearMonthPattern = regexp.MustCompile(`^(\d{4})\s*(?:年\s*(?:(\d{1,2})\s*月)?|[./\-]\s*(\d{1,2})(?:\s*[./\-]\s*\d{1,2})?)?$`)
+	// 03/2019
+	monthYearPattern = regexp.MustCompile(`^(\d{1,2})\s*[./\-]\s*(\d{4})$`)
+	// Mar 2019 / March, 2019
+	monthNamePattern = regexp.MustCompile(`^([a-z]{3,9})\.?,?\s*(\d{4})$`)
+
+	// 在自由文本中查找日期区间，如 "2019.03 - 2021.06"、"2019年3月至今"
+	datePart       = `(?:\d{4}\s*(?:年\s*(?:\d{1,2}\s*月)?|[./\-]\s*\d{1,2})?|[A-Za-z]{3,9}\.?,?\s*\d{4}|\d{1,2}\s*/\s*\d{4})`
+	endPart        = `(?:` + datePart + `|至今|今|现在|目前|[Pp]resent|[Nn]ow|[Cc]urrent)`
+	rangeSeparator = `\s*(?:-|–|—|~|～|至|到|to)\s*`
+	rangePattern   = regexp.MustCompile(`(` + datePart + `)` + rangeSeparator + `(` + endPart + `)`)
+)
+
+// ParseDate 解析单个日期，支持 "2019.03"、"2019-3"、"2019/03"、"2019年3月"、"2019"、
+// "03/2019"、"Mar 2019"、ISO 8601 以及 "至今"/"present" 等写法。只有年份时按1月处理
+func ParseDate(s string) (t time.Time, present bool, err error) {
+	v := strings.ToLower(strings.TrimSpace(foldWidth(s)))
+	if v == "" {
+		return time.Time{}, false, fmt.Errorf("%w: 空字符串", ErrInvalidDate)
+	}
+
+	for _, w := range presentWords {
+		if v == w {
+			return time.Time{}, true, nil
+		}
+	}
+
+	// 小写化后 RFC 3339 中的 "T"、"Z" 无法识别，使用原始写法解析
+	if parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(foldWidth(s))); err == nil {
+		return monthOf(parsed), false, nil
+	}
+	if parsed, err := time.Parse("2006-01-02", v); err == nil {
+		return monthOf(parsed), false, nil
+	}
+
+	if m := yearMonthPattern.FindStringSubmatch(v); m != nil {
+		month := 1
+		if m[2] != "" {
+			month, _ = strconv.Atoi(m[2])
+		} else if m[3] != "" {
+			month, _ = strconv.Atoi(m[3])
+		}
+		return makeMonth(m[1], month, s)
+	}
+	if m := monthYearPattern.FindStringSubmatch(v); m != nil {
+		month, _ := strconv.Atoi(m[1])
+		return makeMonth(m[2], month, s)
+	}
+	if m := monthNamePattern.FindStringSubmatch(v); m != nil {
+		month, ok := monthNames[m[1]]
+		if !ok && len(m[1]) > 3 {
+			month, ok = monthNames[m[1][:3]]
+		}
+		if !ok {
+			return time.Time{}, false, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+		}
+		return makeMonth(m[2], int(month), s)
+	}
+
+	return time.Time{}, false, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+}
+
+// ParseRange 根据开始、结束日期字符串构造区间。结束日期为空视为至今
+func ParseRange(start, end string) (Period, error) {
+	startTime, startPresent, err := ParseDate(start)
+	if err != nil {
+		return Period{}, err
+	}
+	if startPresent {
+		return Period{}, fmt.Errorf("%w: 开始日期不能为至今", ErrInvalidDate)
+	}
+
+	p := Period{Start: startTime}
+	if strings.TrimSpace(end) == "" {
+		p.Ongoing = true
+		return p, nil
+	}
+
+	endTime, endPresent, err := ParseDate(end)
+	if err != nil {
+		return Period{}, err
+	}
+	if endPresent {
+		p.Ongoing = true
+		return p, nil
+	}
+	if endTime.Before(startTime) {
+		return Period{}, fmt.Errorf("%w: 结束日期早于开始日期: %s - %s", ErrInvalidDate, start, end)
+	}
+	p.End = endTime
+	return p, nil
+}
+
+// FindRange 在自由文本中查找第一个日期区间，如 "2019.03 - 2021.06 某某科技 后端工程师"
+func FindRange(text string) (Period, bool) {
+	for _, m := range rangePattern.FindAllStringSubmatch(foldWidth(text), -1) {
+		if p, err := ParseRange(m[1], m[2]); err == nil {
+			return p, true
+		}
+	}
+	return Period{}, false
+}
+
+// FromTimes 根据 time.Time 构造区间，结束时间为零值视为至今
+func FromTimes(start, end time.Time) (Period, bool) {
+	if start.IsZero() {
+		return Period{}, false
+	}
+	p := Period{Start: monthOf(start)}
+	if end.IsZero() {
+		p.Ongoing = true
+	} else {
+		p.End = monthOf(end)
+		if p.End.Before(p.Start) {
+			return Period{}, false
+		}
+	}
+	return p, true
+}
+
+func makeMonth(yearStr string, month int, raw string) (time.Time, bool, error) {
+	year, _ := strconv.Atoi(yearStr)
+	if year < 1950 || year > 2100 || month < 1 || month > 12 {
+		return time.Time{}, false, fmt.Errorf("%w: %q", ErrInvalidDate, raw)
+	}
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), false, nil
+}
+
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// foldWidth 将全角ASCII字符转为半角，如 "２０１９．０３" → "2019.03"
+func foldWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 0xFF01 && r <= 0xFF5E {
+			return r - 0xFEE0
+		}
+		if r == 0x3000 {
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,164 @@
+package timeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in          string
+		want        time.Time
+		wantPresent bool
+		wantErr     bool
+	}{
+		{in: "2019.03", want: month(2019, time.March)},
+		{in: "2019-3", want: month(2019, time.March)},
+		{in: "2019/03", want: month(2019, time.March)},
+		{in: "2019.03.15", want: month(2019, time.March)},
+		{in: "2019年3月", want: month(2019, time.March)},
+		{in: "2019 年 11 月", want: month(2019, time.November)},
+		{in: "2019年", want: month(2019, time.January)},
+		{in: "2019", want: month(2019, time.January)},
+		{in: "03/2019", want: month(2019, time.March)},
+		{in: "Mar 2019", want: month(2019, time.March)},
+		{in: "September, 2019", want: month(2019, time.September)},
+		{in: "Sept. 2019", want: month(2019, time.September)},
+		{in: "2019-03-15", want: month(2019, time.March)},
+		{in: "2019-03-15T10:30:00+08:00", want: month(2019, time.March)},
+		{in: "２０１９．０３", want: month(2019, time.March)},
+		{in: "至今", wantPresent: true},
+		{in: " Present ", wantPresent: true},
+		{in: "till now", wantPresent: true},
+		{in: "", wantErr: true},
+		{in: "2019.13", wantErr: true},
+		{in: "1900.01", wantErr: true},
+		{in: "Foo 2019", wantErr: true},
+		{in: "去年", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, present, err := ParseDate(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDate) {
+					t.Fatalf("ParseDate(%q) error = %v, want ErrInvalidDate", tt.in, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q): %v", tt.in, err)
+			}
+			if present != tt.wantPresent || !got.Equal(tt.want) {
+				t.Errorf("ParseDate(%q) = %v, %v, want %v, %v", tt.in, got, present, tt.want, tt.wantPresent)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		wantStart  string
+		wantEnd    string
+		wantErr    bool
+	}{
+		{name: "closed", start: "2019.03", end: "2021.06", wantStart: "2019-03", wantEnd: "2021-06"},
+		{name: "present", start: "2019.03", end: "至今", wantStart: "2019-03", wantEnd: Present},
+		{name: "empty end is ongoing", start: "2019.03", end: " ", wantStart: "2019-03", wantEnd: Present},
+		{name: "same month", start: "2019.03", end: "2019-03", wantStart: "2019-03", wantEnd: "2019-03"},
+		{name: "end before start", start: "2021.06", end: "2019.03", wantErr: true},
+		{name: "present start", start: "至今", end: "2021.06", wantErr: true},
+		{name: "invalid end", start: "2019.03", end: "明年", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseRange(tt.start, tt.end)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDate) {
+					t.Fatalf("ParseRange error = %v, want ErrInvalidDate", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRange: %v", err)
+			}
+			if p.StartString() != tt.wantStart || p.EndString() != tt.wantEnd {
+				t.Errorf("ParseRange = %s - %s, want %s - %s", p.StartString(), p.EndString(), tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestFindRange(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantStart string
+		wantEnd   string
+		wantOK    bool
+	}{
+		{text: "2019.03 - 2021.06 某某科技 后端工程师", wantStart: "2019-03", wantEnd: "2021-06", wantOK: true},
+		{text: "某某科技（2019年3月至今）", wantStart: "2019-03", wantEnd: Present, wantOK: true},
+		{text: "Mar 2019 – Jun 2021, Example Inc.", wantStart: "2019-03", wantEnd: "2021-06", wantOK: true},
+		{text: "2018/09 ~ Present", wantStart: "2018-09", wantEnd: Present, wantOK: true},
+		{text: "２０１９．０３－２０２１．０６", wantStart: "2019-03", wantEnd: "2021-06", wantOK: true},
+		{text: "2021.06 - 2019.03 无效区间", wantOK: false},
+		{text: "负责后端开发", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			p, ok := FindRange(tt.text)
+			if ok != tt.wantOK {
+				t.Fatalf("FindRange ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if p.StartString() != tt.wantStart || p.EndString() != tt.wantEnd {
+				t.Errorf("FindRange = %s - %s, want %s - %s", p.StartString(), p.EndString(), tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestFromTimes(t *testing.T) {
+	start := time.Date(2019, time.March, 15, 8, 0, 0, 0, time.UTC)
+	end := time.Date(2021, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	p, ok := FromTimes(start, end)
+	if !ok || p.StartString() != "2019-03" || p.EndString() != "2021-06" {
+		t.Errorf("FromTimes = %+v, %v", p, ok)
+	}
+	if p, ok := FromTimes(start, time.Time{}); !ok || !p.Ongoing {
+		t.Errorf("FromTimes with zero end = %+v, %v, want ongoing", p, ok)
+	}
+	if _, ok := FromTimes(time.Time{}, end); ok {
+		t.Error("FromTimes with zero start should fail")
+	}
+	if _, ok := FromTimes(end, start); ok {
+		t.Error("FromTimes with end before start should fail")
+	}
+}
+
+func TestIsPartTime(t *testing.T) {
+	tests := []struct {
+		texts []string
+		want  bool
+	}{
+		{[]string{"后端开发实习生", "某某科技"}, true},
+		{[]string{"Software Engineer Intern"}, true},
+		{[]string{"Part-time Tutor"}, true},
+		{[]string{"高级后端工程师", "某某科技"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsPartTime(tt.texts...); got != tt.want {
+			t.Errorf("IsPartTime(%q) = %v, want %v", tt.texts, got, tt.want)
+		}
+	}
+}